@@ -27,6 +27,14 @@ const (
 	ActivityPipelineTaskFileCommit ActivityType = "bb.pipeline.task.file.commit"
 	// ActivityPipelineTaskStatementUpdate is the type for updating pipeline task SQL statement.
 	ActivityPipelineTaskStatementUpdate ActivityType = "bb.pipeline.task.statement.update"
+	// ActivityPipelineTaskApprove is the type for approving a step of the pipeline task approval chain.
+	ActivityPipelineTaskApprove ActivityType = "bb.pipeline.task.approve"
+	// ActivityPipelineTaskDeploymentWindowOverride is the type for overriding the deployment window policy for pipeline task.
+	ActivityPipelineTaskDeploymentWindowOverride ActivityType = "bb.pipeline.task.deployment-window.override"
+	// ActivityPipelineTaskVerificationFail is the type for failing the post-deployment verification of pipeline task.
+	ActivityPipelineTaskVerificationFail ActivityType = "bb.pipeline.task.verification.fail"
+	// ActivityPipelineStageRolloutPromote is the type for promoting the next rollout wave of the pipeline stage.
+	ActivityPipelineStageRolloutPromote ActivityType = "bb.pipeline.stage.rollout.promote"
 	// ActivityPipelineTaskEarliestAllowedTimeUpdate is the type for updating pipeline task the earliest allowed time.
 	ActivityPipelineTaskEarliestAllowedTimeUpdate ActivityType = "bb.pipeline.task.general.earliest-allowed-time.update"
 
@@ -53,6 +61,8 @@ const (
 	ActivityProjectMemberDelete ActivityType = "bb.project.member.delete"
 	// ActivityProjectMemberRoleUpdate is the type for updating project member roles.
 	ActivityProjectMemberRoleUpdate ActivityType = "bb.project.member.role.update"
+	// ActivityProjectIssueScheduleRun is the type for issue schedule runs which didn't create an issue.
+	ActivityProjectIssueScheduleRun ActivityType = "bb.project.issue-schedule.run"
 
 	// SQL Editor related
 
@@ -81,6 +91,14 @@ func (e ActivityType) String() string {
 		return "bb.pipeline.task.file.commit"
 	case ActivityPipelineTaskStatementUpdate:
 		return "bb.pipeline.task.statement.update"
+	case ActivityPipelineTaskApprove:
+		return "bb.pipeline.task.approve"
+	case ActivityPipelineTaskDeploymentWindowOverride:
+		return "bb.pipeline.task.deployment-window.override"
+	case ActivityPipelineTaskVerificationFail:
+		return "bb.pipeline.task.verification.fail"
+	case ActivityPipelineStageRolloutPromote:
+		return "bb.pipeline.stage.rollout.promote"
 	case ActivityMemberCreate:
 		return "bb.member.create"
 	case ActivityMemberRoleUpdate:
@@ -99,6 +117,8 @@ func (e ActivityType) String() string {
 		return "bb.project.member.delete"
 	case ActivityProjectMemberRoleUpdate:
 		return "bb.project.member.role.update"
+	case ActivityProjectIssueScheduleRun:
+		return "bb.project.issue-schedule.run"
 	case ActivitySQLEditorQuery:
 		return "bb.sql-editor.query"
 	case ActivityDatabaseRecoveryPITRDone:
@@ -194,6 +214,44 @@ type ActivityPipelineTaskStatementUpdatePayload struct {
 	TaskName  string `json:"taskName"`
 }
 
+// ActivityPipelineTaskApprovePayload is the API message payloads for approving a step of the pipeline task approval chain.
+type ActivityPipelineTaskApprovePayload struct {
+	TaskID int `json:"taskId"`
+	// StepIndex is the 0-based index of the approved step in the approval chain.
+	StepIndex int `json:"stepIndex"`
+	StepCount int `json:"stepCount"`
+	// Used by inbox to display info without paying the join cost
+	IssueName string `json:"issueName"`
+	TaskName  string `json:"taskName"`
+}
+
+// ActivityPipelineTaskDeploymentWindowOverridePayload is the API message payloads for overriding the deployment window policy for pipeline task.
+type ActivityPipelineTaskDeploymentWindowOverridePayload struct {
+	TaskID int `json:"taskId"`
+	// Used by inbox to display info without paying the join cost
+	IssueName string `json:"issueName"`
+	TaskName  string `json:"taskName"`
+}
+
+// ActivityPipelineTaskVerificationFailPayload is the API message payloads for failing the post-deployment verification of pipeline task.
+type ActivityPipelineTaskVerificationFailPayload struct {
+	TaskID int `json:"taskId"`
+	// Used by inbox to display info without paying the join cost
+	IssueName string `json:"issueName"`
+	TaskName  string `json:"taskName"`
+}
+
+// ActivityPipelineStageRolloutPromotePayload is the API message payloads for promoting the next rollout wave of the pipeline stage.
+type ActivityPipelineStageRolloutPromotePayload struct {
+	StageID int `json:"stageId"`
+	// WaveIndex is the 0-based index of the promoted wave.
+	WaveIndex int `json:"waveIndex"`
+	WaveCount int `json:"waveCount"`
+	// Used by inbox to display info without paying the join cost
+	IssueName string `json:"issueName"`
+	StageName string `json:"stageName"`
+}
+
 // ActivityPipelineTaskEarliestAllowedTimeUpdatePayload is the API message payloads for pipeline task the earliest allowed time updates.
 type ActivityPipelineTaskEarliestAllowedTimeUpdatePayload struct {
 	TaskID               int   `json:"taskId"`
@@ -246,6 +304,14 @@ type ActivityProjectDatabaseTransferPayload struct {
 	DatabaseName string `json:"databaseName,omitempty"`
 }
 
+// ActivityProjectIssueScheduleRunPayload is the API message payloads for issue schedule runs.
+type ActivityProjectIssueScheduleRunPayload struct {
+	ScheduleID int                    `json:"scheduleId"`
+	Status     IssueScheduleRunStatus `json:"status"`
+	// Used by activity table to display info without paying the join cost
+	ScheduleName string `json:"scheduleName"`
+}
+
 // ActivitySQLEditorQueryPayload is the API message payloads for the executed query info.
 type ActivitySQLEditorQueryPayload struct {
 	// Used by activity table to display info without paying the join cost
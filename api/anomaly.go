@@ -16,6 +16,10 @@ const (
 	AnomalyDatabaseBackupPolicyViolation AnomalyType = "bb.anomaly.database.backup.policy-violation"
 	// AnomalyDatabaseBackupMissing is the anomaly type for missing backups.
 	AnomalyDatabaseBackupMissing AnomalyType = "bb.anomaly.database.backup.missing"
+	// AnomalyDatabaseBackupVerificationFailure is the anomaly type for backups failing to restore in the verification.
+	AnomalyDatabaseBackupVerificationFailure AnomalyType = "bb.anomaly.database.backup.verification-failure"
+	// AnomalyDatabasePITRBinlogGap is the anomaly type for gaps in the archived binlog or WAL, where the database cannot be restored to the latest point in time.
+	AnomalyDatabasePITRBinlogGap AnomalyType = "bb.anomaly.database.pitr.binlog-gap"
 	// AnomalyDatabaseConnection is the anomaly type for database connections.
 	AnomalyDatabaseConnection AnomalyType = "bb.anomaly.database.connection"
 	// AnomalyDatabaseSchemaDrift is the anomaly type for database schema drifts.
@@ -41,6 +45,10 @@ func AnomalySeverityFromType(anomalyType AnomalyType) AnomalySeverity {
 		return AnomalySeverityMedium
 	case AnomalyDatabaseBackupMissing:
 		return AnomalySeverityHigh
+	case AnomalyDatabaseBackupVerificationFailure:
+		return AnomalySeverityHigh
+	case AnomalyDatabasePITRBinlogGap:
+		return AnomalySeverityHigh
 	case AnomalyInstanceConnection:
 	case AnomalyInstanceMigrationSchema:
 	case AnomalyDatabaseConnection:
@@ -70,6 +78,25 @@ type AnomalyDatabaseBackupMissingPayload struct {
 	LastBackupTs int64 `json:"lastBackupTs,omitempty"`
 }
 
+// AnomalyDatabaseBackupVerificationFailurePayload is the API message for backup verification failure payloads.
+type AnomalyDatabaseBackupVerificationFailurePayload struct {
+	BackupID   int    `json:"backupId,omitempty"`
+	BackupName string `json:"backupName,omitempty"`
+	// Verification failure detail
+	Detail string `json:"detail,omitempty"`
+}
+
+// AnomalyDatabasePITRBinlogGapPayload is the API message for archived binlog gap payloads.
+// The binlog files are the WAL archive files for Postgres.
+type AnomalyDatabasePITRBinlogGapPayload struct {
+	// The binlog file archived right before the gap
+	BinlogBefore string `json:"binlogBefore,omitempty"`
+	// The binlog file archived right after the gap
+	BinlogAfter string `json:"binlogAfter,omitempty"`
+	// Time of the latest successful backup, which is before the gap
+	LastBackupTs int64 `json:"lastBackupTs,omitempty"`
+}
+
 // AnomalyDatabaseConnectionPayload is the API message for database connection payloads.
 type AnomalyDatabaseConnectionPayload struct {
 	// Connection failure detail
@@ -17,6 +17,8 @@ const (
 	BackupStatusDone BackupStatus = "DONE"
 	// BackupStatusFailed is the status for FAILED.
 	BackupStatusFailed BackupStatus = "FAILED"
+	// BackupStatusPruned is the status for PRUNED, whose backup file has been deleted by the retention policy.
+	BackupStatusPruned BackupStatus = "PRUNED"
 )
 
 func (e BackupStatus) String() string {
@@ -27,10 +29,26 @@ func (e BackupStatus) String() string {
 		return "DONE"
 	case BackupStatusFailed:
 		return "FAILED"
+	case BackupStatusPruned:
+		return "PRUNED"
 	}
 	return "UNKNOWN"
 }
 
+// BackupVerificationStatus is the status of the backup verification, which restores the backup into a scratch database.
+type BackupVerificationStatus string
+
+const (
+	// BackupVerificationStatusUnset is the status for the backup not to be verified.
+	BackupVerificationStatusUnset BackupVerificationStatus = "UNSET"
+	// BackupVerificationStatusPending is the status for the backup waiting for the verification.
+	BackupVerificationStatusPending BackupVerificationStatus = "PENDING"
+	// BackupVerificationStatusPassed is the status for the backup restored successfully with the expected tables and schema.
+	BackupVerificationStatusPassed BackupVerificationStatus = "PASSED"
+	// BackupVerificationStatusFailed is the status for the backup failed to verify.
+	BackupVerificationStatusFailed BackupVerificationStatus = "FAILED"
+)
+
 // BackupType is the type of a backup.
 type BackupType string
 
@@ -84,6 +102,18 @@ func (e BackupStorageBackend) String() string {
 	return "UNKNOWN"
 }
 
+// BackupCompression is the compression algorithm of the backup file.
+type BackupCompression string
+
+const (
+	// BackupCompressionNone is the backup file without compression.
+	BackupCompressionNone BackupCompression = "NONE"
+	// BackupCompressionGzip is the backup file compressed by gzip.
+	BackupCompressionGzip BackupCompression = "GZIP"
+	// BackupCompressionZstd is the backup file compressed by zstd.
+	BackupCompressionZstd BackupCompression = "ZSTD"
+)
+
 // BinlogInfo is the binlog coordination for MySQL.
 type BinlogInfo struct {
 	FileName string `json:"fileName"`
@@ -95,6 +125,22 @@ func (b BinlogInfo) IsEmpty() bool {
 	return b == BinlogInfo{}
 }
 
+// WALInfo is the Postgres WAL info of the backup.
+type WALInfo struct {
+	// LSN is the WAL location before taking the dump, from which the archived changes are replayed.
+	LSN string `json:"lsn"`
+	// Snapshot is the transaction snapshot of the dump in the txid_current_snapshot() format.
+	// The changes of the transactions visible in the snapshot are already in the dump, and are skipped in the replay.
+	Snapshot string `json:"snapshot"`
+	// Ts is the server time when taking the snapshot, rounded up to the second.
+	Ts int64 `json:"ts"`
+}
+
+// IsEmpty return true if the WALInfo is empty.
+func (w WALInfo) IsEmpty() bool {
+	return w == WALInfo{}
+}
+
 // BackupPayload contains backup related database specific info, it differs for different database types.
 // It is encoded in JSON and stored in the backup table.
 type BackupPayload struct {
@@ -103,6 +149,33 @@ type BackupPayload struct {
 	// It is recorded within the same transaction as the dump so that the binlog position is consistent with the dump.
 	// Please refer to https://github.com/bytebase/bytebase/blob/main/docs/design/pitr-mysql.md#full-backup for details.
 	BinlogInfo BinlogInfo `json:"binlogInfo"`
+
+	// Postgres related fields
+	// WALInfo is recorded when taking the backup.
+	// The dump is taken with the exported snapshot, so that the WAL info is consistent with the dump.
+	WALInfo WALInfo `json:"walInfo"`
+
+	// Compression is the compression algorithm of the backup file.
+	Compression BackupCompression `json:"compression,omitempty"`
+	// Encrypted is true if the backup file is encrypted by the backup encryption key of the workspace.
+	Encrypted bool `json:"encrypted,omitempty"`
+	// Checksum is the hex encoded SHA-256 checksum of the backup file, which is verified before restoring the backup.
+	Checksum string `json:"checksum,omitempty"`
+
+	// Backup verification related fields
+	// TableList is the tables in the backup with the row counts, which are expected in the restored database.
+	TableList []*BackupTable `json:"tableList,omitempty"`
+	// SchemaChecksum is the hex encoded SHA-256 checksum of the normalized schema when taking the backup.
+	SchemaChecksum string `json:"schemaChecksum,omitempty"`
+	// VerificationDetail is the reason of the failed verification.
+	VerificationDetail string `json:"verificationDetail,omitempty"`
+}
+
+// BackupTable is the table recorded for the backup verification.
+// The rows are counted in the consistent snapshot of the dump, so the restored table is expected to have the same row count.
+type BackupTable struct {
+	Name     string `json:"name"`
+	RowCount int64  `json:"rowCount"`
 }
 
 // Backup is the API message for a backup.
@@ -130,6 +203,8 @@ type Backup struct {
 	MigrationHistoryVersion string `jsonapi:"attr,migrationHistoryVersion"`
 	Path                    string `jsonapi:"attr,path"`
 	Comment                 string `jsonapi:"attr,comment"`
+	// VerificationStatus is the status of the test restore, which is UNSET if the backup setting disables the verification.
+	VerificationStatus BackupVerificationStatus `jsonapi:"attr,verificationStatus"`
 	// Payload contains data such as binlog position info which will not be created at first.
 	// It is filled when the backup task executor takes database backups.
 	Payload BackupPayload `jsonapi:"attr,payload"`
@@ -175,8 +250,9 @@ type BackupFind struct {
 	DatabaseID *int
 
 	// Domain specific fields
-	Name   *string
-	Status *BackupStatus
+	Name               *string
+	Status             *BackupStatus
+	VerificationStatus *BackupVerificationStatus
 }
 
 func (find *BackupFind) String() string {
@@ -199,6 +275,8 @@ type BackupPatch struct {
 	Status  string
 	Comment string
 	Payload string
+	// VerificationStatus is kept unchanged if nil.
+	VerificationStatus *BackupVerificationStatus
 }
 
 // BackupSetting is the backup setting for a database.
@@ -225,6 +303,17 @@ type BackupSetting struct {
 	DayOfWeek int  `jsonapi:"attr,dayOfWeek"`
 	// HookURL is the callback url to be requested (using HTTP GET) after a successful backup.
 	HookURL string `jsonapi:"attr,hookUrl"`
+	// RetentionPeriodTs is the period in seconds to retain the backups.
+	// The backups are retained forever if both RetentionPeriodTs and KeepLastCount are 0.
+	RetentionPeriodTs int64 `jsonapi:"attr,retentionPeriodTs"`
+	// KeepLastCount is the number of the latest successful backups retained regardless of the retention period.
+	KeepLastCount int `jsonapi:"attr,keepLastCount"`
+	// Compression is the compression algorithm of the backup files.
+	Compression BackupCompression `jsonapi:"attr,compression"`
+	// Encrypted is true if the backup files are encrypted by the backup encryption key of the workspace.
+	Encrypted bool `jsonapi:"attr,encrypted"`
+	// VerificationEnabled is true if the backups are verified by restoring into a scratch database.
+	VerificationEnabled bool `jsonapi:"attr,verificationEnabled"`
 }
 
 // BackupSettingFind is the message to get a backup settings.
@@ -235,6 +324,9 @@ type BackupSettingFind struct {
 	DatabaseID *int
 
 	// Domain specific fields
+	Enabled *bool
+	// RetentionEnabled filters the backup settings with or without any backup retention rule.
+	RetentionEnabled *bool
 }
 
 // BackupSettingUpsert is the message to upsert a backup settings.
@@ -254,6 +346,14 @@ type BackupSettingUpsert struct {
 	Hour      int    `jsonapi:"attr,hour"`
 	DayOfWeek int    `jsonapi:"attr,dayOfWeek"`
 	HookURL   string `jsonapi:"attr,hookUrl"`
+	// RetentionPeriodTs and KeepLastCount are the retention rules of the backups, 0 means unset.
+	RetentionPeriodTs int64 `jsonapi:"attr,retentionPeriodTs"`
+	KeepLastCount     int   `jsonapi:"attr,keepLastCount"`
+	// Compression and Encrypted are the format of the backup files.
+	Compression BackupCompression `jsonapi:"attr,compression"`
+	Encrypted   bool              `jsonapi:"attr,encrypted"`
+	// VerificationEnabled enables the backup verification by restoring into a scratch database.
+	VerificationEnabled bool `jsonapi:"attr,verificationEnabled"`
 }
 
 // BackupSettingsMatch is the message to find backup settings matching the conditions.
@@ -261,3 +361,15 @@ type BackupSettingsMatch struct {
 	Hour      int
 	DayOfWeek int
 }
+
+// PITRWindow is the API message for the time window that a database can be restored to by point-in-time recovery.
+type PITRWindow struct {
+	// ID is the database ID.
+	ID int `jsonapi:"primary,pitrWindow"`
+
+	// Domain specific fields
+	// EarliestTs is the time when the earliest backup restorable from is taken, or 0 if the database is not restorable.
+	EarliestTs int64 `jsonapi:"attr,earliestTs"`
+	// LatestTs is the time up to which the binlog of the database is archived, or 0 if the database is not restorable.
+	LatestTs int64 `jsonapi:"attr,latestTs"`
+}
@@ -3,6 +3,8 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"path"
+	"regexp"
 
 	"github.com/bytebase/bytebase/common"
 )
@@ -43,6 +45,29 @@ type Deployment struct {
 // DeploymentSpec is the API message for deployment specification.
 type DeploymentSpec struct {
 	Selector *LabelSelector `json:"selector"`
+	// Rollout is the optional canary rollout strategy within the deployment.
+	// Without it, all matched databases are deployed in a single wave.
+	Rollout *RolloutStrategy `json:"rollout,omitempty"`
+}
+
+// RolloutStrategy is the API message for rolling out a deployment in waves.
+// The databases not covered by the waves are deployed in the last wave.
+// For example, waves [{"count": 1}, {"percent": 10}] deploy 1 database, then 10% of the databases, then the rest.
+type RolloutStrategy struct {
+	WaveList []*RolloutWave `json:"waves"`
+	// SoakMinutes is the time to wait after a wave completes before starting the next wave.
+	SoakMinutes int `json:"soakMinutes,omitempty"`
+	// ManualPromotion requires someone to promote each wave before it starts.
+	ManualPromotion bool `json:"manualPromotion,omitempty"`
+}
+
+// RolloutWave is the API message for a rollout wave.
+// Exactly one of Count and Percent should be set.
+type RolloutWave struct {
+	// Count is the number of databases in the wave.
+	Count int `json:"count,omitempty"`
+	// Percent is the percentage of all databases in the deployment in the wave, rounded up.
+	Percent int `json:"percent,omitempty"`
 }
 
 // LabelSelector is the API message for label selector.
@@ -52,15 +77,24 @@ type LabelSelector struct {
 }
 
 // OperatorType is the type of label selector requirement operator.
-// Valid operators are In, Exists.
-// Note: NotIn and DoesNotExist are not supported initially.
+// Valid operators are In, NotIn, Exists, DoesNotExist, Glob and Regex.
 type OperatorType string
 
 const (
 	// InOperatorType is the operator type for In.
 	InOperatorType OperatorType = "In"
+	// NotInOperatorType is the operator type for NotIn.
+	// It also matches databases without the label.
+	NotInOperatorType OperatorType = "NotIn"
 	// ExistsOperatorType is the operator type for Exists.
 	ExistsOperatorType OperatorType = "Exists"
+	// DoesNotExistOperatorType is the operator type for DoesNotExist.
+	DoesNotExistOperatorType OperatorType = "DoesNotExist"
+	// GlobOperatorType is the operator type for matching the label value with any of the glob patterns, e.g. "eu-*".
+	GlobOperatorType OperatorType = "Glob"
+	// RegexOperatorType is the operator type for matching the label value with any of the regular expressions.
+	// The regular expression must match the whole label value.
+	RegexOperatorType OperatorType = "Regex"
 )
 
 // LabelSelectorRequirement is the API message for label selector.
@@ -71,7 +105,7 @@ type LabelSelectorRequirement struct {
 	// Operator represents a key's relationship to a set of values.
 	Operator OperatorType `json:"operator"`
 
-	// Values is an array of string values. If the operator is In or NotIn, the values array must be non-empty. If the operator is Exists or DoesNotExist, the values array must be empty. This array is replaced during a strategic merge patch.
+	// Values is an array of string values. If the operator is In, NotIn, Glob or Regex, the values array must be non-empty. If the operator is Exists or DoesNotExist, the values array must be empty. This array is replaced during a strategic merge patch.
 	Values []string `json:"values"`
 }
 
@@ -100,6 +134,35 @@ type DeploymentConfigUpsert struct {
 	Payload string `jsonapi:"attr,payload"`
 }
 
+// DeploymentConfigPreview is the API message for previewing the database matrix of a deployment configuration without saving it.
+type DeploymentConfigPreview struct {
+	// Payload is a json serialization of DeploymentSchedule.
+	Payload string `jsonapi:"attr,payload"`
+	// DatabaseName is the base database name to deploy in the tenant mode project.
+	DatabaseName string `jsonapi:"attr,databaseName"`
+}
+
+// DeploymentMatrix is the API message for the databases matched by each deployment of a deployment schedule.
+type DeploymentMatrix struct {
+	Deployments []*DeploymentMatrixStage `json:"deployments"`
+}
+
+// DeploymentMatrixStage is the API message for the databases matched by a deployment.
+type DeploymentMatrixStage struct {
+	Name         string                      `json:"name"`
+	DatabaseList []*DeploymentMatrixDatabase `json:"databaseList"`
+}
+
+// DeploymentMatrixDatabase is the API message for a database in the deployment matrix.
+type DeploymentMatrixDatabase struct {
+	ID              int    `json:"id"`
+	Name            string `json:"name"`
+	InstanceName    string `json:"instanceName"`
+	EnvironmentName string `json:"environmentName"`
+	// RolloutWaveIndex is the 0-based index of the rollout wave of the database in the deployment.
+	RolloutWaveIndex int `json:"rolloutWaveIndex"`
+}
+
 // ValidateAndGetDeploymentSchedule validates and returns the deployment schedule.
 // Note: this validation only checks whether the payloads is a valid json, however, invalid field name errors are ignored.
 func ValidateAndGetDeploymentSchedule(payload string) (*DeploymentSchedule, error) {
@@ -115,11 +178,29 @@ func ValidateAndGetDeploymentSchedule(payload string) (*DeploymentSchedule, erro
 		hasEnv := false
 		for _, e := range d.Spec.Selector.MatchExpressions {
 			switch e.Operator {
-			case InOperatorType:
+			case InOperatorType, NotInOperatorType:
 				if len(e.Values) == 0 {
 					return nil, common.Errorf(common.Invalid, fmt.Errorf("expression key %q with %q operator should have at least one value", e.Key, e.Operator))
 				}
-			case ExistsOperatorType:
+			case GlobOperatorType:
+				if len(e.Values) == 0 {
+					return nil, common.Errorf(common.Invalid, fmt.Errorf("expression key %q with %q operator should have at least one value", e.Key, e.Operator))
+				}
+				for _, v := range e.Values {
+					if _, err := path.Match(v, ""); err != nil {
+						return nil, common.Errorf(common.Invalid, fmt.Errorf("expression key %q has invalid glob pattern %q", e.Key, v))
+					}
+				}
+			case RegexOperatorType:
+				if len(e.Values) == 0 {
+					return nil, common.Errorf(common.Invalid, fmt.Errorf("expression key %q with %q operator should have at least one value", e.Key, e.Operator))
+				}
+				for _, v := range e.Values {
+					if _, err := regexp.Compile(v); err != nil {
+						return nil, common.Errorf(common.Invalid, fmt.Errorf("expression key %q has invalid regular expression %q: %v", e.Key, v, err))
+					}
+				}
+			case ExistsOperatorType, DoesNotExistOperatorType:
 				if len(e.Values) > 0 {
 					return nil, common.Errorf(common.Invalid, fmt.Errorf("expression key %q with %q operator shouldn't have values", e.Key, e.Operator))
 				}
@@ -136,6 +217,19 @@ func ValidateAndGetDeploymentSchedule(payload string) (*DeploymentSchedule, erro
 		if !hasEnv {
 			return nil, common.Errorf(common.Invalid, fmt.Errorf("deployment should contain %q label", EnvironmentKeyName))
 		}
+		if r := d.Spec.Rollout; r != nil {
+			if len(r.WaveList) == 0 {
+				return nil, common.Errorf(common.Invalid, fmt.Errorf("deployment %q rollout should have at least one wave", d.Name))
+			}
+			for i, w := range r.WaveList {
+				if (w.Count > 0) == (w.Percent > 0) || w.Count < 0 || w.Percent < 0 || w.Percent > 100 {
+					return nil, common.Errorf(common.Invalid, fmt.Errorf("deployment %q rollout wave %d should have either a positive count or a percent between 1 and 100", d.Name, i+1))
+				}
+			}
+			if r.SoakMinutes < 0 {
+				return nil, common.Errorf(common.Invalid, fmt.Errorf("deployment %q rollout soak minutes should not be negative", d.Name))
+			}
+		}
 	}
 	return schedule, nil
 }
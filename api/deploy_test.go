@@ -97,6 +97,71 @@ func TestGetDeploymentSchedule(t *testing.T) {
 			`{"deployments":[{"name":"deployment1","spec":{"selector":{"matchExpressions":[{"key":"bb.environment","operator":"In","values":["prod", "dev"]},{"key":"location","operator":"In","values":["us-central1","europe-west1"]}]}}}]}`,
 			nil,
 			"should must use operator",
+		}, {
+			"notInOperatorWithNoValue",
+			`{"deployments":[{"name":"deployment1","spec":{"selector":{"matchExpressions":[{"key":"bb.environment","operator":"In","values":["prod"]},{"key":"bb.tenant","operator":"NotIn"}]}}}]}`,
+			nil,
+			"operator should have at least one value",
+		}, {
+			"doesNotExistOperatorWithValues",
+			`{"deployments":[{"name":"deployment1","spec":{"selector":{"matchExpressions":[{"key":"bb.environment","operator":"In","values":["prod"]},{"key":"bb.tenant","operator":"DoesNotExist","values":["beta"]}]}}}]}`,
+			nil,
+			"operator shouldn't have values",
+		}, {
+			"invalidGlob",
+			`{"deployments":[{"name":"deployment1","spec":{"selector":{"matchExpressions":[{"key":"bb.environment","operator":"In","values":["prod"]},{"key":"location","operator":"Glob","values":["eu-["]}]}}}]}`,
+			nil,
+			"invalid glob pattern",
+		}, {
+			"invalidRegex",
+			`{"deployments":[{"name":"deployment1","spec":{"selector":{"matchExpressions":[{"key":"bb.environment","operator":"In","values":["prod"]},{"key":"location","operator":"Regex","values":["eu-(west"]}]}}}]}`,
+			nil,
+			"invalid regular expression",
+		}, {
+			"rollout",
+			`{"deployments":[{"name":"deployment1","spec":{"selector":{"matchExpressions":[{"key":"bb.environment","operator":"In","values":["prod"]}]},"rollout":{"waves":[{"count":1},{"percent":10}],"soakMinutes":30,"manualPromotion":true}}}]}`,
+			&DeploymentSchedule{
+				Deployments: []*Deployment{
+					{
+						Name: "deployment1",
+						Spec: &DeploymentSpec{
+							Selector: &LabelSelector{
+								MatchExpressions: []*LabelSelectorRequirement{
+									{
+										Key:      "bb.environment",
+										Operator: "In",
+										Values:   []string{"prod"},
+									},
+								},
+							},
+							Rollout: &RolloutStrategy{
+								WaveList: []*RolloutWave{
+									{Count: 1},
+									{Percent: 10},
+								},
+								SoakMinutes:     30,
+								ManualPromotion: true,
+							},
+						},
+					},
+				},
+			},
+			"",
+		}, {
+			"rolloutWithoutWaves",
+			`{"deployments":[{"name":"deployment1","spec":{"selector":{"matchExpressions":[{"key":"bb.environment","operator":"In","values":["prod"]}]},"rollout":{"waves":[]}}}]}`,
+			nil,
+			"should have at least one wave",
+		}, {
+			"rolloutWaveWithCountAndPercent",
+			`{"deployments":[{"name":"deployment1","spec":{"selector":{"matchExpressions":[{"key":"bb.environment","operator":"In","values":["prod"]}]},"rollout":{"waves":[{"count":1,"percent":10}]}}}]}`,
+			nil,
+			"should have either a positive count or a percent",
+		}, {
+			"rolloutWavePercentOutOfRange",
+			`{"deployments":[{"name":"deployment1","spec":{"selector":{"matchExpressions":[{"key":"bb.environment","operator":"In","values":["prod"]}]},"rollout":{"waves":[{"percent":150}]}}}]}`,
+			nil,
+			"should have either a positive count or a percent",
 		},
 	}
 
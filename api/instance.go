@@ -36,6 +36,8 @@ type Instance struct {
 	Username      string  `jsonapi:"attr,username"`
 	// Password is not returned to the client
 	Password string
+	// MaxConcurrentTask is the max number of tasks running on the instance at the same time, 0 means unlimited.
+	MaxConcurrentTask int `jsonapi:"attr,maxConcurrentTask"`
 }
 
 // InstanceCreate is the API message for creating an instance.
@@ -58,6 +60,8 @@ type InstanceCreate struct {
 	SslCa        string  `jsonapi:"attr,sslCa"`
 	SslCert      string  `jsonapi:"attr,sslCert"`
 	SslKey       string  `jsonapi:"attr,sslKey"`
+	// MaxConcurrentTask is the max number of tasks running on the instance at the same time, 0 means unlimited.
+	MaxConcurrentTask int `jsonapi:"attr,maxConcurrentTask"`
 	// If true, syncs the schema after adding the instance. The client
 	// may set to false if the target instance contains too many databases
 	// to avoid the request timeout.
@@ -98,6 +102,8 @@ type InstancePatch struct {
 	ExternalLink  *string `jsonapi:"attr,externalLink"`
 	Host          *string `jsonapi:"attr,host"`
 	Port          *string `jsonapi:"attr,port"`
+	// MaxConcurrentTask is the max number of tasks running on the instance at the same time, 0 means unlimited.
+	MaxConcurrentTask *int `jsonapi:"attr,maxConcurrentTask"`
 	// If true, syncs the schema after patching the instance. The client
 	// may set to false if the target instance contains too many databases
 	// to avoid the request timeout.
@@ -31,12 +31,18 @@ const (
 	IssueDatabaseSchemaUpdate IssueType = "bb.issue.database.schema.update"
 	// IssueDatabaseSchemaUpdateGhost is the issue type for updating database schemas using gh-ost.
 	IssueDatabaseSchemaUpdateGhost IssueType = "bb.issue.database.schema.update.ghost"
+	// IssueDatabaseSchemaUpdatePgOnline is the issue type for updating Postgres database schemas online.
+	IssueDatabaseSchemaUpdatePgOnline IssueType = "bb.issue.database.schema.update.pg-online"
 	// IssueDatabaseDataUpdate is the issue type for updating database data (DML).
 	IssueDatabaseDataUpdate IssueType = "bb.issue.database.data.update"
 	// IssueDataSourceRequest is the issue type for requesting database sources.
 	IssueDataSourceRequest IssueType = "bb.issue.data-source.request"
 	// IssueDatabasePITR is the issue type for performing a Point-in-time Recovery.
 	IssueDatabasePITR IssueType = "bb.issue.database.pitr"
+	// IssueDatabaseFlashback is the issue type for reverting the row changes of tables using binlog.
+	IssueDatabaseFlashback IssueType = "bb.issue.database.flashback"
+	// IssueDatabaseClone is the issue type for creating a database from the schema and data of another database.
+	IssueDatabaseClone IssueType = "bb.issue.database.clone"
 )
 
 // IssueFieldID is the field ID for an issue.
@@ -131,7 +137,8 @@ type CreateDatabaseContext struct {
 	Cluster string `json:"cluster"`
 	// Owner is the owner of the database. This is only applicable to Postgres for "WITH OWNER <<owner>>".
 	Owner string `json:"owner"`
-	// BackupID is the ID of the backup.
+	// BackupID is the ID of the backup, which may be of a database in another instance of the same engine.
+	// The character set and collation of the backup database are used if they are not specified.
 	BackupID int `json:"backupId"`
 	// Labels is a json-encoded string from a list of DatabaseLabel.
 	// See definition in api.Database.
@@ -149,6 +156,12 @@ type UpdateSchemaDetail struct {
 	Statement string `json:"statement"`
 	// EarliestAllowedTs the earliest execution time of the change at system local Unix timestamp in seconds.
 	EarliestAllowedTs int64 `jsonapi:"attr,earliestAllowedTs"`
+	// VerificationStatementList is the list of assertion queries run after updating the schema, each of which passes if it returns no rows.
+	VerificationStatementList []string `json:"verificationStatementList,omitempty"`
+	// ExpectedSchema is the expected database schema after updating the schema.
+	ExpectedSchema string `json:"expectedSchema,omitempty"`
+	// Batch runs the data update statement in batches, or nil to run the statement at once.
+	Batch *TaskBatchConfig `json:"batch,omitempty"`
 }
 
 // UpdateSchemaContext is the issue create context for updating database schema.
@@ -173,6 +186,8 @@ type UpdateSchemaGhostDetail struct {
 	Statement string `json:"statement"`
 	// EarliestAllowedTs the earliest execution time of the change at system local Unix timestamp in seconds.
 	EarliestAllowedTs int64 `jsonapi:"attr,earliestAllowedTs"`
+	// Config is the throttling config of gh-ost, or nil to use the defaults.
+	Config *TaskGhostConfig `json:"config,omitempty"`
 }
 
 // UpdateSchemaGhostContext is the issue create context for updating database schema using gh-ost.
@@ -184,6 +199,26 @@ type UpdateSchemaGhostContext struct {
 	VCSPushEvent *vcs.PushEvent
 }
 
+// UpdateSchemaPgOnlineDetail is the detail of updating Postgres database schema online.
+type UpdateSchemaPgOnlineDetail struct {
+	// DatabaseID is the ID of a database.
+	DatabaseID int `json:"databaseId"`
+	// Statement is the statement to update database schema.
+	Statement string `json:"statement"`
+	// EarliestAllowedTs the earliest execution time of the change at system local Unix timestamp in seconds.
+	EarliestAllowedTs int64 `jsonapi:"attr,earliestAllowedTs"`
+	// BatchSize is the number of rows copied to the shadow table in each batch, or zero to use the default.
+	BatchSize int `json:"batchSize,omitempty"`
+}
+
+// UpdateSchemaPgOnlineContext is the issue create context for updating Postgres database schema online.
+type UpdateSchemaPgOnlineContext struct {
+	// DetailList is the details of schema update.
+	DetailList []*UpdateSchemaPgOnlineDetail `json:"updateSchemaPgOnlineDetailList"`
+	// VCSPushEvent is the event information for VCS push.
+	VCSPushEvent *vcs.PushEvent
+}
+
 // PITRContext is the issue create context for performing a PITR in a database.
 type PITRContext struct {
 	DatabaseID int `json:"databaseId"`
@@ -192,6 +227,46 @@ type PITRContext struct {
 	PointInTimeTs int64 `json:"pointInTimeTs"`
 }
 
+// FlashbackContext is the issue create context for reverting the row changes of tables in a database.
+type FlashbackContext struct {
+	DatabaseID int `json:"databaseId"`
+	// TableList is the tables whose row changes are reverted.
+	TableList []string `json:"tableList"`
+	// The row changes in [StartTs, EndTs) are reverted.
+	// Represented in UNIX timestamp in seconds.
+	StartTs int64 `json:"startTs"`
+	EndTs   int64 `json:"endTs"`
+}
+
+// CloneDatabaseContext is the issue create context for cloning a database.
+type CloneDatabaseContext struct {
+	// SourceDatabaseID is the ID of the database cloned from.
+	SourceDatabaseID int `json:"sourceDatabaseId"`
+	// InstanceID is the ID of the instance to create the database in.
+	InstanceID int `json:"instanceId"`
+	// DatabaseName is the name of the database.
+	DatabaseName string `json:"databaseName"`
+	// CharacterSet is the character set of the database, or empty to use the one of the source database.
+	CharacterSet string `json:"characterSet"`
+	// Collation is the collation of the database, or empty to use the one of the source database.
+	Collation string `json:"collation"`
+	// Labels is a json-encoded string from a list of DatabaseLabel.
+	// See definition in api.Database.
+	Labels string `jsonapi:"attr,labels,omitempty"`
+	// Config is how the schema and data are cloned.
+	Config CloneConfig `json:"config"`
+}
+
+// FlashbackPreview is the API message for previewing the flashback statement before creating the flashback issue.
+type FlashbackPreview struct {
+	// Domain specific fields
+	TableList []string `jsonapi:"attr,tableList"`
+	StartTs   int64    `jsonapi:"attr,startTs"`
+	EndTs     int64    `jsonapi:"attr,endTs"`
+	// Statement is the generated statement reverting the row changes.
+	Statement string `jsonapi:"attr,statement"`
+}
+
 // IssueFind is the API message for finding issues.
 type IssueFind struct {
 	ID *int
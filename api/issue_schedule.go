@@ -0,0 +1,185 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bytebase/bytebase/common"
+)
+
+// IssueScheduleRunStatus is the status of an issue schedule run.
+type IssueScheduleRunStatus string
+
+const (
+	// IssueScheduleRunCreated is the issue schedule run status for CREATED, the issue is created.
+	IssueScheduleRunCreated IssueScheduleRunStatus = "CREATED"
+	// IssueScheduleRunSkipped is the issue schedule run status for SKIPPED, the issue created by the previous run is still open.
+	IssueScheduleRunSkipped IssueScheduleRunStatus = "SKIPPED"
+	// IssueScheduleRunFailed is the issue schedule run status for FAILED, the issue failed to be created.
+	IssueScheduleRunFailed IssueScheduleRunStatus = "FAILED"
+)
+
+// IssueSchedule is the API message for an issue schedule.
+// An issue schedule is an issue template which is instantiated into a new issue on the cron schedule.
+type IssueSchedule struct {
+	ID int `jsonapi:"primary,issueSchedule"`
+
+	// Standard fields
+	RowStatus RowStatus `jsonapi:"attr,rowStatus"`
+	CreatorID int
+	Creator   *Principal `jsonapi:"relation,creator"`
+	CreatedTs int64      `jsonapi:"attr,createdTs"`
+	UpdaterID int
+	Updater   *Principal `jsonapi:"relation,updater"`
+	UpdatedTs int64      `jsonapi:"attr,updatedTs"`
+
+	// Related fields
+	// Just returns ProjectID since it always operates within the project context
+	ProjectID  int `jsonapi:"attr,projectId"`
+	AssigneeID int `jsonapi:"attr,assigneeId"`
+
+	// Domain specific fields
+	Name        string    `jsonapi:"attr,name"`
+	Description string    `jsonapi:"attr,description"`
+	Cron        string    `jsonapi:"attr,cron"`
+	Timezone    string    `jsonapi:"attr,timezone"`
+	IssueType   IssueType `jsonapi:"attr,issueType"`
+	// CreateContext is the issue create context for the issue type, e.g. UpdateSchemaContext.
+	CreateContext string `jsonapi:"attr,createContext"`
+	// NextRunTs is the next time the issue is created, or 0 if the schedule is archived.
+	NextRunTs int64 `jsonapi:"attr,nextRunTs"`
+	// StatementUpdatedTs is the time the statement is edited, or 0 if the statement is trusted.
+	// The scheduled issues aren't auto approved until an issue created after the edit is done.
+	StatementUpdatedTs int64 `jsonapi:"attr,statementUpdatedTs"`
+}
+
+// IssueScheduleCreate is the API message for creating an issue schedule.
+type IssueScheduleCreate struct {
+	// Standard fields
+	// Value is assigned from the jwt subject field passed by the client.
+	CreatorID int
+
+	// Related fields
+	ProjectID  int
+	AssigneeID int `jsonapi:"attr,assigneeId"`
+
+	// Domain specific fields
+	Name          string    `jsonapi:"attr,name"`
+	Description   string    `jsonapi:"attr,description"`
+	Cron          string    `jsonapi:"attr,cron"`
+	Timezone      string    `jsonapi:"attr,timezone"`
+	IssueType     IssueType `jsonapi:"attr,issueType"`
+	CreateContext string    `jsonapi:"attr,createContext"`
+	// NextRunTs is computed by the server from the cron schedule.
+	NextRunTs int64
+}
+
+// IssueScheduleFind is the API message for finding issue schedules.
+type IssueScheduleFind struct {
+	ID *int
+
+	// Standard fields
+	RowStatus *RowStatus
+
+	// Related fields
+	ProjectID *int
+
+	// Domain specific fields
+	// DueTs finds the schedules which should run at or before the timestamp.
+	DueTs *int64
+}
+
+func (find *IssueScheduleFind) String() string {
+	str, err := json.Marshal(*find)
+	if err != nil {
+		return err.Error()
+	}
+	return string(str)
+}
+
+// IssueSchedulePatch is the API message for patching an issue schedule.
+type IssueSchedulePatch struct {
+	ID int
+
+	// Standard fields
+	RowStatus *string `jsonapi:"attr,rowStatus"`
+	// Value is assigned from the jwt subject field passed by the client.
+	// The issues are created on behalf of the last updater.
+	UpdaterID int
+
+	// Related fields
+	AssigneeID *int `jsonapi:"attr,assigneeId"`
+
+	// Domain specific fields
+	Name          *string `jsonapi:"attr,name"`
+	Description   *string `jsonapi:"attr,description"`
+	Cron          *string `jsonapi:"attr,cron"`
+	Timezone      *string `jsonapi:"attr,timezone"`
+	CreateContext *string `jsonapi:"attr,createContext"`
+	// NextRunTs is computed by the server from the cron schedule.
+	NextRunTs *int64
+	// StatementUpdatedTs is set by the server when the statement is edited, and reset after the edited statement is trusted.
+	StatementUpdatedTs *int64
+}
+
+// IssueScheduleRun is the API message for a run of an issue schedule.
+type IssueScheduleRun struct {
+	ID int `jsonapi:"primary,issueScheduleRun"`
+
+	// Standard fields
+	CreatedTs int64 `jsonapi:"attr,createdTs"`
+
+	// Related fields
+	ScheduleID int `jsonapi:"attr,scheduleId"`
+	// IssueID is the ID of the created issue, or nil if no issue is created.
+	IssueID *int `jsonapi:"attr,issueId"`
+
+	// Domain specific fields
+	Status IssueScheduleRunStatus `jsonapi:"attr,status"`
+	// Detail is the error message for the failed run, or the reason for the skipped run.
+	Detail string `jsonapi:"attr,detail"`
+}
+
+// IssueScheduleRunCreate is the API message for creating an issue schedule run.
+type IssueScheduleRunCreate struct {
+	// Related fields
+	ScheduleID int
+	IssueID    *int
+
+	// Domain specific fields
+	Status IssueScheduleRunStatus
+	Detail string
+}
+
+// IssueScheduleRunFind is the API message for finding issue schedule runs.
+// The runs are returned in reverse chronological order.
+type IssueScheduleRunFind struct {
+	// Related fields
+	ScheduleID *int
+
+	// Domain specific fields
+	Status *IssueScheduleRunStatus
+
+	// Limit is the max number of runs to return.
+	Limit *int
+}
+
+// GetIssueScheduleNextRunTime returns the next time after t on the cron schedule in the timezone.
+func GetIssueScheduleNextRunTime(cron, timezone string, t time.Time) (time.Time, error) {
+	schedule, err := common.ParseCron(cron)
+	if err != nil {
+		return time.Time{}, common.Errorf(common.Invalid, fmt.Errorf("invalid cron expression %q: %w", cron, err))
+	}
+	loc := time.UTC
+	if timezone != "" {
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return time.Time{}, common.Errorf(common.Invalid, fmt.Errorf("invalid timezone %q: %w", timezone, err))
+		}
+	}
+	next := schedule.Next(t.In(loc))
+	if next.IsZero() {
+		return time.Time{}, common.Errorf(common.Invalid, fmt.Errorf("cron expression %q never runs", cron))
+	}
+	return next, nil
+}
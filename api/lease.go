@@ -0,0 +1,38 @@
+package api
+
+// Lease is the API message for a lease.
+// A lease is the exclusive claim on the background work among the Bytebase replicas sharing the same metadata database.
+type Lease struct {
+	ID int
+
+	// Standard fields
+	CreatedTs int64
+	UpdatedTs int64
+
+	// Domain specific fields
+	// Resource is the claimed work, e.g. "task/101".
+	Resource string
+	// Holder is the ID of the replica holding the lease.
+	Holder   string
+	ExpireTs int64
+}
+
+// LeaseAcquire is the message to acquire or renew a lease.
+type LeaseAcquire struct {
+	Resource string
+	Holder   string
+	// DurationSeconds is how long the lease lasts from now without renewal.
+	DurationSeconds int64
+}
+
+// LeaseFind is the message to find the unexpired leases.
+type LeaseFind struct {
+	// ResourcePrefix finds the leases on the resources with the prefix, e.g. "task/".
+	ResourcePrefix *string
+}
+
+// LeaseRelease is the message to release a lease.
+type LeaseRelease struct {
+	Resource string
+	Holder   string
+}
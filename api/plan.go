@@ -64,7 +64,7 @@ const (
 	// - Support defining extra data source for a database and exposing the related data source UI.
 	FeatureDataSource FeatureType = "bb.feature.data-source"
 
-	// FeatureGhost allows user to use gh-ost for MySQL database migration.
+	// FeatureGhost allows user to use gh-ost for MySQL database migration, and the online schema change for Postgres.
 	FeatureGhost FeatureType = "bb.feature.ghost"
 
 	// FeaturePITR allows user to perform point-in-time recovery for databases.
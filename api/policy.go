@@ -3,7 +3,12 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
+	// Embed the time zone database for the timezone-aware policies.
+	_ "time/tzdata"
+
+	"github.com/bytebase/bytebase/common"
 	"github.com/bytebase/bytebase/plugin/advisor"
 )
 
@@ -13,6 +18,9 @@ type PolicyType string
 // PipelineApprovalValue is value for approval policy.
 type PipelineApprovalValue string
 
+// PipelineApprovalApproverType is the type of the approvers in a pipeline approval step.
+type PipelineApprovalApproverType string
+
 // BackupPlanPolicySchedule is value for backup plan policy.
 type BackupPlanPolicySchedule string
 
@@ -26,12 +34,23 @@ const (
 	PolicyTypeBackupPlan PolicyType = "bb.policy.backup-plan"
 	// PolicyTypeSchemaReview is the schema review policy type.
 	PolicyTypeSchemaReview PolicyType = "bb.policy.schema-review"
+	// PolicyTypeDeploymentWindow is the deployment window policy type.
+	PolicyTypeDeploymentWindow PolicyType = "bb.policy.deployment-window"
 
 	// PipelineApprovalValueManualNever means the pipeline will automatically be approved without user intervention.
 	PipelineApprovalValueManualNever PipelineApprovalValue = "MANUAL_APPROVAL_NEVER"
 	// PipelineApprovalValueManualAlways means the pipeline should be manually approved by user to proceed.
 	PipelineApprovalValueManualAlways PipelineApprovalValue = "MANUAL_APPROVAL_ALWAYS"
 
+	// PipelineApprovalApproverProjectOwner means any owner of the issue project could approve the step.
+	PipelineApprovalApproverProjectOwner PipelineApprovalApproverType = "PROJECT_OWNER"
+	// PipelineApprovalApproverWorkspaceDBA means any workspace DBA could approve the step.
+	PipelineApprovalApproverWorkspaceDBA PipelineApprovalApproverType = "WORKSPACE_DBA"
+	// PipelineApprovalApproverWorkspaceOwner means any workspace owner could approve the step.
+	PipelineApprovalApproverWorkspaceOwner PipelineApprovalApproverType = "WORKSPACE_OWNER"
+	// PipelineApprovalApproverPrincipal means only the named principals could approve the step.
+	PipelineApprovalApproverPrincipal PipelineApprovalApproverType = "PRINCIPAL"
+
 	// BackupPlanPolicyScheduleUnset is NEVER backup plan policy value.
 	BackupPlanPolicyScheduleUnset BackupPlanPolicySchedule = "UNSET"
 	// BackupPlanPolicyScheduleDaily is DAILY backup plan policy value.
@@ -46,6 +65,11 @@ var (
 		PolicyTypePipelineApproval: true,
 		PolicyTypeBackupPlan:       true,
 		PolicyTypeSchemaReview:     true,
+		PolicyTypeDeploymentWindow: true,
+	}
+	// ProjectPolicyTypes is a set of the policy types which could be refined at project level.
+	ProjectPolicyTypes = map[PolicyType]bool{
+		PolicyTypeSchemaReview: true,
 	}
 )
 
@@ -114,9 +138,128 @@ type PolicyDelete struct {
 	Type PolicyType
 }
 
+// ProjectPolicy is the API message for a project policy.
+// A project policy refines the environment policy of the same type for the databases in the project.
+type ProjectPolicy struct {
+	ID int `jsonapi:"primary,projectPolicy"`
+
+	// Standard fields
+	RowStatus RowStatus `jsonapi:"attr,rowStatus"`
+	CreatorID int
+	Creator   *Principal `jsonapi:"relation,creator"`
+	CreatedTs int64      `jsonapi:"attr,createdTs"`
+	UpdaterID int
+	Updater   *Principal `jsonapi:"relation,updater"`
+	UpdatedTs int64      `jsonapi:"attr,updatedTs"`
+
+	// Related fields
+	ProjectID int
+	Project   *Project `jsonapi:"relation,project"`
+
+	// Domain specific fields
+	Type    PolicyType `jsonapi:"attr,type"`
+	Payload string     `jsonapi:"attr,payload"`
+}
+
+// ProjectPolicyFind is the message to get a project policy.
+type ProjectPolicyFind struct {
+	ID *int
+
+	// Related fields
+	ProjectID *int
+
+	// Domain specific fields
+	Type *PolicyType
+}
+
+// ProjectPolicyUpsert is the message to upsert a project policy.
+type ProjectPolicyUpsert struct {
+	// Standard fields
+	// Value is assigned from the jwt subject field passed by the client.
+	// CreatorID is the ID of the creator.
+	UpdaterID int
+	RowStatus *string `jsonapi:"attr,rowStatus"`
+
+	// Related fields
+	ProjectID int
+
+	// Domain specific fields
+	Type    PolicyType
+	Payload *string `jsonapi:"attr,payload"`
+}
+
+// ProjectPolicyDelete is the message to delete a project policy.
+type ProjectPolicyDelete struct {
+	// Standard fields
+	// Value is assigned from the jwt subject field passed by the client.
+	DeleterID int
+
+	// Related fields
+	ProjectID int
+
+	// Domain specific fields
+	Type PolicyType
+}
+
 // PipelineApprovalPolicy is the policy configuration for pipeline approval
 type PipelineApprovalPolicy struct {
 	Value PipelineApprovalValue `json:"value"`
+	// ApprovalStepList is the ordered approval steps for MANUAL_APPROVAL_ALWAYS.
+	// If empty, the issue assignee approves the task alone.
+	ApprovalStepList []*PipelineApprovalStep `json:"approvalStepList,omitempty"`
+	// AutoApproveScheduledIssue approves the tasks of the issues created by issue schedules without manual approval.
+	// After the statement of an issue schedule is edited, its issues need manual approval until one of them is done.
+	// It doesn't apply if ApprovalStepList is not empty.
+	AutoApproveScheduledIssue bool `json:"autoApproveScheduledIssue,omitempty"`
+}
+
+// PipelineApprovalStep is a step in the pipeline approval chain.
+// A step is passed once it has collected the quorum approvals from the eligible approvers.
+type PipelineApprovalStep struct {
+	ApproverType PipelineApprovalApproverType `json:"approverType"`
+	// PrincipalIDList is the list of the eligible approvers for the PRINCIPAL approver type.
+	PrincipalIDList []int `json:"principalIdList,omitempty"`
+	// Quorum is the number of approvals required to pass the step, defaults to 1.
+	Quorum int `json:"quorum,omitempty"`
+}
+
+// GetQuorum returns the number of approvals required to pass the step.
+func (step *PipelineApprovalStep) GetQuorum() int {
+	if step.Quorum <= 0 {
+		return 1
+	}
+	return step.Quorum
+}
+
+// Validate validates the pipeline approval policy.
+func (pa *PipelineApprovalPolicy) Validate() error {
+	if pa.Value != PipelineApprovalValueManualNever && pa.Value != PipelineApprovalValueManualAlways {
+		return fmt.Errorf("invalid approval policy value: %q", pa.Value)
+	}
+	if pa.Value == PipelineApprovalValueManualNever && len(pa.ApprovalStepList) > 0 {
+		return fmt.Errorf("approval steps are not allowed for %s", pa.Value)
+	}
+	for i, step := range pa.ApprovalStepList {
+		if step.Quorum < 0 {
+			return fmt.Errorf("invalid quorum %d in approval step %d", step.Quorum, i+1)
+		}
+		switch step.ApproverType {
+		case PipelineApprovalApproverProjectOwner, PipelineApprovalApproverWorkspaceDBA, PipelineApprovalApproverWorkspaceOwner:
+			if len(step.PrincipalIDList) > 0 {
+				return fmt.Errorf("principal list is only allowed for %s approver type in approval step %d", PipelineApprovalApproverPrincipal, i+1)
+			}
+		case PipelineApprovalApproverPrincipal:
+			if len(step.PrincipalIDList) == 0 {
+				return fmt.Errorf("principal list is required for %s approver type in approval step %d", PipelineApprovalApproverPrincipal, i+1)
+			}
+			if step.GetQuorum() > len(step.PrincipalIDList) {
+				return fmt.Errorf("quorum %d exceeds the number of principals %d in approval step %d", step.GetQuorum(), len(step.PrincipalIDList), i+1)
+			}
+		default:
+			return fmt.Errorf("invalid approver type %q in approval step %d", step.ApproverType, i+1)
+		}
+	}
+	return nil
 }
 
 func (pa PipelineApprovalPolicy) String() (string, error) {
@@ -139,6 +282,9 @@ func UnmarshalPipelineApprovalPolicy(payload string) (*PipelineApprovalPolicy, e
 // BackupPlanPolicy is the policy configuration for backup plan.
 type BackupPlanPolicy struct {
 	Schedule BackupPlanPolicySchedule `json:"schedule"`
+	// RetentionPeriodTs is the minimum period in seconds to retain the backups of the databases in the environment.
+	// The backups newer than it are never pruned, whatever the retention rules of the database backup setting are.
+	RetentionPeriodTs int64 `json:"retentionPeriodTs,omitempty"`
 }
 
 func (bp BackupPlanPolicy) String() (string, error) {
@@ -158,6 +304,79 @@ func UnmarshalBackupPlanPolicy(payload string) (*BackupPlanPolicy, error) {
 	return &bp, nil
 }
 
+// DeploymentWindowPolicy is the policy configuration for the time windows to deploy schema and data changes.
+// An empty policy allows the deployment at any time.
+type DeploymentWindowPolicy struct {
+	// MaintenanceWindowList is the recurring windows allowing the deployment.
+	// If not empty, the deployment is only allowed within one of the windows.
+	MaintenanceWindowList []*MaintenanceWindow `json:"maintenanceWindowList,omitempty"`
+	// FreezePeriodList is the ad-hoc periods disallowing the deployment, which take precedence over the maintenance windows.
+	FreezePeriodList []*FreezePeriod `json:"freezePeriodList,omitempty"`
+}
+
+// MaintenanceWindow is a recurring time window starting at the cron schedule.
+type MaintenanceWindow struct {
+	// Cron is the 5-field cron expression for the window start, e.g. "0 22 * * 1-5" for 22:00 on weekdays.
+	Cron string `json:"cron"`
+	// DurationMinutes is how long the window lasts.
+	DurationMinutes int `json:"durationMinutes"`
+	// Timezone is the IANA time zone name for the cron expression, defaults to UTC.
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// FreezePeriod is an ad-hoc period in [StartTs, EndTs) disallowing the deployment.
+type FreezePeriod struct {
+	StartTs int64  `json:"startTs"`
+	EndTs   int64  `json:"endTs"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// GetLocation returns the location of the window timezone.
+func (w *MaintenanceWindow) GetLocation() (*time.Location, error) {
+	if w.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(w.Timezone)
+}
+
+func (dw DeploymentWindowPolicy) String() (string, error) {
+	s, err := json.Marshal(dw)
+	if err != nil {
+		return "", err
+	}
+	return string(s), nil
+}
+
+// Validate validates the deployment window policy.
+func (dw *DeploymentWindowPolicy) Validate() error {
+	for i, window := range dw.MaintenanceWindowList {
+		if _, err := common.ParseCron(window.Cron); err != nil {
+			return fmt.Errorf("invalid cron in maintenance window %d: %w", i+1, err)
+		}
+		if window.DurationMinutes <= 0 {
+			return fmt.Errorf("invalid duration %d minutes in maintenance window %d", window.DurationMinutes, i+1)
+		}
+		if _, err := window.GetLocation(); err != nil {
+			return fmt.Errorf("invalid timezone %q in maintenance window %d: %w", window.Timezone, i+1, err)
+		}
+	}
+	for i, period := range dw.FreezePeriodList {
+		if period.StartTs >= period.EndTs {
+			return fmt.Errorf("freeze period %d must start before it ends", i+1)
+		}
+	}
+	return nil
+}
+
+// UnmarshalDeploymentWindowPolicy will unmarshal payload to deployment window policy.
+func UnmarshalDeploymentWindowPolicy(payload string) (*DeploymentWindowPolicy, error) {
+	var dw DeploymentWindowPolicy
+	if err := json.Unmarshal([]byte(payload), &dw); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal deployment window policy %q: %q", payload, err)
+	}
+	return &dw, nil
+}
+
 // UnmarshalSchemaReviewPolicy will unmarshal payload to schema review policy.
 func UnmarshalSchemaReviewPolicy(payload string) (*advisor.SchemaReviewPolicy, error) {
 	var sr advisor.SchemaReviewPolicy
@@ -182,8 +401,8 @@ func ValidatePolicy(pType PolicyType, payload string) error {
 		if err != nil {
 			return err
 		}
-		if pa.Value != PipelineApprovalValueManualNever && pa.Value != PipelineApprovalValueManualAlways {
-			return fmt.Errorf("invalid approval policy value: %q", payload)
+		if err := pa.Validate(); err != nil {
+			return fmt.Errorf("invalid approval policy %q: %w", payload, err)
 		}
 	case PolicyTypeBackupPlan:
 		bp, err := UnmarshalBackupPlanPolicy(payload)
@@ -193,6 +412,9 @@ func ValidatePolicy(pType PolicyType, payload string) error {
 		if bp.Schedule != BackupPlanPolicyScheduleUnset && bp.Schedule != BackupPlanPolicyScheduleDaily && bp.Schedule != BackupPlanPolicyScheduleWeekly {
 			return fmt.Errorf("invalid backup plan policy schedule: %q", bp.Schedule)
 		}
+		if bp.RetentionPeriodTs < 0 {
+			return fmt.Errorf("invalid backup plan policy retention period: %d", bp.RetentionPeriodTs)
+		}
 	case PolicyTypeSchemaReview:
 		sr, err := UnmarshalSchemaReviewPolicy(payload)
 		if err != nil {
@@ -201,6 +423,45 @@ func ValidatePolicy(pType PolicyType, payload string) error {
 		if err := sr.Validate(); err != nil {
 			return fmt.Errorf("invalid schema review policy: %w", err)
 		}
+	case PolicyTypeDeploymentWindow:
+		dw, err := UnmarshalDeploymentWindowPolicy(payload)
+		if err != nil {
+			return err
+		}
+		if err := dw.Validate(); err != nil {
+			return fmt.Errorf("invalid deployment window policy: %w", err)
+		}
+	}
+	return nil
+}
+
+// UnmarshalSchemaReviewPolicyOverride will unmarshal payload to schema review policy override.
+func UnmarshalSchemaReviewPolicyOverride(payload string) (*advisor.SchemaReviewPolicyOverride, error) {
+	var sro advisor.SchemaReviewPolicyOverride
+	if err := json.Unmarshal([]byte(payload), &sro); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal schema review policy override %q: %q", payload, err)
+	}
+	return &sro, nil
+}
+
+// ValidateProjectPolicy will validate the project policy type and payload values.
+func ValidateProjectPolicy(pType PolicyType, payload string) error {
+	if !ProjectPolicyTypes[pType] {
+		return fmt.Errorf("invalid project policy type: %s", pType)
+	}
+	if payload == "" {
+		return nil
+	}
+
+	switch pType {
+	case PolicyTypeSchemaReview:
+		sro, err := UnmarshalSchemaReviewPolicyOverride(payload)
+		if err != nil {
+			return err
+		}
+		if err := sro.Validate(); err != nil {
+			return fmt.Errorf("invalid schema review policy override: %w", err)
+		}
 	}
 	return nil
 }
@@ -220,6 +481,8 @@ func GetDefaultPolicy(pType PolicyType) (string, error) {
 	case PolicyTypeSchemaReview:
 		// TODO(ed): we may need to define the default schema review policy payload in the PR of policy data migration.
 		return "{}", nil
+	case PolicyTypeDeploymentWindow:
+		return DeploymentWindowPolicy{}.String()
 	}
 	return "", nil
 }
@@ -16,6 +16,11 @@ const (
 	SettingWorkspaceID SettingName = "bb.workspace.id"
 	// SettingEnterpriseLicense is the setting name for enterprise license.
 	SettingEnterpriseLicense SettingName = "bb.enterprise.license"
+	// SettingBackupEncryptionKey is the setting name for the secret to derive the key encrypting the backup files.
+	SettingBackupEncryptionKey SettingName = "bb.backup.encryption-key"
+	// SettingBackupRetiredEncryptionKey is the setting name for the JSON array of the secrets replaced by the key rotation.
+	// They are kept to decrypt the backup files encrypted before the rotation.
+	SettingBackupRetiredEncryptionKey SettingName = "bb.backup.retired-encryption-key"
 )
 
 // Setting is the API message for a setting.
@@ -59,6 +64,19 @@ type SettingPatch struct {
 	Value string `jsonapi:"attr,value"`
 }
 
+// BackupEncryptionKey is the API message for a backup encryption key.
+type BackupEncryptionKey struct {
+	// ID is the key ID recorded in the header of the backup files encrypted by the key.
+	ID string `jsonapi:"primary,backupEncryptionKey"`
+
+	// Domain specific fields
+	// Key is the secret to pass to "bb restore --encryption-key".
+	Key string `jsonapi:"attr,key"`
+	// Active is whether the key encrypts the new backup files.
+	// The retired keys only decrypt the backup files encrypted before the rotation.
+	Active bool `jsonapi:"attr,active"`
+}
+
 func (find *SettingFind) String() string {
 	str, err := json.Marshal(*find)
 	if err != nil {
@@ -2,6 +2,10 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
 
 	"github.com/bytebase/bytebase/common"
 	"github.com/bytebase/bytebase/plugin/db"
@@ -68,12 +72,19 @@ const (
 	TaskDatabaseSchemaUpdateGhostCutover TaskType = "bb.task.database.schema.update.ghost.cutover"
 	// TaskDatabaseSchemaUpdateGhostDropOriginalTable is the task type for dropping the original table.
 	TaskDatabaseSchemaUpdateGhostDropOriginalTable TaskType = "bb.task.database.schema.update.ghost.drop-original-table"
+	// TaskDatabaseSchemaUpdatePgOnlineSync is the task type for running the online schema update steps of Postgres,
+	// and syncing the shadow table if the table is rewritten.
+	TaskDatabaseSchemaUpdatePgOnlineSync TaskType = "bb.task.database.schema.update.pg-online.sync"
+	// TaskDatabaseSchemaUpdatePgOnlineCutover is the task type for switching the original table and the shadow table of Postgres.
+	TaskDatabaseSchemaUpdatePgOnlineCutover TaskType = "bb.task.database.schema.update.pg-online.cutover"
 	// TaskDatabaseDataUpdate is the task type for updating database data.
 	TaskDatabaseDataUpdate TaskType = "bb.task.database.data.update"
 	// TaskDatabaseBackup is the task type for creating database backups.
 	TaskDatabaseBackup TaskType = "bb.task.database.backup"
 	// TaskDatabaseRestore is the task type for restoring databases.
 	TaskDatabaseRestore TaskType = "bb.task.database.restore"
+	// TaskDatabaseClone is the task type for cloning the schema and data of a database to another one.
+	TaskDatabaseClone TaskType = "bb.task.database.clone"
 	// TaskDatabasePITRRestore is the task type for restoring databases using PITR.
 	TaskDatabasePITRRestore TaskType = "bb.task.database.pitr.restore"
 	// TaskDatabasePITRCutover is the task type for swapping the pitr and original database.
@@ -115,6 +126,12 @@ type TaskDatabaseSchemaUpdatePayload struct {
 	Statement     string           `json:"statement,omitempty"`
 	SchemaVersion string           `json:"schemaVersion,omitempty"`
 	VCSPushEvent  *vcs.PushEvent   `json:"pushEvent,omitempty"`
+	RolloutWave   *TaskRolloutWave `json:"rolloutWave,omitempty"`
+	// VerificationStatementList is the list of assertion queries run after the schema update.
+	// Each query passes if it returns no rows.
+	VerificationStatementList []string `json:"verificationStatementList,omitempty"`
+	// ExpectedSchema is the expected database schema after the schema update, which is compared with the synced schema.
+	ExpectedSchema string `json:"expectedSchema,omitempty"`
 }
 
 // TaskDatabaseSchemaUpdateGhostSyncPayload is the task payload for gh-ost syncing ghost table.
@@ -126,6 +143,39 @@ type TaskDatabaseSchemaUpdateGhostSyncPayload struct {
 	// The name follows this template,
 	// `./tmp/gh-ost.{{ISSUE_ID}}.{{TASK_ID}}.{{DATABASE_ID}}.{{DATABASE_NAME}}.{{TABLE_NAME}}.sock`
 	// SocketFileName will be composed when needed. We don't store it explicitly.
+
+	// Config is the throttling config of gh-ost, or nil to use the defaults.
+	Config *TaskGhostConfig `json:"config,omitempty"`
+	// Paused is true if the row copy is paused.
+	Paused bool `json:"paused,omitempty"`
+}
+
+// TaskGhostConfig is the throttling config of gh-ost, where the zero values use the defaults.
+type TaskGhostConfig struct {
+	// ChunkSize is the number of rows to copy in each iteration, between 10 and 100000.
+	ChunkSize int64 `json:"chunkSize,omitempty"`
+	// MaxLoad throttles the row copy when any of the status variables exceeds the threshold, e.g. "Threads_running=25".
+	MaxLoad string `json:"maxLoad,omitempty"`
+	// CriticalLoad aborts the migration when any of the status variables exceeds the threshold, e.g. "Threads_running=1000".
+	CriticalLoad string `json:"criticalLoad,omitempty"`
+	// MaxLagMillis throttles the row copy when the replication lag exceeds it, at least 100.
+	MaxLagMillis int64 `json:"maxLagMillis,omitempty"`
+}
+
+// TaskGhostPatch is the API message for controlling the running gh-ost migration of a gh-ost sync task.
+// The nil fields are unchanged.
+type TaskGhostPatch struct {
+	// Standard fields
+	// Value is assigned from the jwt subject field passed by the client.
+	UpdaterID int
+
+	// Domain specific fields
+	// Paused pauses or resumes the row copy.
+	Paused       *bool   `jsonapi:"attr,paused"`
+	ChunkSize    *int64  `jsonapi:"attr,chunkSize"`
+	MaxLoad      *string `jsonapi:"attr,maxLoad"`
+	CriticalLoad *string `jsonapi:"attr,criticalLoad"`
+	MaxLagMillis *int64  `jsonapi:"attr,maxLagMillis"`
 }
 
 // TaskDatabaseSchemaUpdateGhostCutoverPayload is the task payload for gh-ost switching the original table and the ghost table.
@@ -139,11 +189,119 @@ type TaskDatabaseSchemaUpdateGhostDropOriginalTablePayload struct {
 	TableName string `json:"tableName,omitempty"`
 }
 
-// TaskDatabaseDataUpdatePayload is the task payload for database data update (DML).
-type TaskDatabaseDataUpdatePayload struct {
+// TaskDatabaseSchemaUpdatePgOnlineSyncPayload is the task payload for the Postgres online schema update.
+type TaskDatabaseSchemaUpdatePgOnlineSyncPayload struct {
 	Statement     string         `json:"statement,omitempty"`
 	SchemaVersion string         `json:"schemaVersion,omitempty"`
 	VCSPushEvent  *vcs.PushEvent `json:"pushEvent,omitempty"`
+	// BatchSize is the number of rows copied to the shadow table in each batch, or zero to use the default.
+	BatchSize int `json:"batchSize,omitempty"`
+}
+
+// TaskDatabaseSchemaUpdatePgOnlineCutoverPayload is the task payload for switching the original table and the shadow table of Postgres.
+type TaskDatabaseSchemaUpdatePgOnlineCutoverPayload struct {
+}
+
+// TaskDatabaseDataUpdatePayload is the task payload for database data update (DML).
+type TaskDatabaseDataUpdatePayload struct {
+	Statement     string           `json:"statement,omitempty"`
+	SchemaVersion string           `json:"schemaVersion,omitempty"`
+	VCSPushEvent  *vcs.PushEvent   `json:"pushEvent,omitempty"`
+	RolloutWave   *TaskRolloutWave `json:"rolloutWave,omitempty"`
+	// Batch runs the statement in batches by the primary key ranges, or nil to run the statement at once.
+	Batch *TaskBatchConfig `json:"batch,omitempty"`
+	// BatchPaused is true if the batches are paused.
+	BatchPaused bool `json:"batchPaused,omitempty"`
+	// BatchCheckpoint is the progress after the last finished chunk, so that a failed task resumes from the next chunk.
+	// It's nil if no chunk has finished, and reset when the statement changes.
+	BatchCheckpoint *TaskBatchCheckpoint `json:"batchCheckpoint,omitempty"`
+}
+
+// TaskBatchCheckpoint is the progress after the last finished chunk of a data update task running in batches.
+type TaskBatchCheckpoint struct {
+	// NextKey is the start of the primary key range of the next chunk.
+	NextKey int64 `json:"nextKey"`
+	// CompletedChunkCount is the number of finished chunks.
+	CompletedChunkCount int64 `json:"completedChunkCount"`
+}
+
+// TaskBatchConfig is the config to run an UPDATE or DELETE statement in batches by the primary key ranges,
+// so that a large data update doesn't cause long locks and replication lag.
+type TaskBatchConfig struct {
+	// Table is the table to update.
+	Table string `json:"table"`
+	// PrimaryKey is the integer primary key column of the table.
+	PrimaryKey string `json:"primaryKey"`
+	// ChunkSize is the size of the primary key range in each batch.
+	ChunkSize int64 `json:"chunkSize"`
+	// SleepMs is the sleep interval in milliseconds between batches.
+	SleepMs int `json:"sleepMs,omitempty"`
+	// MaxReplicationLagSeconds throttles the batches while the replication lag exceeds it, 0 means no throttling.
+	MaxReplicationLagSeconds int `json:"maxReplicationLagSeconds,omitempty"`
+	// ReplicaList is the list of replica addresses in host:port to check the replication lag for MySQL.
+	// The replicas are connected with the admin data source of the instance.
+	// PostgreSQL checks the replication lag on the primary so that it doesn't need the replicas.
+	ReplicaList []string `json:"replicaList,omitempty"`
+}
+
+// batchIdentifierRegexp matches the table and column names allowed in the batch config, such as "public.user".
+var batchIdentifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*(\.[A-Za-z_][A-Za-z0-9_$]*)?$`)
+
+// Validate validates the batch config for the database engine.
+func (c *TaskBatchConfig) Validate(engine db.Type) error {
+	switch engine {
+	case db.MySQL, db.TiDB, db.Postgres:
+	default:
+		return fmt.Errorf("batch mode is not supported for %s", engine)
+	}
+	if !batchIdentifierRegexp.MatchString(c.Table) {
+		return fmt.Errorf("invalid batch table %q", c.Table)
+	}
+	if !batchIdentifierRegexp.MatchString(c.PrimaryKey) || strings.Contains(c.PrimaryKey, ".") {
+		return fmt.Errorf("invalid batch primary key %q", c.PrimaryKey)
+	}
+	if c.ChunkSize <= 0 {
+		return fmt.Errorf("batch chunk size must be positive, got %d", c.ChunkSize)
+	}
+	if c.SleepMs < 0 {
+		return fmt.Errorf("batch sleep interval must not be negative, got %d", c.SleepMs)
+	}
+	if c.MaxReplicationLagSeconds < 0 {
+		return fmt.Errorf("max replication lag must not be negative, got %d", c.MaxReplicationLagSeconds)
+	}
+	if c.MaxReplicationLagSeconds > 0 && engine == db.TiDB {
+		return fmt.Errorf("replication lag throttling is not supported for %s", engine)
+	}
+	if c.MaxReplicationLagSeconds > 0 && engine == db.MySQL && len(c.ReplicaList) == 0 {
+		return fmt.Errorf("replica list is required to check the replication lag for %s", engine)
+	}
+	for _, replica := range c.ReplicaList {
+		if _, _, err := net.SplitHostPort(replica); err != nil {
+			return fmt.Errorf("invalid replica address %q: %w", replica, err)
+		}
+	}
+	return nil
+}
+
+// TaskBatchPatch is the API message for pausing or resuming the batches of a data update task.
+type TaskBatchPatch struct {
+	// Standard fields
+	// Value is assigned from the jwt subject field passed by the client.
+	UpdaterID int
+
+	// Domain specific fields
+	Paused bool `jsonapi:"attr,paused"`
+}
+
+// TaskRolloutWave is the rollout wave of the task in a tenant deployment stage with the canary rollout strategy.
+type TaskRolloutWave struct {
+	// Index is the 0-based index of the wave in the stage.
+	Index int `json:"index"`
+	// Count is the number of waves in the stage.
+	Count int `json:"count"`
+	// SoakMinutes and ManualPromotion are copied from the rollout strategy upon issue creation.
+	SoakMinutes     int  `json:"soakMinutes,omitempty"`
+	ManualPromotion bool `json:"manualPromotion,omitempty"`
 }
 
 // TaskDatabaseBackupPayload is the task payload for database backup.
@@ -159,6 +317,53 @@ type TaskDatabaseRestorePayload struct {
 	BackupID     int    `json:"backupId,omitempty"`
 }
 
+// CloneMaskingType is the way to mask the values of a column when cloning a database.
+type CloneMaskingType string
+
+const (
+	// CloneMaskingNull replaces the values with NULL.
+	CloneMaskingNull CloneMaskingType = "NULL"
+	// CloneMaskingHash replaces the values with their HMAC-SHA256 hex digests keyed by a random key of the clone.
+	// It's pseudonymization rather than anonymization, because the equal values are hashed the same in the clone.
+	CloneMaskingHash CloneMaskingType = "HASH"
+	// CloneMaskingRedact replaces each character of the values with "*".
+	CloneMaskingRedact CloneMaskingType = "REDACT"
+)
+
+// CloneTableFilter is the filter of the rows cloned from a table.
+type CloneTableFilter struct {
+	Table string `json:"table"`
+	// Where is the condition of the rows cloned, such as "created_ts > 1640995200".
+	Where string `json:"where"`
+}
+
+// CloneColumnMasking is the masking of the values cloned from a column.
+type CloneColumnMasking struct {
+	Table  string           `json:"table"`
+	Column string           `json:"column"`
+	Type   CloneMaskingType `json:"type"`
+}
+
+// CloneConfig is the config of cloning a database.
+type CloneConfig struct {
+	// SchemaOnly clones the schema without the data.
+	SchemaOnly bool `json:"schemaOnly"`
+	// TableFilterList is the filters of the rows cloned. All rows are cloned for the tables without a filter.
+	TableFilterList []*CloneTableFilter `json:"tableFilterList,omitempty"`
+	// MaskingList is the masking of the sensitive columns.
+	MaskingList []*CloneColumnMasking `json:"maskingList,omitempty"`
+}
+
+// TaskDatabaseClonePayload is the task payload for database clone.
+type TaskDatabaseClonePayload struct {
+	// SourceDatabaseID is the ID of the database cloned from.
+	SourceDatabaseID int `json:"sourceDatabaseId"`
+	// The database name we clone to. Like restoring a backup to a new database, we only have the database name
+	// and don't have the database id upon constructing the task yet.
+	DatabaseName string      `json:"databaseName,omitempty"`
+	Config       CloneConfig `json:"config"`
+}
+
 // Task is the API message for a task.
 type Task struct {
 	ID int `jsonapi:"primary,task"`
@@ -192,6 +397,9 @@ type Task struct {
 	// BlockedBy is an array of Task ID.
 	// We use string here to workaround jsonapi limitations. https://github.com/google/jsonapi/issues/209
 	BlockedBy []string `jsonapi:"attr,blockedBy"`
+	// QueuePosition is the 1-based position of the RUNNING task waiting for the concurrency limits, 0 if it's not queued.
+	// It's computed by the task scheduler and not persisted.
+	QueuePosition int `jsonapi:"attr,queuePosition"`
 }
 
 // TaskCreate is the API message for creating a task.
@@ -253,9 +461,12 @@ type TaskPatch struct {
 	UpdaterID int
 
 	// Domain specific fields
-	DatabaseID        *int
-	Statement         *string `jsonapi:"attr,statement"`
-	Payload           *string
+	DatabaseID *int
+	Statement  *string `jsonapi:"attr,statement"`
+	Payload    *string
+	// PayloadMerge is a JSON object whose top-level fields are set in the payload in place,
+	// so that the concurrent updates to the other fields of the payload are kept.
+	PayloadMerge      *string
 	EarliestAllowedTs *int64 `jsonapi:"attr,earliestAllowedTs"`
 }
 
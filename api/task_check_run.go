@@ -83,6 +83,8 @@ const (
 	TaskCheckGhostSync TaskCheckType = "bb.task-check.database.ghost.sync"
 	// TaskCheckGeneralEarliestAllowedTime is the task check type for earliest allowed time.
 	TaskCheckGeneralEarliestAllowedTime TaskCheckType = "bb.task-check.general.earliest-allowed-time"
+	// TaskCheckGeneralDeploymentWindow is the task check type for the deployment window policy.
+	TaskCheckGeneralDeploymentWindow TaskCheckType = "bb.task-check.general.deployment-window"
 )
 
 // TaskCheckEarliestAllowedTimePayload is the task check payload for earliest allowed time.
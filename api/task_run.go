@@ -41,6 +41,62 @@ type TaskRunResultPayload struct {
 	Detail      string `json:"detail,omitempty"`
 	MigrationID int64  `json:"migrationId,omitempty"`
 	Version     string `json:"version,omitempty"`
+	// Verification is the result of the post-deployment verification, or nil if the task isn't verified.
+	Verification *TaskRunVerificationResult `json:"verification,omitempty"`
+	// BatchProgress is the progress of the data update task running in batches.
+	BatchProgress *TaskRunBatchProgress `json:"batchProgress,omitempty"`
+	// GhostProgress is the progress of the gh-ost sync task.
+	GhostProgress *TaskRunGhostProgress `json:"ghostProgress,omitempty"`
+}
+
+// TaskRunGhostProgress is the progress of the gh-ost sync task.
+type TaskRunGhostProgress struct {
+	// RowsCopied is the number of rows copied to the ghost table out of RowsEstimate.
+	RowsCopied   int64   `json:"rowsCopied"`
+	RowsEstimate int64   `json:"rowsEstimate"`
+	ProgressPct  float64 `json:"progressPct"`
+	// ETASeconds is the estimated time to finish the row copy, or -1 if unknown.
+	ETASeconds int64 `json:"etaSeconds"`
+	// LagMillis is the replication lag of the changes applied to the ghost table.
+	LagMillis int64 `json:"lagMillis"`
+	// Paused is true if the row copy is paused by the user.
+	Paused bool `json:"paused,omitempty"`
+	// ThrottleReason is the reason why the row copy is throttled, or empty if not throttled.
+	ThrottleReason string `json:"throttleReason,omitempty"`
+}
+
+// TaskRunBatchState is the state of the data update task running in batches.
+type TaskRunBatchState string
+
+const (
+	// TaskRunBatchRunning is the batch state for RUNNING.
+	TaskRunBatchRunning TaskRunBatchState = "RUNNING"
+	// TaskRunBatchPaused is the batch state for PAUSED, the batches are paused by the user.
+	TaskRunBatchPaused TaskRunBatchState = "PAUSED"
+	// TaskRunBatchThrottled is the batch state for THROTTLED, the replication lag exceeds the threshold.
+	TaskRunBatchThrottled TaskRunBatchState = "THROTTLED"
+	// TaskRunBatchDone is the batch state for DONE.
+	TaskRunBatchDone TaskRunBatchState = "DONE"
+)
+
+// TaskRunBatchProgress is the progress of the data update task running in batches.
+type TaskRunBatchProgress struct {
+	State TaskRunBatchState `json:"state"`
+	// CompletedChunkCount is the number of completed chunks out of TotalChunkCount.
+	CompletedChunkCount int64 `json:"completedChunkCount"`
+	TotalChunkCount     int64 `json:"totalChunkCount"`
+	// NextKey is the start of the primary key range of the next chunk.
+	NextKey int64 `json:"nextKey"`
+	// ReplicationLagSeconds is the last checked replication lag.
+	ReplicationLagSeconds int64 `json:"replicationLagSeconds,omitempty"`
+}
+
+// TaskRunVerificationResult is the result of verifying the database after the task run.
+// A failed verification doesn't fail the task run since the change has been applied, but the task needs attention.
+type TaskRunVerificationResult struct {
+	Passed bool `json:"passed"`
+	// ErrorList is the list of the failed verifications.
+	ErrorList []string `json:"errorList,omitempty"`
 }
 
 // TaskRun is the API message for a task run.
@@ -119,3 +175,30 @@ type TaskRunStatusPatch struct {
 	Comment *string
 	Result  *string
 }
+
+// TaskRunResultPatch is the API message for updating the result of the running task run of a task, e.g. the progress.
+type TaskRunResultPatch struct {
+	// Standard fields
+	UpdaterID int
+
+	// Related fields
+	TaskID int
+
+	// Domain specific fields
+	Result string
+}
+
+// TaskRunRetry is the API message for retrying the running task run of a task.
+// The running task run is marked as FAILED, and a new task run is created for the next attempt.
+type TaskRunRetry struct {
+	// Standard fields
+	UpdaterID int
+
+	// Related fields
+	TaskID int
+
+	// Domain specific fields
+	// Code and Result are recorded on the failed task run.
+	Code   common.Code
+	Result string
+}
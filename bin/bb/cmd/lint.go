@@ -0,0 +1,435 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bytebase/bytebase/plugin/advisor"
+	"github.com/bytebase/bytebase/plugin/advisor/catalog"
+	"github.com/bytebase/bytebase/plugin/db"
+	pgquery "github.com/pganalyze/pg_query_go/v2"
+	tidbparser "github.com/pingcap/tidb/parser"
+	"github.com/spf13/cobra"
+	"github.com/xo/dburl"
+
+	// Register mysql advisor.
+	_ "github.com/bytebase/bytebase/plugin/advisor/mysql"
+	// Register postgresql advisor.
+	_ "github.com/bytebase/bytebase/plugin/advisor/pg"
+	// Register postgres parser driver.
+	_ "github.com/bytebase/bytebase/plugin/parser/engine/pg"
+	// Register pingcap parser driver.
+	_ "github.com/pingcap/tidb/types/parser_driver"
+)
+
+// errLintFailed is returned if any advice is at the fail level, so that bb exits with non-zero code.
+var errLintFailed = errors.New("schema review found errors")
+
+const policyUsage = `Schema review policy file in JSON, the same as the schema review policy payload in Bytebase.
+
+Example:
+  {
+    "name": "CI",
+    "ruleList": [
+      {"type": "statement.where.require", "level": "ERROR", "payload": "{}"},
+      {"type": "table.require-pk", "level": "WARNING", "payload": "{}"}
+    ]
+  }
+`
+
+func newLintCmd() *cobra.Command {
+	var (
+		engine     string
+		policyFile string
+		format     string
+		dsn        string
+		charset    string
+		collation  string
+		failOn     string
+	)
+	lintCmd := &cobra.Command{
+		Use:   "lint [flags] FILE...",
+		Short: "Check SQL files against the schema review policy.",
+		Args:  cobra.MinimumNArgs(1),
+		// Usage is useless when the lint fails because of the advices.
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, fileList []string) error {
+			dbType, err := getLintDBType(engine)
+			if err != nil {
+				return err
+			}
+			failLevel, err := getLintFailLevel(failOn)
+			if err != nil {
+				return err
+			}
+			reporter, err := newLintReporter(format, failLevel)
+			if err != nil {
+				return err
+			}
+			policy, err := readSchemaReviewPolicy(policyFile)
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			checkContext := advisor.SchemaReviewCheckContext{
+				Charset:   charset,
+				Collation: collation,
+				DbType:    dbType,
+				Catalog:   &emptyCatalog{},
+			}
+			if dsn != "" {
+				u, err := dburl.Parse(dsn)
+				if err != nil {
+					return fmt.Errorf("failed to parse dsn, got error: %w", err)
+				}
+				driver, err := open(ctx, u)
+				if err != nil {
+					return err
+				}
+				defer driver.Close(ctx)
+				database := getDatabase(u)
+				sqlDB, err := driver.GetDbConnection(ctx, database)
+				if err != nil {
+					return fmt.Errorf("failed to get database connection, got error: %w", err)
+				}
+				checkContext.Driver = sqlDB
+				checkContext.Catalog = &liveCatalog{driver: driver, database: database}
+			}
+
+			var resultList []*lintResult
+			for _, file := range fileList {
+				list, err := lintFile(ctx, file, policy, checkContext)
+				if err != nil {
+					return err
+				}
+				resultList = append(resultList, list...)
+			}
+
+			if err := reporter.report(cmd.OutOrStdout(), fileList, resultList); err != nil {
+				return fmt.Errorf("failed to write lint report, got error: %w", err)
+			}
+			for _, result := range resultList {
+				if isLintFailure(result, failLevel) {
+					return errLintFailed
+				}
+			}
+			return nil
+		},
+	}
+
+	lintCmd.Flags().StringVar(&engine, "engine", "mysql", "Database engine of the SQL files. Supported engines: mysql, tidb, postgres.")
+	lintCmd.Flags().StringVar(&policyFile, "policy", "", policyUsage)
+	lintCmd.Flags().StringVar(&format, "format", "text", "Output format. Supported formats: text, json, junit, sarif.")
+	lintCmd.Flags().StringVar(&dsn, "dsn", "", "Optional database connection string to check against the live database, e.g. for the rules depending on the existing indexes.\n\n"+dsnUsage)
+	lintCmd.Flags().StringVar(&charset, "charset", "utf8mb4", "Character set of the SQL files.")
+	lintCmd.Flags().StringVar(&collation, "collation", "utf8mb4_general_ci", "Collation of the SQL files.")
+	lintCmd.Flags().StringVar(&failOn, "fail-on", "error", "Lowest advice level that fails the lint. Supported levels: error, warning.")
+	if err := lintCmd.MarkFlagRequired("policy"); err != nil {
+		panic(err)
+	}
+	return lintCmd
+}
+
+// lintResult is a non-OK advice located in the SQL file.
+type lintResult struct {
+	File   string
+	Line   int
+	Advice advisor.Advice
+}
+
+func getLintFailLevel(failOn string) (advisor.Status, error) {
+	switch failOn {
+	case "error":
+		return advisor.Error, nil
+	case "warning":
+		return advisor.Warn, nil
+	}
+	return "", fmt.Errorf("fail level %q not supported; supported levels: error, warning", failOn)
+}
+
+// isLintFailure returns whether the result fails the lint at the fail level.
+// Both the exit status and the reports use it, so that they always agree.
+func isLintFailure(result *lintResult, failLevel advisor.Status) bool {
+	switch result.Advice.Status {
+	case advisor.Error:
+		return true
+	case advisor.Warn:
+		return failLevel == advisor.Warn
+	}
+	return false
+}
+
+func getLintDBType(engine string) (advisor.DBType, error) {
+	switch strings.ToLower(engine) {
+	case "mysql":
+		return advisor.MySQL, nil
+	case "tidb":
+		return advisor.TiDB, nil
+	case "postgres", "postgresql", "pg":
+		return advisor.Postgres, nil
+	}
+	return "", fmt.Errorf("engine %q not supported; supported engines: mysql, tidb, postgres", engine)
+}
+
+func readSchemaReviewPolicy(file string) (*advisor.SchemaReviewPolicy, error) {
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file %s, got error: %w", file, err)
+	}
+	var policy advisor.SchemaReviewPolicy
+	if err := json.Unmarshal(buf, &policy); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal policy file %s, got error: %w", file, err)
+	}
+	for _, rule := range policy.RuleList {
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid rule %s in policy file %s, got error: %w", rule.Type, file, err)
+		}
+	}
+	return &policy, nil
+}
+
+// lintFile checks the whole file at once, so that the rules see each statement in the context of the file,
+// e.g. a table created earlier in the file.
+func lintFile(ctx context.Context, file string, policy *advisor.SchemaReviewPolicy, checkContext advisor.SchemaReviewCheckContext) ([]*lintResult, error) {
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SQL file %s, got error: %w", file, err)
+	}
+	text := string(buf)
+
+	adviceList, err := advisor.SchemaReviewCheck(ctx, text, policy, checkContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check SQL file %s, got error: %w", file, err)
+	}
+	stmtList := getStatementList(checkContext.DbType, text, checkContext.Charset, checkContext.Collation)
+	var resultList []*lintResult
+	for _, advice := range adviceList {
+		if advice.Status == advisor.Success {
+			continue
+		}
+		resultList = append(resultList, &lintResult{
+			File:   file,
+			Line:   getAdviceLine(advice, stmtList),
+			Advice: advice,
+		})
+	}
+	sort.SliceStable(resultList, func(i, j int) bool {
+		return resultList[i].Line < resultList[j].Line
+	})
+	return resultList, nil
+}
+
+// statement is a single SQL statement with the line it starts at.
+type statement struct {
+	Text string
+	Line int
+}
+
+var (
+	// The line of the syntax error reported by the TiDB parser, e.g. "line 1 column 20 near ...".
+	syntaxErrorLineReg = regexp.MustCompile(`^line (\d+) column \d+`)
+	// The identifier quoted in the advice, e.g. "Table `tag` requires PRIMARY KEY".
+	quotedIdentifierReg = regexp.MustCompile("`([^`]+)`|\"([^\"]+)\"")
+)
+
+// getStatementList splits the SQL text into statements by the same parser as the advisors.
+// It returns nil if the text cannot be parsed, and the advisors report the syntax error.
+func getStatementList(dbType advisor.DBType, text, charset, collation string) []statement {
+	// The statement text starts right after the previous statement, including the comments in between.
+	var rawList []string
+	switch dbType {
+	case advisor.MySQL, advisor.TiDB:
+		nodeList, _, err := tidbparser.New().Parse(text, charset, collation)
+		if err != nil {
+			return nil
+		}
+		for _, node := range nodeList {
+			rawList = append(rawList, node.Text())
+		}
+	case advisor.Postgres:
+		res, err := pgquery.Parse(text)
+		if err != nil {
+			return nil
+		}
+		for _, stmt := range res.Stmts {
+			end := len(text)
+			// The length of the last statement without the trailing semicolon is zero.
+			if stmt.StmtLen > 0 {
+				end = int(stmt.StmtLocation + stmt.StmtLen)
+			}
+			rawList = append(rawList, text[stmt.StmtLocation:end])
+		}
+	}
+
+	var result []statement
+	cursor := 0
+	for _, raw := range rawList {
+		n := strings.Index(text[cursor:], raw)
+		if n < 0 {
+			return nil
+		}
+		start := cursor + n
+		cursor = start + len(raw)
+		offset := start + getLeadingCommentLength(dbType, raw)
+		if offset >= cursor {
+			continue
+		}
+		result = append(result, statement{
+			Text: strings.TrimSpace(text[offset:cursor]),
+			Line: strings.Count(text[:offset], "\n") + 1,
+		})
+	}
+	return result
+}
+
+// getLeadingCommentLength returns the length of the whitespaces and comments before the statement.
+// The MySQL executable comment, e.g. /*!40101 SET ... */, is a part of the statement.
+func getLeadingCommentLength(dbType advisor.DBType, text string) int {
+	isMySQL := dbType == advisor.MySQL || dbType == advisor.TiDB
+	i := 0
+	for i < len(text) {
+		rest := text[i:]
+		switch {
+		case rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\r' || rest[0] == '\n':
+			i++
+		case strings.HasPrefix(rest, "--") || (isMySQL && rest[0] == '#'):
+			n := strings.IndexByte(rest, '\n')
+			if n < 0 {
+				return len(text)
+			}
+			i += n + 1
+		case strings.HasPrefix(rest, "/*") && !(isMySQL && strings.HasPrefix(rest, "/*!")):
+			n := strings.Index(rest[2:], "*/")
+			if n < 0 {
+				return len(text)
+			}
+			i += n + 4
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// getAdviceLine returns the line of the statement the advice is about. The advices don't carry the position,
+// so the statement is the first one quoted in the advice, or the first one referencing the identifier quoted in the advice,
+// e.g. the table in "Table `tag` requires PRIMARY KEY". The advice is at the first line if neither is found.
+func getAdviceLine(advice advisor.Advice, stmtList []statement) int {
+	if advice.Code == advisor.StatementSyntaxError {
+		if matches := syntaxErrorLineReg.FindStringSubmatch(advice.Content); matches != nil {
+			if line, err := strconv.Atoi(matches[1]); err == nil {
+				return line
+			}
+		}
+		return 1
+	}
+	for _, stmt := range stmtList {
+		if strings.Contains(advice.Content, strings.TrimSuffix(stmt.Text, ";")) {
+			return stmt.Line
+		}
+	}
+	for _, matches := range quotedIdentifierReg.FindAllStringSubmatch(advice.Content, -1) {
+		identifier := matches[1] + matches[2]
+		identifierReg, err := regexp.Compile(fmt.Sprintf(`(?i)(^|[^\w])%s($|[^\w])`, regexp.QuoteMeta(identifier)))
+		if err != nil {
+			continue
+		}
+		for _, stmt := range stmtList {
+			if identifierReg.MatchString(stmt.Text) {
+				return stmt.Line
+			}
+		}
+	}
+	return 1
+}
+
+var (
+	_ catalog.Catalog = (*emptyCatalog)(nil)
+	_ catalog.Catalog = (*liveCatalog)(nil)
+)
+
+// emptyCatalog is the catalog without any database object, used when the dsn is not specified.
+type emptyCatalog struct{}
+
+// FindIndex finds nothing. Implement the catalog.Catalog interface.
+func (*emptyCatalog) FindIndex(_ context.Context, _ *catalog.IndexFind) (*catalog.Index, error) {
+	return nil, nil
+}
+
+// FindIndexList finds nothing. Implement the catalog.Catalog interface.
+func (*emptyCatalog) FindIndexList(_ context.Context, _ *catalog.IndexListFind) ([]*catalog.Index, error) {
+	return nil, catalog.ErrTableNotFound
+}
+
+// liveCatalog is the catalog synced from the database specified by the dsn.
+type liveCatalog struct {
+	driver   db.Driver
+	database string
+	// schema is synced at the first time we need it.
+	schema *db.Schema
+}
+
+// FindIndex finds the index by IndexFind. Implement the catalog.Catalog interface.
+func (c *liveCatalog) FindIndex(ctx context.Context, find *catalog.IndexFind) (*catalog.Index, error) {
+	indexList, err := c.FindIndexList(ctx, &catalog.IndexListFind{TableName: find.TableName})
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range indexList {
+		if index.Name == find.IndexName {
+			return index, nil
+		}
+	}
+	return nil, nil
+}
+
+// FindIndexList finds all the indexes of the table. Implement the catalog.Catalog interface.
+func (c *liveCatalog) FindIndexList(ctx context.Context, find *catalog.IndexListFind) ([]*catalog.Index, error) {
+	if c.schema == nil {
+		schemaList, err := c.driver.SyncSchema(ctx, c.database)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sync schema for database %q, got error: %w", c.database, err)
+		}
+		if len(schemaList) == 0 {
+			return nil, fmt.Errorf("database %q not found", c.database)
+		}
+		c.schema = schemaList[0]
+	}
+
+	for _, table := range c.schema.TableList {
+		if table.Name != find.TableName {
+			continue
+		}
+		indexList := append([]db.Index{}, table.IndexList...)
+		sort.SliceStable(indexList, func(i, j int) bool {
+			if indexList[i].Name != indexList[j].Name {
+				return indexList[i].Name < indexList[j].Name
+			}
+			return indexList[i].Position < indexList[j].Position
+		})
+
+		var result []*catalog.Index
+		for _, index := range indexList {
+			if len(result) == 0 || result[len(result)-1].Name != index.Name {
+				result = append(result, &catalog.Index{
+					Name:      index.Name,
+					TableName: table.Name,
+					Type:      index.Type,
+					Unique:    index.Unique,
+				})
+			}
+			last := result[len(result)-1]
+			last.ColumnExpressions = append(last.ColumnExpressions, index.Expression)
+		}
+		return result, nil
+	}
+	return nil, catalog.ErrTableNotFound
+}
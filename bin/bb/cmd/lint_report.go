@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/bytebase/bytebase/plugin/advisor"
+)
+
+// lintReporter writes the lint results in a specific format.
+type lintReporter interface {
+	report(out io.Writer, fileList []string, resultList []*lintResult) error
+}
+
+func newLintReporter(format string, failLevel advisor.Status) (lintReporter, error) {
+	switch format {
+	case "text":
+		return &textReporter{}, nil
+	case "json":
+		return &jsonReporter{}, nil
+	case "junit":
+		return &junitReporter{failLevel: failLevel}, nil
+	case "sarif":
+		return &sarifReporter{}, nil
+	}
+	return nil, fmt.Errorf("format %q not supported; supported formats: text, json, junit, sarif", format)
+}
+
+// textReporter writes one advice per line in "file:line: STATUS [code] title: content" format.
+type textReporter struct{}
+
+func (*textReporter) report(out io.Writer, _ []string, resultList []*lintResult) error {
+	errorCount, warningCount := 0, 0
+	for _, result := range resultList {
+		switch result.Advice.Status {
+		case advisor.Error:
+			errorCount++
+		case advisor.Warn:
+			warningCount++
+		}
+		if _, err := fmt.Fprintf(out, "%s:%d: %s [%d] %s: %s\n", result.File, result.Line, result.Advice.Status, result.Advice.Code, result.Advice.Title, result.Advice.Content); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(out, "%d error(s), %d warning(s)\n", errorCount, warningCount)
+	return err
+}
+
+// jsonResult is the lint result in JSON format.
+type jsonResult struct {
+	File    string         `json:"file"`
+	Line    int            `json:"line"`
+	Status  advisor.Status `json:"status"`
+	Code    advisor.Code   `json:"code"`
+	Title   string         `json:"title"`
+	Content string         `json:"content"`
+}
+
+// jsonReporter writes the results as a JSON array.
+type jsonReporter struct{}
+
+func (*jsonReporter) report(out io.Writer, _ []string, resultList []*lintResult) error {
+	list := []jsonResult{}
+	for _, result := range resultList {
+		list = append(list, jsonResult{
+			File:    result.File,
+			Line:    result.Line,
+			Status:  result.Advice.Status,
+			Code:    result.Advice.Code,
+			Title:   result.Advice.Title,
+			Content: result.Advice.Content,
+		})
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(list)
+}
+
+// junitTestSuites is the root element of the JUnit XML report.
+type junitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Content string `xml:",chardata"`
+}
+
+// junitReporter writes a test suite for each file and a test case for each advice.
+// Only the advices failing the lint are reported as failures. A file without any advice has a single passed test case.
+type junitReporter struct {
+	failLevel advisor.Status
+}
+
+func (r *junitReporter) report(out io.Writer, fileList []string, resultList []*lintResult) error {
+	suites := junitTestSuites{}
+	for _, file := range fileList {
+		suite := junitTestSuite{Name: file}
+		for _, result := range resultList {
+			if result.File != file {
+				continue
+			}
+			testCase := junitTestCase{
+				Name:      fmt.Sprintf("%s:%d %s", result.File, result.Line, result.Advice.Title),
+				ClassName: file,
+			}
+			if isLintFailure(result, r.failLevel) {
+				testCase.Failure = &junitFailure{
+					Message: result.Advice.Title,
+					Type:    result.Advice.Status.String(),
+					Content: result.Advice.Content,
+				}
+				suite.Failures++
+			}
+			suite.TestCases = append(suite.TestCases, testCase)
+		}
+		if len(suite.TestCases) == 0 {
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      file,
+				ClassName: file,
+			})
+		}
+		suite.Tests = len(suite.TestCases)
+		suites.TestSuites = append(suites.TestSuites, suite)
+	}
+
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(out, "\n")
+	return err
+}
+
+// The SARIF types cover the minimal subset for code scanning tools, e.g. GitHub code scanning.
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string `json:"name"`
+	Version        string `json:"version"`
+	InformationURI string `json:"informationUri"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// sarifReporter writes the results in SARIF 2.1.0.
+type sarifReporter struct{}
+
+func (*sarifReporter) report(out io.Writer, _ []string, resultList []*lintResult) error {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           "bb",
+				Version:        version,
+				InformationURI: "https://bytebase.com",
+			},
+		},
+		Results: []sarifResult{},
+	}
+	for _, result := range resultList {
+		level := "warning"
+		if result.Advice.Status == advisor.Error {
+			level = "error"
+		}
+		text := result.Advice.Title
+		if result.Advice.Content != "" {
+			text = fmt.Sprintf("%s: %s", result.Advice.Title, result.Advice.Content)
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:  fmt.Sprintf("%d", result.Advice.Code),
+			Level:   level,
+			Message: sarifMessage{Text: text},
+			Locations: []sarifLocation{
+				{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: result.File},
+						Region:           sarifRegion{StartLine: result.Line},
+					},
+				},
+			},
+		})
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"testing"
+
+	// Embedded expected output.
+	_ "embed"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bytebase/bytebase/plugin/advisor"
+)
+
+var (
+	//go:embed testdata/expected/lint_test_TestLint_01
+	_TestLint01 string
+	//go:embed testdata/expected/lint_test_TestLint_02
+	_TestLint02 string
+	//go:embed testdata/expected/lint_test_TestLint_03
+	_TestLint03 string
+)
+
+func TestLint(t *testing.T) {
+	tt := []testTable{
+		{
+			args: []string{
+				"lint",
+				"--policy", "testdata/lint/policy.json",
+				"testdata/lint/1_book.sql",
+				"testdata/lint/2_cleanup.sql",
+			},
+			expectedErr: errLintFailed,
+			expected:    _TestLint01,
+		},
+		{
+			args: []string{
+				"lint",
+				"--policy", "testdata/lint/policy.json",
+				"--format", "junit",
+				"testdata/lint/1_book.sql",
+			},
+			expected: _TestLint02,
+		},
+		{
+			args: []string{
+				"lint",
+				"--policy", "testdata/lint/policy.json",
+				"--format", "junit",
+				"--fail-on", "warning",
+				"testdata/lint/1_book.sql",
+				"testdata/lint/3_author.sql",
+			},
+			expectedErr: errLintFailed,
+			expected:    _TestLint03,
+		},
+	}
+	tableTest(t, tt)
+}
+
+func TestGetStatementList(t *testing.T) {
+	tests := []struct {
+		dbType advisor.DBType
+		text   string
+		want   []statement
+	}{
+		{
+			dbType: advisor.MySQL,
+			text:   "SELECT 1;\nSELECT 2;",
+			want: []statement{
+				{Text: "SELECT 1;", Line: 1},
+				{Text: "SELECT 2;", Line: 2},
+			},
+		},
+		{
+			dbType: advisor.MySQL,
+			text:   "-- comment; with semicolon\n# another; comment\n\nINSERT INTO t VALUES ('a;\nb', \"c\"\"d;\");\nSELECT `x;y` FROM t",
+			want: []statement{
+				{Text: "INSERT INTO t VALUES ('a;\nb', \"c\"\"d;\");", Line: 4},
+				{Text: "SELECT `x;y` FROM t", Line: 6},
+			},
+		},
+		{
+			dbType: advisor.TiDB,
+			text:   "/* header;\ncomment */\nCREATE TABLE t (id INT /* inline; */);\n/*!40101 SET NAMES utf8 */;",
+			want: []statement{
+				{Text: "CREATE TABLE t (id INT /* inline; */);", Line: 3},
+				{Text: "/*!40101 SET NAMES utf8 */;", Line: 4},
+			},
+		},
+		{
+			dbType: advisor.Postgres,
+			text:   "-- comment;\nCREATE FUNCTION f() RETURNS INT AS $body$ BEGIN RETURN 1; END; $body$ LANGUAGE plpgsql;\nSELECT 1",
+			want: []statement{
+				{Text: "CREATE FUNCTION f() RETURNS INT AS $body$ BEGIN RETURN 1; END; $body$ LANGUAGE plpgsql", Line: 2},
+				{Text: "SELECT 1", Line: 3},
+			},
+		},
+		{
+			dbType: advisor.MySQL,
+			text:   "SELECT FROM;",
+			want:   nil,
+		},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.want, getStatementList(test.dbType, test.text, "", ""), test.text)
+	}
+}
+
+func TestGetAdviceLine(t *testing.T) {
+	stmtList := []statement{
+		{Text: "CREATE TABLE book (id INT PRIMARY KEY);", Line: 2},
+		{Text: "CREATE TABLE tag (name TEXT);", Line: 8},
+		{Text: "DELETE FROM tag;", Line: 12},
+	}
+	tests := []struct {
+		advice advisor.Advice
+		want   int
+	}{
+		{
+			advice: advisor.Advice{Code: advisor.StatementNoWhere, Content: "\"\nDELETE FROM tag;\" requires WHERE clause"},
+			want:   12,
+		},
+		{
+			advice: advisor.Advice{Code: advisor.TableNoPK, Content: "Table `tag` requires PRIMARY KEY"},
+			want:   8,
+		},
+		{
+			advice: advisor.Advice{Code: advisor.StatementSyntaxError, Content: "line 5 column 20 near \"FROM\""},
+			want:   5,
+		},
+		{
+			advice: advisor.Advice{Code: advisor.TableNoPK, Content: "Table `author` requires PRIMARY KEY"},
+			want:   1,
+		},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.want, getAdviceLine(test.advice, stmtList), test.advice.Content)
+	}
+}
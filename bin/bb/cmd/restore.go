@@ -7,14 +7,16 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/bytebase/bytebase/common/backupfile"
 	"github.com/spf13/cobra"
 	"github.com/xo/dburl"
 )
 
 func newRestoreCmd() *cobra.Command {
 	var (
-		dsn  string
-		file string
+		dsn               string
+		file              string
+		encryptionKeyList []string
 	)
 	restoreCmd := &cobra.Command{
 		Use:   "restore",
@@ -24,11 +26,13 @@ func newRestoreCmd() *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("failed to parse dsn, got error: %w", err)
 			}
-			return restoreDatabase(context.Background(), u, file)
+			return restoreDatabase(context.Background(), u, file, encryptionKeyList)
 		},
 	}
 	restoreCmd.Flags().StringVar(&dsn, "dsn", "", dsnUsage)
 	restoreCmd.Flags().StringVar(&file, "file", "", "File to store the dump.")
+	restoreCmd.Flags().StringArrayVar(&encryptionKeyList, "encryption-key", nil, "The backup encryption key of the Bytebase workspace, required to restore the encrypted backup file. "+
+		"The workspace owner exports the active and the retired keys by GET /api/setting/backup-encryption-key. Repeat the flag to pass several keys, and the one encrypting the backup file is used.")
 	if err := restoreCmd.MarkFlagRequired("file"); err != nil {
 		panic(err)
 	}
@@ -37,13 +41,23 @@ func newRestoreCmd() *cobra.Command {
 }
 
 // restoreDatabase restores the schema of a database instance.
-func restoreDatabase(ctx context.Context, u *dburl.URL, file string) error {
+// The backup file compressed or encrypted by Bytebase is decompressed and decrypted transparently.
+func restoreDatabase(ctx context.Context, u *dburl.URL, file string, encryptionKeyList []string) error {
 	f, err := os.OpenFile(file, os.O_RDONLY, os.ModePerm)
 	if err != nil {
 		return fmt.Errorf("os.OpenFile(%q) error: %v", file, err)
 	}
 	defer f.Close()
-	sc := bufio.NewScanner(f)
+	var keyList []*backupfile.Key
+	for _, encryptionKey := range encryptionKeyList {
+		keyList = append(keyList, backupfile.NewKey(encryptionKey))
+	}
+	r, err := backupfile.NewReader(f, keyList)
+	if err != nil {
+		return fmt.Errorf("failed to read backup file %s got error: %w", file, err)
+	}
+	defer r.Close()
+	sc := bufio.NewScanner(r)
 
 	db, err := open(ctx, u)
 	if err != nil {
@@ -0,0 +1,10 @@
+-- Create the book table.
+CREATE TABLE book (
+  id INT PRIMARY KEY,
+  name TEXT NULL
+);
+
+/* Create the tag table without primary key. */
+CREATE TABLE tag (
+  name VARCHAR(64) COMMENT 'name; of the tag'
+);
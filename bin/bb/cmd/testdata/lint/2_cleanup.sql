@@ -0,0 +1,2 @@
+UPDATE book SET name = 'unknown' WHERE name IS NULL;
+DELETE FROM tag;
@@ -0,0 +1,8 @@
+# The primary key is added after the table is created.
+CREATE TABLE author (
+  id INT,
+  name TEXT NULL
+);
+ALTER TABLE author ADD PRIMARY KEY (id);
+# Nothing is deleted without the condition.
+DELETE FROM author WHERE name IS NULL;
@@ -31,6 +31,7 @@ func activeProfile(dataDir string) server.Profile {
 		Version:              version,
 		GitCommit:            gitcommit,
 		PgURL:                flags.pgURL,
+		MaxConcurrentTask:    flags.maxConcurrentTask,
 		MetricConnectionKey:  "3zcZLeX3ahvlueEJqNyJysGfVAErsjjT",
 	}
 }
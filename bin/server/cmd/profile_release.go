@@ -35,6 +35,7 @@ func activeProfile(dataDir string) server.Profile {
 		Version:              version,
 		GitCommit:            gitcommit,
 		PgURL:                flags.pgURL,
+		MaxConcurrentTask:    flags.maxConcurrentTask,
 		MetricConnectionKey:  "so9lLwj5zLjH09sxNabsyVNYSsAHn68F",
 	}
 }
@@ -0,0 +1,396 @@
+// Package backupfile provides the compression and the encryption of the database backup files.
+//
+// A backup file is the database dump compressed and then encrypted, both of which are optional.
+// The reader detects the encryption and the compression from the file content, so that the backup
+// files in any format, including the plain dump taken before, are restored transparently.
+//
+// The encryption uses the envelope encryption. Each backup file is encrypted by its own random data
+// key with AES-256-GCM, and the data key is encrypted by the key encryption key and stored in the file
+// header along with the key ID. The dump is sealed in chunks, so that the file is encrypted and decrypted
+// in streaming. The key ID lets the reader pick the key from the current and the retired keys after the
+// key rotation, and names the missing key if none of the given keys matches.
+package backupfile
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is the compression algorithm of the backup file.
+type Compression string
+
+const (
+	// CompressionNone is the backup file without compression.
+	CompressionNone Compression = "NONE"
+	// CompressionGzip is the backup file compressed by gzip.
+	CompressionGzip Compression = "GZIP"
+	// CompressionZstd is the backup file compressed by zstd.
+	CompressionZstd Compression = "ZSTD"
+)
+
+const (
+	// encryptionMagic is the header of the encrypted backup file.
+	encryptionMagic = "BBENC2"
+	// chunkSize is the max size of the plaintext sealed in a chunk.
+	chunkSize = 64 * 1024
+	// noncePrefixSize is the size of the random nonce prefix of the chunks.
+	// The chunk nonce is the prefix followed by the 4-byte chunk counter and the 1-byte last chunk flag.
+	noncePrefixSize = 7
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Key is the key encryption key derived from the secret.
+type Key struct {
+	// ID identifies the key in the backup files encrypted by it without revealing the key.
+	ID  string
+	kek []byte
+}
+
+// NewKey derives the 256-bit key encryption key from the secret.
+func NewKey(secret string) *Key {
+	kek := sha256.Sum256([]byte(secret))
+	id := sha256.Sum256(kek[:])
+	return &Key{
+		ID:  hex.EncodeToString(id[:4]),
+		kek: kek[:],
+	}
+}
+
+// NewWriter returns a writer writing the compressed and encrypted backup into w.
+// The backup is not encrypted if key is nil. The caller must close the writer to flush the backup file.
+func NewWriter(w io.Writer, compression Compression, key *Key) (io.WriteCloser, error) {
+	var closers []io.Closer
+	if key != nil {
+		ew, err := newEncryptWriter(w, key)
+		if err != nil {
+			return nil, err
+		}
+		w = ew
+		closers = append(closers, ew)
+	}
+
+	switch compression {
+	case CompressionNone, "":
+	case CompressionGzip:
+		gw := gzip.NewWriter(w)
+		w = gw
+		closers = append(closers, gw)
+	case CompressionZstd:
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd writer: %w", err)
+		}
+		w = zw
+		closers = append(closers, zw)
+	default:
+		return nil, fmt.Errorf("unsupported backup compression %q", compression)
+	}
+	return &writeCloser{Writer: w, closers: closers}, nil
+}
+
+// NewReader returns a reader reading the dump from the backup file in r.
+// The key encrypting the backup file must be in keyList if the backup file is encrypted.
+func NewReader(r io.Reader, keyList []*Key) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(len(encryptionMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if bytes.Equal(header, []byte(encryptionMagic)) {
+		if len(keyList) == 0 {
+			return nil, fmt.Errorf("the backup file is encrypted, but the encryption key is not provided")
+		}
+		dr, err := newDecryptReader(br, keyList)
+		if err != nil {
+			return nil, err
+		}
+		br = bufio.NewReader(dr)
+	}
+
+	header, err = br.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+		return gr, nil
+	case bytes.HasPrefix(header, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd reader: %w", err)
+		}
+		return zr.IOReadCloser(), nil
+	}
+	return io.NopCloser(br), nil
+}
+
+// writeCloser closes the compressor before the encryptor.
+type writeCloser struct {
+	io.Writer
+	closers []io.Closer
+}
+
+func (w *writeCloser) Close() error {
+	for i := len(w.closers) - 1; i >= 0; i-- {
+		if err := w.closers[i].Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encryptWriter seals the plaintext in chunks with the data key.
+type encryptWriter struct {
+	w           io.Writer
+	aead        cipher.AEAD
+	noncePrefix []byte
+	counter     uint32
+	buf         []byte
+}
+
+func newEncryptWriter(w io.Writer, key *Key) (*encryptWriter, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	encryptedDataKey, err := seal(key.kek, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt data key: %w", err)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	noncePrefix := make([]byte, noncePrefixSize)
+	if _, err := rand.Read(noncePrefix); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	// The header is the magic, the length and the content of the key ID and the encrypted data key, and the nonce prefix.
+	var header bytes.Buffer
+	header.WriteString(encryptionMagic)
+	header.WriteByte(byte(len(key.ID)))
+	header.WriteString(key.ID)
+	header.WriteByte(byte(len(encryptedDataKey)))
+	header.Write(encryptedDataKey)
+	header.Write(noncePrefix)
+	if _, err := w.Write(header.Bytes()); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{
+		w:           w,
+		aead:        aead,
+		noncePrefix: noncePrefix,
+		buf:         make([]byte, 0, chunkSize),
+	}, nil
+}
+
+func (w *encryptWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		// Keep the last chunk in the buffer, which is sealed with the last chunk flag on Close.
+		if len(w.buf) == chunkSize {
+			if err := w.writeChunk(false); err != nil {
+				return 0, err
+			}
+		}
+		size := chunkSize - len(w.buf)
+		if size > len(p) {
+			size = len(p)
+		}
+		w.buf = append(w.buf, p[:size]...)
+		p = p[size:]
+	}
+	return n, nil
+}
+
+func (w *encryptWriter) Close() error {
+	return w.writeChunk(true)
+}
+
+func (w *encryptWriter) writeChunk(last bool) error {
+	ciphertext := w.aead.Seal(nil, chunkNonce(w.noncePrefix, w.counter, last), w.buf, nil)
+	w.counter++
+	w.buf = w.buf[:0]
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(ciphertext)))
+	if _, err := w.w.Write(length[:]); err != nil {
+		return err
+	}
+	_, err := w.w.Write(ciphertext)
+	return err
+}
+
+// decryptReader opens the chunks sealed by encryptWriter.
+type decryptReader struct {
+	r           io.Reader
+	aead        cipher.AEAD
+	noncePrefix []byte
+	counter     uint32
+	buf         []byte
+	done        bool
+}
+
+func newDecryptReader(r io.Reader, keyList []*Key) (*decryptReader, error) {
+	magic := make([]byte, len(encryptionMagic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, fmt.Errorf("failed to read encryption header: %w", err)
+	}
+	id, err := readLengthPrefixed(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read encryption key ID: %w", err)
+	}
+	var candidateList []*Key
+	for _, key := range keyList {
+		if key.ID == string(id) {
+			candidateList = append(candidateList, key)
+		}
+	}
+	if len(candidateList) == 0 {
+		return nil, fmt.Errorf("the backup file is encrypted by the key %q, which is not provided", id)
+	}
+	encryptedDataKey, err := readLengthPrefixed(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read encrypted data key: %w", err)
+	}
+	var dataKey []byte
+	for _, key := range candidateList {
+		if dataKey, err = open(key.kek, encryptedDataKey); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data key, the encryption key may be wrong: %w", err)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	noncePrefix := make([]byte, noncePrefixSize)
+	if _, err := io.ReadFull(r, noncePrefix); err != nil {
+		return nil, fmt.Errorf("failed to read nonce: %w", err)
+	}
+	return &decryptReader{
+		r:           r,
+		aead:        aead,
+		noncePrefix: noncePrefix,
+	}, nil
+}
+
+func (r *decryptReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *decryptReader) readChunk() error {
+	var length [4]byte
+	if _, err := io.ReadFull(r.r, length[:]); err != nil {
+		if err == io.EOF {
+			return fmt.Errorf("the encrypted backup file is truncated")
+		}
+		return err
+	}
+	// The length is read from the file, so we bound it before allocating the buffer.
+	size := binary.BigEndian.Uint32(length[:])
+	if size > uint32(chunkSize+r.aead.Overhead()) {
+		return fmt.Errorf("invalid encrypted chunk %d size %d, the backup file may be corrupted", r.counter, size)
+	}
+	ciphertext := make([]byte, size)
+	if _, err := io.ReadFull(r.r, ciphertext); err != nil {
+		return fmt.Errorf("failed to read encrypted chunk: %w", err)
+	}
+	plaintext, err := r.aead.Open(nil, chunkNonce(r.noncePrefix, r.counter, false), ciphertext, nil)
+	if err != nil {
+		plaintext, err = r.aead.Open(nil, chunkNonce(r.noncePrefix, r.counter, true), ciphertext, nil)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt chunk %d, the backup file may be corrupted: %w", r.counter, err)
+		}
+		r.done = true
+	}
+	r.counter++
+	r.buf = plaintext
+	return nil
+}
+
+// readLengthPrefixed reads the content prefixed by its 1-byte length.
+func readLengthPrefixed(r io.Reader) ([]byte, error) {
+	var length [1]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return nil, err
+	}
+	content := make([]byte, length[0])
+	if _, err := io.ReadFull(r, content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+func chunkNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, noncePrefixSize+5)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], counter)
+	if last {
+		nonce[noncePrefixSize+4] = 1
+	}
+	return nonce
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts the plaintext with a random nonce, which is prepended to the ciphertext.
+func seal(key, plaintext []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key, ciphertext []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext is too short")
+	}
+	return aead.Open(nil, ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():], nil)
+}
@@ -0,0 +1,116 @@
+package backupfile
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupFileRoundTrip(t *testing.T) {
+	dump := strings.Repeat("INSERT INTO t VALUES (1, 'bytebase');\n", 10000)
+	key := NewKey("secret")
+
+	tests := []struct {
+		compression Compression
+		key         *Key
+	}{
+		{compression: CompressionNone},
+		{compression: CompressionGzip},
+		{compression: CompressionZstd},
+		{compression: CompressionNone, key: key},
+		{compression: CompressionGzip, key: key},
+		{compression: CompressionZstd, key: key},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, test.compression, test.key)
+		require.NoError(t, err)
+		_, err = io.WriteString(w, dump)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		if test.compression != CompressionNone {
+			require.Less(t, buf.Len(), len(dump))
+		}
+		if test.key != nil {
+			require.NotContains(t, buf.String(), "bytebase")
+		}
+
+		r, err := NewReader(bytes.NewReader(buf.Bytes()), []*Key{test.key})
+		require.NoError(t, err)
+		got, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, dump, string(got))
+	}
+}
+
+func TestBackupFileEncryptionFailure(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, CompressionNone, NewKey("secret"))
+	require.NoError(t, err)
+	_, err = io.WriteString(w, strings.Repeat("x", 3*chunkSize))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	file := buf.Bytes()
+
+	// Missing key.
+	_, err = NewReader(bytes.NewReader(file), nil)
+	require.Error(t, err)
+
+	// Wrong key.
+	_, err = NewReader(bytes.NewReader(file), []*Key{NewKey("wrong")})
+	require.Error(t, err)
+
+	// Truncated file.
+	r, err := NewReader(bytes.NewReader(file[:len(file)-100]), []*Key{NewKey("secret")})
+	require.NoError(t, err)
+	_, err = io.ReadAll(r)
+	require.Error(t, err)
+
+	// Tampered file.
+	tampered := append([]byte{}, file...)
+	tampered[len(tampered)/2] ^= 0xff
+	r, err = NewReader(bytes.NewReader(tampered), []*Key{NewKey("secret")})
+	require.NoError(t, err)
+	_, err = io.ReadAll(r)
+	require.Error(t, err)
+
+	// Oversized chunk length.
+	key := NewKey("secret")
+	headerSize := len(encryptionMagic) + 1 + len(key.ID)
+	headerSize += 1 + int(file[headerSize]) + noncePrefixSize
+	oversized := append([]byte{}, file[:headerSize]...)
+	oversized = append(oversized, 0xff, 0xff, 0xff, 0xff)
+	_, err = NewReader(bytes.NewReader(oversized), []*Key{key})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid encrypted chunk")
+}
+
+func TestBackupFileKeyRotation(t *testing.T) {
+	dump := "INSERT INTO t VALUES (1, 'bytebase');\n"
+	oldKey, newKey := NewKey("old"), NewKey("new")
+	require.NotEqual(t, oldKey.ID, newKey.ID)
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, CompressionGzip, oldKey)
+	require.NoError(t, err)
+	_, err = io.WriteString(w, dump)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	file := buf.Bytes()
+
+	// The backup file taken before the rotation is decrypted by the retired key.
+	r, err := NewReader(bytes.NewReader(file), []*Key{newKey, oldKey})
+	require.NoError(t, err)
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, dump, string(got))
+
+	// The error names the missing key.
+	_, err = NewReader(bytes.NewReader(file), []*Key{newKey})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), oldKey.ID)
+
+}
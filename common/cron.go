@@ -0,0 +1,167 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed standard 5-field cron expression: minute, hour, day of month, month and day of week.
+// Each field supports "*", single values, ranges "a-b", steps "*/n" or "a-b/n", and comma-separated lists.
+// Day of week accepts 0-7 where both 0 and 7 are Sunday.
+type CronSchedule struct {
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	// As standard cron, if both day of month and day of week are restricted, a day matches either of them.
+	domRestricted bool
+	dowRestricted bool
+}
+
+type cronField struct {
+	name string
+	min  int
+	max  int
+}
+
+var (
+	cronMinute = cronField{name: "minute", min: 0, max: 59}
+	cronHour   = cronField{name: "hour", min: 0, max: 23}
+	cronDom    = cronField{name: "day of month", min: 1, max: 31}
+	cronMonth  = cronField{name: "month", min: 1, max: 12}
+	cronDow    = cronField{name: "day of week", min: 0, max: 7}
+)
+
+// ParseCron parses the standard 5-field cron expression.
+func ParseCron(expr string) (*CronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields, got %d", expr, len(fields))
+	}
+	schedule := &CronSchedule{}
+	var err error
+	if schedule.minute, err = parseCronField(fields[0], cronMinute); err != nil {
+		return nil, err
+	}
+	if schedule.hour, err = parseCronField(fields[1], cronHour); err != nil {
+		return nil, err
+	}
+	if schedule.dom, err = parseCronField(fields[2], cronDom); err != nil {
+		return nil, err
+	}
+	if schedule.month, err = parseCronField(fields[3], cronMonth); err != nil {
+		return nil, err
+	}
+	if schedule.dow, err = parseCronField(fields[4], cronDow); err != nil {
+		return nil, err
+	}
+	// Sunday could be either 0 or 7.
+	if schedule.dow&(1<<7) != 0 {
+		schedule.dow |= 1
+	}
+	schedule.domRestricted = fields[2] != "*"
+	schedule.dowRestricted = fields[4] != "*"
+	return schedule, nil
+}
+
+func parseCronField(field string, f cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			rangePart = part[:i]
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q in cron %s field", part[i+1:], f.name)
+			}
+			step = n
+		}
+
+		start, end := f.min, f.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if start, err = parseCronValue(bounds[0], f); err != nil {
+				return 0, err
+			}
+			if end, err = parseCronValue(bounds[1], f); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid range %q in cron %s field", rangePart, f.name)
+			}
+		default:
+			v, err := parseCronValue(rangePart, f)
+			if err != nil {
+				return 0, err
+			}
+			start = v
+			// "a/n" means from a to the max with step n.
+			if step == 1 {
+				end = v
+			}
+		}
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func parseCronValue(s string, f cronField) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q in cron %s field", s, f.name)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d] in cron %s field", v, f.min, f.max, f.name)
+	}
+	return v, nil
+}
+
+func (c *CronSchedule) matchDay(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domRestricted && c.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// Next returns the earliest time matching the schedule strictly after t, in the location of t.
+// Returns the zero time if there is no match in the next 5 years, e.g. for "0 0 31 2 *".
+func (c *CronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Add(time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+	yearLimit := t.Year() + 5
+	for t.Year() <= yearLimit {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			// Guard against the repeated hour when the daylight saving time ends.
+			if !next.After(t) {
+				next = t.Add(time.Hour)
+			}
+			t = next
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
@@ -0,0 +1,83 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	base := time.Date(2022, 7, 6, 10, 30, 15, 0, time.UTC) // Wednesday
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{
+			expr: "* * * * *",
+			want: time.Date(2022, 7, 6, 10, 31, 0, 0, time.UTC),
+		},
+		{
+			expr: "0 22 * * 1-5",
+			want: time.Date(2022, 7, 6, 22, 0, 0, 0, time.UTC),
+		},
+		{
+			expr: "*/15 10 * * *",
+			want: time.Date(2022, 7, 6, 10, 45, 0, 0, time.UTC),
+		},
+		{
+			expr: "0 2 * * 6,7",
+			want: time.Date(2022, 7, 9, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			expr: "0 0 1 * *",
+			want: time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			// Either day of month or day of week matches.
+			expr: "0 0 31 * 5",
+			want: time.Date(2022, 7, 8, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			expr: "0 0 31 2 *",
+			want: time.Time{},
+		},
+	}
+
+	for _, test := range tests {
+		schedule, err := ParseCron(test.expr)
+		require.NoError(t, err, test.expr)
+		assert.Equal(t, test.want, schedule.Next(base), test.expr)
+	}
+}
+
+func TestCronScheduleNextTimezone(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+	schedule, err := ParseCron("0 2 * * *")
+	require.NoError(t, err)
+
+	got := schedule.Next(time.Date(2022, 7, 6, 0, 0, 0, 0, time.UTC).In(loc))
+	assert.Equal(t, time.Date(2022, 7, 6, 18, 0, 0, 0, time.UTC), got.UTC())
+}
+
+func TestParseCronError(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+	}
+
+	for _, expr := range tests {
+		_, err := ParseCron(expr)
+		assert.Error(t, err, expr)
+	}
+}
@@ -33,7 +33,8 @@ const (
 	MigrationFailed          Code = 206
 
 	// 301 task error
-	TaskTimingNotAllowed Code = 301
+	TaskTimingNotAllowed           Code = 301
+	TaskDeploymentWindowNotAllowed Code = 302
 )
 
 // Int returns the int type of code.
@@ -61,6 +62,11 @@ func (e *Error) Error() string {
 	return e.Err.Error()
 }
 
+// Unwrap returns the embedded error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
 // ErrorCode unwraps an application error and returns its code.
 // Non-application errors always return EINTERNAL.
 func ErrorCode(err error) Code {
@@ -3,6 +3,8 @@
 package advisor
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"sync"
 
@@ -96,6 +98,30 @@ const (
 	// MySQLNoSelectAll is an advisor type for MySQL no select all.
 	MySQLNoSelectAll Type = "bb.plugin.advisor.mysql.select.no-select-all"
 
+	// MySQLDisallowDropTruncate is an advisor type for MySQL disallowing DROP and TRUNCATE.
+	MySQLDisallowDropTruncate Type = "bb.plugin.advisor.mysql.drop-truncate.disallow"
+
+	// MySQLDMLNoLimit is an advisor type for MySQL no LIMIT in UPDATE and DELETE.
+	MySQLDMLNoLimit Type = "bb.plugin.advisor.mysql.dml.no-limit"
+
+	// MySQLDMLNoOrderBy is an advisor type for MySQL no ORDER BY in UPDATE and DELETE.
+	MySQLDMLNoOrderBy Type = "bb.plugin.advisor.mysql.dml.no-order-by"
+
+	// MySQLAffectedRowLimit is an advisor type for MySQL UPDATE and DELETE affected row limit.
+	MySQLAffectedRowLimit Type = "bb.plugin.advisor.mysql.dml.affected-row-limit"
+
+	// MySQLInsertMustSpecifyColumn is an advisor type for MySQL INSERT must specify column.
+	MySQLInsertMustSpecifyColumn Type = "bb.plugin.advisor.mysql.insert.must-specify-column"
+
+	// MySQLInsertNoSelectAll is an advisor type for MySQL no INSERT ... SELECT *.
+	MySQLInsertNoSelectAll Type = "bb.plugin.advisor.mysql.insert.no-select-all"
+
+	// MySQLIndexSuggestion is an advisor type for MySQL missing index suggestion.
+	MySQLIndexSuggestion Type = "bb.plugin.advisor.mysql.index-suggestion"
+
+	// MySQLCustomRule is an advisor type for MySQL user-defined custom rules.
+	MySQLCustomRule Type = "bb.plugin.advisor.mysql.custom"
+
 	// MySQLTableRequirePK is an advisor type for MySQL table require primary key.
 	MySQLTableRequirePK Type = "bb.plugin.advisor.mysql.table.require-pk"
 
@@ -151,6 +177,13 @@ type Context struct {
 	// Schema review rule special fields.
 	Rule    *SchemaReviewRule
 	Catalog catalog.Catalog
+
+	// EnvironmentName is the name of the environment where the statement would run.
+	EnvironmentName string
+	// Driver is the connection to the target database, could be nil.
+	Driver *sql.DB
+	// Context is the context of the queries to the target database through Driver, which must be set if Driver is set.
+	Context context.Context
 }
 
 // Advisor is the interface for advisor.
@@ -2,11 +2,18 @@ package catalog
 
 import (
 	"context"
+	"errors"
 )
 
+// ErrTableNotFound is the error returned by FindIndexList if the table is unknown to the catalog,
+// e.g. the table is never synced. It's different from a table without any index.
+var ErrTableNotFound = errors.New("table not found in catalog")
+
 // Catalog is the service for catalog.
 type Catalog interface {
 	FindIndex(ctx context.Context, find *IndexFind) (*Index, error)
+	// FindIndexList finds all the indexes of the table, returns ErrTableNotFound if the table is unknown.
+	FindIndexList(ctx context.Context, find *IndexListFind) ([]*Index, error)
 }
 
 // Index is the API message for an index.
@@ -23,3 +30,8 @@ type IndexFind struct {
 	TableName string
 	IndexName string
 }
+
+// IndexListFind is the API message for find the index list of a table.
+type IndexListFind struct {
+	TableName string
+}
@@ -27,6 +27,14 @@ const (
 	StatementNoWhere             Code = 202
 	StatementSelectAll           Code = 203
 	StatementLeadingWildcardLike Code = 204
+	StatementDropOrTruncate      Code = 205
+	StatementDMLWithLimit        Code = 206
+	StatementDMLWithOrderBy      Code = 207
+	StatementAffectedRowExceeded Code = 208
+	StatementExplainQueryFailed  Code = 209
+	StatementInsertNoColumn      Code = 210
+	StatementInsertSelectAll     Code = 211
+	StatementMissingIndex        Code = 212
 
 	// 301 ～ 399 naming error code
 	// 301 table naming advisor error code
@@ -49,6 +57,9 @@ const (
 
 	// 601 table rule advisor error code
 	TableNoPK Code = 601
+
+	// 701 custom rule advisor error code
+	CustomRuleViolation Code = 701
 )
 
 // Int returns the int type of code.
@@ -0,0 +1,260 @@
+package advisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+)
+
+// Custom rules are the schema review rules defined by users in the schema review policy payload.
+// The rule type must start with CustomRuleTypePrefix, e.g. "custom.no-blob-column", and the payload is CustomRulePayload.
+// Each parsed statement is normalized into a StatementView, and the CEL expression in the payload
+// is evaluated against it as the "stmt" variable. For example,
+//   stmt.kind == "CREATE_TABLE" && stmt.columns.exists(c, c.type == "blob")
+// reports an advice for each CREATE TABLE statement with a BLOB column.
+
+// CustomRuleTypePrefix is the rule type prefix for custom rules.
+const CustomRuleTypePrefix = "custom."
+
+// StatementKind is the kind of the normalized statement.
+type StatementKind string
+
+const (
+	// StatementKindCreateDatabase is the statement kind for CREATE DATABASE.
+	StatementKindCreateDatabase StatementKind = "CREATE_DATABASE"
+	// StatementKindDropDatabase is the statement kind for DROP DATABASE.
+	StatementKindDropDatabase StatementKind = "DROP_DATABASE"
+	// StatementKindCreateTable is the statement kind for CREATE TABLE.
+	StatementKindCreateTable StatementKind = "CREATE_TABLE"
+	// StatementKindAlterTable is the statement kind for ALTER TABLE.
+	StatementKindAlterTable StatementKind = "ALTER_TABLE"
+	// StatementKindDropTable is the statement kind for DROP TABLE.
+	StatementKindDropTable StatementKind = "DROP_TABLE"
+	// StatementKindRenameTable is the statement kind for RENAME TABLE.
+	StatementKindRenameTable StatementKind = "RENAME_TABLE"
+	// StatementKindTruncateTable is the statement kind for TRUNCATE TABLE.
+	StatementKindTruncateTable StatementKind = "TRUNCATE_TABLE"
+	// StatementKindCreateIndex is the statement kind for CREATE INDEX.
+	StatementKindCreateIndex StatementKind = "CREATE_INDEX"
+	// StatementKindDropIndex is the statement kind for DROP INDEX.
+	StatementKindDropIndex StatementKind = "DROP_INDEX"
+	// StatementKindInsert is the statement kind for INSERT and REPLACE.
+	StatementKindInsert StatementKind = "INSERT"
+	// StatementKindUpdate is the statement kind for UPDATE.
+	StatementKindUpdate StatementKind = "UPDATE"
+	// StatementKindDelete is the statement kind for DELETE.
+	StatementKindDelete StatementKind = "DELETE"
+	// StatementKindSelect is the statement kind for SELECT.
+	StatementKindSelect StatementKind = "SELECT"
+	// StatementKindOther is the statement kind for all the other statements.
+	StatementKindOther StatementKind = "OTHER"
+)
+
+// ConstraintKind is the kind of the normalized constraint.
+type ConstraintKind string
+
+const (
+	// ConstraintKindPrimaryKey is the constraint kind for primary key.
+	ConstraintKindPrimaryKey ConstraintKind = "PRIMARY_KEY"
+	// ConstraintKindUnique is the constraint kind for unique key.
+	ConstraintKindUnique ConstraintKind = "UNIQUE"
+	// ConstraintKindIndex is the constraint kind for normal index.
+	ConstraintKindIndex ConstraintKind = "INDEX"
+	// ConstraintKindForeignKey is the constraint kind for foreign key.
+	ConstraintKindForeignKey ConstraintKind = "FOREIGN_KEY"
+	// ConstraintKindFulltext is the constraint kind for fulltext index.
+	ConstraintKindFulltext ConstraintKind = "FULLTEXT"
+	// ConstraintKindCheck is the constraint kind for check constraint.
+	ConstraintKindCheck ConstraintKind = "CHECK"
+)
+
+// StatementView is the normalized view of a parsed statement for custom rules.
+type StatementView struct {
+	Kind  StatementKind
+	Text  string
+	Table string
+	// Columns are the defined or changed columns, e.g. the columns in CREATE TABLE or ALTER TABLE ADD COLUMN.
+	Columns []*ColumnView
+	// Constraints are the defined constraints and indexes, including the ones defined in the column definitions.
+	Constraints []*ConstraintView
+	// HasWhere is whether the UPDATE, DELETE or SELECT statement has the WHERE clause.
+	HasWhere bool
+}
+
+// ColumnView is the normalized view of a column definition.
+type ColumnView struct {
+	Name       string
+	Type       string
+	Nullable   bool
+	HasDefault bool
+	Comment    string
+}
+
+// ConstraintView is the normalized view of a constraint definition.
+type ConstraintView struct {
+	Kind    ConstraintKind
+	Name    string
+	Columns []string
+}
+
+// celValue converts the statement view to the value evaluated by CEL.
+// We use the lower camel case keys, e.g. stmt.columns[0].hasDefault.
+func (v *StatementView) celValue() map[string]interface{} {
+	var columnList []interface{}
+	for _, column := range v.Columns {
+		columnList = append(columnList, map[string]interface{}{
+			"name":       column.Name,
+			"type":       column.Type,
+			"nullable":   column.Nullable,
+			"hasDefault": column.HasDefault,
+			"comment":    column.Comment,
+		})
+	}
+	var constraintList []interface{}
+	for _, constraint := range v.Constraints {
+		var keyList []interface{}
+		for _, column := range constraint.Columns {
+			keyList = append(keyList, column)
+		}
+		constraintList = append(constraintList, map[string]interface{}{
+			"kind":    string(constraint.Kind),
+			"name":    constraint.Name,
+			"columns": nonNilList(keyList),
+		})
+	}
+	return map[string]interface{}{
+		"kind":        string(v.Kind),
+		"text":        v.Text,
+		"table":       v.Table,
+		"columns":     nonNilList(columnList),
+		"constraints": nonNilList(constraintList),
+		"hasWhere":    v.HasWhere,
+	}
+}
+
+func nonNilList(list []interface{}) []interface{} {
+	if list == nil {
+		return []interface{}{}
+	}
+	return list
+}
+
+const (
+	// StatementTemplateToken is the token for the statement text in custom rule templates.
+	StatementTemplateToken = "{{statement}}"
+	// StatementKindTemplateToken is the token for the statement kind in custom rule templates.
+	StatementKindTemplateToken = "{{kind}}"
+)
+
+// CustomRulePayload is the payload for custom rules.
+type CustomRulePayload struct {
+	// Expression is the CEL expression evaluated against the "stmt" variable.
+	// The advice is reported if the expression evaluates to true.
+	Expression string `json:"expression"`
+	// Title and Content are the templates for the advice.
+	// They support {{statement}}, {{kind}}, {{table}} and {{column_list}} tokens.
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+// CustomRule is the compiled custom rule.
+type CustomRule struct {
+	program cel.Program
+	title   string
+	content string
+}
+
+var customRuleEnv *cel.Env
+
+func init() {
+	env, err := cel.NewEnv(
+		cel.Declarations(
+			decls.NewVar("stmt", decls.NewMapType(decls.String, decls.Dyn)),
+		),
+	)
+	if err != nil {
+		panic(fmt.Sprintf("advisor: failed to create the CEL environment for custom rules: %v", err))
+	}
+	customRuleEnv = env
+}
+
+// IsCustomRule returns whether the rule type is a custom rule type.
+func IsCustomRule(ruleType SchemaReviewRuleType) bool {
+	return strings.HasPrefix(string(ruleType), CustomRuleTypePrefix)
+}
+
+// NewCustomRule will unmarshal payload to CustomRulePayload and compile the expression.
+func NewCustomRule(ruleType SchemaReviewRuleType, payload string) (*CustomRule, error) {
+	var cr CustomRulePayload
+	if err := json.Unmarshal([]byte(payload), &cr); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal custom rule payload %q: %q", payload, err)
+	}
+	if cr.Expression == "" {
+		return nil, fmt.Errorf("invalid custom rule payload, expression cannot be empty")
+	}
+	ast, issues := customRuleEnv.Compile(cr.Expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("failed to compile custom rule expression %q: %v", cr.Expression, issues.Err())
+	}
+	if resultType := ast.ResultType(); resultType.GetPrimitive() != decls.Bool.GetPrimitive() && resultType.GetDyn() == nil {
+		return nil, fmt.Errorf("custom rule expression %q must be a boolean expression, got %s", cr.Expression, cel.FormatType(resultType))
+	}
+	program, err := customRuleEnv.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build custom rule expression %q: %v", cr.Expression, err)
+	}
+
+	rule := &CustomRule{
+		program: program,
+		title:   cr.Title,
+		content: cr.Content,
+	}
+	if rule.title == "" {
+		rule.title = string(ruleType)
+	}
+	if rule.content == "" {
+		rule.content = fmt.Sprintf("\"%s\" violates the custom rule", StatementTemplateToken)
+	}
+	return rule, nil
+}
+
+// Match returns whether the statement matches the rule expression.
+func (r *CustomRule) Match(stmt *StatementView) (bool, error) {
+	out, _, err := r.program.Eval(map[string]interface{}{
+		"stmt": stmt.celValue(),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate custom rule for %q: %w", stmt.Text, err)
+	}
+	match, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("custom rule returns non-boolean value %v for %q", out.Value(), stmt.Text)
+	}
+	return match, nil
+}
+
+// Title returns the advice title for the statement.
+func (r *CustomRule) Title(stmt *StatementView) string {
+	return renderCustomRuleTemplate(r.title, stmt)
+}
+
+// Content returns the advice content for the statement.
+func (r *CustomRule) Content(stmt *StatementView) string {
+	return renderCustomRuleTemplate(r.content, stmt)
+}
+
+func renderCustomRuleTemplate(template string, stmt *StatementView) string {
+	var columnList []string
+	for _, column := range stmt.Columns {
+		columnList = append(columnList, column.Name)
+	}
+	return strings.NewReplacer(
+		StatementTemplateToken, stmt.Text,
+		StatementKindTemplateToken, string(stmt.Kind),
+		TableNameTemplateToken, stmt.Table,
+		ColumnListTemplateToken, strings.Join(columnList, ", "),
+	).Replace(template)
+}
@@ -0,0 +1,278 @@
+package mysql
+
+import (
+	"strings"
+
+	"github.com/bytebase/bytebase/plugin/advisor"
+
+	"github.com/pingcap/tidb/parser/ast"
+	"github.com/pingcap/tidb/parser/types"
+)
+
+var (
+	_ advisor.Advisor = (*CustomRuleAdvisor)(nil)
+)
+
+func init() {
+	advisor.Register(advisor.MySQL, advisor.MySQLCustomRule, &CustomRuleAdvisor{})
+	advisor.Register(advisor.TiDB, advisor.MySQLCustomRule, &CustomRuleAdvisor{})
+}
+
+// CustomRuleAdvisor is the advisor checking for the user-defined custom rules.
+type CustomRuleAdvisor struct {
+}
+
+// Check checks for the user-defined custom rules.
+func (adv *CustomRuleAdvisor) Check(ctx advisor.Context, statement string) ([]advisor.Advice, error) {
+	root, errAdvice := parseStatement(statement, ctx.Charset, ctx.Collation)
+	if errAdvice != nil {
+		return errAdvice, nil
+	}
+
+	level, err := advisor.NewStatusBySchemaReviewRuleLevel(ctx.Rule.Level)
+	if err != nil {
+		return nil, err
+	}
+	rule, err := advisor.NewCustomRule(ctx.Rule.Type, ctx.Rule.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var adviceList []advisor.Advice
+	for _, stmtNode := range root {
+		view := newStatementView(stmtNode)
+		match, err := rule.Match(view)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			adviceList = append(adviceList, advisor.Advice{
+				Status:  level,
+				Code:    advisor.CustomRuleViolation,
+				Title:   rule.Title(view),
+				Content: rule.Content(view),
+			})
+		}
+	}
+
+	if len(adviceList) == 0 {
+		adviceList = append(adviceList, advisor.Advice{
+			Status:  advisor.Success,
+			Code:    advisor.Ok,
+			Title:   "OK",
+			Content: "",
+		})
+	}
+	return adviceList, nil
+}
+
+// newStatementView normalizes the statement node for custom rules.
+func newStatementView(in ast.StmtNode) *advisor.StatementView {
+	view := &advisor.StatementView{
+		Kind: advisor.StatementKindOther,
+		Text: in.Text(),
+	}
+	switch node := in.(type) {
+	case *ast.CreateDatabaseStmt:
+		view.Kind = advisor.StatementKindCreateDatabase
+	case *ast.DropDatabaseStmt:
+		view.Kind = advisor.StatementKindDropDatabase
+	case *ast.CreateTableStmt:
+		view.Kind = advisor.StatementKindCreateTable
+		view.Table = node.Table.Name.O
+		for _, column := range node.Cols {
+			view.Columns = append(view.Columns, newColumnView(column))
+			view.Constraints = append(view.Constraints, newColumnConstraintViewList(column)...)
+		}
+		for _, constraint := range node.Constraints {
+			if constraintView := newConstraintView(constraint); constraintView != nil {
+				view.Constraints = append(view.Constraints, constraintView)
+			}
+		}
+	case *ast.AlterTableStmt:
+		view.Kind = advisor.StatementKindAlterTable
+		view.Table = node.Table.Name.O
+		for _, spec := range node.Specs {
+			switch spec.Tp {
+			case ast.AlterTableAddColumns, ast.AlterTableChangeColumn, ast.AlterTableModifyColumn:
+				for _, column := range spec.NewColumns {
+					view.Columns = append(view.Columns, newColumnView(column))
+					view.Constraints = append(view.Constraints, newColumnConstraintViewList(column)...)
+				}
+			case ast.AlterTableAddConstraint:
+				if constraintView := newConstraintView(spec.Constraint); constraintView != nil {
+					view.Constraints = append(view.Constraints, constraintView)
+				}
+			}
+		}
+	case *ast.DropTableStmt:
+		if node.IsView {
+			break
+		}
+		view.Kind = advisor.StatementKindDropTable
+		if len(node.Tables) > 0 {
+			view.Table = node.Tables[0].Name.O
+		}
+	case *ast.RenameTableStmt:
+		view.Kind = advisor.StatementKindRenameTable
+		if len(node.TableToTables) > 0 {
+			view.Table = node.TableToTables[0].OldTable.Name.O
+		}
+	case *ast.TruncateTableStmt:
+		view.Kind = advisor.StatementKindTruncateTable
+		view.Table = node.Table.Name.O
+	case *ast.CreateIndexStmt:
+		view.Kind = advisor.StatementKindCreateIndex
+		view.Table = node.Table.Name.O
+		kind := advisor.ConstraintKindIndex
+		switch node.KeyType {
+		case ast.IndexKeyTypeUnique:
+			kind = advisor.ConstraintKindUnique
+		case ast.IndexKeyTypeFullText:
+			kind = advisor.ConstraintKindFulltext
+		}
+		view.Constraints = append(view.Constraints, &advisor.ConstraintView{
+			Kind:    kind,
+			Name:    node.IndexName,
+			Columns: indexColumnList(node.IndexPartSpecifications),
+		})
+	case *ast.DropIndexStmt:
+		view.Kind = advisor.StatementKindDropIndex
+		view.Table = node.Table.Name.O
+	case *ast.InsertStmt:
+		view.Kind = advisor.StatementKindInsert
+		view.Table = firstTableName(node.Table)
+		for _, column := range node.Columns {
+			view.Columns = append(view.Columns, &advisor.ColumnView{Name: column.Name.O})
+		}
+	case *ast.UpdateStmt:
+		view.Kind = advisor.StatementKindUpdate
+		view.Table = firstTableName(node.TableRefs)
+		view.HasWhere = node.Where != nil
+	case *ast.DeleteStmt:
+		view.Kind = advisor.StatementKindDelete
+		view.Table = firstTableName(node.TableRefs)
+		view.HasWhere = node.Where != nil
+	case *ast.SelectStmt:
+		view.Kind = advisor.StatementKindSelect
+		view.Table = firstTableName(node.From)
+		view.HasWhere = node.Where != nil
+	}
+	return view
+}
+
+func newColumnView(column *ast.ColumnDef) *advisor.ColumnView {
+	view := &advisor.ColumnView{
+		Name:     column.Name.Name.O,
+		Nullable: true,
+	}
+	if column.Tp != nil {
+		view.Type = strings.ToLower(types.TypeToStr(column.Tp.Tp, column.Tp.Charset))
+	}
+	for _, option := range column.Options {
+		switch option.Tp {
+		case ast.ColumnOptionNotNull, ast.ColumnOptionPrimaryKey:
+			view.Nullable = false
+		case ast.ColumnOptionDefaultValue:
+			view.HasDefault = true
+		case ast.ColumnOptionComment:
+			if value, ok := option.Expr.(ast.ValueExpr); ok {
+				view.Comment = value.GetString()
+			}
+		}
+	}
+	return view
+}
+
+// newColumnConstraintViewList returns the constraints defined in the column definition, e.g. "id INT PRIMARY KEY".
+func newColumnConstraintViewList(column *ast.ColumnDef) []*advisor.ConstraintView {
+	var result []*advisor.ConstraintView
+	for _, option := range column.Options {
+		switch option.Tp {
+		case ast.ColumnOptionPrimaryKey:
+			result = append(result, &advisor.ConstraintView{
+				Kind:    advisor.ConstraintKindPrimaryKey,
+				Columns: []string{column.Name.Name.O},
+			})
+		case ast.ColumnOptionUniqKey:
+			result = append(result, &advisor.ConstraintView{
+				Kind:    advisor.ConstraintKindUnique,
+				Columns: []string{column.Name.Name.O},
+			})
+		case ast.ColumnOptionCheck:
+			result = append(result, &advisor.ConstraintView{
+				Kind:    advisor.ConstraintKindCheck,
+				Name:    option.ConstraintName,
+				Columns: []string{column.Name.Name.O},
+			})
+		}
+	}
+	return result
+}
+
+func newConstraintView(constraint *ast.Constraint) *advisor.ConstraintView {
+	var kind advisor.ConstraintKind
+	switch constraint.Tp {
+	case ast.ConstraintPrimaryKey:
+		kind = advisor.ConstraintKindPrimaryKey
+	case ast.ConstraintUniq, ast.ConstraintUniqKey, ast.ConstraintUniqIndex:
+		kind = advisor.ConstraintKindUnique
+	case ast.ConstraintKey, ast.ConstraintIndex:
+		kind = advisor.ConstraintKindIndex
+	case ast.ConstraintForeignKey:
+		kind = advisor.ConstraintKindForeignKey
+	case ast.ConstraintFulltext:
+		kind = advisor.ConstraintKindFulltext
+	case ast.ConstraintCheck:
+		kind = advisor.ConstraintKindCheck
+	default:
+		return nil
+	}
+	return &advisor.ConstraintView{
+		Kind:    kind,
+		Name:    constraint.Name,
+		Columns: indexColumnList(constraint.Keys),
+	}
+}
+
+func indexColumnList(keys []*ast.IndexPartSpecification) []string {
+	var columnList []string
+	for _, key := range keys {
+		// The expression index doesn't have the column name.
+		if key.Column != nil {
+			columnList = append(columnList, key.Column.Name.O)
+		}
+	}
+	return columnList
+}
+
+// firstTableName returns the name of the first table in the table references.
+func firstTableName(node *ast.TableRefsClause) string {
+	if node == nil {
+		return ""
+	}
+	finder := &tableNameFinder{}
+	node.Accept(finder)
+	return finder.name
+}
+
+type tableNameFinder struct {
+	name string
+}
+
+// Enter implements the ast.Visitor interface
+func (f *tableNameFinder) Enter(in ast.Node) (ast.Node, bool) {
+	if f.name != "" {
+		return in, true
+	}
+	if node, ok := in.(*ast.TableName); ok {
+		f.name = node.Name.O
+		return in, true
+	}
+	return in, false
+}
+
+// Leave implements the ast.Visitor interface
+func (f *tableNameFinder) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
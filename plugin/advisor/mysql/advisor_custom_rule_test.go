@@ -0,0 +1,143 @@
+package mysql
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bytebase/bytebase/plugin/advisor"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomRule(t *testing.T) {
+	tests := []advisor.TestCase{
+		{
+			Statement: "CREATE TABLE t(id INT PRIMARY KEY, content BLOB)",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Error,
+					Code:    advisor.CustomRuleViolation,
+					Title:   "No BLOB column in t",
+					Content: "CREATE_TABLE t defines BLOB in id, content",
+				},
+			},
+		},
+		{
+			Statement: "ALTER TABLE t ADD COLUMN content BLOB",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Error,
+					Code:    advisor.CustomRuleViolation,
+					Title:   "No BLOB column in t",
+					Content: "ALTER_TABLE t defines BLOB in content",
+				},
+			},
+		},
+		{
+			Statement: "CREATE TABLE t(id INT PRIMARY KEY, content TEXT)",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    advisor.Ok,
+					Title:   "OK",
+					Content: "",
+				},
+			},
+		},
+	}
+
+	payload, err := json.Marshal(advisor.CustomRulePayload{
+		Expression: `stmt.kind in ["CREATE_TABLE", "ALTER_TABLE"] && stmt.columns.exists(c, c.type == "blob")`,
+		Title:      "No BLOB column in {{table}}",
+		Content:    "{{kind}} {{table}} defines BLOB in {{column_list}}",
+	})
+	require.NoError(t, err)
+	advisor.RunSchemaReviewRuleTests(t, tests, &CustomRuleAdvisor{}, &advisor.SchemaReviewRule{
+		Type:    "custom.no-blob-column",
+		Level:   advisor.SchemaRuleLevelError,
+		Payload: string(payload),
+	}, &advisor.MockCatalogService{})
+
+	tests = []advisor.TestCase{
+		{
+			Statement: "CREATE TABLE t(id INT, name VARCHAR(20), UNIQUE KEY uk_name (name))",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Warn,
+					Code:    advisor.CustomRuleViolation,
+					Title:   "custom.table-require-pk",
+					Content: "\"CREATE TABLE t(id INT, name VARCHAR(20), UNIQUE KEY uk_name (name))\" violates the custom rule",
+				},
+			},
+		},
+		{
+			Statement: "CREATE TABLE t(id INT, name VARCHAR(20), PRIMARY KEY (id))",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    advisor.Ok,
+					Title:   "OK",
+					Content: "",
+				},
+			},
+		},
+		{
+			Statement: "CREATE TABLE t(id INT PRIMARY KEY)",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    advisor.Ok,
+					Title:   "OK",
+					Content: "",
+				},
+			},
+		},
+	}
+
+	payload, err = json.Marshal(advisor.CustomRulePayload{
+		Expression: `stmt.kind == "CREATE_TABLE" && !stmt.constraints.exists(c, c.kind == "PRIMARY_KEY")`,
+	})
+	require.NoError(t, err)
+	advisor.RunSchemaReviewRuleTests(t, tests, &CustomRuleAdvisor{}, &advisor.SchemaReviewRule{
+		Type:    "custom.table-require-pk",
+		Level:   advisor.SchemaRuleLevelWarning,
+		Payload: string(payload),
+	}, &advisor.MockCatalogService{})
+}
+
+func TestCustomRuleValidate(t *testing.T) {
+	tests := []struct {
+		expression string
+		valid      bool
+	}{
+		{
+			expression: `stmt.kind == "DELETE" && !stmt.hasWhere`,
+			valid:      true,
+		},
+		{
+			expression: `stmt.kind ==`,
+			valid:      false,
+		},
+		{
+			expression: `"DELETE"`,
+			valid:      false,
+		},
+		{
+			expression: ``,
+			valid:      false,
+		},
+	}
+
+	for _, tc := range tests {
+		payload, err := json.Marshal(advisor.CustomRulePayload{
+			Expression: tc.expression,
+		})
+		require.NoError(t, err)
+		rule := &advisor.SchemaReviewRule{
+			Type:    "custom.rule",
+			Level:   advisor.SchemaRuleLevelError,
+			Payload: string(payload),
+		}
+		err = rule.Validate()
+		require.Equal(t, tc.valid, err == nil, tc.expression)
+	}
+}
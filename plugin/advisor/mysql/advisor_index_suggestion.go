@@ -0,0 +1,394 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bytebase/bytebase/plugin/advisor"
+	"github.com/bytebase/bytebase/plugin/advisor/catalog"
+
+	"github.com/pingcap/tidb/parser/ast"
+	"github.com/pingcap/tidb/parser/opcode"
+)
+
+var (
+	_ advisor.Advisor = (*IndexSuggestionAdvisor)(nil)
+)
+
+// maxSuggestedIndexColumnCount is the maximum column count of the suggested index.
+// The index with too many columns is expensive to maintain and rarely helps more.
+const maxSuggestedIndexColumnCount = 5
+
+func init() {
+	advisor.Register(advisor.MySQL, advisor.MySQLIndexSuggestion, &IndexSuggestionAdvisor{})
+	advisor.Register(advisor.TiDB, advisor.MySQLIndexSuggestion, &IndexSuggestionAdvisor{})
+}
+
+// IndexSuggestionAdvisor is the advisor suggesting the missing indexes for SELECT, UPDATE and DELETE.
+type IndexSuggestionAdvisor struct {
+}
+
+// Check suggests the composite indexes for the WHERE, JOIN and ORDER BY columns which are not covered by the existing indexes.
+func (adv *IndexSuggestionAdvisor) Check(ctx advisor.Context, statement string) ([]advisor.Advice, error) {
+	root, errAdvice := parseStatement(statement, ctx.Charset, ctx.Collation)
+	if errAdvice != nil {
+		return errAdvice, nil
+	}
+
+	level, err := advisor.NewStatusBySchemaReviewRuleLevel(ctx.Rule.Level)
+	if err != nil {
+		return nil, err
+	}
+	checker := &indexSuggestionChecker{
+		level:     level,
+		title:     string(ctx.Rule.Type),
+		catalog:   ctx.Catalog,
+		suggested: make(map[string]bool),
+	}
+	for _, stmtNode := range root {
+		checker.text = stmtNode.Text()
+		(stmtNode).Accept(checker)
+	}
+
+	if len(checker.adviceList) == 0 {
+		checker.adviceList = append(checker.adviceList, advisor.Advice{
+			Status:  advisor.Success,
+			Code:    advisor.Ok,
+			Title:   "OK",
+			Content: "",
+		})
+	}
+	return checker.adviceList, nil
+}
+
+type indexSuggestionChecker struct {
+	adviceList []advisor.Advice
+	level      advisor.Status
+	title      string
+	text       string
+	catalog    catalog.Catalog
+	// suggested is the set of the suggested "table(column_list)" to avoid the duplicate advices.
+	suggested map[string]bool
+}
+
+// Enter implements the ast.Visitor interface
+func (v *indexSuggestionChecker) Enter(in ast.Node) (ast.Node, bool) {
+	switch node := in.(type) {
+	case *ast.SelectStmt:
+		v.check(node.From, node.Where, node.OrderBy)
+	case *ast.UpdateStmt:
+		v.check(node.TableRefs, node.Where, node.Order)
+	case *ast.DeleteStmt:
+		v.check(node.TableRefs, node.Where, node.Order)
+	}
+	// Visit the children to check the subqueries.
+	return in, false
+}
+
+// Leave implements the ast.Visitor interface
+func (v *indexSuggestionChecker) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+// queryTable is a base table referenced by the query.
+type queryTable struct {
+	schema string
+	name   string
+	// equalityColumns are the columns compared by equality, e.g. "a = 1", "a IN (1, 2)", "a IS NULL" or "a = t2.b" in JOIN.
+	equalityColumns []string
+	// rangeColumn is the first column compared by range, e.g. "a > 1", "a BETWEEN 1 AND 2" or "a LIKE 'x%'".
+	rangeColumn string
+}
+
+func (t *queryTable) addEqualityColumn(column string) {
+	for _, c := range t.equalityColumns {
+		if strings.EqualFold(c, column) {
+			return
+		}
+	}
+	t.equalityColumns = append(t.equalityColumns, column)
+}
+
+func (t *queryTable) addRangeColumn(column string) {
+	if t.rangeColumn == "" {
+		t.rangeColumn = column
+	}
+}
+
+// query is the column usage of a single SELECT, UPDATE or DELETE.
+type query struct {
+	tableList []*queryTable
+	// tableMap is the map from the lower case alias or table name to the table.
+	tableMap map[string]*queryTable
+}
+
+// resolve returns the table the column belongs to, or nil if the column is ambiguous or unknown.
+func (q *query) resolve(column *ast.ColumnName) *queryTable {
+	if column.Table.O == "" {
+		if len(q.tableList) == 1 {
+			return q.tableList[0]
+		}
+		return nil
+	}
+	return q.tableMap[column.Table.L]
+}
+
+func (v *indexSuggestionChecker) check(from *ast.TableRefsClause, where ast.ExprNode, orderBy *ast.OrderByClause) {
+	if from == nil {
+		return
+	}
+	q := &query{tableMap: make(map[string]*queryTable)}
+	q.collectTable(from.TableRefs)
+	if len(q.tableList) == 0 {
+		return
+	}
+	if where != nil {
+		q.collectCondition(where)
+	}
+
+	for _, table := range q.tableList {
+		columnList := append([]string{}, table.equalityColumns...)
+		if table.rangeColumn != "" {
+			columnList = append(columnList, table.rangeColumn)
+		} else if len(q.tableList) == 1 {
+			columnList = append(columnList, q.orderByColumnList(table, orderBy)...)
+		}
+		if len(columnList) == 0 {
+			continue
+		}
+		if len(columnList) > maxSuggestedIndexColumnCount {
+			columnList = columnList[:maxSuggestedIndexColumnCount]
+		}
+		v.suggest(table, columnList)
+	}
+}
+
+func (v *indexSuggestionChecker) suggest(table *queryTable, columnList []string) {
+	key := fmt.Sprintf("%s.%s(%s)", strings.ToLower(table.schema), strings.ToLower(table.name), strings.ToLower(strings.Join(columnList, ",")))
+	if v.suggested[key] {
+		return
+	}
+	v.suggested[key] = true
+
+	indexList, err := v.catalog.FindIndexList(context.Background(), &catalog.IndexListFind{TableName: table.name})
+	if errors.Is(err, catalog.ErrTableNotFound) {
+		// We don't know the indexes of the table, so we can't tell whether the index is missing.
+		return
+	}
+	if err != nil {
+		log.Printf("Cannot find the index list of table %s with error %v\n", table.name, err)
+		return
+	}
+	equalityCount := len(table.equalityColumns)
+	if equalityCount > len(columnList) {
+		equalityCount = len(columnList)
+	}
+	for _, index := range indexList {
+		if isIndexCovered(index, columnList, equalityCount) {
+			return
+		}
+	}
+
+	indexName := fmt.Sprintf("idx_%s_%s", table.name, strings.Join(columnList, "_"))
+	tableName := fmt.Sprintf("`%s`", table.name)
+	if table.schema != "" {
+		tableName = fmt.Sprintf("`%s`.`%s`", table.schema, table.name)
+	}
+	var quotedColumnList []string
+	for _, column := range columnList {
+		quotedColumnList = append(quotedColumnList, fmt.Sprintf("`%s`", column))
+	}
+	v.adviceList = append(v.adviceList, advisor.Advice{
+		Status: v.level,
+		Code:   advisor.StatementMissingIndex,
+		Title:  v.title,
+		Content: fmt.Sprintf("\"%s\" has no index on %s(%s), consider adding the index: CREATE INDEX `%s` ON %s (%s);",
+			v.text,
+			tableName,
+			strings.Join(columnList, ", "),
+			indexName,
+			tableName,
+			strings.Join(quotedColumnList, ", "),
+		),
+	})
+}
+
+// isIndexCovered returns whether the index could serve the columns.
+// The first equalityCount columns are compared by equality, so their order doesn't matter.
+// The index could also serve the query if it's a unique index on a subset of the equality columns,
+// because there is at most one matching row.
+func isIndexCovered(index *catalog.Index, columnList []string, equalityCount int) bool {
+	equalitySet := make(map[string]bool)
+	for _, column := range columnList[:equalityCount] {
+		equalitySet[strings.ToLower(column)] = true
+	}
+
+	if index.Unique && len(index.ColumnExpressions) > 0 {
+		unique := true
+		for _, expression := range index.ColumnExpressions {
+			if !equalitySet[strings.ToLower(expression)] {
+				unique = false
+				break
+			}
+		}
+		if unique {
+			return true
+		}
+	}
+
+	if len(index.ColumnExpressions) < len(columnList) {
+		return false
+	}
+	for i, column := range columnList {
+		expression := strings.ToLower(index.ColumnExpressions[i])
+		if i < equalityCount {
+			if !equalitySet[expression] {
+				return false
+			}
+		} else if expression != strings.ToLower(column) {
+			return false
+		}
+	}
+	return true
+}
+
+// collectTable collects the base tables and the JOIN conditions.
+func (q *query) collectTable(node ast.ResultSetNode) {
+	switch n := node.(type) {
+	case *ast.Join:
+		if n.Left != nil {
+			q.collectTable(n.Left)
+		}
+		if n.Right != nil {
+			q.collectTable(n.Right)
+		}
+		if n.On != nil {
+			q.collectCondition(n.On.Expr)
+		}
+	case *ast.TableSource:
+		tableName, ok := n.Source.(*ast.TableName)
+		if !ok {
+			return
+		}
+		table := &queryTable{
+			schema: tableName.Schema.O,
+			name:   tableName.Name.O,
+		}
+		q.tableList = append(q.tableList, table)
+		if n.AsName.O != "" {
+			q.tableMap[n.AsName.L] = table
+		} else {
+			q.tableMap[tableName.Name.L] = table
+		}
+	}
+}
+
+// collectCondition collects the columns in the conjuncts of the condition.
+// The disjunctions are skipped because a single composite index could not serve them.
+func (q *query) collectCondition(expr ast.ExprNode) {
+	switch e := expr.(type) {
+	case *ast.ParenthesesExpr:
+		q.collectCondition(e.Expr)
+	case *ast.BinaryOperationExpr:
+		switch e.Op {
+		case opcode.LogicAnd:
+			q.collectCondition(e.L)
+			q.collectCondition(e.R)
+		case opcode.EQ, opcode.NullEQ:
+			left, leftOK := e.L.(*ast.ColumnNameExpr)
+			right, rightOK := e.R.(*ast.ColumnNameExpr)
+			switch {
+			case leftOK && rightOK:
+				// The JOIN condition, e.g. t1.a = t2.b, could use the index on either side.
+				leftTable, rightTable := q.resolve(left.Name), q.resolve(right.Name)
+				if leftTable != nil && rightTable != nil && leftTable != rightTable {
+					leftTable.addEqualityColumn(left.Name.Name.O)
+					rightTable.addEqualityColumn(right.Name.Name.O)
+				}
+			case leftOK:
+				if table := q.resolve(left.Name); table != nil {
+					table.addEqualityColumn(left.Name.Name.O)
+				}
+			case rightOK:
+				if table := q.resolve(right.Name); table != nil {
+					table.addEqualityColumn(right.Name.Name.O)
+				}
+			}
+		case opcode.LT, opcode.LE, opcode.GT, opcode.GE:
+			if column, ok := e.L.(*ast.ColumnNameExpr); ok {
+				if _, ok := e.R.(*ast.ColumnNameExpr); !ok {
+					if table := q.resolve(column.Name); table != nil {
+						table.addRangeColumn(column.Name.Name.O)
+					}
+				}
+			} else if column, ok := e.R.(*ast.ColumnNameExpr); ok {
+				if table := q.resolve(column.Name); table != nil {
+					table.addRangeColumn(column.Name.Name.O)
+				}
+			}
+		}
+	case *ast.PatternInExpr:
+		if column, ok := e.Expr.(*ast.ColumnNameExpr); ok && !e.Not {
+			if table := q.resolve(column.Name); table != nil {
+				table.addEqualityColumn(column.Name.Name.O)
+			}
+		}
+	case *ast.IsNullExpr:
+		if column, ok := e.Expr.(*ast.ColumnNameExpr); ok && !e.Not {
+			if table := q.resolve(column.Name); table != nil {
+				table.addEqualityColumn(column.Name.Name.O)
+			}
+		}
+	case *ast.BetweenExpr:
+		if column, ok := e.Expr.(*ast.ColumnNameExpr); ok && !e.Not {
+			if table := q.resolve(column.Name); table != nil {
+				table.addRangeColumn(column.Name.Name.O)
+			}
+		}
+	case *ast.PatternLikeExpr:
+		column, ok := e.Expr.(*ast.ColumnNameExpr)
+		if !ok || e.Not {
+			return
+		}
+		// Only the LIKE with the constant prefix could use the index.
+		pattern, ok := e.Pattern.(ast.ValueExpr)
+		if !ok {
+			return
+		}
+		if s := pattern.GetString(); s == "" || s[0] == '%' || s[0] == '_' {
+			return
+		}
+		if table := q.resolve(column.Name); table != nil {
+			table.addRangeColumn(column.Name.Name.O)
+		}
+	}
+}
+
+// orderByColumnList returns the ORDER BY columns which could be appended to the index,
+// or nil if any ORDER BY item is not a column of the table.
+func (q *query) orderByColumnList(table *queryTable, orderBy *ast.OrderByClause) []string {
+	if orderBy == nil {
+		return nil
+	}
+	var columnList []string
+	for _, item := range orderBy.Items {
+		column, ok := item.Expr.(*ast.ColumnNameExpr)
+		if !ok || q.resolve(column.Name) != table {
+			return nil
+		}
+		duplicate := false
+		for _, c := range table.equalityColumns {
+			if strings.EqualFold(c, column.Name.Name.O) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			columnList = append(columnList, column.Name.Name.O)
+		}
+	}
+	return columnList
+}
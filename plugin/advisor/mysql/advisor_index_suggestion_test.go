@@ -0,0 +1,110 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/bytebase/bytebase/plugin/advisor"
+)
+
+func TestIndexSuggestion(t *testing.T) {
+	tests := []advisor.TestCase{
+		{
+			Statement: "SELECT * FROM t WHERE a = 1 AND b > 2",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Warn,
+					Code:    advisor.StatementMissingIndex,
+					Title:   "statement.suggest-index",
+					Content: "\"SELECT * FROM t WHERE a = 1 AND b > 2\" has no index on `t`(a, b), consider adding the index: CREATE INDEX `idx_t_a_b` ON `t` (`a`, `b`);",
+				},
+			},
+		},
+		{
+			Statement: "SELECT * FROM t WHERE a IN (1, 2) AND c IS NULL ORDER BY d",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Warn,
+					Code:    advisor.StatementMissingIndex,
+					Title:   "statement.suggest-index",
+					Content: "\"SELECT * FROM t WHERE a IN (1, 2) AND c IS NULL ORDER BY d\" has no index on `t`(a, c, d), consider adding the index: CREATE INDEX `idx_t_a_c_d` ON `t` (`a`, `c`, `d`);",
+				},
+			},
+		},
+		{
+			Statement: "SELECT * FROM t1 JOIN db.t2 AS x ON t1.id = x.t1_id WHERE x.status = 'done'",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Warn,
+					Code:    advisor.StatementMissingIndex,
+					Title:   "statement.suggest-index",
+					Content: "\"SELECT * FROM t1 JOIN db.t2 AS x ON t1.id = x.t1_id WHERE x.status = 'done'\" has no index on `db`.`t2`(t1_id, status), consider adding the index: CREATE INDEX `idx_t2_t1_id_status` ON `db`.`t2` (`t1_id`, `status`);",
+				},
+			},
+		},
+		{
+			Statement: "UPDATE t SET a = 1 WHERE name LIKE 'x%' ORDER BY id",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Warn,
+					Code:    advisor.StatementMissingIndex,
+					Title:   "statement.suggest-index",
+					Content: "\"UPDATE t SET a = 1 WHERE name LIKE 'x%' ORDER BY id\" has no index on `t`(name), consider adding the index: CREATE INDEX `idx_t_name` ON `t` (`name`);",
+				},
+			},
+		},
+		{
+			// Covered by the primary key.
+			Statement: "DELETE FROM t WHERE id = 1 AND name = 2 AND a = 3",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    advisor.Ok,
+					Title:   "OK",
+					Content: "",
+				},
+			},
+		},
+		{
+			// Covered by the index on (id, name).
+			Statement: "SELECT * FROM t WHERE name = 'a' AND id = 1",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    advisor.Ok,
+					Title:   "OK",
+					Content: "",
+				},
+			},
+		},
+		{
+			// The table is unknown to the catalog, so we can't tell whether the index is missing.
+			Statement: "SELECT * FROM unknown_table WHERE a = 1",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    advisor.Ok,
+					Title:   "OK",
+					Content: "",
+				},
+			},
+		},
+		{
+			// The disjunction and the leading wildcard could not use the index.
+			Statement: "SELECT * FROM t WHERE a = 1 OR b LIKE '%x'",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    advisor.Ok,
+					Title:   "OK",
+					Content: "",
+				},
+			},
+		},
+	}
+
+	advisor.RunSchemaReviewRuleTests(t, tests, &IndexSuggestionAdvisor{}, &advisor.SchemaReviewRule{
+		Type:    advisor.SchemaRuleStatementSuggestIndex,
+		Level:   advisor.SchemaRuleLevelWarning,
+		Payload: "",
+	}, &advisor.MockCatalogService{})
+}
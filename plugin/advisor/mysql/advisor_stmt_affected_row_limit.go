@@ -0,0 +1,138 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bytebase/bytebase/plugin/advisor"
+
+	"github.com/pingcap/tidb/parser/ast"
+)
+
+var (
+	_ advisor.Advisor = (*AffectedRowLimitAdvisor)(nil)
+)
+
+func init() {
+	advisor.Register(advisor.MySQL, advisor.MySQLAffectedRowLimit, &AffectedRowLimitAdvisor{})
+	advisor.Register(advisor.TiDB, advisor.MySQLAffectedRowLimit, &AffectedRowLimitAdvisor{})
+}
+
+// AffectedRowLimitAdvisor is the advisor checking for the estimated affected rows of UPDATE and DELETE.
+type AffectedRowLimitAdvisor struct {
+}
+
+// Check checks for the estimated affected rows of UPDATE and DELETE.
+func (adv *AffectedRowLimitAdvisor) Check(ctx advisor.Context, statement string) ([]advisor.Advice, error) {
+	root, errAdvice := parseStatement(statement, ctx.Charset, ctx.Collation)
+	if errAdvice != nil {
+		return errAdvice, nil
+	}
+
+	level, err := advisor.NewStatusBySchemaReviewRuleLevel(ctx.Rule.Level)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := advisor.UnmarshalNumberLimitRulePayload(ctx.Rule.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var adviceList []advisor.Advice
+	// We can only estimate the affected rows with a connection to the target database.
+	if ctx.Driver != nil {
+		for _, stmtNode := range root {
+			switch stmtNode.(type) {
+			case *ast.UpdateStmt, *ast.DeleteStmt:
+			default:
+				continue
+			}
+			text := stmtNode.Text()
+			rows, err := getAffectedRows(ctx.Context, ctx.Driver, text)
+			if err != nil {
+				adviceList = append(adviceList, advisor.Advice{
+					Status:  level,
+					Code:    advisor.StatementExplainQueryFailed,
+					Title:   string(ctx.Rule.Type),
+					Content: fmt.Sprintf("Failed to estimate the affected rows of \"%s\": %v", text, err),
+				})
+				continue
+			}
+			if rows > int64(payload.Number) {
+				adviceList = append(adviceList, advisor.Advice{
+					Status:  level,
+					Code:    advisor.StatementAffectedRowExceeded,
+					Title:   string(ctx.Rule.Type),
+					Content: fmt.Sprintf("\"%s\" affects %d rows (estimated). The count exceeds %d.", text, rows, payload.Number),
+				})
+			}
+		}
+	}
+
+	if len(adviceList) == 0 {
+		adviceList = append(adviceList, advisor.Advice{
+			Status:  advisor.Success,
+			Code:    advisor.Ok,
+			Title:   "OK",
+			Content: "",
+		})
+	}
+	return adviceList, nil
+}
+
+// getAffectedRows returns the estimated affected rows of the statement by EXPLAIN.
+// MySQL reports the estimation in the "rows" column and TiDB reports it in the "estRows" column.
+// We take the maximum among all the rows of the plan.
+func getAffectedRows(ctx context.Context, driver *sql.DB, statement string) (int64, error) {
+	statement = strings.TrimSuffix(strings.TrimSpace(statement), ";")
+	rows, err := driver.QueryContext(ctx, fmt.Sprintf("EXPLAIN %s", statement))
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	columnNames, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	rowsIndex := -1
+	for i, name := range columnNames {
+		if strings.EqualFold(name, "rows") || strings.EqualFold(name, "estRows") {
+			rowsIndex = i
+			break
+		}
+	}
+	if rowsIndex < 0 {
+		return 0, fmt.Errorf("cannot find the estimated rows in the EXPLAIN result, columns: %v", columnNames)
+	}
+
+	var result int64
+	for rows.Next() {
+		values := make([]sql.NullString, len(columnNames))
+		scanArgs := make([]interface{}, len(columnNames))
+		for i := range values {
+			scanArgs[i] = &values[i]
+		}
+		if err := rows.Scan(scanArgs...); err != nil {
+			return 0, err
+		}
+		// TiDB reports "N/A" for the operators without the estimation, e.g. Delete.
+		if !values[rowsIndex].Valid || values[rowsIndex].String == "N/A" {
+			continue
+		}
+		count, err := strconv.ParseFloat(values[rowsIndex].String, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse the estimated rows %q: %w", values[rowsIndex].String, err)
+		}
+		if int64(count) > result {
+			result = int64(count)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	return result, nil
+}
@@ -0,0 +1,200 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bytebase/bytebase/plugin/advisor"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAffectedRowLimit(t *testing.T) {
+	// Without a database connection, the advisor cannot estimate the affected rows and passes.
+	tests := []advisor.TestCase{
+		{
+			Statement: "DELETE FROM t1 WHERE a > 0",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    advisor.Ok,
+					Title:   "OK",
+					Content: "",
+				},
+			},
+		},
+		{
+			Statement: "DELETE FROM t1 WHERE",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Error,
+					Code:    advisor.StatementSyntaxError,
+					Title:   advisor.SyntaxErrorTitle,
+					Content: "line 1 column 20 near \"\" ",
+				},
+			},
+		},
+	}
+
+	payload, err := json.Marshal(advisor.NumberLimitRulePayload{
+		Number: 1000,
+	})
+	require.NoError(t, err)
+	advisor.RunSchemaReviewRuleTests(t, tests, &AffectedRowLimitAdvisor{}, &advisor.SchemaReviewRule{
+		Type:    advisor.SchemaRuleStatementDMLAffectedRowLimit,
+		Level:   advisor.SchemaRuleLevelWarning,
+		Payload: string(payload),
+	}, &advisor.MockCatalogService{})
+}
+
+// explainStubDriver is a database/sql driver returning the EXPLAIN result keyed by the DSN.
+type explainStubDriver struct{}
+
+type explainStubResult struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+var explainStubResultMap = map[string]*explainStubResult{
+	"mysql": {
+		columns: []string{"id", "select_type", "table", "rows"},
+		rows:    [][]driver.Value{{"1", "DELETE", "t1", "1500"}, {"2", "SUBQUERY", "t2", nil}},
+	},
+	"tidb": {
+		columns: []string{"id", "estRows", "task"},
+		rows:    [][]driver.Value{{"Delete_4", "N/A", "root"}, {"TableReader_8", "500.00", "root"}},
+	},
+}
+
+func (explainStubDriver) Open(name string) (driver.Conn, error) {
+	return &explainStubConn{result: explainStubResultMap[name]}, nil
+}
+
+type explainStubConn struct {
+	result *explainStubResult
+}
+
+func (*explainStubConn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (*explainStubConn) Close() error {
+	return nil
+}
+
+func (*explainStubConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (c *explainStubConn) QueryContext(ctx context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(query, "EXPLAIN ") {
+		return nil, fmt.Errorf("unexpected query %q", query)
+	}
+	return &explainStubRows{result: c.result}, nil
+}
+
+type explainStubRows struct {
+	result *explainStubResult
+	next   int
+}
+
+func (r *explainStubRows) Columns() []string {
+	return r.result.columns
+}
+
+func (*explainStubRows) Close() error {
+	return nil
+}
+
+func (r *explainStubRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.rows) {
+		return io.EOF
+	}
+	copy(dest, r.result.rows[r.next])
+	r.next++
+	return nil
+}
+
+func init() {
+	sql.Register("explain_stub", explainStubDriver{})
+}
+
+func TestAffectedRowLimitExplain(t *testing.T) {
+	a := require.New(t)
+	payload, err := json.Marshal(advisor.NumberLimitRulePayload{
+		Number: 1000,
+	})
+	a.NoError(err)
+	rule := &advisor.SchemaReviewRule{
+		Type:    advisor.SchemaRuleStatementDMLAffectedRowLimit,
+		Level:   advisor.SchemaRuleLevelWarning,
+		Payload: string(payload),
+	}
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		dsn  string
+		ctx  context.Context
+		want []advisor.Advice
+	}{
+		{
+			dsn: "mysql",
+			ctx: context.Background(),
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Warn,
+					Code:    advisor.StatementAffectedRowExceeded,
+					Title:   string(advisor.SchemaRuleStatementDMLAffectedRowLimit),
+					Content: "\"DELETE FROM t1 WHERE a > 0\" affects 1500 rows (estimated). The count exceeds 1000.",
+				},
+			},
+		},
+		{
+			dsn: "tidb",
+			ctx: context.Background(),
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    advisor.Ok,
+					Title:   "OK",
+					Content: "",
+				},
+			},
+		},
+		{
+			// The EXPLAIN is canceled with the context of the check.
+			dsn: "mysql",
+			ctx: canceledCtx,
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Warn,
+					Code:    advisor.StatementExplainQueryFailed,
+					Title:   string(advisor.SchemaRuleStatementDMLAffectedRowLimit),
+					Content: "Failed to estimate the affected rows of \"DELETE FROM t1 WHERE a > 0\": context canceled",
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		db, err := sql.Open("explain_stub", test.dsn)
+		a.NoError(err)
+		adviceList, err := (&AffectedRowLimitAdvisor{}).Check(advisor.Context{
+			Rule:    rule,
+			Driver:  db,
+			Context: test.ctx,
+		}, "DELETE FROM t1 WHERE a > 0")
+		a.NoError(err)
+		a.Equal(test.want, adviceList, test.dsn)
+		a.NoError(db.Close())
+	}
+}
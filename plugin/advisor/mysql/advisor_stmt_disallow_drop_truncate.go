@@ -0,0 +1,110 @@
+package mysql
+
+import (
+	"fmt"
+
+	"github.com/bytebase/bytebase/plugin/advisor"
+
+	"github.com/pingcap/tidb/parser/ast"
+)
+
+var (
+	_ advisor.Advisor = (*DisallowDropTruncateAdvisor)(nil)
+)
+
+func init() {
+	advisor.Register(advisor.MySQL, advisor.MySQLDisallowDropTruncate, &DisallowDropTruncateAdvisor{})
+	advisor.Register(advisor.TiDB, advisor.MySQLDisallowDropTruncate, &DisallowDropTruncateAdvisor{})
+}
+
+// DisallowDropTruncateAdvisor is the advisor checking for DROP and TRUNCATE outside the allowed environments.
+type DisallowDropTruncateAdvisor struct {
+}
+
+// Check checks for DROP and TRUNCATE outside the allowed environments.
+func (adv *DisallowDropTruncateAdvisor) Check(ctx advisor.Context, statement string) ([]advisor.Advice, error) {
+	root, errAdvice := parseStatement(statement, ctx.Charset, ctx.Collation)
+	if errAdvice != nil {
+		return errAdvice, nil
+	}
+
+	level, err := advisor.NewStatusBySchemaReviewRuleLevel(ctx.Rule.Level)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := advisor.UnmarshalEnvironmentListRulePayload(ctx.Rule.Payload)
+	if err != nil {
+		return nil, err
+	}
+	for _, environment := range payload.EnvironmentList {
+		if environment == ctx.EnvironmentName {
+			return []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    advisor.Ok,
+					Title:   "OK",
+					Content: "",
+				},
+			}, nil
+		}
+	}
+
+	checker := &disallowDropTruncateChecker{
+		level:       level,
+		title:       string(ctx.Rule.Type),
+		environment: ctx.EnvironmentName,
+	}
+	for _, stmtNode := range root {
+		checker.text = stmtNode.Text()
+		(stmtNode).Accept(checker)
+	}
+
+	if len(checker.adviceList) == 0 {
+		checker.adviceList = append(checker.adviceList, advisor.Advice{
+			Status:  advisor.Success,
+			Code:    advisor.Ok,
+			Title:   "OK",
+			Content: "",
+		})
+	}
+	return checker.adviceList, nil
+}
+
+type disallowDropTruncateChecker struct {
+	adviceList  []advisor.Advice
+	level       advisor.Status
+	title       string
+	text        string
+	environment string
+}
+
+// Enter implements the ast.Visitor interface
+func (v *disallowDropTruncateChecker) Enter(in ast.Node) (ast.Node, bool) {
+	found := false
+	switch node := in.(type) {
+	// DROP DATABASE
+	case *ast.DropDatabaseStmt:
+		found = true
+	// DROP TABLE
+	case *ast.DropTableStmt:
+		found = !node.IsView
+	// TRUNCATE TABLE
+	case *ast.TruncateTableStmt:
+		found = true
+	}
+
+	if found {
+		v.adviceList = append(v.adviceList, advisor.Advice{
+			Status:  v.level,
+			Code:    advisor.StatementDropOrTruncate,
+			Title:   v.title,
+			Content: fmt.Sprintf("\"%s\" is not allowed in environment %q", v.text, v.environment),
+		})
+	}
+	return in, true
+}
+
+// Leave implements the ast.Visitor interface
+func (v *disallowDropTruncateChecker) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
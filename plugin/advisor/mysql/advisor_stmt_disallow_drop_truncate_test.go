@@ -0,0 +1,98 @@
+package mysql
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bytebase/bytebase/plugin/advisor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisallowDropTruncate(t *testing.T) {
+	tests := []struct {
+		environment string
+		statement   string
+		want        []advisor.Advice
+	}{
+		{
+			environment: "Prod",
+			statement:   "DROP TABLE t",
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Error,
+					Code:    advisor.StatementDropOrTruncate,
+					Title:   "statement.disallow-drop-truncate",
+					Content: "\"DROP TABLE t\" is not allowed in environment \"Prod\"",
+				},
+			},
+		},
+		{
+			environment: "Prod",
+			statement:   "TRUNCATE TABLE t",
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Error,
+					Code:    advisor.StatementDropOrTruncate,
+					Title:   "statement.disallow-drop-truncate",
+					Content: "\"TRUNCATE TABLE t\" is not allowed in environment \"Prod\"",
+				},
+			},
+		},
+		{
+			environment: "Prod",
+			statement:   "DROP DATABASE db",
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Error,
+					Code:    advisor.StatementDropOrTruncate,
+					Title:   "statement.disallow-drop-truncate",
+					Content: "\"DROP DATABASE db\" is not allowed in environment \"Prod\"",
+				},
+			},
+		},
+		{
+			environment: "Prod",
+			statement:   "DROP VIEW v",
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    advisor.Ok,
+					Title:   "OK",
+					Content: "",
+				},
+			},
+		},
+		{
+			environment: "Test",
+			statement:   "DROP TABLE t",
+			want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    advisor.Ok,
+					Title:   "OK",
+					Content: "",
+				},
+			},
+		},
+	}
+
+	payload, err := json.Marshal(advisor.EnvironmentListRulePayload{
+		EnvironmentList: []string{"Dev", "Test"},
+	})
+	require.NoError(t, err)
+	adv := &DisallowDropTruncateAdvisor{}
+	for _, tc := range tests {
+		adviceList, err := adv.Check(advisor.Context{
+			Rule: &advisor.SchemaReviewRule{
+				Type:    advisor.SchemaRuleStatementDisallowDropTruncate,
+				Level:   advisor.SchemaRuleLevelError,
+				Payload: string(payload),
+			},
+			Catalog:         &advisor.MockCatalogService{},
+			EnvironmentName: tc.environment,
+		}, tc.statement)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, adviceList, tc.statement)
+	}
+}
@@ -0,0 +1,92 @@
+package mysql
+
+import (
+	"fmt"
+
+	"github.com/bytebase/bytebase/plugin/advisor"
+
+	"github.com/pingcap/tidb/parser/ast"
+)
+
+var (
+	_ advisor.Advisor = (*DMLNoLimitAdvisor)(nil)
+)
+
+func init() {
+	advisor.Register(advisor.MySQL, advisor.MySQLDMLNoLimit, &DMLNoLimitAdvisor{})
+	advisor.Register(advisor.TiDB, advisor.MySQLDMLNoLimit, &DMLNoLimitAdvisor{})
+}
+
+// DMLNoLimitAdvisor is the advisor checking for no LIMIT in UPDATE and DELETE.
+type DMLNoLimitAdvisor struct {
+}
+
+// Check checks for no LIMIT in UPDATE and DELETE.
+func (adv *DMLNoLimitAdvisor) Check(ctx advisor.Context, statement string) ([]advisor.Advice, error) {
+	root, errAdvice := parseStatement(statement, ctx.Charset, ctx.Collation)
+	if errAdvice != nil {
+		return errAdvice, nil
+	}
+
+	level, err := advisor.NewStatusBySchemaReviewRuleLevel(ctx.Rule.Level)
+	if err != nil {
+		return nil, err
+	}
+	checker := &dmlNoLimitChecker{
+		level: level,
+		title: string(ctx.Rule.Type),
+	}
+	for _, stmtNode := range root {
+		checker.text = stmtNode.Text()
+		(stmtNode).Accept(checker)
+	}
+
+	if len(checker.adviceList) == 0 {
+		checker.adviceList = append(checker.adviceList, advisor.Advice{
+			Status:  advisor.Success,
+			Code:    advisor.Ok,
+			Title:   "OK",
+			Content: "",
+		})
+	}
+	return checker.adviceList, nil
+}
+
+type dmlNoLimitChecker struct {
+	adviceList []advisor.Advice
+	level      advisor.Status
+	title      string
+	text       string
+}
+
+// Enter implements the ast.Visitor interface
+func (v *dmlNoLimitChecker) Enter(in ast.Node) (ast.Node, bool) {
+	code := advisor.Ok
+	switch node := in.(type) {
+	// DELETE
+	case *ast.DeleteStmt:
+		if node.Limit != nil {
+			code = advisor.StatementDMLWithLimit
+		}
+	// UPDATE
+	case *ast.UpdateStmt:
+		if node.Limit != nil {
+			code = advisor.StatementDMLWithLimit
+		}
+	}
+
+	if code != advisor.Ok {
+		v.adviceList = append(v.adviceList, advisor.Advice{
+			Status:  v.level,
+			Code:    code,
+			Title:   v.title,
+			Content: fmt.Sprintf("\"%s\" uses LIMIT", v.text),
+		})
+	}
+	return in, false
+}
+
+// Leave implements the ast.Visitor interface
+func (v *dmlNoLimitChecker) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
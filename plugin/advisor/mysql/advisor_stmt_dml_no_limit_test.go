@@ -0,0 +1,62 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/bytebase/bytebase/plugin/advisor"
+)
+
+func TestDMLNoLimit(t *testing.T) {
+	tests := []advisor.TestCase{
+		{
+			Statement: "DELETE FROM t1 WHERE a > 0 LIMIT 10",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Warn,
+					Code:    advisor.StatementDMLWithLimit,
+					Title:   "statement.dml.disallow-limit",
+					Content: "\"DELETE FROM t1 WHERE a > 0 LIMIT 10\" uses LIMIT",
+				},
+			},
+		},
+		{
+			Statement: "UPDATE t1 SET a = 1 WHERE a > 0 LIMIT 10",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Warn,
+					Code:    advisor.StatementDMLWithLimit,
+					Title:   "statement.dml.disallow-limit",
+					Content: "\"UPDATE t1 SET a = 1 WHERE a > 0 LIMIT 10\" uses LIMIT",
+				},
+			},
+		},
+		{
+			Statement: "DELETE FROM t1 WHERE a > 0",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    advisor.Ok,
+					Title:   "OK",
+					Content: "",
+				},
+			},
+		},
+		{
+			Statement: "SELECT a FROM t1 LIMIT 10",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    advisor.Ok,
+					Title:   "OK",
+					Content: "",
+				},
+			},
+		},
+	}
+
+	advisor.RunSchemaReviewRuleTests(t, tests, &DMLNoLimitAdvisor{}, &advisor.SchemaReviewRule{
+		Type:    advisor.SchemaRuleStatementDMLDisallowLimit,
+		Level:   advisor.SchemaRuleLevelWarning,
+		Payload: "",
+	}, &advisor.MockCatalogService{})
+}
@@ -0,0 +1,92 @@
+package mysql
+
+import (
+	"fmt"
+
+	"github.com/bytebase/bytebase/plugin/advisor"
+
+	"github.com/pingcap/tidb/parser/ast"
+)
+
+var (
+	_ advisor.Advisor = (*DMLNoOrderByAdvisor)(nil)
+)
+
+func init() {
+	advisor.Register(advisor.MySQL, advisor.MySQLDMLNoOrderBy, &DMLNoOrderByAdvisor{})
+	advisor.Register(advisor.TiDB, advisor.MySQLDMLNoOrderBy, &DMLNoOrderByAdvisor{})
+}
+
+// DMLNoOrderByAdvisor is the advisor checking for no ORDER BY in UPDATE and DELETE.
+type DMLNoOrderByAdvisor struct {
+}
+
+// Check checks for no ORDER BY in UPDATE and DELETE.
+func (adv *DMLNoOrderByAdvisor) Check(ctx advisor.Context, statement string) ([]advisor.Advice, error) {
+	root, errAdvice := parseStatement(statement, ctx.Charset, ctx.Collation)
+	if errAdvice != nil {
+		return errAdvice, nil
+	}
+
+	level, err := advisor.NewStatusBySchemaReviewRuleLevel(ctx.Rule.Level)
+	if err != nil {
+		return nil, err
+	}
+	checker := &dmlNoOrderByChecker{
+		level: level,
+		title: string(ctx.Rule.Type),
+	}
+	for _, stmtNode := range root {
+		checker.text = stmtNode.Text()
+		(stmtNode).Accept(checker)
+	}
+
+	if len(checker.adviceList) == 0 {
+		checker.adviceList = append(checker.adviceList, advisor.Advice{
+			Status:  advisor.Success,
+			Code:    advisor.Ok,
+			Title:   "OK",
+			Content: "",
+		})
+	}
+	return checker.adviceList, nil
+}
+
+type dmlNoOrderByChecker struct {
+	adviceList []advisor.Advice
+	level      advisor.Status
+	title      string
+	text       string
+}
+
+// Enter implements the ast.Visitor interface
+func (v *dmlNoOrderByChecker) Enter(in ast.Node) (ast.Node, bool) {
+	code := advisor.Ok
+	switch node := in.(type) {
+	// DELETE
+	case *ast.DeleteStmt:
+		if node.Order != nil {
+			code = advisor.StatementDMLWithOrderBy
+		}
+	// UPDATE
+	case *ast.UpdateStmt:
+		if node.Order != nil {
+			code = advisor.StatementDMLWithOrderBy
+		}
+	}
+
+	if code != advisor.Ok {
+		v.adviceList = append(v.adviceList, advisor.Advice{
+			Status:  v.level,
+			Code:    code,
+			Title:   v.title,
+			Content: fmt.Sprintf("\"%s\" uses ORDER BY", v.text),
+		})
+	}
+	return in, false
+}
+
+// Leave implements the ast.Visitor interface
+func (v *dmlNoOrderByChecker) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
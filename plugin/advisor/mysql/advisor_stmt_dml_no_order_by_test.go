@@ -0,0 +1,62 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/bytebase/bytebase/plugin/advisor"
+)
+
+func TestDMLNoOrderBy(t *testing.T) {
+	tests := []advisor.TestCase{
+		{
+			Statement: "DELETE FROM t1 WHERE a > 0 ORDER BY a",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Error,
+					Code:    advisor.StatementDMLWithOrderBy,
+					Title:   "statement.dml.disallow-order-by",
+					Content: "\"DELETE FROM t1 WHERE a > 0 ORDER BY a\" uses ORDER BY",
+				},
+			},
+		},
+		{
+			Statement: "UPDATE t1 SET a = 1 WHERE a > 0 ORDER BY a DESC LIMIT 1",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Error,
+					Code:    advisor.StatementDMLWithOrderBy,
+					Title:   "statement.dml.disallow-order-by",
+					Content: "\"UPDATE t1 SET a = 1 WHERE a > 0 ORDER BY a DESC LIMIT 1\" uses ORDER BY",
+				},
+			},
+		},
+		{
+			Statement: "UPDATE t1 SET a = 1 WHERE a > 0",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    advisor.Ok,
+					Title:   "OK",
+					Content: "",
+				},
+			},
+		},
+		{
+			Statement: "SELECT a FROM t1 ORDER BY a",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    advisor.Ok,
+					Title:   "OK",
+					Content: "",
+				},
+			},
+		},
+	}
+
+	advisor.RunSchemaReviewRuleTests(t, tests, &DMLNoOrderByAdvisor{}, &advisor.SchemaReviewRule{
+		Type:    advisor.SchemaRuleStatementDMLDisallowOrderBy,
+		Level:   advisor.SchemaRuleLevelError,
+		Payload: "",
+	}, &advisor.MockCatalogService{})
+}
@@ -0,0 +1,79 @@
+package mysql
+
+import (
+	"fmt"
+
+	"github.com/bytebase/bytebase/plugin/advisor"
+
+	"github.com/pingcap/tidb/parser/ast"
+)
+
+var (
+	_ advisor.Advisor = (*InsertMustSpecifyColumnAdvisor)(nil)
+)
+
+func init() {
+	advisor.Register(advisor.MySQL, advisor.MySQLInsertMustSpecifyColumn, &InsertMustSpecifyColumnAdvisor{})
+	advisor.Register(advisor.TiDB, advisor.MySQLInsertMustSpecifyColumn, &InsertMustSpecifyColumnAdvisor{})
+}
+
+// InsertMustSpecifyColumnAdvisor is the advisor checking for the column list in INSERT.
+type InsertMustSpecifyColumnAdvisor struct {
+}
+
+// Check checks for the column list in INSERT.
+func (adv *InsertMustSpecifyColumnAdvisor) Check(ctx advisor.Context, statement string) ([]advisor.Advice, error) {
+	root, errAdvice := parseStatement(statement, ctx.Charset, ctx.Collation)
+	if errAdvice != nil {
+		return errAdvice, nil
+	}
+
+	level, err := advisor.NewStatusBySchemaReviewRuleLevel(ctx.Rule.Level)
+	if err != nil {
+		return nil, err
+	}
+	checker := &insertMustSpecifyColumnChecker{
+		level: level,
+		title: string(ctx.Rule.Type),
+	}
+	for _, stmtNode := range root {
+		checker.text = stmtNode.Text()
+		(stmtNode).Accept(checker)
+	}
+
+	if len(checker.adviceList) == 0 {
+		checker.adviceList = append(checker.adviceList, advisor.Advice{
+			Status:  advisor.Success,
+			Code:    advisor.Ok,
+			Title:   "OK",
+			Content: "",
+		})
+	}
+	return checker.adviceList, nil
+}
+
+type insertMustSpecifyColumnChecker struct {
+	adviceList []advisor.Advice
+	level      advisor.Status
+	title      string
+	text       string
+}
+
+// Enter implements the ast.Visitor interface
+func (v *insertMustSpecifyColumnChecker) Enter(in ast.Node) (ast.Node, bool) {
+	// INSERT ... SET col = value names the columns in the assignment list.
+	if node, ok := in.(*ast.InsertStmt); ok && len(node.Columns) == 0 && len(node.Setlist) == 0 {
+		v.adviceList = append(v.adviceList, advisor.Advice{
+			Status:  v.level,
+			Code:    advisor.StatementInsertNoColumn,
+			Title:   v.title,
+			Content: fmt.Sprintf("\"%s\" does not specify the column list", v.text),
+		})
+	}
+	return in, false
+}
+
+// Leave implements the ast.Visitor interface
+func (v *insertMustSpecifyColumnChecker) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
@@ -0,0 +1,62 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/bytebase/bytebase/plugin/advisor"
+)
+
+func TestInsertMustSpecifyColumn(t *testing.T) {
+	tests := []advisor.TestCase{
+		{
+			Statement: "INSERT INTO t VALUES (1, 2)",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Warn,
+					Code:    advisor.StatementInsertNoColumn,
+					Title:   "statement.insert.must-specify-column",
+					Content: "\"INSERT INTO t VALUES (1, 2)\" does not specify the column list",
+				},
+			},
+		},
+		{
+			Statement: "INSERT INTO t SELECT a, b FROM s",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Warn,
+					Code:    advisor.StatementInsertNoColumn,
+					Title:   "statement.insert.must-specify-column",
+					Content: "\"INSERT INTO t SELECT a, b FROM s\" does not specify the column list",
+				},
+			},
+		},
+		{
+			Statement: "INSERT INTO t (a, b) VALUES (1, 2)",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    advisor.Ok,
+					Title:   "OK",
+					Content: "",
+				},
+			},
+		},
+		{
+			Statement: "INSERT INTO t SET a = 1, b = 2",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    advisor.Ok,
+					Title:   "OK",
+					Content: "",
+				},
+			},
+		},
+	}
+
+	advisor.RunSchemaReviewRuleTests(t, tests, &InsertMustSpecifyColumnAdvisor{}, &advisor.SchemaReviewRule{
+		Type:    advisor.SchemaRuleStatementInsertMustSpecifyColumn,
+		Level:   advisor.SchemaRuleLevelWarning,
+		Payload: "",
+	}, &advisor.MockCatalogService{})
+}
@@ -0,0 +1,111 @@
+package mysql
+
+import (
+	"fmt"
+
+	"github.com/bytebase/bytebase/plugin/advisor"
+
+	"github.com/pingcap/tidb/parser/ast"
+)
+
+var (
+	_ advisor.Advisor = (*InsertNoSelectAllAdvisor)(nil)
+)
+
+func init() {
+	advisor.Register(advisor.MySQL, advisor.MySQLInsertNoSelectAll, &InsertNoSelectAllAdvisor{})
+	advisor.Register(advisor.TiDB, advisor.MySQLInsertNoSelectAll, &InsertNoSelectAllAdvisor{})
+}
+
+// InsertNoSelectAllAdvisor is the advisor checking for no "INSERT ... SELECT *".
+type InsertNoSelectAllAdvisor struct {
+}
+
+// Check checks for no "INSERT ... SELECT *".
+func (adv *InsertNoSelectAllAdvisor) Check(ctx advisor.Context, statement string) ([]advisor.Advice, error) {
+	root, errAdvice := parseStatement(statement, ctx.Charset, ctx.Collation)
+	if errAdvice != nil {
+		return errAdvice, nil
+	}
+
+	level, err := advisor.NewStatusBySchemaReviewRuleLevel(ctx.Rule.Level)
+	if err != nil {
+		return nil, err
+	}
+	checker := &insertNoSelectAllChecker{
+		level: level,
+		title: string(ctx.Rule.Type),
+	}
+	for _, stmtNode := range root {
+		checker.text = stmtNode.Text()
+		(stmtNode).Accept(checker)
+	}
+
+	if len(checker.adviceList) == 0 {
+		checker.adviceList = append(checker.adviceList, advisor.Advice{
+			Status:  advisor.Success,
+			Code:    advisor.Ok,
+			Title:   "OK",
+			Content: "",
+		})
+	}
+	return checker.adviceList, nil
+}
+
+type insertNoSelectAllChecker struct {
+	adviceList []advisor.Advice
+	level      advisor.Status
+	title      string
+	text       string
+}
+
+// Enter implements the ast.Visitor interface
+func (v *insertNoSelectAllChecker) Enter(in ast.Node) (ast.Node, bool) {
+	if node, ok := in.(*ast.InsertStmt); ok && node.Select != nil && selectAll(node.Select) {
+		v.adviceList = append(v.adviceList, advisor.Advice{
+			Status:  v.level,
+			Code:    advisor.StatementInsertSelectAll,
+			Title:   v.title,
+			Content: fmt.Sprintf("\"%s\" uses SELECT all", v.text),
+		})
+	}
+	return in, false
+}
+
+// Leave implements the ast.Visitor interface
+func (v *insertNoSelectAllChecker) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+// selectAll returns true if the result set selects all the columns,
+// i.e. the SELECT itself or any SELECT in the UNION uses "*".
+// Subqueries in the WHERE clause don't affect the inserted columns, so we don't inspect them.
+func selectAll(node ast.Node) bool {
+	switch n := node.(type) {
+	case *ast.SelectStmt:
+		if n.Fields == nil {
+			return false
+		}
+		for _, field := range n.Fields.Fields {
+			if field.WildCard != nil {
+				return true
+			}
+		}
+	case *ast.SetOprStmt:
+		if n.SelectList == nil {
+			return false
+		}
+		for _, sel := range n.SelectList.Selects {
+			if selectAll(sel) {
+				return true
+			}
+		}
+	case *ast.SetOprSelectList:
+		for _, sel := range n.Selects {
+			if selectAll(sel) {
+				return true
+			}
+		}
+	}
+	return false
+}
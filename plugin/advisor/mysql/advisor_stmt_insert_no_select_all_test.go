@@ -0,0 +1,62 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/bytebase/bytebase/plugin/advisor"
+)
+
+func TestInsertNoSelectAll(t *testing.T) {
+	tests := []advisor.TestCase{
+		{
+			Statement: "INSERT INTO t (a, b) SELECT * FROM s",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Warn,
+					Code:    advisor.StatementInsertSelectAll,
+					Title:   "statement.insert.no-select-all",
+					Content: "\"INSERT INTO t (a, b) SELECT * FROM s\" uses SELECT all",
+				},
+			},
+		},
+		{
+			Statement: "INSERT INTO t (a, b) SELECT a, b FROM s UNION SELECT * FROM r",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Warn,
+					Code:    advisor.StatementInsertSelectAll,
+					Title:   "statement.insert.no-select-all",
+					Content: "\"INSERT INTO t (a, b) SELECT a, b FROM s UNION SELECT * FROM r\" uses SELECT all",
+				},
+			},
+		},
+		{
+			Statement: "INSERT INTO t (a, b) SELECT a, b FROM s WHERE EXISTS (SELECT * FROM r)",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    advisor.Ok,
+					Title:   "OK",
+					Content: "",
+				},
+			},
+		},
+		{
+			Statement: "SELECT * FROM s",
+			Want: []advisor.Advice{
+				{
+					Status:  advisor.Success,
+					Code:    advisor.Ok,
+					Title:   "OK",
+					Content: "",
+				},
+			},
+		},
+	}
+
+	advisor.RunSchemaReviewRuleTests(t, tests, &InsertNoSelectAllAdvisor{}, &advisor.SchemaReviewRule{
+		Type:    advisor.SchemaRuleStatementInsertNoSelectAll,
+		Level:   advisor.SchemaRuleLevelWarning,
+		Payload: "",
+	}, &advisor.MockCatalogService{})
+}
@@ -2,6 +2,7 @@ package advisor
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -15,6 +16,8 @@ import (
 //   2. Register this advisor in map[DBType][AdvisorType].(plugin/advisor.go)
 //   3. Add advisor error code if needed(plugin/advisor/code.go).
 //   4. Map SchemaReviewRuleType to advisor.Type in getAdvisorTypeByRule(current file).
+//
+// Users could also define custom rules in the policy without code changes, see custom_rule.go.
 
 // SchemaReviewRuleLevel is the error level for schema review rule.
 type SchemaReviewRuleLevel string
@@ -50,6 +53,20 @@ const (
 	SchemaRuleStatementRequireWhere SchemaReviewRuleType = "statement.where.require"
 	// SchemaRuleStatementNoLeadingWildcardLike disallow leading '%' in LIKE, e.g. LIKE foo = '%x' is not allowed.
 	SchemaRuleStatementNoLeadingWildcardLike SchemaReviewRuleType = "statement.where.no-leading-wildcard-like"
+	// SchemaRuleStatementDisallowDropTruncate disallow 'DROP' and 'TRUNCATE' outside the allowed environments.
+	SchemaRuleStatementDisallowDropTruncate SchemaReviewRuleType = "statement.disallow-drop-truncate"
+	// SchemaRuleStatementDMLDisallowLimit disallow 'LIMIT' in 'UPDATE' and 'DELETE'.
+	SchemaRuleStatementDMLDisallowLimit SchemaReviewRuleType = "statement.dml.disallow-limit"
+	// SchemaRuleStatementDMLDisallowOrderBy disallow 'ORDER BY' in 'UPDATE' and 'DELETE'.
+	SchemaRuleStatementDMLDisallowOrderBy SchemaReviewRuleType = "statement.dml.disallow-order-by"
+	// SchemaRuleStatementDMLAffectedRowLimit limit the estimated affected rows of 'UPDATE' and 'DELETE'.
+	SchemaRuleStatementDMLAffectedRowLimit SchemaReviewRuleType = "statement.dml.affected-row-limit"
+	// SchemaRuleStatementInsertMustSpecifyColumn require 'INSERT' to specify the column list.
+	SchemaRuleStatementInsertMustSpecifyColumn SchemaReviewRuleType = "statement.insert.must-specify-column"
+	// SchemaRuleStatementInsertNoSelectAll disallow 'INSERT ... SELECT *'.
+	SchemaRuleStatementInsertNoSelectAll SchemaReviewRuleType = "statement.insert.no-select-all"
+	// SchemaRuleStatementSuggestIndex suggest the missing indexes for the WHERE, JOIN and ORDER BY columns.
+	SchemaRuleStatementSuggestIndex SchemaReviewRuleType = "statement.suggest-index"
 
 	// SchemaRuleTableRequirePK require the table to have a primary key.
 	SchemaRuleTableRequirePK SchemaReviewRuleType = "table.require-pk"
@@ -115,6 +132,133 @@ func (policy *SchemaReviewPolicy) Validate() error {
 	return nil
 }
 
+// SchemaReviewPolicyOverride is the project-level refinement of the environment schema review policy.
+type SchemaReviewPolicyOverride struct {
+	RuleList []*SchemaReviewRuleOverride `json:"ruleList"`
+}
+
+// SchemaReviewRuleOverride overrides the rule with the same type in the inherited policy.
+// Nil Level or Payload means inheriting the value. A rule absent in the inherited policy
+// is added, and it stays disabled unless the Level is specified.
+type SchemaReviewRuleOverride struct {
+	Type    SchemaReviewRuleType   `json:"type"`
+	Level   *SchemaReviewRuleLevel `json:"level,omitempty"`
+	Payload *string                `json:"payload,omitempty"`
+}
+
+// Validate validates the SchemaReviewPolicyOverride.
+func (override *SchemaReviewPolicyOverride) Validate() error {
+	ruleTypeSet := make(map[SchemaReviewRuleType]bool)
+	for _, rule := range override.RuleList {
+		if rule.Type == "" {
+			return fmt.Errorf("invalid payload, rule type cannot be empty")
+		}
+		if ruleTypeSet[rule.Type] {
+			return fmt.Errorf("invalid payload, duplicate rule type %s", rule.Type)
+		}
+		ruleTypeSet[rule.Type] = true
+		if level := rule.Level; level != nil && *level != SchemaRuleLevelError && *level != SchemaRuleLevelWarning && *level != SchemaRuleLevelDisabled {
+			return fmt.Errorf("invalid rule level %q for rule %s", *level, rule.Type)
+		}
+		if rule.Payload != nil {
+			r := &SchemaReviewRule{Type: rule.Type, Payload: *rule.Payload}
+			if err := r.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateNewRules validates the rules added by the override to any of the inherited policies.
+// Such a rule has no payload to inherit, so it must carry a valid payload unless it stays disabled.
+func (override *SchemaReviewPolicyOverride) ValidateNewRules(inheritedList []*SchemaReviewPolicy) error {
+	for _, rule := range override.RuleList {
+		if rule.Level == nil || *rule.Level == SchemaRuleLevelDisabled {
+			continue
+		}
+		isNew := len(inheritedList) == 0
+		for _, policy := range inheritedList {
+			if !policy.hasRule(rule.Type) {
+				isNew = true
+				break
+			}
+		}
+		if !isNew {
+			continue
+		}
+		if err := newOverrideRule(rule).Validate(); err != nil {
+			return fmt.Errorf("rule %s is absent in the inherited policy and requires a valid payload: %w", rule.Type, err)
+		}
+	}
+	return nil
+}
+
+func (policy *SchemaReviewPolicy) hasRule(ruleType SchemaReviewRuleType) bool {
+	for _, rule := range policy.RuleList {
+		if rule.Type == ruleType {
+			return true
+		}
+	}
+	return false
+}
+
+// newOverrideRule returns the rule added by the override.
+func newOverrideRule(ruleOverride *SchemaReviewRuleOverride) *SchemaReviewRule {
+	rule := &SchemaReviewRule{
+		Type:  ruleOverride.Type,
+		Level: SchemaRuleLevelDisabled,
+	}
+	if ruleOverride.Level != nil {
+		rule.Level = *ruleOverride.Level
+	}
+	if ruleOverride.Payload != nil {
+		rule.Payload = *ruleOverride.Payload
+	}
+	return rule
+}
+
+// MergeSchemaReviewPolicy returns the effective policy by applying the override to the inherited policy.
+// The inherited policy could be nil if there is no policy to inherit.
+func MergeSchemaReviewPolicy(policy *SchemaReviewPolicy, override *SchemaReviewPolicyOverride) *SchemaReviewPolicy {
+	result := &SchemaReviewPolicy{}
+	ruleMap := make(map[SchemaReviewRuleType]*SchemaReviewRule)
+	if policy != nil {
+		result.Name = policy.Name
+		for _, rule := range policy.RuleList {
+			r := *rule
+			result.RuleList = append(result.RuleList, &r)
+			ruleMap[r.Type] = &r
+		}
+	}
+	if override == nil {
+		return result
+	}
+
+	for _, ruleOverride := range override.RuleList {
+		rule, ok := ruleMap[ruleOverride.Type]
+		if !ok {
+			rule = newOverrideRule(ruleOverride)
+			// The inherited policy may have dropped the rule after the override is saved.
+			// The rule stays disabled rather than failing the whole check.
+			if err := rule.Validate(); err != nil {
+				log.Printf("disable rule %v added by the override with invalid payload. error: %v\n", rule.Type, err)
+				rule.Level = SchemaRuleLevelDisabled
+			}
+			result.RuleList = append(result.RuleList, rule)
+			ruleMap[rule.Type] = rule
+			continue
+		}
+		if ruleOverride.Level != nil {
+			rule.Level = *ruleOverride.Level
+		}
+		if ruleOverride.Payload != nil {
+			rule.Payload = *ruleOverride.Payload
+		}
+	}
+	return result
+}
+
 // SchemaReviewRule is the rule for schema review policy.
 type SchemaReviewRule struct {
 	Type  SchemaReviewRuleType  `json:"type"`
@@ -126,6 +270,12 @@ type SchemaReviewRule struct {
 
 // Validate validates the schema review rule.
 func (rule *SchemaReviewRule) Validate() error {
+	if IsCustomRule(rule.Type) {
+		if _, err := NewCustomRule(rule.Type, rule.Payload); err != nil {
+			return err
+		}
+		return nil
+	}
 	// TODO(rebelice): add other schema review rule validation.
 	switch rule.Type {
 	case SchemaRuleTableNaming, SchemaRuleColumnNaming:
@@ -140,6 +290,14 @@ func (rule *SchemaReviewRule) Validate() error {
 		if _, err := UnmarshalRequiredColumnRulePayload(rule.Payload); err != nil {
 			return err
 		}
+	case SchemaRuleStatementDisallowDropTruncate:
+		if _, err := UnmarshalEnvironmentListRulePayload(rule.Payload); err != nil {
+			return err
+		}
+	case SchemaRuleStatementDMLAffectedRowLimit:
+		if _, err := UnmarshalNumberLimitRulePayload(rule.Payload); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -154,6 +312,16 @@ type RequiredColumnRulePayload struct {
 	ColumnList []string `json:"columnList"`
 }
 
+// EnvironmentListRulePayload is the payload for rules which only take effect outside the listed environments.
+type EnvironmentListRulePayload struct {
+	EnvironmentList []string `json:"environmentList"`
+}
+
+// NumberLimitRulePayload is the payload for rules with a numeric upper limit.
+type NumberLimitRulePayload struct {
+	Number int `json:"number"`
+}
+
 // UnamrshalNamingRulePayloadAsRegexp will unmarshal payload to NamingRulePayload and compile it as regular expression.
 func UnamrshalNamingRulePayloadAsRegexp(payload string) (*regexp.Regexp, error) {
 	var nr NamingRulePayload
@@ -221,12 +389,43 @@ func UnmarshalRequiredColumnRulePayload(payload string) (*RequiredColumnRulePayl
 	return &rcr, nil
 }
 
+// UnmarshalEnvironmentListRulePayload will unmarshal payload to EnvironmentListRulePayload.
+// An empty payload means the rule takes effect in every environment.
+func UnmarshalEnvironmentListRulePayload(payload string) (*EnvironmentListRulePayload, error) {
+	var elr EnvironmentListRulePayload
+	if payload == "" {
+		return &elr, nil
+	}
+	if err := json.Unmarshal([]byte(payload), &elr); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal environment list rule payload %q: %q", payload, err)
+	}
+	return &elr, nil
+}
+
+// UnmarshalNumberLimitRulePayload will unmarshal payload to NumberLimitRulePayload.
+func UnmarshalNumberLimitRulePayload(payload string) (*NumberLimitRulePayload, error) {
+	var nlr NumberLimitRulePayload
+	if err := json.Unmarshal([]byte(payload), &nlr); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal number limit rule payload %q: %q", payload, err)
+	}
+	if nlr.Number <= 0 {
+		return nil, fmt.Errorf("invalid number limit rule payload, number must be positive")
+	}
+	return &nlr, nil
+}
+
 // SchemaReviewCheckContext is the context for schema review check.
 type SchemaReviewCheckContext struct {
 	Charset   string
 	Collation string
 	DbType    DBType
 	Catalog   catalog.Catalog
+
+	// EnvironmentName is the name of the environment where the statements would run.
+	EnvironmentName string
+	// Driver is the connection to the target database. It's used by the rules which need to
+	// consult the database, e.g. estimating the affected rows by EXPLAIN, and could be nil.
+	Driver *sql.DB
 }
 
 // SchemaReviewCheck checks the statments with schema review policy.
@@ -251,6 +450,10 @@ func SchemaReviewCheck(ctx context.Context, statements string, policy *SchemaRev
 				Collation: context.Collation,
 				Rule:      rule,
 				Catalog:   context.Catalog,
+
+				EnvironmentName: context.EnvironmentName,
+				Driver:          context.Driver,
+				Context:         ctx,
 			},
 			statements,
 		)
@@ -277,6 +480,13 @@ func SchemaReviewCheck(ctx context.Context, statements string, policy *SchemaRev
 }
 
 func getAdvisorTypeByRule(ruleType SchemaReviewRuleType, engine DBType) (Type, error) {
+	if IsCustomRule(ruleType) {
+		switch engine {
+		case MySQL, TiDB:
+			return MySQLCustomRule, nil
+		}
+		return Fake, fmt.Errorf("custom schema review rule %v is not supported for %v", ruleType, engine)
+	}
 	switch ruleType {
 	case SchemaRuleStatementRequireWhere:
 		switch engine {
@@ -293,6 +503,41 @@ func getAdvisorTypeByRule(ruleType SchemaReviewRuleType, engine DBType) (Type, e
 		case MySQL, TiDB:
 			return MySQLNoSelectAll, nil
 		}
+	case SchemaRuleStatementDisallowDropTruncate:
+		switch engine {
+		case MySQL, TiDB:
+			return MySQLDisallowDropTruncate, nil
+		}
+	case SchemaRuleStatementDMLDisallowLimit:
+		switch engine {
+		case MySQL, TiDB:
+			return MySQLDMLNoLimit, nil
+		}
+	case SchemaRuleStatementDMLDisallowOrderBy:
+		switch engine {
+		case MySQL, TiDB:
+			return MySQLDMLNoOrderBy, nil
+		}
+	case SchemaRuleStatementDMLAffectedRowLimit:
+		switch engine {
+		case MySQL, TiDB:
+			return MySQLAffectedRowLimit, nil
+		}
+	case SchemaRuleStatementInsertMustSpecifyColumn:
+		switch engine {
+		case MySQL, TiDB:
+			return MySQLInsertMustSpecifyColumn, nil
+		}
+	case SchemaRuleStatementInsertNoSelectAll:
+		switch engine {
+		case MySQL, TiDB:
+			return MySQLInsertNoSelectAll, nil
+		}
+	case SchemaRuleStatementSuggestIndex:
+		switch engine {
+		case MySQL, TiDB:
+			return MySQLIndexSuggestion, nil
+		}
 	case SchemaRuleSchemaBackwardCompatibility:
 		switch engine {
 		case MySQL, TiDB:
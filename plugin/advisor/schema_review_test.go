@@ -0,0 +1,239 @@
+package advisor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeSchemaReviewPolicy(t *testing.T) {
+	errorLevel := SchemaRuleLevelError
+	disabledLevel := SchemaRuleLevelDisabled
+	payload := `{"number":100}`
+
+	policy := &SchemaReviewPolicy{
+		Name: "Prod",
+		RuleList: []*SchemaReviewRule{
+			{Type: SchemaRuleStatementRequireWhere, Level: SchemaRuleLevelWarning},
+			{Type: SchemaRuleTableRequirePK, Level: SchemaRuleLevelError},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		policy   *SchemaReviewPolicy
+		override *SchemaReviewPolicyOverride
+		want     *SchemaReviewPolicy
+	}{
+		{
+			name:     "no override",
+			policy:   policy,
+			override: nil,
+			want:     policy,
+		},
+		{
+			name:   "override level",
+			policy: policy,
+			override: &SchemaReviewPolicyOverride{
+				RuleList: []*SchemaReviewRuleOverride{
+					{Type: SchemaRuleStatementRequireWhere, Level: &errorLevel},
+					{Type: SchemaRuleTableRequirePK, Level: &disabledLevel},
+				},
+			},
+			want: &SchemaReviewPolicy{
+				Name: "Prod",
+				RuleList: []*SchemaReviewRule{
+					{Type: SchemaRuleStatementRequireWhere, Level: SchemaRuleLevelError},
+					{Type: SchemaRuleTableRequirePK, Level: SchemaRuleLevelDisabled},
+				},
+			},
+		},
+		{
+			name:   "add rule",
+			policy: policy,
+			override: &SchemaReviewPolicyOverride{
+				RuleList: []*SchemaReviewRuleOverride{
+					{Type: SchemaRuleStatementDMLAffectedRowLimit, Level: &errorLevel, Payload: &payload},
+				},
+			},
+			want: &SchemaReviewPolicy{
+				Name: "Prod",
+				RuleList: []*SchemaReviewRule{
+					{Type: SchemaRuleStatementRequireWhere, Level: SchemaRuleLevelWarning},
+					{Type: SchemaRuleTableRequirePK, Level: SchemaRuleLevelError},
+					{Type: SchemaRuleStatementDMLAffectedRowLimit, Level: SchemaRuleLevelError, Payload: payload},
+				},
+			},
+		},
+		{
+			name:   "no inherited policy",
+			policy: nil,
+			override: &SchemaReviewPolicyOverride{
+				RuleList: []*SchemaReviewRuleOverride{
+					{Type: SchemaRuleTableRequirePK, Level: &errorLevel},
+					{Type: SchemaRuleStatementRequireWhere},
+				},
+			},
+			want: &SchemaReviewPolicy{
+				RuleList: []*SchemaReviewRule{
+					{Type: SchemaRuleTableRequirePK, Level: SchemaRuleLevelError},
+					{Type: SchemaRuleStatementRequireWhere, Level: SchemaRuleLevelDisabled},
+				},
+			},
+		},
+		{
+			name:   "add rule without payload",
+			policy: policy,
+			override: &SchemaReviewPolicyOverride{
+				RuleList: []*SchemaReviewRuleOverride{
+					{Type: SchemaRuleStatementDMLAffectedRowLimit, Level: &errorLevel},
+				},
+			},
+			want: &SchemaReviewPolicy{
+				Name: "Prod",
+				RuleList: []*SchemaReviewRule{
+					{Type: SchemaRuleStatementRequireWhere, Level: SchemaRuleLevelWarning},
+					{Type: SchemaRuleTableRequirePK, Level: SchemaRuleLevelError},
+					{Type: SchemaRuleStatementDMLAffectedRowLimit, Level: SchemaRuleLevelDisabled},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got := MergeSchemaReviewPolicy(test.policy, test.override)
+		assert.Equal(t, test.want, got, test.name)
+	}
+	// The inherited policy must not be modified.
+	assert.Equal(t, SchemaRuleLevelWarning, policy.RuleList[0].Level)
+	assert.Equal(t, SchemaRuleLevelError, policy.RuleList[1].Level)
+}
+
+func TestSchemaReviewPolicyOverrideValidate(t *testing.T) {
+	invalidLevel := SchemaReviewRuleLevel("FATAL")
+	invalidPayload := `{"number":`
+
+	tests := []struct {
+		name     string
+		override *SchemaReviewPolicyOverride
+		wantErr  bool
+	}{
+		{
+			name: "valid",
+			override: &SchemaReviewPolicyOverride{
+				RuleList: []*SchemaReviewRuleOverride{
+					{Type: SchemaRuleTableRequirePK},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty type",
+			override: &SchemaReviewPolicyOverride{
+				RuleList: []*SchemaReviewRuleOverride{
+					{Type: ""},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate type",
+			override: &SchemaReviewPolicyOverride{
+				RuleList: []*SchemaReviewRuleOverride{
+					{Type: SchemaRuleTableRequirePK},
+					{Type: SchemaRuleTableRequirePK},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid level",
+			override: &SchemaReviewPolicyOverride{
+				RuleList: []*SchemaReviewRuleOverride{
+					{Type: SchemaRuleTableRequirePK, Level: &invalidLevel},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid payload",
+			override: &SchemaReviewPolicyOverride{
+				RuleList: []*SchemaReviewRuleOverride{
+					{Type: SchemaRuleStatementDMLAffectedRowLimit, Payload: &invalidPayload},
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		err := test.override.Validate()
+		assert.Equal(t, test.wantErr, err != nil, test.name)
+	}
+}
+
+func TestSchemaReviewPolicyOverrideValidateNewRules(t *testing.T) {
+	errorLevel := SchemaRuleLevelError
+	disabledLevel := SchemaRuleLevelDisabled
+	payload := `{"number":100}`
+	prod := &SchemaReviewPolicy{
+		Name: "Prod",
+		RuleList: []*SchemaReviewRule{
+			{Type: SchemaRuleStatementDMLAffectedRowLimit, Level: SchemaRuleLevelWarning, Payload: payload},
+		},
+	}
+	testEnv := &SchemaReviewPolicy{Name: "Test"}
+
+	tests := []struct {
+		name          string
+		inheritedList []*SchemaReviewPolicy
+		override      *SchemaReviewPolicyOverride
+		wantErr       bool
+	}{
+		{
+			name:          "inherit payload",
+			inheritedList: []*SchemaReviewPolicy{prod},
+			override: &SchemaReviewPolicyOverride{
+				RuleList: []*SchemaReviewRuleOverride{{Type: SchemaRuleStatementDMLAffectedRowLimit, Level: &errorLevel}},
+			},
+		},
+		{
+			name:          "new rule without payload",
+			inheritedList: []*SchemaReviewPolicy{prod, testEnv},
+			override: &SchemaReviewPolicyOverride{
+				RuleList: []*SchemaReviewRuleOverride{{Type: SchemaRuleStatementDMLAffectedRowLimit, Level: &errorLevel}},
+			},
+			wantErr: true,
+		},
+		{
+			name:          "new rule with payload",
+			inheritedList: []*SchemaReviewPolicy{prod, testEnv},
+			override: &SchemaReviewPolicyOverride{
+				RuleList: []*SchemaReviewRuleOverride{{Type: SchemaRuleStatementDMLAffectedRowLimit, Level: &errorLevel, Payload: &payload}},
+			},
+		},
+		{
+			name:          "new rule disabled",
+			inheritedList: []*SchemaReviewPolicy{testEnv},
+			override: &SchemaReviewPolicyOverride{
+				RuleList: []*SchemaReviewRuleOverride{{Type: SchemaRuleStatementDMLAffectedRowLimit, Level: &disabledLevel}},
+			},
+		},
+		{
+			name: "no inherited policy",
+			override: &SchemaReviewPolicyOverride{
+				RuleList: []*SchemaReviewRuleOverride{{Type: SchemaRuleRequiredColumn, Level: &errorLevel}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		err := test.override.ValidateNewRules(test.inheritedList)
+		if test.wantErr {
+			assert.Error(t, err, test.name)
+		} else {
+			assert.NoError(t, err, test.name)
+		}
+	}
+}
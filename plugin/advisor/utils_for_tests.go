@@ -20,6 +20,8 @@ const (
 	MockOldUKName = "old_uk"
 	// MockOldPKName is the mock old foreign key for test.
 	MockOldPKName = "PRIMARY"
+	// MockUnknownTableName is the mock table unknown to the catalog for test.
+	MockUnknownTableName = "unknown_table"
 )
 
 var (
@@ -51,6 +53,27 @@ func (c *MockCatalogService) FindIndex(ctx context.Context, find *catalog.IndexF
 	return nil, fmt.Errorf("cannot find index for %v", find)
 }
 
+// FindIndexList implements the catalog interface.
+// Each table has the PRIMARY and old_index indexes on MockIndexColumnList, except MockUnknownTableName.
+func (c *MockCatalogService) FindIndexList(ctx context.Context, find *catalog.IndexListFind) ([]*catalog.Index, error) {
+	if find.TableName == MockUnknownTableName {
+		return nil, catalog.ErrTableNotFound
+	}
+	return []*catalog.Index{
+		{
+			Unique:            true,
+			Name:              MockOldPKName,
+			TableName:         find.TableName,
+			ColumnExpressions: MockIndexColumnList,
+		},
+		{
+			Name:              MockOldIndexName,
+			TableName:         find.TableName,
+			ColumnExpressions: MockIndexColumnList,
+		},
+	}, nil
+}
+
 // TestCase is the data struct for test.
 type TestCase struct {
 	Statement string
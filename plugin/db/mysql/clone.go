@@ -0,0 +1,518 @@
+package mysql
+
+// This file implements the database clone for MySQL and TiDB.
+// The schema and data are read in a single read-only transaction of the source database for a consistent snapshot,
+// which is a stale read transaction as of the start on TiDB, and written to the target database directly:
+// 1. The tables are created, and their rows are copied by the multi-row INSERT with the foreign key checks disabled.
+// 2. The views are created after all the tables, since they may reference the tables in any order.
+// 3. The routines, events and triggers are created at last, so that the triggers don't fire on the copied rows.
+//    They are skipped if the target is TiDB, which doesn't support them.
+// The hashed columns are masked by HMAC-SHA256 with a random key of the clone, so that the same values are hashed
+// the same within the clone, but cannot be matched with the source by hashing the guessed values. It's pseudonymization
+// rather than anonymization, because the equal values are still linkable in the clone.
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	tidbparser "github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/parser/ast"
+	"github.com/pingcap/tidb/parser/format"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/common/log"
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/db/util"
+	"go.uber.org/zap"
+)
+
+const (
+	// cloneBatchRowCount is the maximum number of rows inserted by one statement.
+	cloneBatchRowCount = 100
+	// cloneBatchSize is the size of the statement after which the rows are flushed,
+	// which is kept well below the default max_allowed_packet.
+	cloneBatchSize = 1024 * 1024
+	// cloneHashKeySize is the size of the random HMAC key hashing the masked values in a clone.
+	cloneHashKeySize = 32
+)
+
+var (
+	cloneNumericTypes = map[string]bool{
+		"tinyint": true, "smallint": true, "mediumint": true, "int": true, "integer": true, "bigint": true,
+		"decimal": true, "numeric": true, "float": true, "double": true, "real": true, "year": true,
+	}
+	cloneBinaryTypes = map[string]bool{
+		"binary": true, "varbinary": true, "tinyblob": true, "blob": true, "mediumblob": true, "longblob": true, "bit": true,
+		"geometry": true, "point": true, "linestring": true, "polygon": true, "multipoint": true, "multilinestring": true,
+		"multipolygon": true, "geometrycollection": true, "geomcollection": true,
+	}
+	cloneStringTypes = map[string]bool{
+		"char": true, "varchar": true, "tinytext": true, "text": true, "mediumtext": true, "longtext": true,
+	}
+	// cloneRowFilterFunctions is the functions allowed in the row filters, which have no side effects.
+	cloneRowFilterFunctions = map[string]bool{
+		// Control flow functions.
+		"if": true, "ifnull": true, "nullif": true, "coalesce": true, "isnull": true, "greatest": true, "least": true,
+		// String functions.
+		"concat": true, "concat_ws": true, "lower": true, "lcase": true, "upper": true, "ucase": true, "length": true,
+		"char_length": true, "character_length": true, "substring": true, "substr": true, "left": true, "right": true,
+		"trim": true, "ltrim": true, "rtrim": true, "replace": true, "locate": true, "instr": true, "convert": true,
+		// Numeric functions.
+		"abs": true, "mod": true, "floor": true, "ceil": true, "ceiling": true, "round": true, "truncate": true,
+		// Date and time functions.
+		"now": true, "current_timestamp": true, "curdate": true, "current_date": true, "curtime": true, "current_time": true,
+		"utc_timestamp": true, "utc_date": true, "date": true, "time": true, "year": true, "month": true, "day": true,
+		"dayofmonth": true, "dayofweek": true, "weekday": true, "hour": true, "minute": true, "second": true,
+		"date_add": true, "date_sub": true, "adddate": true, "subdate": true, "datediff": true, "timestampdiff": true,
+		"date_format": true, "str_to_date": true, "unix_timestamp": true, "from_unixtime": true, "last_day": true, "extract": true,
+	}
+)
+
+// cloneColumn is the column of the table for cloning the rows.
+type cloneColumn struct {
+	Name     string
+	DataType string
+	// MaxLength is the maximum length in characters of the string column.
+	MaxLength int64
+	// Generated is true for the generated column, which cannot be written.
+	Generated bool
+}
+
+// CloneDatabase clones the schema and, unless the config is schema only, the data of the database to the target database,
+// which must be empty. The target driver must be connected to the target database.
+// The progress is called with the table name and the number of rows copied after each batch.
+func (driver *Driver) CloneDatabase(ctx context.Context, database string, target *Driver, targetDatabase string, config api.CloneConfig, progress func(table string, copied int64)) error {
+	conn, err := driver.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	options := sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	// The READ ONLY transaction is a no-op in TiDB, so the next transaction is made the stale read transaction,
+	// which rejects the writes. It requires TiDB 5.3 or later.
+	if driver.dbType == db.TiDB {
+		options = sql.TxOptions{}
+		if _, err := conn.ExecContext(ctx, "SET TRANSACTION READ ONLY AS OF TIMESTAMP NOW()"); err != nil {
+			return fmt.Errorf("failed to start the read-only transaction, TiDB 5.3 or later is required, error: %w", err)
+		}
+	}
+	txn, err := conn.BeginTx(ctx, &options)
+	if err != nil {
+		return err
+	}
+	defer txn.Rollback()
+
+	tables, err := getTablesTx(txn, database)
+	if err != nil {
+		return fmt.Errorf("failed to get tables of database %q, error: %w", database, err)
+	}
+	columnMap, err := getCloneColumnMap(txn, database)
+	if err != nil {
+		return err
+	}
+	filterMap, maskingMap, err := getCloneConfigMap(config, tables, columnMap)
+	if err != nil {
+		return err
+	}
+	hashKey := make([]byte, cloneHashKeySize)
+	if _, err := rand.Read(hashKey); err != nil {
+		return fmt.Errorf("failed to generate the hash key, error: %w", err)
+	}
+
+	targetConn, err := target.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer targetConn.Close()
+	targetTables, err := getTables(ctx, targetConn, targetDatabase)
+	if err != nil {
+		return fmt.Errorf("failed to get tables of target database %q, error: %w", targetDatabase, err)
+	}
+	if len(targetTables) > 0 {
+		return common.Errorf(common.Invalid, fmt.Errorf("target database %q is not empty", targetDatabase))
+	}
+	// The tables are created in the name order, which may differ from the order of the references.
+	if _, err := targetConn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+		return err
+	}
+	defer func() {
+		if _, err := targetConn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 1"); err != nil {
+			log.Warn("Failed to restore the foreign key checks", zap.Error(err))
+		}
+	}()
+
+	for _, tbl := range tables {
+		if tbl.TableType != baseTableType {
+			continue
+		}
+		stmt := getCreateStatement(tbl.Statement)
+		if config.SchemaOnly {
+			stmt = excludeSchemaAutoIncrementValue(stmt)
+		}
+		if _, err := targetConn.ExecContext(ctx, stmt); err != nil {
+			return util.FormatErrorWithQuery(err, stmt)
+		}
+		if config.SchemaOnly {
+			continue
+		}
+		if err := cloneTableData(ctx, txn, database, tbl.Name, columnMap[tbl.Name], filterMap[tbl.Name], maskingMap[tbl.Name], hashKey, targetConn, progress); err != nil {
+			return fmt.Errorf("failed to clone the data of table %q, error: %w", tbl.Name, err)
+		}
+	}
+	for _, tbl := range tables {
+		if tbl.TableType != viewTableType {
+			continue
+		}
+		// SHOW CREATE VIEW qualifies the referenced tables with the database name.
+		stmt := strings.ReplaceAll(getCreateStatement(tbl.Statement), fmt.Sprintf("`%s`.", database), fmt.Sprintf("`%s`.", targetDatabase))
+		if _, err := targetConn.ExecContext(ctx, stmt); err != nil {
+			return util.FormatErrorWithQuery(err, stmt)
+		}
+	}
+
+	if target.dbType == db.TiDB {
+		log.Debug("Skip cloning the routines, events and triggers to TiDB", zap.String("database", database))
+		return txn.Commit()
+	}
+	var sb strings.Builder
+	routines, err := getRoutines(txn, database)
+	if err != nil {
+		return fmt.Errorf("failed to get routines of database %q: %s", database, err)
+	}
+	for _, rt := range routines {
+		sb.WriteString(fmt.Sprintf("%s\n", rt.statement))
+	}
+	events, err := getEvents(txn, database)
+	if err != nil {
+		return fmt.Errorf("failed to get events of database %q: %s", database, err)
+	}
+	for _, et := range events {
+		sb.WriteString(fmt.Sprintf("%s\n", et.statement))
+	}
+	triggers, err := getTriggers(txn, database)
+	if err != nil {
+		return fmt.Errorf("failed to get triggers of database %q: %s", database, err)
+	}
+	for _, tr := range triggers {
+		sb.WriteString(fmt.Sprintf("%s\n", tr.statement))
+	}
+	if err := util.ApplyMultiStatements(bufio.NewScanner(strings.NewReader(sb.String())), func(stmt string) error {
+		if _, err := targetConn.ExecContext(ctx, stmt); err != nil {
+			return util.FormatErrorWithQuery(err, stmt)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return txn.Commit()
+}
+
+// getCreateStatement strips the comment header and the trailing semicolon from the statement of a table or view in the dump.
+func getCreateStatement(stmt string) string {
+	var lineList []string
+	for _, line := range strings.Split(stmt, "\n") {
+		if strings.HasPrefix(line, "--") {
+			continue
+		}
+		lineList = append(lineList, line)
+	}
+	return strings.TrimSuffix(strings.TrimSpace(strings.Join(lineList, "\n")), ";")
+}
+
+// getCloneColumnMap returns the columns of the tables in the database.
+func getCloneColumnMap(txn *sql.Tx, database string) (map[string][]*cloneColumn, error) {
+	query := `
+		SELECT TABLE_NAME, COLUMN_NAME, DATA_TYPE, IFNULL(CHARACTER_MAXIMUM_LENGTH, 0), EXTRA
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ?
+		ORDER BY TABLE_NAME, ORDINAL_POSITION`
+	rows, err := txn.Query(query, database)
+	if err != nil {
+		return nil, util.FormatErrorWithQuery(err, query)
+	}
+	defer rows.Close()
+
+	columnMap := make(map[string][]*cloneColumn)
+	for rows.Next() {
+		var table, extra string
+		column := &cloneColumn{}
+		if err := rows.Scan(&table, &column.Name, &column.DataType, &column.MaxLength, &extra); err != nil {
+			return nil, err
+		}
+		column.DataType = strings.ToLower(column.DataType)
+		column.Generated = strings.Contains(strings.ToUpper(extra), "GENERATED")
+		columnMap[table] = append(columnMap[table], column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return columnMap, nil
+}
+
+// getCloneConfigMap returns the row filter of each table and the masking of each column of the tables,
+// and validates that they are applicable to the tables.
+func getCloneConfigMap(config api.CloneConfig, tables []*TableSchema, columnMap map[string][]*cloneColumn) (map[string]string, map[string]map[string]api.CloneMaskingType, error) {
+	baseTableSet := make(map[string]bool)
+	for _, tbl := range tables {
+		if tbl.TableType == baseTableType {
+			baseTableSet[tbl.Name] = true
+		}
+	}
+
+	filterMap := make(map[string]string)
+	for _, filter := range config.TableFilterList {
+		if !baseTableSet[filter.Table] {
+			return nil, nil, common.Errorf(common.Invalid, fmt.Errorf("table %q of the row filter not found", filter.Table))
+		}
+		if _, ok := filterMap[filter.Table]; ok {
+			return nil, nil, common.Errorf(common.Invalid, fmt.Errorf("table %q has more than one row filter", filter.Table))
+		}
+		where, err := GetCloneRowFilter(filter.Where)
+		if err != nil {
+			return nil, nil, common.Errorf(common.Invalid, fmt.Errorf("invalid row filter of table %q, error: %w", filter.Table, err))
+		}
+		filterMap[filter.Table] = where
+	}
+
+	maskingMap := make(map[string]map[string]api.CloneMaskingType)
+	for _, masking := range config.MaskingList {
+		if !baseTableSet[masking.Table] {
+			return nil, nil, common.Errorf(common.Invalid, fmt.Errorf("table %q of the masking not found", masking.Table))
+		}
+		var column *cloneColumn
+		for _, c := range columnMap[masking.Table] {
+			if c.Name == masking.Column {
+				column = c
+				break
+			}
+		}
+		if column == nil {
+			return nil, nil, common.Errorf(common.Invalid, fmt.Errorf("column %q of table %q not found", masking.Column, masking.Table))
+		}
+		if column.Generated {
+			return nil, nil, common.Errorf(common.Invalid, fmt.Errorf("generated column %q of table %q cannot be masked", masking.Column, masking.Table))
+		}
+		switch masking.Type {
+		case api.CloneMaskingNull:
+		case api.CloneMaskingHash, api.CloneMaskingRedact:
+			if !cloneStringTypes[column.DataType] {
+				return nil, nil, common.Errorf(common.Invalid, fmt.Errorf("column %q of table %q with type %q cannot be masked by %s, only the string columns are supported", masking.Column, masking.Table, column.DataType, masking.Type))
+			}
+		default:
+			return nil, nil, common.Errorf(common.Invalid, fmt.Errorf("unsupported masking type %q", masking.Type))
+		}
+		if maskingMap[masking.Table] == nil {
+			maskingMap[masking.Table] = make(map[string]api.CloneMaskingType)
+		}
+		maskingMap[masking.Table][masking.Column] = masking.Type
+	}
+	return filterMap, maskingMap, nil
+}
+
+// GetCloneRowFilter parses the row filter, and returns the filter restored from the parsed expression.
+// The filter must be a single expression without subqueries, variables, aggregations or the functions with side effects,
+// so that it neither reads the other tables nor changes anything when it's evaluated on the source database.
+func GetCloneRowFilter(where string) (string, error) {
+	const prefix = "SELECT 1 FROM `t` WHERE "
+	nodeList, _, err := tidbparser.New().Parse(prefix+where, "", "")
+	if err != nil {
+		return "", fmt.Errorf("failed to parse row filter %q, error: %w", where, err)
+	}
+	if len(nodeList) != 1 {
+		return "", fmt.Errorf("row filter %q must be a single expression", where)
+	}
+	selectStmt, ok := nodeList[0].(*ast.SelectStmt)
+	if !ok || selectStmt.Where == nil {
+		return "", fmt.Errorf("row filter %q must be a single expression", where)
+	}
+	visitor := &cloneRowFilterVisitor{}
+	selectStmt.Where.Accept(visitor)
+	if visitor.err != nil {
+		return "", fmt.Errorf("invalid row filter %q, error: %w", where, visitor.err)
+	}
+
+	restore := func(node ast.Node) (string, error) {
+		var buf strings.Builder
+		// The charset of the string literals without the explicit charset follows the connection.
+		if err := node.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags|format.RestoreStringWithoutDefaultCharset, &buf)); err != nil {
+			return "", fmt.Errorf("failed to restore row filter %q, error: %w", where, err)
+		}
+		return buf.String(), nil
+	}
+	filter, err := restore(selectStmt.Where)
+	if err != nil {
+		return "", err
+	}
+	// The clauses following the expression, e.g. ORDER BY, LIMIT or FOR UPDATE, are part of the statement instead.
+	stmt, err := restore(selectStmt)
+	if err != nil {
+		return "", err
+	}
+	if stmt != prefix+filter {
+		return "", fmt.Errorf("row filter %q must be a single expression", where)
+	}
+	return filter, nil
+}
+
+// cloneRowFilterVisitor rejects the expressions not allowed in the row filter.
+type cloneRowFilterVisitor struct {
+	err error
+}
+
+// Enter implements the ast.Visitor interface.
+func (v *cloneRowFilterVisitor) Enter(in ast.Node) (ast.Node, bool) {
+	switch node := in.(type) {
+	case *ast.SubqueryExpr, *ast.ExistsSubqueryExpr:
+		v.err = fmt.Errorf("subqueries are not allowed")
+	case *ast.VariableExpr:
+		v.err = fmt.Errorf("variables are not allowed")
+	case *ast.AggregateFuncExpr, *ast.WindowFuncExpr:
+		v.err = fmt.Errorf("aggregate and window functions are not allowed")
+	case *ast.FuncCallExpr:
+		if node.Schema.L != "" || !cloneRowFilterFunctions[node.FnName.L] {
+			v.err = fmt.Errorf("function %s is not allowed", node.FnName.O)
+		}
+	case *ast.ColumnNameExpr:
+		if node.Name.Schema.L != "" || node.Name.Table.L != "" {
+			v.err = fmt.Errorf("column %s must not be qualified", node.Name.Name.O)
+		}
+	}
+	return in, v.err != nil
+}
+
+// Leave implements the ast.Visitor interface.
+func (v *cloneRowFilterVisitor) Leave(in ast.Node) (ast.Node, bool) {
+	return in, v.err == nil
+}
+
+// getCloneHashPads returns the inner and outer pads of HMAC-SHA256 with the key no longer than the block size.
+func getCloneHashPads(key []byte) ([]byte, []byte) {
+	ipad := make([]byte, sha256.BlockSize)
+	opad := make([]byte, sha256.BlockSize)
+	copy(ipad, key)
+	copy(opad, key)
+	for i := range ipad {
+		ipad[i] ^= 0x36
+		opad[i] ^= 0x5c
+	}
+	return ipad, opad
+}
+
+// getCloneSelectStatement returns the statement selecting the rows cloned from the table with the columns masked,
+// and the columns selected.
+func getCloneSelectStatement(database, table string, columnList []*cloneColumn, where string, maskingMap map[string]api.CloneMaskingType, hashKey []byte) (string, []*cloneColumn) {
+	ipad, opad := getCloneHashPads(hashKey)
+	var selectedList []*cloneColumn
+	var exprList []string
+	for _, column := range columnList {
+		if column.Generated {
+			continue
+		}
+		selectedList = append(selectedList, column)
+		name := fmt.Sprintf("`%s`", column.Name)
+		switch maskingMap[column.Name] {
+		case api.CloneMaskingNull:
+			exprList = append(exprList, "NULL")
+		case api.CloneMaskingHash:
+			// MySQL has no HMAC function, so it's computed as SHA2(opad || UNHEX(SHA2(ipad || value))).
+			// The digest is truncated to fit in the column.
+			exprList = append(exprList, fmt.Sprintf("LEFT(SHA2(CONCAT(0x%s, UNHEX(SHA2(CONCAT(0x%s, %s), 256))), 256), %d)", hex.EncodeToString(opad), hex.EncodeToString(ipad), name, column.MaxLength))
+		case api.CloneMaskingRedact:
+			exprList = append(exprList, fmt.Sprintf("REPEAT('*', CHAR_LENGTH(%s))", name))
+		default:
+			exprList = append(exprList, name)
+		}
+	}
+	stmt := fmt.Sprintf("SELECT %s FROM `%s`.`%s`", strings.Join(exprList, ", "), database, table)
+	if where != "" {
+		stmt = fmt.Sprintf("%s WHERE %s", stmt, where)
+	}
+	return stmt, selectedList
+}
+
+// getCloneValueLiteral returns the SQL literal of the column value.
+func getCloneValueLiteral(column *cloneColumn, value sql.RawBytes) string {
+	switch {
+	case value == nil:
+		return "NULL"
+	case cloneNumericTypes[column.DataType]:
+		return string(value)
+	case cloneBinaryTypes[column.DataType]:
+		if len(value) == 0 {
+			return "''"
+		}
+		return fmt.Sprintf("0x%s", hex.EncodeToString(value))
+	default:
+		return quoteString(string(value))
+	}
+}
+
+// cloneTableData copies the rows of the table selected in the source transaction to the target.
+func cloneTableData(ctx context.Context, txn *sql.Tx, database, table string, columnList []*cloneColumn, where string, maskingMap map[string]api.CloneMaskingType, hashKey []byte, targetConn *sql.Conn, progress func(table string, copied int64)) error {
+	query, selectedList := getCloneSelectStatement(database, table, columnList, where, maskingMap, hashKey)
+	rows, err := txn.QueryContext(ctx, query)
+	if err != nil {
+		return util.FormatErrorWithQuery(err, query)
+	}
+	defer rows.Close()
+
+	var nameList []string
+	for _, column := range selectedList {
+		nameList = append(nameList, fmt.Sprintf("`%s`", column.Name))
+	}
+	insertPrefix := fmt.Sprintf("INSERT INTO `%s` (%s) VALUES ", table, strings.Join(nameList, ", "))
+	var sb strings.Builder
+	var batchRowCount, copied int64
+	flush := func() error {
+		if batchRowCount == 0 {
+			return nil
+		}
+		if _, err := targetConn.ExecContext(ctx, sb.String()); err != nil {
+			return err
+		}
+		copied += batchRowCount
+		progress(table, copied)
+		sb.Reset()
+		batchRowCount = 0
+		return nil
+	}
+
+	values := make([]sql.RawBytes, len(selectedList))
+	dest := make([]interface{}, len(selectedList))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		if batchRowCount == 0 {
+			sb.WriteString(insertPrefix)
+		} else {
+			sb.WriteString(", ")
+		}
+		var literalList []string
+		for i, column := range selectedList {
+			literalList = append(literalList, getCloneValueLiteral(column, values[i]))
+		}
+		sb.WriteString(fmt.Sprintf("(%s)", strings.Join(literalList, ", ")))
+		batchRowCount++
+		if batchRowCount >= cloneBatchRowCount || sb.Len() >= cloneBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
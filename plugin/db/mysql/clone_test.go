@@ -0,0 +1,179 @@
+package mysql
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/stretchr/testify/require"
+
+	// Register pingcap parser driver.
+	_ "github.com/pingcap/tidb/types/parser_driver"
+)
+
+func TestGetCloneSelectStatement(t *testing.T) {
+	a := require.New(t)
+	columnList := []*cloneColumn{
+		{Name: "id", DataType: "int"},
+		{Name: "email", DataType: "varchar", MaxLength: 32},
+		{Name: "name", DataType: "text", MaxLength: 65535},
+		{Name: "phone", DataType: "varchar", MaxLength: 20},
+		{Name: "name_len", DataType: "int", Generated: true},
+	}
+	maskingMap := map[string]api.CloneMaskingType{
+		"email": api.CloneMaskingHash,
+		"name":  api.CloneMaskingRedact,
+		"phone": api.CloneMaskingNull,
+	}
+
+	hashKey := []byte("key")
+	ipad, opad := getCloneHashPads(hashKey)
+	stmt, selectedList := getCloneSelectStatement("db", "user", columnList, "id > 10", maskingMap, hashKey)
+	a.Equal(fmt.Sprintf("SELECT `id`, LEFT(SHA2(CONCAT(0x%s, UNHEX(SHA2(CONCAT(0x%s, `email`), 256))), 256), 32), REPEAT('*', CHAR_LENGTH(`name`)), NULL FROM `db`.`user` WHERE id > 10", hex.EncodeToString(opad), hex.EncodeToString(ipad)), stmt)
+	a.Equal(columnList[:4], selectedList)
+
+	stmt, _ = getCloneSelectStatement("db", "user", columnList, "", nil, hashKey)
+	a.Equal("SELECT `id`, `email`, `name`, `phone` FROM `db`.`user`", stmt)
+}
+
+func TestGetCloneHashPads(t *testing.T) {
+	a := require.New(t)
+	key := []byte("0123456789abcdef0123456789abcdef")
+	value := []byte("alice@example.com")
+
+	// The hash computed in the select statement is the same as HMAC-SHA256.
+	ipad, opad := getCloneHashPads(key)
+	inner := sha256.Sum256(append(ipad, value...))
+	outer := sha256.Sum256(append(opad, inner[:]...))
+	mac := hmac.New(sha256.New, key)
+	_, err := mac.Write(value)
+	a.NoError(err)
+	a.Equal(mac.Sum(nil), outer[:])
+}
+
+func TestGetCloneValueLiteral(t *testing.T) {
+	tests := []struct {
+		dataType string
+		value    sql.RawBytes
+		want     string
+	}{
+		{dataType: "int", value: nil, want: "NULL"},
+		{dataType: "bigint", value: sql.RawBytes("-42"), want: "-42"},
+		{dataType: "decimal", value: sql.RawBytes("3.14"), want: "3.14"},
+		{dataType: "varbinary", value: sql.RawBytes{0x00, 0xff, '\''}, want: "0x00ff27"},
+		{dataType: "blob", value: sql.RawBytes{}, want: "''"},
+		{dataType: "varchar", value: sql.RawBytes("it's\n"), want: `'it\'s\n'`},
+		{dataType: "varchar", value: sql.RawBytes{}, want: "''"},
+		{dataType: "datetime", value: sql.RawBytes("2022-01-01 00:00:00"), want: "'2022-01-01 00:00:00'"},
+	}
+
+	a := require.New(t)
+	for _, test := range tests {
+		a.Equal(test.want, getCloneValueLiteral(&cloneColumn{DataType: test.dataType}, test.value), test.dataType)
+	}
+}
+
+func TestGetCloneConfigMap(t *testing.T) {
+	tables := []*TableSchema{
+		{Name: "user", TableType: baseTableType},
+		{Name: "user_view", TableType: viewTableType},
+	}
+	columnMap := map[string][]*cloneColumn{
+		"user": {
+			{Name: "id", DataType: "int"},
+			{Name: "email", DataType: "varchar", MaxLength: 32},
+		},
+	}
+	tests := []struct {
+		config  api.CloneConfig
+		wantErr bool
+	}{
+		{
+			config: api.CloneConfig{
+				TableFilterList: []*api.CloneTableFilter{{Table: "user", Where: "id > 10"}},
+				MaskingList: []*api.CloneColumnMasking{
+					{Table: "user", Column: "email", Type: api.CloneMaskingHash},
+					{Table: "user", Column: "id", Type: api.CloneMaskingNull},
+				},
+			},
+		},
+		{
+			config:  api.CloneConfig{TableFilterList: []*api.CloneTableFilter{{Table: "user_view", Where: "id > 10"}}},
+			wantErr: true,
+		},
+		{
+			config:  api.CloneConfig{TableFilterList: []*api.CloneTableFilter{{Table: "user", Where: "id > 10"}, {Table: "user", Where: "id < 5"}}},
+			wantErr: true,
+		},
+		{
+			config:  api.CloneConfig{TableFilterList: []*api.CloneTableFilter{{Table: "user", Where: "id > 10; DROP TABLE user"}}},
+			wantErr: true,
+		},
+		{
+			config:  api.CloneConfig{MaskingList: []*api.CloneColumnMasking{{Table: "user", Column: "phone", Type: api.CloneMaskingNull}}},
+			wantErr: true,
+		},
+		{
+			config:  api.CloneConfig{MaskingList: []*api.CloneColumnMasking{{Table: "user", Column: "id", Type: api.CloneMaskingRedact}}},
+			wantErr: true,
+		},
+		{
+			config:  api.CloneConfig{MaskingList: []*api.CloneColumnMasking{{Table: "user", Column: "email", Type: "SHUFFLE"}}},
+			wantErr: true,
+		},
+	}
+
+	a := require.New(t)
+	for i, test := range tests {
+		filterMap, maskingMap, err := getCloneConfigMap(test.config, tables, columnMap)
+		if test.wantErr {
+			a.Error(err, i)
+			continue
+		}
+		a.NoError(err, i)
+		a.Equal(map[string]string{"user": "`id`>10"}, filterMap)
+		a.Equal(map[string]map[string]api.CloneMaskingType{
+			"user": {"email": api.CloneMaskingHash, "id": api.CloneMaskingNull},
+		}, maskingMap)
+	}
+}
+
+func TestGetCloneRowFilter(t *testing.T) {
+	tests := []struct {
+		where   string
+		want    string
+		wantErr bool
+	}{
+		{where: "id > 10", want: "`id`>10"},
+		{where: "status IN ('active', 'pending') AND created_ts > UNIX_TIMESTAMP(NOW() - INTERVAL 30 DAY)", want: "`status` IN ('active','pending') AND `created_ts`>UNIX_TIMESTAMP(DATE_SUB(NOW(), INTERVAL 30 DAY))"},
+		{where: "name LIKE 'a%' OR email IS NULL", want: "`name` LIKE 'a%' OR `email` IS NULL"},
+		{where: "id > 10; DROP TABLE user", wantErr: true},
+		{where: "id > 10 UNION SELECT password FROM mysql.user", wantErr: true},
+		{where: "id > 10 ORDER BY id LIMIT 1", wantErr: true},
+		{where: "id > 10 FOR UPDATE", wantErr: true},
+		{where: "id IN (SELECT id FROM other)", wantErr: true},
+		{where: "EXISTS (SELECT 1 FROM other)", wantErr: true},
+		{where: "SLEEP(10) = 0", wantErr: true},
+		{where: "GET_LOCK('lock', 10)", wantErr: true},
+		{where: "(@a := 1) = 1", wantErr: true},
+		{where: "db.func(id) = 1", wantErr: true},
+		{where: "other.id = 1", wantErr: true},
+		{where: "COUNT(*) > 0", wantErr: true},
+		{where: "", wantErr: true},
+	}
+
+	a := require.New(t)
+	for _, test := range tests {
+		got, err := GetCloneRowFilter(test.where)
+		if test.wantErr {
+			a.Error(err, test.where)
+			continue
+		}
+		a.NoError(err, test.where)
+		a.Equal(test.want, got, test.where)
+	}
+}
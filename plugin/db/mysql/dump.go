@@ -73,6 +73,24 @@ var (
 
 // Dump dumps the database.
 func (driver *Driver) Dump(ctx context.Context, database string, out io.Writer, schemaOnly bool) (string, error) {
+	return driver.dump(ctx, database, out, schemaOnly, nil /* rowCountMap */, nil /* schemaOut */)
+}
+
+// DumpWithTableRowCount dumps the database like Dump, and returns the row counts of the tables in the dump.
+// The rows are counted while being dumped, so that the row counts are consistent with the dump.
+// The schema-only dump in the same transaction is written into schemaOut if it's not nil.
+func (driver *Driver) DumpWithTableRowCount(ctx context.Context, database string, out, schemaOut io.Writer) (string, map[string]int64, error) {
+	rowCountMap := make(map[string]int64)
+	payload, err := driver.dump(ctx, database, out, false /* schemaOnly */, rowCountMap, schemaOut)
+	if err != nil {
+		return "", nil, err
+	}
+	return payload, rowCountMap, nil
+}
+
+// dump dumps the database, and records the row counts of the dumped tables in rowCountMap if it's not nil.
+// The schema-only dump is written into schemaOut as well if it's not nil.
+func (driver *Driver) dump(ctx context.Context, database string, out io.Writer, schemaOnly bool, rowCountMap map[string]int64, schemaOut io.Writer) (string, error) {
 	// mysqldump -u root --databases dbName --no-data --routines --events --triggers --compact
 
 	// We must use the same MySQL connection to lock and unlock tables.
@@ -123,7 +141,7 @@ func (driver *Driver) Dump(ctx context.Context, database string, out io.Writer,
 	defer txn.Rollback()
 
 	log.Debug("begin to dump database", zap.String("database", database))
-	if err := dumpTxn(ctx, txn, database, out, schemaOnly); err != nil {
+	if err := dumpTxn(ctx, txn, database, out, schemaOnly, rowCountMap, schemaOut); err != nil {
 		return "", err
 	}
 
@@ -172,8 +190,13 @@ func FlushTablesWithReadLock(ctx context.Context, conn *sql.Conn, database strin
 	return nil
 }
 
-func dumpTxn(ctx context.Context, txn *sql.Tx, database string, out io.Writer, schemaOnly bool) error {
+func dumpTxn(ctx context.Context, txn *sql.Tx, database string, out io.Writer, schemaOnly bool, rowCountMap map[string]int64, schemaOut io.Writer) error {
 	log.Debug("begin to dump database", zap.String("database", database))
+	// The schema statements are written into both out and schemaOut, and the data only into out.
+	dataOut := out
+	if schemaOut != nil {
+		out = io.MultiWriter(out, schemaOut)
+	}
 	// Find all dumpable databases
 	dbNames, err := getDatabases(ctx, txn)
 	if err != nil {
@@ -233,15 +256,28 @@ func dumpTxn(ctx context.Context, txn *sql.Tx, database string, out io.Writer, s
 			if schemaOnly && tbl.TableType == baseTableType {
 				tbl.Statement = excludeSchemaAutoIncrementValue(tbl.Statement)
 			}
-			if _, err := io.WriteString(out, fmt.Sprintf("%s\n", tbl.Statement)); err != nil {
+			if schemaOut != nil {
+				statement := tbl.Statement
+				if tbl.TableType == baseTableType {
+					statement = excludeSchemaAutoIncrementValue(statement)
+				}
+				if _, err := io.WriteString(schemaOut, fmt.Sprintf("%s\n", statement)); err != nil {
+					return err
+				}
+			}
+			if _, err := io.WriteString(dataOut, fmt.Sprintf("%s\n", tbl.Statement)); err != nil {
 				return err
 			}
 			if !schemaOnly && tbl.TableType == baseTableType {
 				// Include db prefix if dumping multiple databases.
 				includeDbPrefix := len(dumpableDbNames) > 1
-				if err := exportTableData(txn, dbName, tbl.Name, includeDbPrefix, out); err != nil {
+				rowCount, err := exportTableData(txn, dbName, tbl.Name, includeDbPrefix, dataOut)
+				if err != nil {
 					return err
 				}
+				if rowCountMap != nil {
+					rowCountMap[tbl.Name] = rowCount
+				}
 			}
 		}
 
@@ -452,29 +488,30 @@ func getTableStmt(txn *sql.Tx, dbName, tblName, tblType string) (string, error)
 }
 
 // exportTableData gets the data of a table.
-func exportTableData(txn *sql.Tx, dbName, tblName string, includeDbPrefix bool, out io.Writer) error {
+func exportTableData(txn *sql.Tx, dbName, tblName string, includeDbPrefix bool, out io.Writer) (int64, error) {
 	query := fmt.Sprintf("SELECT * FROM `%s`.`%s`;", dbName, tblName)
 	rows, err := txn.Query(query)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer rows.Close()
 
 	cols, err := rows.ColumnTypes()
 	if err != nil {
-		return err
+		return 0, err
 	}
 	if len(cols) == 0 {
-		return nil
+		return 0, nil
 	}
 	values := make([]*sql.NullString, len(cols))
 	refs := make([]interface{}, len(cols))
 	for i := 0; i < len(cols); i++ {
 		refs[i] = &values[i]
 	}
+	var rowCount int64
 	for rows.Next() {
 		if err := rows.Scan(refs...); err != nil {
-			return err
+			return 0, err
 		}
 		tokens := make([]string, len(cols))
 		for i, v := range values {
@@ -493,16 +530,17 @@ func exportTableData(txn *sql.Tx, dbName, tblName string, includeDbPrefix bool,
 		}
 		stmt := fmt.Sprintf("INSERT INTO %s`%s` VALUES (%s);\n", dbPrefix, tblName, strings.Join(tokens, ", "))
 		if _, err := io.WriteString(out, stmt); err != nil {
-			return err
+			return 0, err
 		}
+		rowCount++
 	}
 	if err := rows.Err(); err != nil {
-		return err
+		return 0, err
 	}
 	if _, err := io.WriteString(out, "\n"); err != nil {
-		return err
+		return 0, err
 	}
-	return nil
+	return rowCount, nil
 }
 
 // isNumeric determines whether the value needs quotes.
@@ -0,0 +1,418 @@
+package mysql
+
+// This file implements the table-level flashback for MySQL.
+// The row events of the tables between the start and the end time are decoded from the local binlog files by mysqlbinlog,
+// and reverted by the inverse DML in the reverse order of the events:
+// 1. INSERT is reverted by DELETE of the inserted row.
+// 2. DELETE is reverted by INSERT of the deleted row.
+// 3. UPDATE is reverted by UPDATE from the after image to the before image.
+// The binlog must be in the ROW format with the FULL row image, so that the row events contain all the columns.
+// The columns in the row events are mapped by the position to the current table schema, so the tables must not be
+// altered since the start time.
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bytebase/bytebase/common/log"
+	"github.com/bytebase/bytebase/plugin/db/util"
+	"github.com/bytebase/bytebase/resources/mysqlutil"
+	"go.uber.org/zap"
+)
+
+var (
+	// The row event header in the mysqlbinlog verbose output, e.g. "### INSERT INTO `db`.`tbl`".
+	rowEventHeaderReg = regexp.MustCompile("^### (INSERT INTO|DELETE FROM|UPDATE) `([^`]*)`\\.`([^`]*)`$")
+	// The column value in the mysqlbinlog verbose output, e.g. "###   @1=1 /* INT meta=0 nullable=0 is_null=0 */".
+	rowEventValueReg = regexp.MustCompile(`^###   @(\d+)=(.*)$`)
+)
+
+// flashbackColumn is the column of the table for generating the flashback statement.
+type flashbackColumn struct {
+	Name string
+	// Unsigned is true for the unsigned integer column, whose value is printed as signed by mysqlbinlog.
+	Unsigned bool
+	// Timestamp is true for the TIMESTAMP column, whose value is printed as the Unix timestamp by mysqlbinlog.
+	Timestamp bool
+	// Generated is true for the generated column, which cannot be written.
+	Generated bool
+}
+
+// binlogValue is a column value decoded by mysqlbinlog.
+type binlogValue struct {
+	// literal is the value in the SQL literal, or "NULL".
+	literal string
+	// unsigned is the value interpreted as unsigned, which is only printed for the negative integer.
+	unsigned string
+}
+
+// binlogRowEvent is a row change decoded by mysqlbinlog.
+type binlogRowEvent struct {
+	table  string
+	action string
+	// before is the row before the change in the DELETE and the UPDATE.
+	before []*binlogValue
+	// after is the row after the change in the INSERT and the UPDATE.
+	after []*binlogValue
+}
+
+// CheckBinlogRowImageFull checks whether the binlog row image is FULL, which is required by the flashback.
+func (driver *Driver) CheckBinlogRowImageFull(ctx context.Context) error {
+	value, err := driver.getServerVariable(ctx, "binlog_row_image")
+	if err != nil {
+		return err
+	}
+	if strings.ToUpper(value) != "FULL" {
+		return fmt.Errorf("binlog row image is not FULL but %s", value)
+	}
+	return nil
+}
+
+// GenerateFlashbackStatement generates the statement reverting the row changes of the tables in the database
+// in [startTs, endTs) from the local binlog files.
+func (driver *Driver) GenerateFlashbackStatement(ctx context.Context, database string, tableList []string, startTs, endTs int64) (string, error) {
+	columnMap, err := driver.getFlashbackColumnMap(ctx, database)
+	if err != nil {
+		return "", err
+	}
+	tableSet := make(map[string]bool)
+	for _, table := range tableList {
+		if _, ok := columnMap[table]; !ok {
+			return "", fmt.Errorf("table %q not found in database %q", table, database)
+		}
+		tableSet[table] = true
+	}
+
+	binlogList, err := driver.getFlashbackBinlogList(ctx, startTs, endTs)
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{
+		// Decode the row events into the pseudo SQL statements with the column types.
+		"--base64-output=DECODE-ROWS",
+		"--verbose",
+		"--verbose",
+		// List entries for just this database.
+		"--database", database,
+		"--start-datetime", formatDateTime(startTs),
+		// Stop reading the binary log at the first event having a timestamp equal to or later than the datetime argument.
+		"--stop-datetime", formatDateTime(endTs),
+	}
+	for _, binlog := range binlogList {
+		args = append(args, filepath.Join(driver.binlogDir, binlog.Name))
+	}
+	cmd := exec.CommandContext(ctx, driver.mysqlutil.GetPath(mysqlutil.MySQLBinlog), args...)
+	cmd.Stderr = os.Stderr
+	pr, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	log.Debug("Start decoding binlog for flashback", zap.String("mysqlbinlog", cmd.String()))
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("cannot start mysqlbinlog command, error: %w", err)
+	}
+	eventList, err := parseBinlogRowEventList(bufio.NewScanner(pr), database, tableSet)
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return "", err
+	}
+	if err := cmd.Wait(); err != nil {
+		return "", fmt.Errorf("error occurred while waiting for mysqlbinlog to exit: %w", err)
+	}
+
+	return getFlashbackStatement(eventList, columnMap)
+}
+
+// getFlashbackBinlogList returns the local binlog files containing the events in [startTs, endTs).
+func (driver *Driver) getFlashbackBinlogList(ctx context.Context, startTs, endTs int64) ([]BinlogFile, error) {
+	binlogFilesLocalSorted, err := GetSortedLocalBinlogFiles(driver.binlogDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sorted local binlog files, error: %w", err)
+	}
+	if len(binlogFilesLocalSorted) == 0 {
+		return nil, fmt.Errorf("no local binlog files found")
+	}
+	if !binlogFilesAreContinuous(binlogFilesLocalSorted) {
+		return nil, fmt.Errorf("local binlog files are not continuous")
+	}
+
+	var firstEventTsList []int64
+	for _, file := range binlogFilesLocalSorted {
+		eventTs, err := driver.parseLocalBinlogFirstEventTs(ctx, file.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the local binlog file %q's first binlog event ts, error: %w", file.Name, err)
+		}
+		firstEventTsList = append(firstEventTsList, eventTs)
+	}
+	if firstEventTsList[0] > startTs {
+		return nil, fmt.Errorf("the startTs %d is before the first event ts %d of the oldest binlog file %q", startTs, firstEventTsList[0], binlogFilesLocalSorted[0].Name)
+	}
+
+	var binlogList []BinlogFile
+	for i, file := range binlogFilesLocalSorted {
+		if firstEventTsList[i] >= endTs {
+			break
+		}
+		// The file is skipped if all its events are before the startTs.
+		if i+1 < len(binlogFilesLocalSorted) && firstEventTsList[i+1] < startTs {
+			continue
+		}
+		binlogList = append(binlogList, file)
+	}
+	return binlogList, nil
+}
+
+// getFlashbackColumnMap returns the columns of the tables in the database.
+func (driver *Driver) getFlashbackColumnMap(ctx context.Context, database string) (map[string][]*flashbackColumn, error) {
+	db, err := driver.GetDbConnection(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT TABLE_NAME, COLUMN_NAME, COLUMN_TYPE, DATA_TYPE, EXTRA
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ?
+		ORDER BY TABLE_NAME, ORDINAL_POSITION`
+	rows, err := db.QueryContext(ctx, query, database)
+	if err != nil {
+		return nil, util.FormatErrorWithQuery(err, query)
+	}
+	defer rows.Close()
+
+	columnMap := make(map[string][]*flashbackColumn)
+	for rows.Next() {
+		var table, name, columnType, dataType, extra string
+		if err := rows.Scan(&table, &name, &columnType, &dataType, &extra); err != nil {
+			return nil, err
+		}
+		columnMap[table] = append(columnMap[table], &flashbackColumn{
+			Name:      name,
+			Unsigned:  strings.Contains(strings.ToLower(columnType), "unsigned"),
+			Timestamp: strings.ToLower(dataType) == "timestamp",
+			Generated: strings.Contains(strings.ToUpper(extra), "GENERATED"),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return columnMap, nil
+}
+
+// parseBinlogRowEventList parses the row events of the tables in the database from the mysqlbinlog verbose output.
+func parseBinlogRowEventList(sc *bufio.Scanner, database string, tableSet map[string]bool) ([]*binlogRowEvent, error) {
+	// The long rows are printed in one line.
+	sc.Buffer(make([]byte, bufio.MaxScanTokenSize), 1024*1024*1024)
+
+	var eventList []*binlogRowEvent
+	var event *binlogRowEvent
+	// image is the row image of the event where the values are appended.
+	var image *[]*binlogValue
+	for sc.Scan() {
+		line := sc.Text()
+		if !strings.HasPrefix(line, "### ") {
+			continue
+		}
+		if matches := rowEventHeaderReg.FindStringSubmatch(line); matches != nil {
+			event, image = nil, nil
+			if matches[2] != database || !tableSet[matches[3]] {
+				continue
+			}
+			event = &binlogRowEvent{table: matches[3], action: strings.Fields(matches[1])[0]}
+			eventList = append(eventList, event)
+			continue
+		}
+		if event == nil {
+			continue
+		}
+		switch {
+		case line == "### WHERE":
+			image = &event.before
+		case line == "### SET":
+			image = &event.after
+		default:
+			matches := rowEventValueReg.FindStringSubmatch(line)
+			if matches == nil || image == nil {
+				return nil, fmt.Errorf("unexpected mysqlbinlog output line %q", line)
+			}
+			position, err := strconv.Atoi(matches[1])
+			if err != nil {
+				return nil, err
+			}
+			if position != len(*image)+1 {
+				return nil, fmt.Errorf("unexpected column @%d in row event of table %q, the binlog row image must be FULL", position, event.table)
+			}
+			value, err := parseBinlogValue(matches[2])
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse column @%d in row event of table %q, error: %w", position, event.table, err)
+			}
+			*image = append(*image, value)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return eventList, nil
+}
+
+// parseBinlogValue parses the column value printed by mysqlbinlog, e.g.
+// 'foo' /* VARSTRING(255) meta=255 nullable=1 is_null=0 */
+// -1 (4294967295) /* INT meta=0 nullable=0 is_null=0 */
+// The non-printable characters, the quote and the backslash in the string are printed in the \xNN format.
+func parseBinlogValue(s string) (*binlogValue, error) {
+	value := &binlogValue{}
+	switch {
+	case strings.HasPrefix(s, "'"):
+		end := strings.Index(s[1:], "'")
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated string %q", s)
+		}
+		decoded, err := decodeBinlogString(s[1 : end+1])
+		if err != nil {
+			return nil, err
+		}
+		value.literal = quoteString(decoded)
+		s = s[end+2:]
+	case strings.HasPrefix(s, "b'"):
+		end := strings.Index(s[2:], "'")
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated bit string %q", s)
+		}
+		value.literal = s[:end+3]
+		s = s[end+3:]
+	default:
+		end := strings.Index(s, " ")
+		if end < 0 {
+			end = len(s)
+		}
+		value.literal = s[:end]
+		s = s[end:]
+	}
+	if value.literal == "" {
+		return nil, fmt.Errorf("value not found")
+	}
+
+	s = strings.TrimLeft(s, " ")
+	if strings.HasPrefix(s, "(") {
+		end := strings.Index(s, ")")
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated unsigned value %q", s)
+		}
+		value.unsigned = s[1:end]
+	}
+	return value, nil
+}
+
+func decodeBinlogString(s string) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) && s[i+1] == 'x' {
+			b, err := strconv.ParseUint(s[i+2:i+4], 16, 8)
+			if err != nil {
+				return "", fmt.Errorf("invalid escaped character %q", s[i:i+4])
+			}
+			sb.WriteByte(byte(b))
+			i += 3
+			continue
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String(), nil
+}
+
+// quoteString quotes the string as the MySQL string literal.
+func quoteString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('\'')
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case 0:
+			sb.WriteString(`\0`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case 0x1a:
+			sb.WriteString(`\Z`)
+		case '\'':
+			sb.WriteString(`\'`)
+		case '\\':
+			sb.WriteString(`\\`)
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	sb.WriteByte('\'')
+	return sb.String()
+}
+
+// getFlashbackStatement generates the inverse DML of the row events in the reverse order.
+func getFlashbackStatement(eventList []*binlogRowEvent, columnMap map[string][]*flashbackColumn) (string, error) {
+	var stmtList []string
+	for i := len(eventList) - 1; i >= 0; i-- {
+		event := eventList[i]
+		columnList := columnMap[event.table]
+		table := fmt.Sprintf("`%s`", event.table)
+		switch event.action {
+		case "INSERT":
+			whereList, err := getFlashbackAssignmentList(columnList, event.after, true)
+			if err != nil {
+				return "", fmt.Errorf("failed to revert INSERT of table %q, error: %w", event.table, err)
+			}
+			stmtList = append(stmtList, fmt.Sprintf("DELETE FROM %s WHERE %s LIMIT 1;", table, strings.Join(whereList, " AND ")))
+		case "DELETE":
+			assignmentList, err := getFlashbackAssignmentList(columnList, event.before, false)
+			if err != nil {
+				return "", fmt.Errorf("failed to revert DELETE of table %q, error: %w", event.table, err)
+			}
+			stmtList = append(stmtList, fmt.Sprintf("INSERT INTO %s SET %s;", table, strings.Join(assignmentList, ", ")))
+		case "UPDATE":
+			assignmentList, err := getFlashbackAssignmentList(columnList, event.before, false)
+			if err != nil {
+				return "", fmt.Errorf("failed to revert UPDATE of table %q, error: %w", event.table, err)
+			}
+			whereList, err := getFlashbackAssignmentList(columnList, event.after, true)
+			if err != nil {
+				return "", fmt.Errorf("failed to revert UPDATE of table %q, error: %w", event.table, err)
+			}
+			stmtList = append(stmtList, fmt.Sprintf("UPDATE %s SET %s WHERE %s LIMIT 1;", table, strings.Join(assignmentList, ", "), strings.Join(whereList, " AND ")))
+		}
+	}
+	return strings.Join(stmtList, "\n"), nil
+}
+
+// getFlashbackAssignmentList returns the assignments of the columns to the values in the row image,
+// or the conditions matching the row image if isCondition is true. The generated columns are skipped.
+func getFlashbackAssignmentList(columnList []*flashbackColumn, image []*binlogValue, isCondition bool) ([]string, error) {
+	if len(image) != len(columnList) {
+		return nil, fmt.Errorf("the row has %d columns, but the table has %d columns now", len(image), len(columnList))
+	}
+	var list []string
+	for i, column := range columnList {
+		if column.Generated {
+			continue
+		}
+		value := image[i].literal
+		if column.Unsigned && image[i].unsigned != "" {
+			value = image[i].unsigned
+		}
+		if column.Timestamp && value != "NULL" {
+			value = fmt.Sprintf("FROM_UNIXTIME(%s)", value)
+		}
+		operator := "="
+		if isCondition {
+			// Use the NULL-safe equal to match the NULL value.
+			operator = "<=>"
+		}
+		list = append(list, fmt.Sprintf("`%s` %s %s", column.Name, operator, value))
+	}
+	return list, nil
+}
@@ -0,0 +1,156 @@
+package mysql
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBinlogValue(t *testing.T) {
+	tests := []struct {
+		s    string
+		want binlogValue
+	}{
+		{
+			s:    "1 /* INT meta=0 nullable=0 is_null=0 */",
+			want: binlogValue{literal: "1"},
+		},
+		{
+			s:    "-1 (4294967295) /* INT meta=0 nullable=0 is_null=0 */",
+			want: binlogValue{literal: "-1", unsigned: "4294967295"},
+		},
+		{
+			s:    `'it\x27s a\x5cb\x0a' /* VARSTRING(255) meta=255 nullable=1 is_null=0 */`,
+			want: binlogValue{literal: `'it\'s a\\b\n'`},
+		},
+		{
+			s:    "'' /* VARSTRING(255) meta=255 nullable=1 is_null=0 */",
+			want: binlogValue{literal: "''"},
+		},
+		{
+			s:    "b'0101' /* BIT(4) meta=4 nullable=1 is_null=0 */",
+			want: binlogValue{literal: "b'0101'"},
+		},
+		{
+			s:    "NULL /* VARSTRING(255) meta=255 nullable=1 is_null=1 */",
+			want: binlogValue{literal: "NULL"},
+		},
+	}
+
+	a := require.New(t)
+	for _, test := range tests {
+		value, err := parseBinlogValue(test.s)
+		a.NoError(err, test.s)
+		a.Equal(test.want, *value, test.s)
+	}
+
+	_, err := parseBinlogValue("'unterminated /* VARSTRING(255) meta=255 nullable=1 is_null=0 */")
+	a.Error(err)
+}
+
+func TestParseBinlogRowEventList(t *testing.T) {
+	a := require.New(t)
+	output := strings.Join([]string{
+		"# at 4",
+		"#220708 10:00:00 server id 1  end_log_pos 125 CRC32 0x00000000 	Start: binlog v 4, server v 8.0.28 created 220708 10:00:00",
+		"### INSERT INTO `db`.`tbl`",
+		"### SET",
+		"###   @1=1 /* INT meta=0 nullable=0 is_null=0 */",
+		"###   @2='foo' /* VARSTRING(255) meta=255 nullable=1 is_null=0 */",
+		"### INSERT INTO `db`.`other`",
+		"### SET",
+		"###   @1=1 /* INT meta=0 nullable=0 is_null=0 */",
+		"### INSERT INTO `other_db`.`tbl`",
+		"### SET",
+		"###   @1=1 /* INT meta=0 nullable=0 is_null=0 */",
+		"### UPDATE `db`.`tbl`",
+		"### WHERE",
+		"###   @1=1 /* INT meta=0 nullable=0 is_null=0 */",
+		"###   @2='foo' /* VARSTRING(255) meta=255 nullable=1 is_null=0 */",
+		"### SET",
+		"###   @1=1 /* INT meta=0 nullable=0 is_null=0 */",
+		"###   @2=NULL /* VARSTRING(255) meta=255 nullable=1 is_null=1 */",
+		"# at 500",
+		"### DELETE FROM `db`.`tbl`",
+		"### WHERE",
+		"###   @1=1 /* INT meta=0 nullable=0 is_null=0 */",
+		"###   @2=NULL /* VARSTRING(255) meta=255 nullable=1 is_null=1 */",
+		"COMMIT/*!*/;",
+	}, "\n")
+
+	eventList, err := parseBinlogRowEventList(bufio.NewScanner(strings.NewReader(output)), "db", map[string]bool{"tbl": true})
+	a.NoError(err)
+	a.Equal([]*binlogRowEvent{
+		{
+			table:  "tbl",
+			action: "INSERT",
+			after:  []*binlogValue{{literal: "1"}, {literal: "'foo'"}},
+		},
+		{
+			table:  "tbl",
+			action: "UPDATE",
+			before: []*binlogValue{{literal: "1"}, {literal: "'foo'"}},
+			after:  []*binlogValue{{literal: "1"}, {literal: "NULL"}},
+		},
+		{
+			table:  "tbl",
+			action: "DELETE",
+			before: []*binlogValue{{literal: "1"}, {literal: "NULL"}},
+		},
+	}, eventList)
+
+	// The row image is not FULL.
+	output = strings.Join([]string{
+		"### DELETE FROM `db`.`tbl`",
+		"### WHERE",
+		"###   @1=1 /* INT meta=0 nullable=0 is_null=0 */",
+		"###   @3=NULL /* VARSTRING(255) meta=255 nullable=1 is_null=1 */",
+	}, "\n")
+	_, err = parseBinlogRowEventList(bufio.NewScanner(strings.NewReader(output)), "db", map[string]bool{"tbl": true})
+	a.Error(err)
+}
+
+func TestGetFlashbackStatement(t *testing.T) {
+	a := require.New(t)
+	columnMap := map[string][]*flashbackColumn{
+		"tbl": {
+			{Name: "id", Unsigned: true},
+			{Name: "name"},
+			{Name: "created_ts", Timestamp: true},
+			{Name: "name_len", Generated: true},
+		},
+	}
+	eventList := []*binlogRowEvent{
+		{
+			table:  "tbl",
+			action: "INSERT",
+			after:  []*binlogValue{{literal: "-1", unsigned: "4294967295"}, {literal: "'foo'"}, {literal: "1657245600"}, {literal: "3"}},
+		},
+		{
+			table:  "tbl",
+			action: "UPDATE",
+			before: []*binlogValue{{literal: "-1", unsigned: "4294967295"}, {literal: "'foo'"}, {literal: "1657245600"}, {literal: "3"}},
+			after:  []*binlogValue{{literal: "-1", unsigned: "4294967295"}, {literal: "NULL"}, {literal: "NULL"}, {literal: "NULL"}},
+		},
+		{
+			table:  "tbl",
+			action: "DELETE",
+			before: []*binlogValue{{literal: "-1", unsigned: "4294967295"}, {literal: "NULL"}, {literal: "NULL"}, {literal: "NULL"}},
+		},
+	}
+
+	statement, err := getFlashbackStatement(eventList, columnMap)
+	a.NoError(err)
+	a.Equal(strings.Join([]string{
+		"INSERT INTO `tbl` SET `id` = 4294967295, `name` = NULL, `created_ts` = NULL;",
+		"UPDATE `tbl` SET `id` = 4294967295, `name` = 'foo', `created_ts` = FROM_UNIXTIME(1657245600) WHERE `id` <=> 4294967295 AND `name` <=> NULL AND `created_ts` <=> NULL LIMIT 1;",
+		"DELETE FROM `tbl` WHERE `id` <=> 4294967295 AND `name` <=> 'foo' AND `created_ts` <=> FROM_UNIXTIME(1657245600) LIMIT 1;",
+	}, "\n"), statement)
+
+	// The table has a new column added after the change.
+	columnMap["tbl"] = append(columnMap["tbl"], &flashbackColumn{Name: "new_column"})
+	_, err = getFlashbackStatement(eventList, columnMap)
+	a.Error(err)
+}
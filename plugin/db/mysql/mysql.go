@@ -12,6 +12,8 @@ import (
 	"github.com/bytebase/bytebase/plugin/db/util"
 	"github.com/bytebase/bytebase/resources/mysqlutil"
 	"github.com/go-sql-driver/mysql"
+	tidbparser "github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/parser/ast"
 	"go.uber.org/zap"
 )
 
@@ -159,19 +161,32 @@ func (driver *Driver) GetVersion(ctx context.Context) (string, error) {
 func (driver *Driver) Execute(ctx context.Context, statement string) error {
 	tx, err := driver.db.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return &util.NotAppliedError{Err: err}
 	}
 	defer tx.Rollback()
 
-	_, err = tx.ExecContext(ctx, statement)
-
-	if err == nil {
-		if err := tx.Commit(); err != nil {
-			return err
+	if _, err := tx.ExecContext(ctx, statement); err != nil {
+		// The DML statements are rolled back together, while the other statements such as DDL commit the transaction implicitly.
+		if isDMLStatement(statement) {
+			return &util.NotAppliedError{Err: err}
 		}
+		return err
 	}
+	return tx.Commit()
+}
 
-	return err
+// isDMLStatement returns true if the statement only consists of DML statements.
+func isDMLStatement(statement string) bool {
+	nodeList, _, err := tidbparser.New().Parse(statement, "", "")
+	if err != nil {
+		return false
+	}
+	for _, node := range nodeList {
+		if _, ok := node.(ast.DMLNode); !ok {
+			return false
+		}
+	}
+	return true
 }
 
 // Query queries a SQL statement.
@@ -0,0 +1,39 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	// Register pingcap parser driver.
+	_ "github.com/pingcap/tidb/types/parser_driver"
+)
+
+func TestIsDMLStatement(t *testing.T) {
+	tests := []struct {
+		statement string
+		want      bool
+	}{
+		{
+			statement: "UPDATE t SET a = 1 WHERE id = 1; DELETE FROM t WHERE id = 2; INSERT INTO t (id) VALUES (3);",
+			want:      true,
+		},
+		{
+			// The DDL commits the transaction implicitly.
+			statement: "UPDATE t SET a = 1; ALTER TABLE t ADD COLUMN b INT;",
+			want:      false,
+		},
+		{
+			statement: "SET @a = 1; UPDATE t SET a = @a;",
+			want:      false,
+		},
+		{
+			statement: "UPDATE t SET",
+			want:      false,
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.want, isDMLStatement(test.statement), test.statement)
+	}
+}
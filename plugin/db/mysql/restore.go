@@ -453,6 +453,93 @@ func binlogFilesAreContinuous(files []BinlogFile) bool {
 	return true
 }
 
+// BinlogGap is a gap in the local binlog files, where the binlog files are purged on server before being downloaded.
+type BinlogGap struct {
+	// Before is the binlog file right before the gap.
+	Before string
+	// After is the binlog file right after the gap.
+	After string
+}
+
+// GetBinlogGapList returns the gaps in the sorted binlog files.
+func GetBinlogGapList(files []BinlogFile) []BinlogGap {
+	var gapList []BinlogGap
+	for i := 0; i < len(files)-1; i++ {
+		if files[i].Seq+1 != files[i+1].Seq {
+			gapList = append(gapList, BinlogGap{Before: files[i].Name, After: files[i+1].Name})
+		}
+	}
+	return gapList
+}
+
+// GetEarliestRestorableBackup returns the earliest backup from which the sorted binlog files are continuous to the latest one,
+// or nil if there is no such backup. The backupList should only contain DONE backups.
+func GetEarliestRestorableBackup(backupList []*api.Backup, files []BinlogFile) (*api.Backup, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+	// The binlog files after the last gap are continuous to the latest one.
+	startSeq := files[0].Seq
+	if gapList := GetBinlogGapList(files); len(gapList) > 0 {
+		lastGap := gapList[len(gapList)-1]
+		seq, err := getBinlogNameSeq(lastGap.After)
+		if err != nil {
+			return nil, err
+		}
+		startSeq = seq
+	}
+	endSeq := files[len(files)-1].Seq
+
+	var backup *api.Backup
+	var backupCoordinate binlogCoordinate
+	for _, b := range backupList {
+		if b.Payload.BinlogInfo.IsEmpty() {
+			continue
+		}
+		c, err := newBinlogCoordinate(b.Payload.BinlogInfo.FileName, b.Payload.BinlogInfo.Position)
+		if err != nil {
+			return nil, err
+		}
+		if c.Seq < startSeq || c.Seq > endSeq {
+			continue
+		}
+		if backup == nil || c.Seq < backupCoordinate.Seq || (c.Seq == backupCoordinate.Seq && c.Pos < backupCoordinate.Pos) {
+			backup = b
+			backupCoordinate = c
+		}
+	}
+	return backup, nil
+}
+
+// ArchiveBinlogFiles downloads the new binlog files on server to `binlogDir`. The modification time of the latest
+// binlog file is set to the time before listing the binlog files on server, so that it is the time up to which
+// all the binlog events are archived.
+func (driver *Driver) ArchiveBinlogFiles(ctx context.Context) error {
+	archiveTime := time.Now()
+	binlogFilesOnServerSorted, err := driver.GetSortedBinlogFilesMetaOnServer(ctx)
+	if err != nil {
+		return err
+	}
+	if len(binlogFilesOnServerSorted) == 0 {
+		log.Debug("No binlog file found on server to archive")
+		return nil
+	}
+
+	binlogFilesLocalSorted, err := GetSortedLocalBinlogFiles(driver.binlogDir)
+	if err != nil {
+		return fmt.Errorf("failed to read local binlog files, error: %w", err)
+	}
+	if err := driver.downloadBinlogFilesOnServer(ctx, binlogFilesLocalSorted, binlogFilesOnServerSorted); err != nil {
+		return err
+	}
+
+	latestBinlogFilePath := filepath.Join(driver.binlogDir, binlogFilesOnServerSorted[len(binlogFilesOnServerSorted)-1].Name)
+	if err := os.Chtimes(latestBinlogFilePath, archiveTime, archiveTime); err != nil {
+		return fmt.Errorf("failed to update the modification time of binlog file %q, error: %w", latestBinlogFilePath, err)
+	}
+	return nil
+}
+
 // Download binlog files on server.
 func (driver *Driver) downloadBinlogFilesOnServer(ctx context.Context, binlogFilesLocal, binlogFilesOnServerSorted []BinlogFile) error {
 	if len(binlogFilesOnServerSorted) == 0 {
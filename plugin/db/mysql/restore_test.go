@@ -334,6 +334,58 @@ func TestBinlogFilesAreContinuous(t *testing.T) {
 	}
 }
 
+func TestGetBinlogGapList(t *testing.T) {
+	a := require.New(t)
+	files := []BinlogFile{
+		{Name: "binlog.000001", Seq: 1},
+		{Name: "binlog.000002", Seq: 2},
+		{Name: "binlog.000005", Seq: 5},
+		{Name: "binlog.000006", Seq: 6},
+		{Name: "binlog.000008", Seq: 8},
+	}
+	a.Equal([]BinlogGap{
+		{Before: "binlog.000002", After: "binlog.000005"},
+		{Before: "binlog.000006", After: "binlog.000008"},
+	}, GetBinlogGapList(files))
+	a.Empty(GetBinlogGapList(files[:2]))
+	a.Empty(GetBinlogGapList(nil))
+}
+
+func TestGetEarliestRestorableBackup(t *testing.T) {
+	a := require.New(t)
+	files := []BinlogFile{
+		{Name: "binlog.000001", Seq: 1},
+		{Name: "binlog.000002", Seq: 2},
+		{Name: "binlog.000005", Seq: 5},
+		{Name: "binlog.000006", Seq: 6},
+	}
+	backupList := []*api.Backup{
+		{ID: 1, Payload: api.BackupPayload{BinlogInfo: api.BinlogInfo{FileName: "binlog.000001", Position: 100}}},
+		{ID: 2, Payload: api.BackupPayload{BinlogInfo: api.BinlogInfo{FileName: "binlog.000006", Position: 200}}},
+		{ID: 3, Payload: api.BackupPayload{BinlogInfo: api.BinlogInfo{FileName: "binlog.000005", Position: 300}}},
+		{ID: 4, Payload: api.BackupPayload{BinlogInfo: api.BinlogInfo{FileName: "binlog.000005", Position: 100}}},
+		{ID: 5},
+	}
+
+	// The backups before the gap are not restorable.
+	backup, err := GetEarliestRestorableBackup(backupList, files)
+	a.NoError(err)
+	a.Equal(4, backup.ID)
+
+	backup, err = GetEarliestRestorableBackup(backupList, files[:2])
+	a.NoError(err)
+	a.Equal(1, backup.ID)
+
+	// The binlog files of the backups are purged before being archived.
+	backup, err = GetEarliestRestorableBackup(backupList[:2], files[2:3])
+	a.NoError(err)
+	a.Nil(backup)
+
+	backup, err = GetEarliestRestorableBackup(backupList, nil)
+	a.NoError(err)
+	a.Nil(backup)
+}
+
 func TestParseBinlogEventTsInLine(t *testing.T) {
 	a := require.New(t)
 	tests := []struct {
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -11,18 +12,33 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/common/log"
 	"github.com/bytebase/bytebase/plugin/db/util"
+	"go.uber.org/zap"
 )
 
 // Dump dumps the database.
 func (driver *Driver) Dump(ctx context.Context, database string, out io.Writer, schemaOnly bool) (string, error) {
+	payload, _, err := driver.dump(ctx, database, out, schemaOnly, false /* countRows */, nil /* schemaOut */)
+	return payload, err
+}
+
+// DumpWithTableRowCount dumps the database like Dump, and returns the row counts of the tables in the dump.
+// The rows are counted in the snapshot exported to pg_dump, so that the row counts are consistent with the dump.
+// The schema-only dump in the same snapshot is written into schemaOut if it's not nil.
+// The returned row counts are nil, and nothing is written into schemaOut, if the snapshot cannot be exported.
+func (driver *Driver) DumpWithTableRowCount(ctx context.Context, database string, out, schemaOut io.Writer) (string, map[string]int64, error) {
+	return driver.dump(ctx, database, out, false /* schemaOnly */, true /* countRows */, schemaOut)
+}
+
+func (driver *Driver) dump(ctx context.Context, database string, out io.Writer, schemaOnly bool, countRows bool, schemaOut io.Writer) (string, map[string]int64, error) {
 	// pg_dump -d dbName --schema-only+
 
 	// Find all dumpable databases
 	databases, err := driver.getDatabases()
 	if err != nil {
-		return "", fmt.Errorf("failed to get databases: %s", err)
+		return "", nil, fmt.Errorf("failed to get databases: %s", err)
 	}
 
 	var dumpableDbNames []string
@@ -35,7 +51,7 @@ func (driver *Driver) Dump(ctx context.Context, database string, out io.Writer,
 			}
 		}
 		if !exist {
-			return "", fmt.Errorf("database %s not found", database)
+			return "", nil, fmt.Errorf("database %s not found", database)
 		}
 		dumpableDbNames = []string{database}
 	} else {
@@ -47,17 +63,73 @@ func (driver *Driver) Dump(ctx context.Context, database string, out io.Writer,
 		}
 	}
 
+	// Before we dump the real data, we should export the snapshot with the WAL info for PITR.
+	// The exporting transaction must be kept open until pg_dump imports the snapshot.
+	snapshot := ""
+	var payloadBytes []byte
+	var rowCountMap map[string]int64
+	if !schemaOnly && database != "" {
+		snapshotDB, tx, snapshotName, walInfo, err := driver.exportSnapshot(ctx, database)
+		if err != nil {
+			// The snapshot cannot be exported on the standby, or without the privilege of reading the WAL location.
+			// The backup is still useful except for PITR.
+			log.Warn("failed to export snapshot for PITR, dump without the WAL info", zap.String("database", database), zap.Error(err))
+		} else {
+			defer snapshotDB.Close()
+			defer tx.Rollback()
+			log.Debug("WAL info at dump time",
+				zap.String("lsn", walInfo.LSN),
+				zap.String("snapshot", walInfo.Snapshot))
+
+			snapshot = snapshotName
+			payload := api.BackupPayload{WALInfo: walInfo}
+			payloadBytes, err = json.Marshal(payload)
+			if err != nil {
+				return "", nil, err
+			}
+			if countRows {
+				if rowCountMap, err = getTableRowCountMap(ctx, tx); err != nil {
+					return "", nil, err
+				}
+			}
+			if schemaOut != nil {
+				if err := driver.dumpOneDatabaseWithPgDump(ctx, database, schemaOut, true /* schemaOnly */, false /* includeUseDatabase */, snapshot); err != nil {
+					return "", nil, err
+				}
+			}
+		}
+	}
+
 	for _, dbName := range dumpableDbNames {
 		includeUseDatabase := len(dumpableDbNames) > 1
-		if err := driver.dumpOneDatabaseWithPgDump(ctx, dbName, out, schemaOnly, includeUseDatabase); err != nil {
-			return "", err
+		if err := driver.dumpOneDatabaseWithPgDump(ctx, dbName, out, schemaOnly, includeUseDatabase, snapshot); err != nil {
+			return "", nil, err
 		}
 	}
 
-	return "", nil
+	return string(payloadBytes), rowCountMap, nil
 }
 
-func (driver *Driver) dumpOneDatabaseWithPgDump(ctx context.Context, database string, out io.Writer, schemaOnly bool, includeUseDatabase bool) error {
+// getTableRowCountMap counts the rows of the tables in txn.
+// The table names are the same as the ones in SyncSchema.
+func getTableRowCountMap(ctx context.Context, txn *sql.Tx) (map[string]int64, error) {
+	tables, err := getPgTables(txn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tables, error: %w", err)
+	}
+	rowCountMap := make(map[string]int64)
+	for _, tbl := range tables {
+		name := fmt.Sprintf("%s.%s", tbl.schemaName, tbl.name)
+		var rowCount int64
+		if err := txn.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s", name)).Scan(&rowCount); err != nil {
+			return nil, fmt.Errorf("failed to count rows of table %q, error: %w", name, err)
+		}
+		rowCountMap[name] = rowCount
+	}
+	return rowCountMap, nil
+}
+
+func (driver *Driver) dumpOneDatabaseWithPgDump(ctx context.Context, database string, out io.Writer, schemaOnly bool, includeUseDatabase bool, snapshot string) error {
 	var args []string
 	args = append(args, fmt.Sprintf("--username=%s", driver.config.Username))
 	if driver.config.Password == "" {
@@ -70,6 +142,9 @@ func (driver *Driver) dumpOneDatabaseWithPgDump(ctx context.Context, database st
 	}
 	args = append(args, "--inserts")
 	args = append(args, "--use-set-session-authorization")
+	if snapshot != "" {
+		args = append(args, fmt.Sprintf("--snapshot=%s", snapshot))
+	}
 	args = append(args, database)
 	pgDumpPath := filepath.Join(driver.pgInstanceDir, "bin", "pg_dump")
 	cmd := exec.Command(pgDumpPath, args...)
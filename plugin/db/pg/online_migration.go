@@ -0,0 +1,925 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/bytebase/bytebase/common/log"
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/db/util"
+	pgquery "github.com/pganalyze/pg_query_go/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	// onlineMigrationLockTimeout is the lock timeout of the steps which lock the table briefly, and the cutover.
+	// The step fails instead of blocking the reads and writes queued behind it if the lock is not acquired in time.
+	onlineMigrationLockTimeout = "3s"
+)
+
+// OnlineMigrationStep is a statement of the online migration.
+type OnlineMigrationStep struct {
+	Statement string
+	// Concurrent is true if the statement builds or drops an index concurrently, which cannot run in a transaction.
+	Concurrent bool
+	// Index is the index built concurrently by the statement, which is dropped if left invalid by a failed build.
+	Index string
+}
+
+// ShadowTableMigration is the table rewrite through a shadow table.
+// The shadow table is created like the table and altered, then the rows are copied to it in batches,
+// while the trigger on the table applies the concurrent changes to it. The shadow table is swapped with the table
+// at cutover, and the table is kept as `_<table>_del`.
+type ShadowTableMigration struct {
+	// Schema is the schema of the table, or empty to use the search path.
+	Schema string
+	Table  string
+	// AlterStatement is the ALTER TABLE statement applied to the shadow table.
+	AlterStatement string
+}
+
+// OnlineMigrationPlan is the plan to change the schema without blocking the reads and writes for long.
+type OnlineMigrationPlan struct {
+	StepList []OnlineMigrationStep
+	// ShadowTable is the table rewrite, or nil if no table is rewritten.
+	ShadowTable *ShadowTableMigration
+}
+
+// GetOnlineMigrationPlan analyzes the statement and returns the plan to run it online.
+// The indexes are built and dropped concurrently, the constraints are added as NOT VALID and validated afterwards,
+// and SET NOT NULL is backed by a validated CHECK constraint. The column type changes rewrite the table,
+// so the ALTER TABLE statement is applied through a shadow table, and it must be the only statement and change the column types only.
+// The other statements are run as they are.
+func GetOnlineMigrationPlan(statement string) (*OnlineMigrationPlan, error) {
+	tree, err := pgquery.Parse(statement)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse statement, error: %w", err)
+	}
+	if len(tree.Stmts) == 0 {
+		return nil, fmt.Errorf("empty statement")
+	}
+
+	plan := &OnlineMigrationPlan{}
+	for _, rawStmt := range tree.Stmts {
+		switch node := rawStmt.Stmt.Node.(type) {
+		case *pgquery.Node_TransactionStmt:
+			return nil, fmt.Errorf("transaction statements are not allowed, because the steps of the online migration are committed one by one")
+		case *pgquery.Node_IndexStmt:
+			step, err := getCreateIndexStep(node.IndexStmt)
+			if err != nil {
+				return nil, err
+			}
+			plan.StepList = append(plan.StepList, step)
+		case *pgquery.Node_DropStmt:
+			// DROP INDEX CONCURRENTLY drops a single index without CASCADE only.
+			if node.DropStmt.RemoveType == pgquery.ObjectType_OBJECT_INDEX && len(node.DropStmt.Objects) == 1 && node.DropStmt.Behavior != pgquery.DropBehavior_DROP_CASCADE {
+				node.DropStmt.Concurrent = true
+			}
+			stmt, err := deparse(rawStmt.Stmt)
+			if err != nil {
+				return nil, err
+			}
+			plan.StepList = append(plan.StepList, OnlineMigrationStep{Statement: stmt, Concurrent: node.DropStmt.Concurrent})
+		case *pgquery.Node_AlterTableStmt:
+			if needsTableRewrite(node.AlterTableStmt) {
+				if len(tree.Stmts) > 1 {
+					return nil, fmt.Errorf("the ALTER TABLE statement changing the column types must be the only statement")
+				}
+				shadowTable, err := getShadowTableMigration(node.AlterTableStmt)
+				if err != nil {
+					return nil, err
+				}
+				plan.ShadowTable = shadowTable
+				continue
+			}
+			stepList, err := getAlterTableStepList(node.AlterTableStmt)
+			if err != nil {
+				return nil, err
+			}
+			plan.StepList = append(plan.StepList, stepList...)
+		default:
+			stmt, err := deparse(rawStmt.Stmt)
+			if err != nil {
+				return nil, err
+			}
+			plan.StepList = append(plan.StepList, OnlineMigrationStep{Statement: stmt})
+		}
+	}
+	return plan, nil
+}
+
+func deparse(node *pgquery.Node) (string, error) {
+	stmt, err := pgquery.Deparse(&pgquery.ParseResult{Stmts: []*pgquery.RawStmt{{Stmt: node}}})
+	if err != nil {
+		return "", fmt.Errorf("failed to deparse statement, error: %w", err)
+	}
+	return stmt, nil
+}
+
+func getCreateIndexStep(indexStmt *pgquery.IndexStmt) (OnlineMigrationStep, error) {
+	indexStmt.Concurrent = true
+	stmt, err := deparse(&pgquery.Node{Node: &pgquery.Node_IndexStmt{IndexStmt: indexStmt}})
+	if err != nil {
+		return OnlineMigrationStep{}, err
+	}
+	step := OnlineMigrationStep{Statement: stmt, Concurrent: true}
+	// The index is created in the schema of the table.
+	if indexStmt.Idxname != "" {
+		step.Index = quoteName(indexStmt.Idxname)
+		if schema := indexStmt.Relation.Schemaname; schema != "" {
+			step.Index = fmt.Sprintf("%s.%s", quoteName(schema), step.Index)
+		}
+	}
+	return step, nil
+}
+
+func needsTableRewrite(alterTableStmt *pgquery.AlterTableStmt) bool {
+	for _, cmd := range alterTableStmt.Cmds {
+		if cmd.GetAlterTableCmd().GetSubtype() == pgquery.AlterTableType_AT_AlterColumnType {
+			return true
+		}
+	}
+	return false
+}
+
+func getShadowTableMigration(alterTableStmt *pgquery.AlterTableStmt) (*ShadowTableMigration, error) {
+	if alterTableStmt.Relkind != pgquery.ObjectType_OBJECT_TABLE {
+		return nil, fmt.Errorf("only the tables can be rewritten through a shadow table")
+	}
+	for _, cmd := range alterTableStmt.Cmds {
+		alterTableCmd := cmd.GetAlterTableCmd()
+		// The other sub-commands, e.g. adding a column with a default or a constraint, could make the rows
+		// copied by the trigger and the batches diverge from the table, so they must run in their own statements.
+		if alterTableCmd.GetSubtype() != pgquery.AlterTableType_AT_AlterColumnType {
+			return nil, fmt.Errorf("the ALTER TABLE statement changing the column types must not have other sub-commands, got %s", alterTableCmd.GetSubtype())
+		}
+		// The copied rows are converted by the assignment casts, so the USING expressions are not applied to them.
+		if alterTableCmd.Def.GetColumnDef().GetRawDefault() != nil {
+			return nil, fmt.Errorf("changing the type of column %q with USING is not supported", alterTableCmd.Name)
+		}
+	}
+
+	relation := alterTableStmt.Relation
+	shadowTable := &ShadowTableMigration{
+		Schema: relation.Schemaname,
+		Table:  relation.Relname,
+	}
+	shadowStmt := &pgquery.AlterTableStmt{
+		Relation: &pgquery.RangeVar{
+			Schemaname:     relation.Schemaname,
+			Relname:        getShadowTableName(relation.Relname),
+			Inh:            true,
+			Relpersistence: relation.Relpersistence,
+		},
+		Cmds:    alterTableStmt.Cmds,
+		Relkind: alterTableStmt.Relkind,
+	}
+	stmt, err := deparse(&pgquery.Node{Node: &pgquery.Node_AlterTableStmt{AlterTableStmt: shadowStmt}})
+	if err != nil {
+		return nil, err
+	}
+	shadowTable.AlterStatement = stmt
+	return shadowTable, nil
+}
+
+// getAlterTableStepList splits the ALTER TABLE statement into steps.
+// The sub-commands which are rewritten run in their own steps, and the others run together in the original order.
+func getAlterTableStepList(alterTableStmt *pgquery.AlterTableStmt) ([]OnlineMigrationStep, error) {
+	if alterTableStmt.Relkind != pgquery.ObjectType_OBJECT_TABLE {
+		stmt, err := deparse(&pgquery.Node{Node: &pgquery.Node_AlterTableStmt{AlterTableStmt: alterTableStmt}})
+		if err != nil {
+			return nil, err
+		}
+		return []OnlineMigrationStep{{Statement: stmt}}, nil
+	}
+
+	var stepList []OnlineMigrationStep
+	var pendingCmdList []*pgquery.Node
+	addAlterTableStep := func(cmdList ...*pgquery.Node) error {
+		stmt, err := deparse(&pgquery.Node{Node: &pgquery.Node_AlterTableStmt{AlterTableStmt: &pgquery.AlterTableStmt{
+			Relation:  alterTableStmt.Relation,
+			Cmds:      cmdList,
+			Relkind:   alterTableStmt.Relkind,
+			MissingOk: alterTableStmt.MissingOk,
+		}}})
+		if err != nil {
+			return err
+		}
+		stepList = append(stepList, OnlineMigrationStep{Statement: stmt})
+		return nil
+	}
+	flush := func() error {
+		if len(pendingCmdList) == 0 {
+			return nil
+		}
+		if err := addAlterTableStep(pendingCmdList...); err != nil {
+			return err
+		}
+		pendingCmdList = nil
+		return nil
+	}
+
+	for _, cmd := range alterTableStmt.Cmds {
+		alterTableCmd := cmd.GetAlterTableCmd()
+		switch alterTableCmd.GetSubtype() {
+		case pgquery.AlterTableType_AT_AddConstraint:
+			constraint := alterTableCmd.Def.GetConstraint()
+			switch {
+			case isValidatedLater(constraint):
+				if err := flush(); err != nil {
+					return nil, err
+				}
+				constraint.SkipValidation = true
+				constraint.InitiallyValid = false
+				if err := addAlterTableStep(cmd); err != nil {
+					return nil, err
+				}
+				if err := addAlterTableStep(makeAlterTableCmdNode(pgquery.AlterTableType_AT_ValidateConstraint, constraint.Conname, nil)); err != nil {
+					return nil, err
+				}
+			case isBuiltWithIndex(constraint):
+				if err := flush(); err != nil {
+					return nil, err
+				}
+				indexStep, err := getCreateIndexStep(&pgquery.IndexStmt{
+					Idxname:      constraint.Conname,
+					Relation:     alterTableStmt.Relation,
+					AccessMethod: "btree",
+					IndexParams:  makeIndexElemNodeList(constraint.Keys),
+					Unique:       true,
+				})
+				if err != nil {
+					return nil, err
+				}
+				stepList = append(stepList, indexStep)
+				if err := addAlterTableStep(makeAlterTableCmdNode(pgquery.AlterTableType_AT_AddConstraint, "", &pgquery.Node{Node: &pgquery.Node_Constraint{Constraint: &pgquery.Constraint{
+					Contype:      constraint.Contype,
+					Conname:      constraint.Conname,
+					Indexname:    constraint.Conname,
+					Deferrable:   constraint.Deferrable,
+					Initdeferred: constraint.Initdeferred,
+				}}})); err != nil {
+					return nil, err
+				}
+			default:
+				pendingCmdList = append(pendingCmdList, cmd)
+			}
+		case pgquery.AlterTableType_AT_SetNotNull:
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			// Since Postgres 12, SET NOT NULL skips the table scan if a validated CHECK constraint proves the column is not null.
+			checkName := getSafeName(fmt.Sprintf("%s_%s", alterTableStmt.Relation.Relname, alterTableCmd.Name), "not_null")
+			checkCmd := makeAlterTableCmdNode(pgquery.AlterTableType_AT_AddConstraint, "", &pgquery.Node{Node: &pgquery.Node_Constraint{Constraint: &pgquery.Constraint{
+				Contype: pgquery.ConstrType_CONSTR_CHECK,
+				Conname: checkName,
+				RawExpr: &pgquery.Node{Node: &pgquery.Node_NullTest{NullTest: &pgquery.NullTest{
+					Arg:          pgquery.MakeColumnRefNode([]*pgquery.Node{pgquery.MakeStrNode(alterTableCmd.Name)}, -1),
+					Nulltesttype: pgquery.NullTestType_IS_NOT_NULL,
+				}}},
+				SkipValidation: true,
+			}}})
+			for _, cmd := range []*pgquery.Node{
+				checkCmd,
+				makeAlterTableCmdNode(pgquery.AlterTableType_AT_ValidateConstraint, checkName, nil),
+				cmd,
+				makeAlterTableCmdNode(pgquery.AlterTableType_AT_DropConstraint, checkName, nil),
+			} {
+				if err := addAlterTableStep(cmd); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			pendingCmdList = append(pendingCmdList, cmd)
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return stepList, nil
+}
+
+// isValidatedLater returns true if the constraint is a named FOREIGN KEY or CHECK constraint to be validated.
+// Such constraint can be added as NOT VALID and validated afterwards without blocking the writes.
+func isValidatedLater(constraint *pgquery.Constraint) bool {
+	if constraint == nil || constraint.Conname == "" || constraint.SkipValidation {
+		return false
+	}
+	return constraint.Contype == pgquery.ConstrType_CONSTR_FOREIGN || constraint.Contype == pgquery.ConstrType_CONSTR_CHECK
+}
+
+// isBuiltWithIndex returns true if the constraint is a named PRIMARY KEY or UNIQUE constraint on plain columns.
+// Such constraint can be added with the unique index built concurrently.
+func isBuiltWithIndex(constraint *pgquery.Constraint) bool {
+	if constraint == nil || constraint.Conname == "" || constraint.Indexname != "" || len(constraint.Keys) == 0 {
+		return false
+	}
+	if len(constraint.Including) > 0 || len(constraint.Options) > 0 || constraint.Indexspace != "" {
+		return false
+	}
+	return constraint.Contype == pgquery.ConstrType_CONSTR_PRIMARY || constraint.Contype == pgquery.ConstrType_CONSTR_UNIQUE
+}
+
+func makeAlterTableCmdNode(subtype pgquery.AlterTableType, name string, def *pgquery.Node) *pgquery.Node {
+	return &pgquery.Node{Node: &pgquery.Node_AlterTableCmd{AlterTableCmd: &pgquery.AlterTableCmd{
+		Subtype:  subtype,
+		Name:     name,
+		Def:      def,
+		Behavior: pgquery.DropBehavior_DROP_RESTRICT,
+	}}}
+}
+
+func makeIndexElemNodeList(keyList []*pgquery.Node) []*pgquery.Node {
+	var nodeList []*pgquery.Node
+	for _, key := range keyList {
+		nodeList = append(nodeList, &pgquery.Node{Node: &pgquery.Node_IndexElem{IndexElem: &pgquery.IndexElem{
+			Name:          key.GetString_().GetStr(),
+			Ordering:      pgquery.SortByDir_SORTBY_DEFAULT,
+			NullsOrdering: pgquery.SortByNulls_SORTBY_NULLS_DEFAULT,
+		}}})
+	}
+	return nodeList
+}
+
+// getShadowTableName returns the name of the shadow table, e.g. "_tbl_new".
+func getShadowTableName(table string) string {
+	return getSafeName("_"+table, "new")
+}
+
+// GetShadowOldTableName returns the name that the table is renamed to at cutover, e.g. "_tbl_del".
+func GetShadowOldTableName(table string) string {
+	return getSafeName("_"+table, "del")
+}
+
+// getShadowTriggerName returns the name of the trigger and the trigger function applying the changes to the shadow table.
+func getShadowTriggerName(table string) string {
+	return getSafeName("_"+table, "sync")
+}
+
+// onlineMigrationExecutor is the migration executor running the online migration in place of the statement.
+type onlineMigrationExecutor struct {
+	*Driver
+	execute func(ctx context.Context) error
+}
+
+func (e *onlineMigrationExecutor) Execute(ctx context.Context, _ string) error {
+	return e.execute(ctx)
+}
+
+// ExecuteOnlineMigration records the migration history of the online migration like ExecuteMigration,
+// while the migration is executed by execute through the connection to the database instead of the statement.
+func (driver *Driver) ExecuteOnlineMigration(ctx context.Context, m *db.MigrationInfo, statement string, execute func(ctx context.Context) error) (int64, string, error) {
+	executor := &onlineMigrationExecutor{Driver: driver, execute: execute}
+	if driver.strictUseDb() {
+		return util.ExecuteMigration(ctx, executor, m, statement, driver.strictDatabase)
+	}
+	return util.ExecuteMigration(ctx, executor, m, statement, db.BytebaseDatabase)
+}
+
+// ExecuteOnlineMigrationStep executes the step of the online migration.
+// The steps building or dropping the indexes concurrently run outside of transactions,
+// and the others run with the lock timeout.
+func (driver *Driver) ExecuteOnlineMigrationStep(ctx context.Context, step OnlineMigrationStep) error {
+	if !step.Concurrent {
+		return driver.executeWithLockTimeout(ctx, []string{step.Statement})
+	}
+	if _, err := driver.db.ExecContext(ctx, step.Statement); err != nil {
+		if step.Index != "" {
+			if err := driver.dropInvalidIndex(ctx, step.Index); err != nil {
+				log.Warn("Failed to drop the invalid index", zap.String("index", step.Index), zap.Error(err))
+			}
+		}
+		return util.FormatErrorWithQuery(err, step.Statement)
+	}
+	return nil
+}
+
+func (driver *Driver) executeWithLockTimeout(ctx context.Context, statementList []string) error {
+	tx, err := driver.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL lock_timeout = '%s'", onlineMigrationLockTimeout)); err != nil {
+		return err
+	}
+	for _, stmt := range statementList {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return util.FormatErrorWithQuery(err, stmt)
+		}
+	}
+	return tx.Commit()
+}
+
+// dropInvalidIndex drops the index left invalid by a failed concurrent build.
+// The index is kept if it is valid, e.g. it exists before the build.
+func (driver *Driver) dropInvalidIndex(ctx context.Context, index string) error {
+	var invalid bool
+	if err := driver.db.QueryRowContext(ctx, "SELECT NOT indisvalid FROM pg_index WHERE indexrelid = to_regclass($1)", index).Scan(&invalid); err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+	if !invalid {
+		return nil
+	}
+	_, err := driver.db.ExecContext(ctx, fmt.Sprintf("DROP INDEX CONCURRENTLY IF EXISTS %s", index))
+	return err
+}
+
+// shadowTable is the table in the shadow table migration, with the schema resolved.
+type shadowTable struct {
+	schema string
+	table  string
+}
+
+func (t *shadowTable) qualifiedName(table string) string {
+	return fmt.Sprintf("%s.%s", quoteName(t.schema), quoteName(table))
+}
+
+func (driver *Driver) getShadowTable(ctx context.Context, m *ShadowTableMigration) (*shadowTable, error) {
+	name := quoteName(m.Table)
+	if m.Schema != "" {
+		name = fmt.Sprintf("%s.%s", quoteName(m.Schema), name)
+	}
+	t := &shadowTable{table: m.Table}
+	query := `
+		SELECT n.nspname
+		FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.oid = to_regclass($1) AND c.relkind = 'r'`
+	if err := driver.db.QueryRowContext(ctx, query, name).Scan(&t.schema); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("table %s not found", name)
+		}
+		return nil, util.FormatErrorWithQuery(err, query)
+	}
+	return t, nil
+}
+
+// PrepareShadowTable creates the shadow table and the trigger applying the changes of the table to it.
+// The shadow table left by a previous attempt is recreated.
+// The table must have a single column primary key, and must not have foreign keys, dependent views, inheritance,
+// triggers, row level security, rules or publications, because they are not copied or cannot be moved to the shadow table.
+func (driver *Driver) PrepareShadowTable(ctx context.Context, m *ShadowTableMigration) error {
+	t, err := driver.getShadowTable(ctx, m)
+	if err != nil {
+		return err
+	}
+	tableName := t.qualifiedName(t.table)
+	shadowTableName := t.qualifiedName(getShadowTableName(t.table))
+
+	primaryKeyList, err := driver.getPrimaryKeyColumnList(ctx, tableName)
+	if err != nil {
+		return err
+	}
+	if len(primaryKeyList) != 1 {
+		return fmt.Errorf("table %s must have a single column primary key", tableName)
+	}
+	primaryKey := quoteName(primaryKeyList[0])
+	// The triggers applying the changes to the shadow table are left by a previous attempt.
+	triggerName := getShadowTriggerName(t.table)
+	truncateTriggerName := getSafeName(triggerName, "truncate")
+	checkList := []struct {
+		query string
+		args  []interface{}
+		msg   string
+	}{
+		{
+			query: `SELECT count(*) FROM pg_constraint WHERE contype = 'f' AND (conrelid = $1::regclass OR confrelid = $1::regclass)`,
+			msg:   "has foreign keys",
+		},
+		{
+			query: `
+				SELECT count(*)
+				FROM pg_depend d JOIN pg_rewrite r ON r.oid = d.objid
+				WHERE d.classid = 'pg_rewrite'::regclass AND d.refobjid = $1::regclass AND r.ev_class <> $1::regclass`,
+			msg: "has dependent views",
+		},
+		{
+			query: `SELECT count(*) FROM pg_inherits WHERE inhrelid = $1::regclass OR inhparent = $1::regclass`,
+			msg:   "has inheritance",
+		},
+		{
+			query: `SELECT count(*) FROM pg_trigger WHERE tgrelid = $1::regclass AND NOT tgisinternal AND tgname NOT IN ($2, $3)`,
+			args:  []interface{}{tableName, triggerName, truncateTriggerName},
+			msg:   "has triggers",
+		},
+		{
+			query: `
+				SELECT count(*)
+				FROM pg_class c
+				WHERE c.oid = $1::regclass AND (c.relrowsecurity OR c.relforcerowsecurity OR EXISTS (SELECT 1 FROM pg_policy p WHERE p.polrelid = c.oid))`,
+			msg: "has row level security",
+		},
+		{
+			query: `SELECT count(*) FROM pg_rewrite WHERE ev_class = $1::regclass`,
+			msg:   "has rules",
+		},
+		{
+			query: `SELECT count(*) FROM pg_publication_rel WHERE prrelid = $1::regclass`,
+			msg:   "is in publications",
+		},
+	}
+	for _, check := range checkList {
+		args := check.args
+		if args == nil {
+			args = []interface{}{tableName}
+		}
+		var count int
+		if err := driver.db.QueryRowContext(ctx, check.query, args...).Scan(&count); err != nil {
+			return util.FormatErrorWithQuery(err, check.query)
+		}
+		if count > 0 {
+			return fmt.Errorf("table %s %s", tableName, check.msg)
+		}
+	}
+	// The table is renamed to the old table at cutover.
+	oldTableName := t.qualifiedName(GetShadowOldTableName(t.table))
+	var oldTableExists bool
+	if err := driver.db.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", oldTableName).Scan(&oldTableExists); err != nil {
+		return err
+	}
+	if oldTableExists {
+		return fmt.Errorf("table %s already exists, drop it before rewriting table %s", oldTableName, tableName)
+	}
+
+	tx, err := driver.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	functionName := t.qualifiedName(triggerName)
+	stmtList := []string{
+		fmt.Sprintf("SET LOCAL lock_timeout = '%s'", onlineMigrationLockTimeout),
+		fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s", quoteName(triggerName), tableName),
+		fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s", quoteName(truncateTriggerName), tableName),
+		fmt.Sprintf("DROP FUNCTION IF EXISTS %s()", functionName),
+		fmt.Sprintf("DROP TABLE IF EXISTS %s", shadowTableName),
+		fmt.Sprintf("CREATE TABLE %s (LIKE %s INCLUDING ALL)", shadowTableName, tableName),
+		m.AlterStatement,
+	}
+	for _, stmt := range stmtList {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return util.FormatErrorWithQuery(err, stmt)
+		}
+	}
+
+	// The shadow table takes over the owner and the privileges of the table.
+	var stmtAfterCreateList []string
+	var owner string
+	if err := tx.QueryRowContext(ctx, "SELECT pg_get_userbyid(relowner) FROM pg_class WHERE oid = $1::regclass", tableName).Scan(&owner); err != nil {
+		return err
+	}
+	stmtAfterCreateList = append(stmtAfterCreateList, fmt.Sprintf("ALTER TABLE %s OWNER TO %s", shadowTableName, quoteName(owner)))
+	grantList, err := getTableGrantList(ctx, tx, tableName, shadowTableName)
+	if err != nil {
+		return err
+	}
+	stmtAfterCreateList = append(stmtAfterCreateList, grantList...)
+
+	columnList, err := getShadowColumnList(ctx, tx, t)
+	if err != nil {
+		return err
+	}
+	hasPrimaryKey := false
+	var newColumnList []string
+	for _, column := range columnList {
+		if column == primaryKey {
+			hasPrimaryKey = true
+		}
+		newColumnList = append(newColumnList, fmt.Sprintf("NEW.%s", column))
+	}
+	if !hasPrimaryKey {
+		return fmt.Errorf("primary key %s is not in the shadow table", primaryKey)
+	}
+	stmtAfterCreateList = append(stmtAfterCreateList,
+		fmt.Sprintf(`CREATE FUNCTION %s() RETURNS trigger LANGUAGE plpgsql AS $bytebase$
+BEGIN
+	IF TG_OP = 'TRUNCATE' THEN
+		TRUNCATE %s;
+		RETURN NULL;
+	END IF;
+	IF TG_OP IN ('UPDATE', 'DELETE') THEN
+		DELETE FROM %s WHERE %s = OLD.%s;
+	END IF;
+	IF TG_OP IN ('INSERT', 'UPDATE') THEN
+		INSERT INTO %s (%s) OVERRIDING SYSTEM VALUE VALUES (%s);
+	END IF;
+	RETURN NULL;
+END
+$bytebase$`, functionName, shadowTableName, shadowTableName, primaryKey, primaryKey, shadowTableName, strings.Join(columnList, ", "), strings.Join(newColumnList, ", ")),
+		fmt.Sprintf("CREATE TRIGGER %s AFTER INSERT OR UPDATE OR DELETE ON %s FOR EACH ROW EXECUTE PROCEDURE %s()", quoteName(triggerName), tableName, functionName),
+		fmt.Sprintf("CREATE TRIGGER %s AFTER TRUNCATE ON %s FOR EACH STATEMENT EXECUTE PROCEDURE %s()", quoteName(truncateTriggerName), tableName, functionName),
+	)
+	for _, stmt := range stmtAfterCreateList {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return util.FormatErrorWithQuery(err, stmt)
+		}
+	}
+	return tx.Commit()
+}
+
+// getShadowColumnList returns the quoted columns of the shadow table which are in the table as well, excluding the generated columns.
+func getShadowColumnList(ctx context.Context, tx *sql.Tx, t *shadowTable) ([]string, error) {
+	query := `
+		SELECT column_name
+		FROM information_schema.columns
+		WHERE table_schema = $1 AND table_name = $2 AND is_generated = 'NEVER' AND column_name IN (
+			SELECT column_name FROM information_schema.columns WHERE table_schema = $1 AND table_name = $3 AND is_generated = 'NEVER'
+		)
+		ORDER BY ordinal_position`
+	rows, err := tx.QueryContext(ctx, query, t.schema, getShadowTableName(t.table), t.table)
+	if err != nil {
+		return nil, util.FormatErrorWithQuery(err, query)
+	}
+	defer rows.Close()
+
+	var columnList []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, err
+		}
+		columnList = append(columnList, quoteName(column))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return columnList, nil
+}
+
+// getTableGrantList returns the GRANT statements granting the privileges on the table to the target table.
+func getTableGrantList(ctx context.Context, tx *sql.Tx, table, targetTable string) ([]string, error) {
+	query := `
+		SELECT CASE WHEN acl.grantee = 0 THEN 'PUBLIC' ELSE quote_ident(pg_get_userbyid(acl.grantee)) END, acl.privilege_type, acl.is_grantable
+		FROM pg_class c, aclexplode(c.relacl) acl
+		WHERE c.oid = $1::regclass AND acl.grantee <> c.relowner`
+	rows, err := tx.QueryContext(ctx, query, table)
+	if err != nil {
+		return nil, util.FormatErrorWithQuery(err, query)
+	}
+	defer rows.Close()
+
+	var grantList []string
+	for rows.Next() {
+		var grantee, privilege string
+		var grantable bool
+		if err := rows.Scan(&grantee, &privilege, &grantable); err != nil {
+			return nil, err
+		}
+		grant := fmt.Sprintf("GRANT %s ON %s TO %s", privilege, targetTable, grantee)
+		if grantable {
+			grant += " WITH GRANT OPTION"
+		}
+		grantList = append(grantList, grant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return grantList, nil
+}
+
+// CopyShadowTable copies the rows of the table to the shadow table in batches ordered by the primary key.
+// The rows changed concurrently are applied by the trigger, so the copied rows conflicting with them on the primary key are skipped.
+// The other unique violations fail the copy instead of dropping the rows silently.
+// The progress is called with the number of the rows copied after each batch.
+func (driver *Driver) CopyShadowTable(ctx context.Context, m *ShadowTableMigration, batchSize int, progress func(copied int64)) (int64, error) {
+	t, err := driver.getShadowTable(ctx, m)
+	if err != nil {
+		return 0, err
+	}
+	tableName := t.qualifiedName(t.table)
+	shadowTableName := t.qualifiedName(getShadowTableName(t.table))
+	primaryKeyList, err := driver.getPrimaryKeyColumnList(ctx, tableName)
+	if err != nil {
+		return 0, err
+	}
+	if len(primaryKeyList) != 1 {
+		return 0, fmt.Errorf("table %s must have a single column primary key", tableName)
+	}
+	primaryKey := quoteName(primaryKeyList[0])
+	// The last key of the previous batch is passed as text, and cast to the type of the primary key.
+	var primaryKeyType string
+	if err := driver.db.QueryRowContext(ctx, "SELECT format_type(atttypid, atttypmod) FROM pg_attribute WHERE attrelid = $1::regclass AND attname = $2", tableName, primaryKeyList[0]).Scan(&primaryKeyType); err != nil {
+		return 0, err
+	}
+
+	tx, err := driver.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return 0, err
+	}
+	columnList, err := getShadowColumnList(ctx, tx, t)
+	tx.Rollback()
+	if err != nil {
+		return 0, err
+	}
+	columns := strings.Join(columnList, ", ")
+
+	copyQuery := func(where string) string {
+		return fmt.Sprintf(`
+			WITH batch AS (
+				SELECT %s FROM %s %s ORDER BY %s LIMIT %d FOR SHARE
+			), copied AS (
+				INSERT INTO %s (%s) OVERRIDING SYSTEM VALUE SELECT %s FROM batch ON CONFLICT (%s) DO NOTHING
+			)
+			SELECT (SELECT count(*) FROM batch), (SELECT %s::text FROM batch ORDER BY %s DESC LIMIT 1)`,
+			columns, tableName, where, primaryKey, batchSize,
+			shadowTableName, columns, columns, primaryKey,
+			primaryKey, primaryKey)
+	}
+	var copied int64
+	var lastKey sql.NullString
+	for {
+		var count int64
+		var err error
+		if lastKey.Valid {
+			query := copyQuery(fmt.Sprintf("WHERE %s > $1::text::%s", primaryKey, primaryKeyType))
+			err = driver.db.QueryRowContext(ctx, query, lastKey.String).Scan(&count, &lastKey)
+		} else {
+			err = driver.db.QueryRowContext(ctx, copyQuery("")).Scan(&count, &lastKey)
+		}
+		if err != nil {
+			return copied, fmt.Errorf("failed to copy rows to the shadow table %s, error: %w", shadowTableName, err)
+		}
+		copied += count
+		progress(copied)
+		if count < int64(batchSize) {
+			break
+		}
+	}
+
+	if _, err := driver.db.ExecContext(ctx, fmt.Sprintf("ANALYZE %s", shadowTableName)); err != nil {
+		return copied, err
+	}
+	return copied, nil
+}
+
+// CutoverShadowTable swaps the shadow table with the table in a transaction, and drops the trigger.
+// The table is renamed to `_<table>_del` and kept, and the indexes of the shadow table take over the names of the table's indexes.
+func (driver *Driver) CutoverShadowTable(ctx context.Context, m *ShadowTableMigration) error {
+	t, err := driver.getShadowTable(ctx, m)
+	if err != nil {
+		return err
+	}
+	tableName := t.qualifiedName(t.table)
+	shadowTable := getShadowTableName(t.table)
+	shadowTableName := t.qualifiedName(shadowTable)
+	triggerName := getShadowTriggerName(t.table)
+
+	tx, err := driver.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range []string{
+		fmt.Sprintf("SET LOCAL lock_timeout = '%s'", onlineMigrationLockTimeout),
+		fmt.Sprintf("LOCK TABLE %s IN ACCESS EXCLUSIVE MODE", tableName),
+	} {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return util.FormatErrorWithQuery(err, stmt)
+		}
+	}
+	var shadowTableExists bool
+	if err := tx.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", shadowTableName).Scan(&shadowTableExists); err != nil {
+		return err
+	}
+	if !shadowTableExists {
+		return fmt.Errorf("shadow table %s not found", shadowTableName)
+	}
+
+	stmtList, err := getShadowSequenceStatementList(ctx, tx, tableName, shadowTableName)
+	if err != nil {
+		return err
+	}
+	indexList, err := getTableIndexList(ctx, tx, t.schema, t.table)
+	if err != nil {
+		return err
+	}
+	shadowIndexList, err := getTableIndexList(ctx, tx, t.schema, shadowTable)
+	if err != nil {
+		return err
+	}
+	for _, rename := range getShadowIndexRenameList(indexList, shadowIndexList) {
+		stmtList = append(stmtList,
+			fmt.Sprintf("ALTER INDEX %s RENAME TO %s", t.qualifiedName(rename.index), quoteName(getSafeName("_"+rename.index, "del"))),
+			fmt.Sprintf("ALTER INDEX %s RENAME TO %s", t.qualifiedName(rename.shadowIndex), quoteName(rename.index)),
+		)
+	}
+	stmtList = append(stmtList,
+		fmt.Sprintf("DROP TRIGGER %s ON %s", quoteName(triggerName), tableName),
+		fmt.Sprintf("DROP TRIGGER %s ON %s", quoteName(getSafeName(triggerName, "truncate")), tableName),
+		fmt.Sprintf("DROP FUNCTION %s()", t.qualifiedName(triggerName)),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", tableName, quoteName(GetShadowOldTableName(t.table))),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", shadowTableName, quoteName(t.table)),
+	)
+	for _, stmt := range stmtList {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return util.FormatErrorWithQuery(err, stmt)
+		}
+	}
+	return tx.Commit()
+}
+
+// getShadowSequenceStatementList returns the statements moving the sequences of the table to the shadow table.
+// The serial sequences shared with the shadow table are owned by the shadow table, so that they are kept after the table is dropped.
+// The identity sequences of the shadow table are advanced to the ones of the table.
+func getShadowSequenceStatementList(ctx context.Context, tx *sql.Tx, table, shadowTable string) ([]string, error) {
+	query := `
+		SELECT a.attname, pg_get_serial_sequence($1, a.attname), pg_get_serial_sequence($2, a.attname)
+		FROM pg_attribute a
+		WHERE a.attrelid = $1::regclass AND a.attnum > 0 AND NOT a.attisdropped AND pg_get_serial_sequence($1, a.attname) IS NOT NULL
+			AND EXISTS (SELECT 1 FROM pg_attribute s WHERE s.attrelid = $2::regclass AND s.attname = a.attname AND NOT s.attisdropped)`
+	rows, err := tx.QueryContext(ctx, query, table, shadowTable)
+	if err != nil {
+		return nil, util.FormatErrorWithQuery(err, query)
+	}
+	defer rows.Close()
+
+	var stmtList []string
+	for rows.Next() {
+		var column, sequence string
+		var shadowSequence sql.NullString
+		if err := rows.Scan(&column, &sequence, &shadowSequence); err != nil {
+			return nil, err
+		}
+		if shadowSequence.Valid {
+			if shadowSequence.String != sequence {
+				stmtList = append(stmtList, fmt.Sprintf("SELECT setval('%s', last_value, is_called) FROM %s", strings.ReplaceAll(shadowSequence.String, "'", "''"), sequence))
+			}
+			continue
+		}
+		stmtList = append(stmtList, fmt.Sprintf("ALTER SEQUENCE %s OWNED BY %s.%s", sequence, shadowTable, quoteName(column)))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return stmtList, nil
+}
+
+type tableIndex struct {
+	name       string
+	definition string
+}
+
+func getTableIndexList(ctx context.Context, tx *sql.Tx, schema, table string) ([]tableIndex, error) {
+	query := `SELECT indexname, indexdef FROM pg_indexes WHERE schemaname = $1 AND tablename = $2 ORDER BY indexname`
+	rows, err := tx.QueryContext(ctx, query, schema, table)
+	if err != nil {
+		return nil, util.FormatErrorWithQuery(err, query)
+	}
+	defer rows.Close()
+
+	var indexList []tableIndex
+	for rows.Next() {
+		var index tableIndex
+		if err := rows.Scan(&index.name, &index.definition); err != nil {
+			return nil, err
+		}
+		indexList = append(indexList, index)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return indexList, nil
+}
+
+type shadowIndexRename struct {
+	index       string
+	shadowIndex string
+}
+
+// getShadowIndexRenameList pairs the indexes of the table with the ones of the shadow table by their definitions,
+// e.g. "CREATE UNIQUE INDEX tbl_pkey ON public.tbl USING btree (id)" and "CREATE UNIQUE INDEX _tbl_new_pkey ON public._tbl_new USING btree (id)".
+// The unpaired indexes are not renamed.
+func getShadowIndexRenameList(indexList, shadowIndexList []tableIndex) []shadowIndexRename {
+	// The definition without the index and table names, e.g. "CREATE UNIQUE INDEX USING btree (id)".
+	getKey := func(definition string) string {
+		i := strings.Index(definition, " INDEX ")
+		j := strings.Index(definition, " USING ")
+		if i < 0 || j < i {
+			return definition
+		}
+		return definition[:i+len(" INDEX")] + definition[j:]
+	}
+	shadowIndexMap := make(map[string][]string)
+	for _, index := range shadowIndexList {
+		key := getKey(index.definition)
+		shadowIndexMap[key] = append(shadowIndexMap[key], index.name)
+	}
+	var renameList []shadowIndexRename
+	for _, index := range indexList {
+		key := getKey(index.definition)
+		if len(shadowIndexMap[key]) == 0 {
+			continue
+		}
+		renameList = append(renameList, shadowIndexRename{index: index.name, shadowIndex: shadowIndexMap[key][0]})
+		shadowIndexMap[key] = shadowIndexMap[key][1:]
+	}
+	return renameList
+}
@@ -0,0 +1,123 @@
+package pg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetOnlineMigrationPlan(t *testing.T) {
+	tests := []struct {
+		statement string
+		want      *OnlineMigrationPlan
+		wantErr   bool
+	}{
+		{
+			statement: "CREATE INDEX idx_a ON public.tbl (a); DROP INDEX idx_b; DROP INDEX idx_c, idx_d;",
+			want: &OnlineMigrationPlan{
+				StepList: []OnlineMigrationStep{
+					{Statement: "CREATE INDEX CONCURRENTLY idx_a ON public.tbl USING btree (a)", Concurrent: true, Index: `"public"."idx_a"`},
+					{Statement: "DROP INDEX CONCURRENTLY idx_b", Concurrent: true},
+					{Statement: "DROP INDEX idx_c, idx_d"},
+				},
+			},
+		},
+		{
+			statement: "ALTER TABLE tbl ADD COLUMN c int, ADD CONSTRAINT fk FOREIGN KEY (a) REFERENCES ref (id), ADD CONSTRAINT chk CHECK (a > 0), ADD COLUMN d int",
+			want: &OnlineMigrationPlan{
+				StepList: []OnlineMigrationStep{
+					{Statement: "ALTER TABLE tbl ADD COLUMN c int"},
+					{Statement: "ALTER TABLE tbl ADD CONSTRAINT fk FOREIGN KEY (a) REFERENCES ref (id) NOT VALID"},
+					{Statement: "ALTER TABLE tbl VALIDATE CONSTRAINT fk"},
+					{Statement: "ALTER TABLE tbl ADD CONSTRAINT chk CHECK (a > 0) NOT VALID"},
+					{Statement: "ALTER TABLE tbl VALIDATE CONSTRAINT chk"},
+					{Statement: "ALTER TABLE tbl ADD COLUMN d int"},
+				},
+			},
+		},
+		{
+			statement: "ALTER TABLE s.tbl ADD CONSTRAINT tbl_pkey PRIMARY KEY (a, b), ALTER COLUMN x SET NOT NULL",
+			want: &OnlineMigrationPlan{
+				StepList: []OnlineMigrationStep{
+					{Statement: "CREATE UNIQUE INDEX CONCURRENTLY tbl_pkey ON s.tbl USING btree (a, b)", Concurrent: true, Index: `"s"."tbl_pkey"`},
+					{Statement: "ALTER TABLE s.tbl ADD CONSTRAINT tbl_pkey PRIMARY KEY USING INDEX tbl_pkey"},
+					{Statement: "ALTER TABLE s.tbl ADD CONSTRAINT tbl_x_not_null CHECK (x IS NOT NULL) NOT VALID"},
+					{Statement: "ALTER TABLE s.tbl VALIDATE CONSTRAINT tbl_x_not_null"},
+					{Statement: "ALTER TABLE s.tbl ALTER COLUMN x SET NOT NULL"},
+					{Statement: "ALTER TABLE s.tbl DROP CONSTRAINT tbl_x_not_null"},
+				},
+			},
+		},
+		{
+			// The unnamed constraints are added as they are.
+			statement: "ALTER TABLE tbl ADD UNIQUE (a), ADD CHECK (a > 0)",
+			want: &OnlineMigrationPlan{
+				StepList: []OnlineMigrationStep{
+					{Statement: "ALTER TABLE tbl ADD UNIQUE (a), ADD CHECK (a > 0)"},
+				},
+			},
+		},
+		{
+			statement: "ALTER TABLE s.tbl ALTER COLUMN a TYPE bigint, ALTER COLUMN b TYPE text",
+			want: &OnlineMigrationPlan{
+				ShadowTable: &ShadowTableMigration{
+					Schema:         "s",
+					Table:          "tbl",
+					AlterStatement: "ALTER TABLE s._tbl_new ALTER COLUMN a TYPE bigint, ALTER COLUMN b TYPE text",
+				},
+			},
+		},
+		{
+			statement: "ALTER TABLE s.tbl ALTER COLUMN a TYPE bigint, ADD COLUMN z text",
+			wantErr:   true,
+		},
+		{
+			statement: "ALTER TABLE tbl ALTER COLUMN a TYPE bigint, ADD CONSTRAINT uk_b UNIQUE (b)",
+			wantErr:   true,
+		},
+		{
+			statement: "ALTER TABLE tbl ALTER COLUMN a TYPE bigint USING a::bigint",
+			wantErr:   true,
+		},
+		{
+			statement: "ALTER TABLE tbl ALTER COLUMN a TYPE bigint; CREATE INDEX idx_a ON tbl (a);",
+			wantErr:   true,
+		},
+		{
+			statement: "BEGIN; CREATE INDEX idx_a ON tbl (a); COMMIT;",
+			wantErr:   true,
+		},
+	}
+
+	a := require.New(t)
+	for _, test := range tests {
+		plan, err := GetOnlineMigrationPlan(test.statement)
+		if test.wantErr {
+			a.Error(err, test.statement)
+			continue
+		}
+		a.NoError(err, test.statement)
+		a.Equal(test.want, plan, test.statement)
+	}
+}
+
+func TestGetShadowIndexRenameList(t *testing.T) {
+	a := require.New(t)
+	indexList := []tableIndex{
+		{name: "idx_a", definition: "CREATE INDEX idx_a ON public.tbl USING btree (a)"},
+		{name: "idx_a_dup", definition: "CREATE INDEX idx_a_dup ON public.tbl USING btree (a)"},
+		{name: "idx_expr", definition: "CREATE INDEX idx_expr ON public.tbl USING btree (lower(b))"},
+		{name: "tbl_pkey", definition: "CREATE UNIQUE INDEX tbl_pkey ON public.tbl USING btree (id)"},
+	}
+	shadowIndexList := []tableIndex{
+		{name: "_tbl_new_a_idx", definition: "CREATE INDEX _tbl_new_a_idx ON public._tbl_new USING btree (a)"},
+		{name: "_tbl_new_a_idx1", definition: "CREATE INDEX _tbl_new_a_idx1 ON public._tbl_new USING btree (a)"},
+		{name: "_tbl_new_lower_idx", definition: "CREATE INDEX _tbl_new_lower_idx ON public._tbl_new USING btree (lower((b)::text))"},
+		{name: "_tbl_new_pkey", definition: "CREATE UNIQUE INDEX _tbl_new_pkey ON public._tbl_new USING btree (id)"},
+	}
+	a.Equal([]shadowIndexRename{
+		{index: "idx_a", shadowIndex: "_tbl_new_a_idx"},
+		{index: "idx_a_dup", shadowIndex: "_tbl_new_a_idx1"},
+		{index: "tbl_pkey", shadowIndex: "_tbl_new_pkey"},
+	}, getShadowIndexRenameList(indexList, shadowIndexList))
+}
@@ -226,9 +226,18 @@ func (driver *Driver) GetVersion(ctx context.Context) (string, error) {
 func (driver *Driver) Execute(ctx context.Context, statement string) error {
 	owner, err := driver.getCurrentDatabaseOwner()
 	if err != nil {
-		return err
+		return &util.NotAppliedError{Err: err}
 	}
 
+	// applied is set once a statement is executed out of the transaction below.
+	applied := false
+	// notApplied returns NotAppliedError if no change is committed, because the remaining statements are rolled back together.
+	notApplied := func(err error) error {
+		if applied {
+			return err
+		}
+		return &util.NotAppliedError{Err: err}
+	}
 	var remainingStmts []string
 	f := func(stmt string) error {
 		stmt = strings.TrimLeft(stmt, " \t")
@@ -255,11 +264,13 @@ func (driver *Driver) Execute(ctx context.Context, statement string) error {
 				if _, err := driver.db.ExecContext(ctx, stmt); err != nil {
 					return err
 				}
+				applied = true
 			}
 		} else if strings.HasPrefix(stmt, "ALTER DATABASE") && strings.Contains(stmt, " OWNER TO ") {
 			if _, err := driver.db.ExecContext(ctx, stmt); err != nil {
 				return err
 			}
+			applied = true
 		} else if strings.HasPrefix(stmt, "\\connect ") {
 			// For the case of `\connect "dbname";`, we need to use GetDbConnection() instead of executing the statement.
 			parts := strings.Split(stmt, `"`)
@@ -285,7 +296,7 @@ func (driver *Driver) Execute(ctx context.Context, statement string) error {
 	}
 	sc := bufio.NewScanner(strings.NewReader(statement))
 	if err := util.ApplyMultiStatements(sc, f); err != nil {
-		return err
+		return notApplied(err)
 	}
 
 	if len(remainingStmts) == 0 {
@@ -294,17 +305,17 @@ func (driver *Driver) Execute(ctx context.Context, statement string) error {
 
 	tx, err := driver.db.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return notApplied(err)
 	}
 	defer tx.Rollback()
 
 	// Set the current transaction role to the database owner so that the owner of created database will be the same as the database owner.
 	if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL ROLE %s", owner)); err != nil {
-		return err
+		return notApplied(err)
 	}
 
 	if _, err := tx.ExecContext(ctx, strings.Join(remainingStmts, "\n")); err != nil {
-		return err
+		return notApplied(err)
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -0,0 +1,1115 @@
+package pg
+
+// This file implements recovery functions for Postgres.
+// PITR is built on the logical dump as the base backup and the logical decoding of the WAL.
+// For example, the original database is `dbfoo` with ID 101. The suffixTs, derived from the PITR issue's CreateTs, is 1653018005.
+// Bytebase will do the following:
+// 1. Create a logical replication slot called `bytebase_pitr_101` with the test_decoding plugin in `dbfoo`,
+//    and archive the changes decoded from the slot into the Bytebase storage continuously.
+// 2. Take the dump with an exported snapshot, and record the WAL location and the snapshot in the backup.
+// 3. Create a database called `dbfoo_pitr_1653018005`, restore the latest backup before the target time to it,
+//    and replay the archived changes committed after the backup and before the target time.
+// 4. Rename `dbfoo` to `dbfoo_pitr_1653018005_old`, and `dbfoo_pitr_1653018005` to `dbfoo`.
+// The logical decoding does not decode the DDL statements and the sequence changes, so the schema changes after
+// the backup are not replayed, and the sequences are left as in the backup.
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common/log"
+	"github.com/bytebase/bytebase/plugin/db/util"
+	"go.uber.org/zap"
+)
+
+const (
+	// MaxDatabaseNameLength is the allowed max database name length in Postgres.
+	MaxDatabaseNameLength = 63
+	// pitrSlotPrefix is the prefix of the logical replication slot names for PITR.
+	pitrSlotPrefix = "bytebase_pitr_"
+	// walArchiveBatchSize is the number of the changes peeked from the slot in a batch.
+	// The batch always ends at a transaction boundary, so it might contain more changes.
+	walArchiveBatchSize = 10000
+)
+
+// WALChange is a change decoded from the WAL by the test_decoding plugin.
+// The changes are archived in the JSON lines format.
+type WALChange struct {
+	LSN  string `json:"lsn"`
+	XID  uint32 `json:"xid"`
+	Data string `json:"data"`
+}
+
+// WALArchiveFile is the metadata of the WAL archive file.
+// The archive file contains the changes decoded from the WAL between StartLSN and EndLSN.
+type WALArchiveFile struct {
+	Name     string
+	StartLSN uint64
+	EndLSN   uint64
+}
+
+func newWALArchiveFile(name string) (WALArchiveFile, error) {
+	var startLSN, endLSN uint64
+	if _, err := fmt.Sscanf(name, "%016X-%016X.log", &startLSN, &endLSN); err != nil {
+		return WALArchiveFile{}, fmt.Errorf("invalid WAL archive file name %q", name)
+	}
+	return WALArchiveFile{Name: name, StartLSN: startLSN, EndLSN: endLSN}, nil
+}
+
+func getWALArchiveFileName(startLSN, endLSN uint64) string {
+	return fmt.Sprintf("%016X-%016X.log", startLSN, endLSN)
+}
+
+// GetPITRSlotName returns the name of the logical replication slot archiving the WAL of the database.
+func GetPITRSlotName(databaseID int) string {
+	return fmt.Sprintf("%s%d", pitrSlotPrefix, databaseID)
+}
+
+// CheckLogicalDecodingEnabled checks whether the wal_level is logical, which is required by the WAL archiving.
+func (driver *Driver) CheckLogicalDecodingEnabled(ctx context.Context) error {
+	query := "SHOW wal_level"
+	var walLevel string
+	if err := driver.db.QueryRowContext(ctx, query).Scan(&walLevel); err != nil {
+		return util.FormatErrorWithQuery(err, query)
+	}
+	if walLevel != "logical" {
+		return fmt.Errorf("wal_level is %q, but PITR requires it to be \"logical\"", walLevel)
+	}
+	return nil
+}
+
+// ListPITRSlots returns the names of the PITR slots in the instance.
+func (driver *Driver) ListPITRSlots(ctx context.Context) ([]string, error) {
+	query := fmt.Sprintf("SELECT slot_name FROM pg_replication_slots WHERE slot_name LIKE '%s%%'", strings.ReplaceAll(pitrSlotPrefix, "_", `\_`))
+	rows, err := driver.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, util.FormatErrorWithQuery(err, query)
+	}
+	defer rows.Close()
+
+	var slotList []string
+	for rows.Next() {
+		var slot string
+		if err := rows.Scan(&slot); err != nil {
+			return nil, err
+		}
+		slotList = append(slotList, slot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return slotList, nil
+}
+
+// CreatePITRSlotIfNotExists creates the PITR slot in the database, and starts the WAL archive in archiveDir
+// with an empty archive file at the location of the new slot.
+func (driver *Driver) CreatePITRSlotIfNotExists(ctx context.Context, database, slot, archiveDir string) error {
+	if _, err := driver.getSlotConfirmedLSN(ctx, slot); err == nil {
+		return nil
+	} else if err != sql.ErrNoRows {
+		return err
+	}
+
+	// The logical replication slot is bound to the database it is created in.
+	db, err := driver.GetDbConnection(ctx, database)
+	if err != nil {
+		return err
+	}
+	var lsnStr string
+	if err := db.QueryRowContext(ctx, "SELECT lsn::text FROM pg_create_logical_replication_slot($1, 'test_decoding')", slot).Scan(&lsnStr); err != nil {
+		return fmt.Errorf("failed to create logical replication slot %q in database %q, error: %w", slot, database, err)
+	}
+	lsn, err := parseLSN(lsnStr)
+	if err != nil {
+		return err
+	}
+	log.Info("Created PITR slot", zap.String("database", database), zap.String("slot", slot), zap.String("lsn", lsnStr))
+
+	return writeWALArchiveFile(archiveDir, lsn, lsn, nil)
+}
+
+// DropPITRSlot drops the PITR slot, so that the instance no longer retains the WAL for it.
+func (driver *Driver) DropPITRSlot(ctx context.Context, slot string) error {
+	if _, err := driver.db.ExecContext(ctx, "SELECT pg_drop_replication_slot($1)", slot); err != nil {
+		return fmt.Errorf("failed to drop logical replication slot %q, error: %w", slot, err)
+	}
+	log.Info("Dropped PITR slot", zap.String("slot", slot))
+	return nil
+}
+
+// ArchiveWAL archives the changes decoded from the PITR slot of the database into archiveDir.
+// The changes are peeked from the slot and written into the archive file before the slot is advanced,
+// so that no change is lost if the archiving is interrupted. The modification time of the latest archive file
+// is set to the time before peeking the slot last time, so that it is the time up to which all the changes are archived.
+// If the slot is consumed beyond the archive, the archive restarts at the slot, which leaves a gap in the archive files.
+func (driver *Driver) ArchiveWAL(ctx context.Context, database, slot, archiveDir string) error {
+	// The slot can only be decoded and advanced in the database it is created in.
+	db, err := driver.GetDbConnection(ctx, database)
+	if err != nil {
+		return err
+	}
+	confirmedLSN, err := driver.getSlotConfirmedLSN(ctx, slot)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("PITR slot %q not found, please enable the automatic backup for database %q", slot, database)
+		}
+		return err
+	}
+	archiveFileList, err := GetSortedWALArchiveFiles(archiveDir)
+	if err != nil {
+		return err
+	}
+	latestFileName := ""
+	if len(archiveFileList) > 0 {
+		latestFileName = archiveFileList[len(archiveFileList)-1].Name
+		lastEndLSN := archiveFileList[len(archiveFileList)-1].EndLSN
+		if lastEndLSN < confirmedLSN {
+			log.Warn("The PITR slot is consumed beyond the WAL archive, the changes in between are lost",
+				zap.String("slot", slot),
+				zap.String("archivedLSN", formatLSN(lastEndLSN)),
+				zap.String("confirmedLSN", formatLSN(confirmedLSN)))
+			// Restart the archive at the slot, and the gap is reported by the PITR archiver.
+			if err := writeWALArchiveFile(archiveDir, confirmedLSN, confirmedLSN, nil); err != nil {
+				return err
+			}
+			latestFileName = getWALArchiveFileName(confirmedLSN, confirmedLSN)
+		} else if lastEndLSN > confirmedLSN {
+			// The previous archiving is interrupted after writing the archive file.
+			if err := driver.advanceSlot(ctx, slot, lastEndLSN); err != nil {
+				return err
+			}
+			confirmedLSN = lastEndLSN
+		}
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// Decode the commit time in UTC.
+	if _, err := conn.ExecContext(ctx, "SET TIME ZONE 'UTC'"); err != nil {
+		return err
+	}
+
+	for {
+		// The changes committed before peeking are all decoded from the slot.
+		peekTime := time.Now()
+		changeList, err := peekChanges(ctx, conn, slot)
+		if err != nil {
+			return err
+		}
+		if len(changeList) == 0 {
+			if latestFileName == "" {
+				return nil
+			}
+			latestFilePath := filepath.Join(archiveDir, latestFileName)
+			if err := os.Chtimes(latestFilePath, peekTime, peekTime); err != nil {
+				return fmt.Errorf("failed to update the modification time of WAL archive file %q, error: %w", latestFilePath, err)
+			}
+			return nil
+		}
+		endLSN, err := parseLSN(changeList[len(changeList)-1].LSN)
+		if err != nil {
+			return err
+		}
+		if err := writeWALArchiveFile(archiveDir, confirmedLSN, endLSN, changeList); err != nil {
+			return err
+		}
+		latestFileName = getWALArchiveFileName(confirmedLSN, endLSN)
+		if err := driver.advanceSlot(ctx, slot, endLSN); err != nil {
+			return err
+		}
+		log.Debug("Archived WAL changes",
+			zap.String("slot", slot),
+			zap.Int("count", len(changeList)),
+			zap.String("endLSN", formatLSN(endLSN)))
+		confirmedLSN = endLSN
+	}
+}
+
+func peekChanges(ctx context.Context, conn *sql.Conn, slot string) ([]WALChange, error) {
+	query := "SELECT lsn::text, xid::text, data FROM pg_logical_slot_peek_changes($1, NULL, $2, 'include-timestamp', 'on')"
+	rows, err := conn.QueryContext(ctx, query, slot, walArchiveBatchSize)
+	if err != nil {
+		return nil, util.FormatErrorWithQuery(err, query)
+	}
+	defer rows.Close()
+
+	var changeList []WALChange
+	for rows.Next() {
+		var change WALChange
+		var xid string
+		if err := rows.Scan(&change.LSN, &xid, &change.Data); err != nil {
+			return nil, err
+		}
+		v, err := strconv.ParseUint(xid, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid xid %q, error: %w", xid, err)
+		}
+		change.XID = uint32(v)
+		changeList = append(changeList, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return changeList, nil
+}
+
+func (driver *Driver) getSlotConfirmedLSN(ctx context.Context, slot string) (uint64, error) {
+	var lsn sql.NullString
+	if err := driver.db.QueryRowContext(ctx, "SELECT confirmed_flush_lsn::text FROM pg_replication_slots WHERE slot_name = $1", slot).Scan(&lsn); err != nil {
+		return 0, err
+	}
+	if !lsn.Valid {
+		return 0, fmt.Errorf("PITR slot %q is not a logical replication slot", slot)
+	}
+	return parseLSN(lsn.String)
+}
+
+func (driver *Driver) advanceSlot(ctx context.Context, slot string, lsn uint64) error {
+	if _, err := driver.db.ExecContext(ctx, "SELECT pg_replication_slot_advance($1, $2::pg_lsn)", slot, formatLSN(lsn)); err != nil {
+		return fmt.Errorf("failed to advance logical replication slot %q to %s, error: %w", slot, formatLSN(lsn), err)
+	}
+	return nil
+}
+
+// writeWALArchiveFile writes the changes into the archive file atomically.
+func writeWALArchiveFile(archiveDir string, startLSN, endLSN uint64, changeList []WALChange) error {
+	name := getWALArchiveFileName(startLSN, endLSN)
+	tmpPath := filepath.Join(archiveDir, name+".tmp")
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create WAL archive file %q, error: %w", tmpPath, err)
+	}
+	defer os.Remove(tmpPath)
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	encoder := json.NewEncoder(w)
+	for _, change := range changeList {
+		if err := encoder.Encode(change); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, filepath.Join(archiveDir, name))
+}
+
+// GetSortedWALArchiveFiles returns the WAL archive files in archiveDir in ascending order by the location.
+func GetSortedWALArchiveFiles(archiveDir string) ([]WALArchiveFile, error) {
+	fileInfoList, err := ioutil.ReadDir(archiveDir)
+	if err != nil {
+		return nil, err
+	}
+	var fileList []WALArchiveFile
+	for _, fileInfo := range fileInfoList {
+		if fileInfo.IsDir() || !strings.HasSuffix(fileInfo.Name(), ".log") {
+			continue
+		}
+		file, err := newWALArchiveFile(fileInfo.Name())
+		if err != nil {
+			log.Warn("Skip the invalid WAL archive file", zap.String("name", fileInfo.Name()), zap.Error(err))
+			continue
+		}
+		fileList = append(fileList, file)
+	}
+	sort.Slice(fileList, func(i, j int) bool {
+		return fileList[i].StartLSN < fileList[j].StartLSN || (fileList[i].StartLSN == fileList[j].StartLSN && fileList[i].EndLSN < fileList[j].EndLSN)
+	})
+	return fileList, nil
+}
+
+// getWALReplayList returns the archive files containing the changes after the startLSN.
+// The files must be continuous from the one containing the startLSN.
+func getWALReplayList(archiveFileList []WALArchiveFile, startLSN uint64) ([]WALArchiveFile, error) {
+	var replayList []WALArchiveFile
+	for _, file := range archiveFileList {
+		if file.EndLSN < startLSN {
+			continue
+		}
+		if len(replayList) == 0 {
+			if file.StartLSN > startLSN {
+				return nil, fmt.Errorf("the WAL archive starts at %s, which is after the backup at %s", formatLSN(file.StartLSN), formatLSN(startLSN))
+			}
+		} else if prev := replayList[len(replayList)-1]; file.StartLSN != prev.EndLSN {
+			return nil, fmt.Errorf("the WAL archive is not continuous between %s and %s", formatLSN(prev.EndLSN), formatLSN(file.StartLSN))
+		}
+		replayList = append(replayList, file)
+	}
+	if len(replayList) == 0 {
+		return nil, fmt.Errorf("the WAL archive does not cover the backup at %s", formatLSN(startLSN))
+	}
+	return replayList, nil
+}
+
+// GetEarliestRestorableBackup returns the earliest backup from which the sorted WAL archive files are continuous to the latest one,
+// or nil if there is no such backup. The backupList should only contain DONE backups.
+func GetEarliestRestorableBackup(backupList []*api.Backup, archiveFileList []WALArchiveFile) (*api.Backup, error) {
+	if len(archiveFileList) == 0 {
+		return nil, nil
+	}
+	// The archive files after the last gap are continuous to the latest one.
+	startLSN := archiveFileList[0].StartLSN
+	for i := 1; i < len(archiveFileList); i++ {
+		if archiveFileList[i].StartLSN != archiveFileList[i-1].EndLSN {
+			startLSN = archiveFileList[i].StartLSN
+		}
+	}
+	endLSN := archiveFileList[len(archiveFileList)-1].EndLSN
+
+	var backup *api.Backup
+	var backupLSN uint64
+	for _, b := range backupList {
+		if b.Payload.WALInfo.IsEmpty() {
+			continue
+		}
+		lsn, err := parseLSN(b.Payload.WALInfo.LSN)
+		if err != nil {
+			return nil, err
+		}
+		if lsn < startLSN || lsn > endLSN {
+			continue
+		}
+		if backup == nil || lsn < backupLSN {
+			backup = b
+			backupLSN = lsn
+		}
+	}
+	return backup, nil
+}
+
+// PruneWALArchiveFiles deletes the archive files containing only the changes before the earliest backup in the backupList,
+// which are no longer needed by any backup. The latest archive file is always kept to continue the archive.
+// Nothing is deleted if none of the backups has the WAL info.
+func PruneWALArchiveFiles(archiveDir string, backupList []*api.Backup) error {
+	var earliestLSN uint64
+	found := false
+	for _, backup := range backupList {
+		if backup.Payload.WALInfo.IsEmpty() {
+			continue
+		}
+		lsn, err := parseLSN(backup.Payload.WALInfo.LSN)
+		if err != nil {
+			return err
+		}
+		if !found || lsn < earliestLSN {
+			earliestLSN = lsn
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	archiveFileList, err := GetSortedWALArchiveFiles(archiveDir)
+	if err != nil {
+		return err
+	}
+	for i, file := range archiveFileList {
+		if i == len(archiveFileList)-1 || file.EndLSN >= earliestLSN {
+			break
+		}
+		if err := os.Remove(filepath.Join(archiveDir, file.Name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete WAL archive file %q, error: %w", file.Name, err)
+		}
+		log.Debug("Pruned WAL archive file", zap.String("name", file.Name))
+	}
+	return nil
+}
+
+// parseLSN parses the text representation of pg_lsn, e.g. "16/B374D848".
+func parseLSN(s string) (uint64, error) {
+	var hi, lo uint32
+	if _, err := fmt.Sscanf(s, "%X/%X", &hi, &lo); err != nil {
+		return 0, fmt.Errorf("invalid LSN %q", s)
+	}
+	return uint64(hi)<<32 | uint64(lo), nil
+}
+
+func formatLSN(lsn uint64) string {
+	return fmt.Sprintf("%X/%X", uint32(lsn>>32), uint32(lsn))
+}
+
+// exportSnapshot begins a transaction in the database, and exports the snapshot of the transaction for pg_dump.
+// The caller must keep the transaction open until pg_dump imports the snapshot, and then close the returned database.
+func (driver *Driver) exportSnapshot(ctx context.Context, database string) (*sql.DB, *sql.Tx, string, api.WALInfo, error) {
+	// The snapshot can only be imported in the same database.
+	db, err := sql.Open(driverName, fmt.Sprintf("%s dbname=%s", driver.baseDSN, database))
+	if err != nil {
+		return nil, nil, "", api.WALInfo{}, err
+	}
+
+	// Read the WAL location before taking the snapshot, so that the transactions invisible in the snapshot
+	// are all committed after the location. The transactions committed after the location but visible
+	// in the snapshot are skipped by the snapshot in the replay.
+	var walInfo api.WALInfo
+	if err := db.QueryRowContext(ctx, "SELECT pg_current_wal_lsn()::text").Scan(&walInfo.LSN); err != nil {
+		db.Close()
+		return nil, nil, "", api.WALInfo{}, err
+	}
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		db.Close()
+		return nil, nil, "", api.WALInfo{}, err
+	}
+	var snapshotName string
+	query := "SELECT pg_export_snapshot(), txid_current_snapshot()::text, ceil(extract(epoch from clock_timestamp()))::bigint"
+	if err := tx.QueryRowContext(ctx, query).Scan(&snapshotName, &walInfo.Snapshot, &walInfo.Ts); err != nil {
+		tx.Rollback()
+		db.Close()
+		return nil, nil, "", api.WALInfo{}, util.FormatErrorWithQuery(err, query)
+	}
+	return db, tx, snapshotName, walInfo, nil
+}
+
+// GetLatestBackupBeforeOrEqualTs finds the latest logical backup whose snapshot is taken before or equal to `targetTs`.
+// The backupList should only contain DONE backups.
+func GetLatestBackupBeforeOrEqualTs(backupList []*api.Backup, targetTs int64) (*api.Backup, error) {
+	var backup *api.Backup
+	for _, b := range backupList {
+		if b.Payload.WALInfo.IsEmpty() {
+			log.Debug("Skip the backup where WALInfo is empty", zap.Int("backupId", b.ID), zap.String("backupName", b.Name))
+			continue
+		}
+		if b.Payload.WALInfo.Ts > targetTs {
+			continue
+		}
+		if backup == nil || b.Payload.WALInfo.Ts > backup.Payload.WALInfo.Ts {
+			backup = b
+		}
+	}
+	if backup == nil {
+		return nil, fmt.Errorf("no valid backup before or equal to %s", time.Unix(targetTs, 0).Format(time.RFC822))
+	}
+	return backup, nil
+}
+
+// RestorePITR is a wrapper to perform PITR. It restores a full backup followed by replaying the archived WAL changes.
+// It performs the step 3 of the restore process.
+func (driver *Driver) RestorePITR(ctx context.Context, fullBackup *bufio.Scanner, walInfo api.WALInfo, archiveDir, database string, suffixTs, targetTs int64) error {
+	startLSN, err := parseLSN(walInfo.LSN)
+	if err != nil {
+		return err
+	}
+	snapshot, err := parseSnapshot(walInfo.Snapshot)
+	if err != nil {
+		return err
+	}
+	archiveFileList, err := GetSortedWALArchiveFiles(archiveDir)
+	if err != nil {
+		return err
+	}
+	replayList, err := getWALReplayList(archiveFileList, startLSN)
+	if err != nil {
+		return err
+	}
+	if err := checkWALArchiveCoverage(archiveDir, replayList, targetTs); err != nil {
+		return err
+	}
+
+	pitrDatabaseName := getPITRDatabaseName(database, suffixTs)
+	if _, err := driver.db.ExecContext(ctx, fmt.Sprintf("CREATE DATABASE %s", quoteName(pitrDatabaseName))); err != nil {
+		return fmt.Errorf("failed to create PITR database %q, error: %w", pitrDatabaseName, err)
+	}
+	if _, err := driver.GetDbConnection(ctx, pitrDatabaseName); err != nil {
+		return err
+	}
+	if err := driver.Restore(ctx, fullBackup); err != nil {
+		return fmt.Errorf("failed to restore the full backup, error: %w", err)
+	}
+
+	if err := driver.replayWAL(ctx, replayList, archiveDir, snapshot, targetTs); err != nil {
+		return fmt.Errorf("failed to replay WAL, error: %w", err)
+	}
+	return nil
+}
+
+// checkWALArchiveCoverage checks that the changes are archived up to targetTs, i.e. the slot is drained into the archive
+// after targetTs, which is the modification time of the latest archive file.
+func checkWALArchiveCoverage(archiveDir string, replayList []WALArchiveFile, targetTs int64) error {
+	latestFilePath := filepath.Join(archiveDir, replayList[len(replayList)-1].Name)
+	fileInfo, err := os.Stat(latestFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to get file %q stat, error: %w", latestFilePath, err)
+	}
+	if archivedTs := fileInfo.ModTime().Unix(); archivedTs < targetTs {
+		return fmt.Errorf("the WAL is archived up to %s, which is before the target time %s",
+			time.Unix(archivedTs, 0).Format(time.RFC822), time.Unix(targetTs, 0).Format(time.RFC822))
+	}
+	return nil
+}
+
+// replayWAL replays the transactions in the archive files committed before or equal to `targetTs`,
+// except the ones visible in the snapshot of the backup.
+func (driver *Driver) replayWAL(ctx context.Context, replayList []WALArchiveFile, archiveDir string, snapshot *txSnapshot, targetTs int64) error {
+	targetTime := time.Unix(targetTs, 0)
+	keyColumnMap := make(map[string][]string)
+	getKeyColumnList := func(table string) ([]string, error) {
+		if keyColumnList, ok := keyColumnMap[table]; ok {
+			return keyColumnList, nil
+		}
+		keyColumnList, err := driver.getPrimaryKeyColumnList(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+		keyColumnMap[table] = keyColumnList
+		return keyColumnList, nil
+	}
+
+	var rowChangeList []*walRowChange
+	replayed := 0
+	for _, file := range replayList {
+		done, err := func() (bool, error) {
+			f, err := os.Open(filepath.Join(archiveDir, file.Name))
+			if err != nil {
+				return false, err
+			}
+			defer f.Close()
+
+			decoder := json.NewDecoder(bufio.NewReader(f))
+			for decoder.More() {
+				var change WALChange
+				if err := decoder.Decode(&change); err != nil {
+					return false, fmt.Errorf("failed to decode WAL archive file %q, error: %w", file.Name, err)
+				}
+				switch {
+				case strings.HasPrefix(change.Data, "BEGIN"):
+					rowChangeList = nil
+				case strings.HasPrefix(change.Data, "table "):
+					rowChange, err := parseWALRowChange(change.Data)
+					if err != nil {
+						return false, err
+					}
+					rowChangeList = append(rowChangeList, rowChange)
+				case strings.HasPrefix(change.Data, "COMMIT"):
+					commitTime, err := parseCommitTime(change.Data)
+					if err != nil {
+						return false, err
+					}
+					if commitTime.After(targetTime) {
+						return true, nil
+					}
+					if snapshot.isVisible(change.XID) {
+						continue
+					}
+					var statementList []string
+					for _, rowChange := range rowChangeList {
+						stmt, err := rowChange.getStatement(getKeyColumnList)
+						if err != nil {
+							return false, fmt.Errorf("failed to replay transaction %d, error: %w", change.XID, err)
+						}
+						if stmt != "" {
+							statementList = append(statementList, stmt)
+						}
+					}
+					if err := driver.applyTransaction(ctx, statementList); err != nil {
+						return false, fmt.Errorf("failed to replay transaction %d, error: %w", change.XID, err)
+					}
+					replayed++
+				}
+			}
+			return false, nil
+		}()
+		if err != nil {
+			return err
+		}
+		if done {
+			break
+		}
+	}
+	log.Debug("Replayed WAL transactions", zap.Int("count", replayed))
+	return nil
+}
+
+func (driver *Driver) applyTransaction(ctx context.Context, statementList []string) error {
+	if len(statementList) == 0 {
+		return nil
+	}
+	tx, err := driver.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The triggers and the foreign key checks are disabled, since their effects are replayed as the changes too.
+	if _, err := tx.ExecContext(ctx, "SET LOCAL session_replication_role = replica"); err != nil {
+		return err
+	}
+	for _, stmt := range statementList {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return util.FormatErrorWithQuery(err, stmt)
+		}
+	}
+	return tx.Commit()
+}
+
+func (driver *Driver) getPrimaryKeyColumnList(ctx context.Context, table string) ([]string, error) {
+	query := `
+		SELECT a.attname
+		FROM pg_index i JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+		WHERE i.indrelid = $1::regclass AND i.indisprimary`
+	rows, err := driver.db.QueryContext(ctx, query, table)
+	if err != nil {
+		return nil, util.FormatErrorWithQuery(err, query)
+	}
+	defer rows.Close()
+
+	var columnList []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, err
+		}
+		columnList = append(columnList, column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return columnList, nil
+}
+
+// parseCommitTime parses the commit time in the COMMIT change, e.g. "COMMIT 529 (at 2022-07-08 10:00:00.123456+00)".
+func parseCommitTime(data string) (time.Time, error) {
+	begin := strings.Index(data, "(at ")
+	if begin < 0 || !strings.HasSuffix(data, ")") {
+		return time.Time{}, fmt.Errorf("commit time not found in %q, the slot must be decoded with include-timestamp", data)
+	}
+	s := data[begin+len("(at ") : len(data)-1]
+	for _, layout := range []string{"2006-01-02 15:04:05.999999-07", "2006-01-02 15:04:05.999999-07:00"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid commit time %q", s)
+}
+
+// txSnapshot is the transaction snapshot in the txid_current_snapshot() format "xmin:xmax:xip_list".
+// The 64-bit txids are truncated to the 32-bit xids decoded from the WAL, which are compared with wraparound.
+type txSnapshot struct {
+	xmin    uint32
+	xmax    uint32
+	xipList map[uint32]bool
+}
+
+func parseSnapshot(s string) (*txSnapshot, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid snapshot %q", s)
+	}
+	var txidList []uint64
+	for _, part := range parts[:2] {
+		txid, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid snapshot %q", s)
+		}
+		txidList = append(txidList, txid)
+	}
+	snapshot := &txSnapshot{
+		xmin:    uint32(txidList[0]),
+		xmax:    uint32(txidList[1]),
+		xipList: make(map[uint32]bool),
+	}
+	if parts[2] != "" {
+		for _, xip := range strings.Split(parts[2], ",") {
+			txid, err := strconv.ParseUint(xip, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid snapshot %q", s)
+			}
+			snapshot.xipList[uint32(txid)] = true
+		}
+	}
+	return snapshot, nil
+}
+
+// isVisible returns true if the committed transaction is visible in the snapshot.
+func (s *txSnapshot) isVisible(xid uint32) bool {
+	if xidPrecedes(xid, s.xmin) {
+		return true
+	}
+	if !xidPrecedes(xid, s.xmax) {
+		return false
+	}
+	return !s.xipList[xid]
+}
+
+// xidPrecedes compares the xids with wraparound as TransactionIdPrecedes in Postgres.
+func xidPrecedes(a, b uint32) bool {
+	return int32(a-b) < 0
+}
+
+// walColumn is a column decoded by the test_decoding plugin, e.g. `name[text]:'foo'`.
+type walColumn struct {
+	// name is the column name quoted as needed.
+	name string
+	// value is the column value in the SQL literal, or "null".
+	value string
+	// unchanged is true if the TOAST value is not changed in the UPDATE, so the value is not decoded.
+	unchanged bool
+}
+
+// walRowChange is a row change decoded by the test_decoding plugin.
+type walRowChange struct {
+	// table is the table name qualified with the schema name and quoted as needed.
+	table  string
+	action string
+	// oldKeyList is the replica identity of the old row in the UPDATE.
+	oldKeyList []*walColumn
+	// columnList is the new row in the INSERT and the UPDATE, or the replica identity of the row in the DELETE.
+	columnList []*walColumn
+	// noTupleData is true if the row is not decoded, because the table has no replica identity.
+	noTupleData bool
+	// truncateOptions is the options of the TRUNCATE.
+	truncateOptions string
+}
+
+// parseWALRowChange parses the row change decoded by the test_decoding plugin, e.g.
+// table public.tbl: INSERT: id[integer]:1 name[text]:'foo'
+// table public.tbl: UPDATE: old-key: id[integer]:1 new-tuple: id[integer]:2 name[text]:'foo'
+// table public.tbl: DELETE: id[integer]:2
+// table public.tbl: TRUNCATE: restart_seqs cascade
+func parseWALRowChange(data string) (*walRowChange, error) {
+	s := strings.TrimPrefix(data, "table ")
+	// The TRUNCATE might contain multiple tables separated by comma.
+	var tableList []string
+	for {
+		var nameList []string
+		for {
+			name, rest, err := scanIdentifier(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid row change %q, error: %w", data, err)
+			}
+			nameList = append(nameList, name)
+			s = rest
+			if !strings.HasPrefix(s, ".") {
+				break
+			}
+			s = s[1:]
+		}
+		tableList = append(tableList, strings.Join(nameList, "."))
+		if !strings.HasPrefix(s, ", ") {
+			break
+		}
+		s = s[2:]
+	}
+	if !strings.HasPrefix(s, ": ") {
+		return nil, fmt.Errorf("invalid row change %q", data)
+	}
+	s = s[2:]
+	i := strings.Index(s, ":")
+	if i < 0 {
+		return nil, fmt.Errorf("invalid row change %q", data)
+	}
+	change := &walRowChange{
+		table:  strings.Join(tableList, ", "),
+		action: s[:i],
+	}
+	s = strings.TrimPrefix(s[i+1:], " ")
+
+	if s == "(no-tuple-data)" {
+		change.noTupleData = true
+		return change, nil
+	}
+	switch change.action {
+	case "INSERT", "DELETE":
+		columnList, _, err := scanColumnList(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid row change %q, error: %w", data, err)
+		}
+		change.columnList = columnList
+	case "UPDATE":
+		if strings.HasPrefix(s, "old-key: ") {
+			oldKeyList, rest, err := scanColumnList(strings.TrimPrefix(s, "old-key: "))
+			if err != nil {
+				return nil, fmt.Errorf("invalid row change %q, error: %w", data, err)
+			}
+			change.oldKeyList = oldKeyList
+			s = strings.TrimPrefix(rest, "new-tuple: ")
+		}
+		columnList, _, err := scanColumnList(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid row change %q, error: %w", data, err)
+		}
+		change.columnList = columnList
+	case "TRUNCATE":
+		change.truncateOptions = s
+	default:
+		return nil, fmt.Errorf("unsupported action %q in row change %q", change.action, data)
+	}
+	return change, nil
+}
+
+// scanIdentifier scans an identifier quoted as needed, and returns the identifier and the rest of the string.
+func scanIdentifier(s string) (string, string, error) {
+	if strings.HasPrefix(s, `"`) {
+		for i := 1; i < len(s); i++ {
+			if s[i] != '"' {
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '"' {
+				i++
+				continue
+			}
+			return s[:i+1], s[i+1:], nil
+		}
+		return "", "", fmt.Errorf("unterminated quoted identifier")
+	}
+	i := strings.IndexAny(s, ".,:[ ")
+	if i <= 0 {
+		return "", "", fmt.Errorf("identifier not found")
+	}
+	return s[:i], s[i:], nil
+}
+
+// scanColumnList scans the columns until the end of the string or the "new-tuple:" token.
+func scanColumnList(s string) ([]*walColumn, string, error) {
+	var columnList []*walColumn
+	for {
+		s = strings.TrimLeft(s, " ")
+		if s == "" || strings.HasPrefix(s, "new-tuple:") {
+			return columnList, s, nil
+		}
+		name, rest, err := scanIdentifier(s)
+		if err != nil {
+			return nil, "", err
+		}
+		// The type name might contain the brackets, e.g. integer[].
+		if !strings.HasPrefix(rest, "[") {
+			return nil, "", fmt.Errorf("type of column %s not found", name)
+		}
+		i := strings.Index(rest, "]:")
+		if i < 0 {
+			return nil, "", fmt.Errorf("type of column %s not found", name)
+		}
+		value, rest, err := scanValue(rest[i+2:])
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid value of column %s, error: %w", name, err)
+		}
+		column := &walColumn{name: name, value: value}
+		if value == "unchanged-toast-datum" {
+			column.unchanged = true
+		}
+		columnList = append(columnList, column)
+		s = rest
+	}
+}
+
+// scanValue scans a value printed by the test_decoding plugin, which is a quoted literal, a bit string literal,
+// or an unquoted value such as a number, a boolean and null.
+func scanValue(s string) (string, string, error) {
+	begin := 0
+	if strings.HasPrefix(s, "B'") {
+		begin = 1
+	}
+	if strings.HasPrefix(s[begin:], "'") {
+		for i := begin + 1; i < len(s); i++ {
+			if s[i] != '\'' {
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '\'' {
+				i++
+				continue
+			}
+			return s[:i+1], s[i+1:], nil
+		}
+		return "", "", fmt.Errorf("unterminated quoted literal")
+	}
+	i := strings.Index(s, " ")
+	if i < 0 {
+		i = len(s)
+	}
+	if i == 0 {
+		return "", "", fmt.Errorf("value not found")
+	}
+	return s[:i], s[i:], nil
+}
+
+// getStatement returns the statement replaying the row change.
+// getKeyColumnList returns the unquoted primary key columns of the table, which identify the row in the UPDATE without the old key.
+func (c *walRowChange) getStatement(getKeyColumnList func(table string) ([]string, error)) (string, error) {
+	if c.noTupleData {
+		return "", fmt.Errorf("%s of table %s cannot be replayed, because the table has no replica identity", c.action, c.table)
+	}
+	switch c.action {
+	case "INSERT":
+		var nameList, valueList []string
+		for _, column := range c.columnList {
+			nameList = append(nameList, column.name)
+			valueList = append(valueList, column.value)
+		}
+		return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s);", c.table, strings.Join(nameList, ", "), strings.Join(valueList, ", ")), nil
+	case "UPDATE":
+		var setList []string
+		for _, column := range c.columnList {
+			if column.unchanged {
+				continue
+			}
+			setList = append(setList, fmt.Sprintf("%s = %s", column.name, column.value))
+		}
+		keyList := c.oldKeyList
+		if len(keyList) == 0 {
+			keyColumnList, err := getKeyColumnList(c.table)
+			if err != nil {
+				return "", err
+			}
+			if len(keyColumnList) == 0 {
+				return "", fmt.Errorf("UPDATE of table %s cannot be replayed, because the table has no primary key", c.table)
+			}
+			keySet := make(map[string]bool)
+			for _, key := range keyColumnList {
+				keySet[key] = true
+			}
+			for _, column := range c.columnList {
+				if keySet[unquoteIdentifier(column.name)] {
+					keyList = append(keyList, column)
+				}
+			}
+		}
+		if len(setList) == 0 {
+			return "", nil
+		}
+		return fmt.Sprintf("UPDATE %s SET %s WHERE %s;", c.table, strings.Join(setList, ", "), getWhereClause(keyList)), nil
+	case "DELETE":
+		return fmt.Sprintf("DELETE FROM %s WHERE %s;", c.table, getWhereClause(c.columnList)), nil
+	case "TRUNCATE":
+		stmt := fmt.Sprintf("TRUNCATE TABLE %s", c.table)
+		if strings.Contains(c.truncateOptions, "restart_seqs") {
+			stmt += " RESTART IDENTITY"
+		}
+		if strings.Contains(c.truncateOptions, "cascade") {
+			stmt += " CASCADE"
+		}
+		return stmt + ";", nil
+	}
+	return "", fmt.Errorf("unsupported action %q", c.action)
+}
+
+func getWhereClause(columnList []*walColumn) string {
+	var condList []string
+	for _, column := range columnList {
+		if column.unchanged {
+			continue
+		}
+		if column.value == "null" {
+			condList = append(condList, fmt.Sprintf("%s IS NULL", column.name))
+		} else {
+			condList = append(condList, fmt.Sprintf("%s = %s", column.name, column.value))
+		}
+	}
+	return strings.Join(condList, " AND ")
+}
+
+func unquoteIdentifier(s string) string {
+	if strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) && len(s) >= 2 {
+		return strings.ReplaceAll(s[1:len(s)-1], `""`, `"`)
+	}
+	return s
+}
+
+func quoteName(s string) string {
+	return fmt.Sprintf(`"%s"`, strings.ReplaceAll(s, `"`, `""`))
+}
+
+// SwapPITRDatabase renames the original database to the old database, and the pitr database to the original database.
+// It returns the pitr and old database names after swap.
+// It performs the step 4 of the restore process.
+func (driver *Driver) SwapPITRDatabase(ctx context.Context, database string, suffixTs int64) (string, string, error) {
+	pitrDatabaseName := getPITRDatabaseName(database, suffixTs)
+	pitrOldDatabaseName := getPITROldDatabaseName(database, suffixTs)
+
+	// The databases cannot be renamed through the connections to them.
+	if driver.databaseName == database || driver.databaseName == pitrDatabaseName {
+		return pitrDatabaseName, pitrOldDatabaseName, fmt.Errorf("cannot swap the PITR database through the connection to database %q", driver.databaseName)
+	}
+
+	log.Debug("Checking database exists.", zap.String("database", database))
+	var owner string
+	dbExists := true
+	if err := driver.db.QueryRowContext(ctx, "SELECT pg_get_userbyid(datdba) FROM pg_database WHERE datname = $1", database).Scan(&owner); err != nil {
+		if err != sql.ErrNoRows {
+			return pitrDatabaseName, pitrOldDatabaseName, fmt.Errorf("failed to check whether database %q exists, error: %w", database, err)
+		}
+		dbExists = false
+	}
+
+	if dbExists {
+		log.Debug("Renaming the original database to the old database.", zap.String("database", database), zap.String("oldDatabase", pitrOldDatabaseName))
+		// Reject the new connections to the original database before terminating the existing ones.
+		if _, err := driver.db.ExecContext(ctx, fmt.Sprintf("ALTER DATABASE %s WITH ALLOW_CONNECTIONS false", quoteName(database))); err != nil {
+			return pitrDatabaseName, pitrOldDatabaseName, err
+		}
+		if err := driver.terminateConnections(ctx, database); err != nil {
+			return pitrDatabaseName, pitrOldDatabaseName, err
+		}
+		if _, err := driver.db.ExecContext(ctx, fmt.Sprintf("ALTER DATABASE %s RENAME TO %s", quoteName(database), quoteName(pitrOldDatabaseName))); err != nil {
+			return pitrDatabaseName, pitrOldDatabaseName, err
+		}
+		if _, err := driver.db.ExecContext(ctx, fmt.Sprintf("ALTER DATABASE %s WITH ALLOW_CONNECTIONS true", quoteName(pitrOldDatabaseName))); err != nil {
+			return pitrDatabaseName, pitrOldDatabaseName, err
+		}
+	}
+
+	log.Debug("Renaming the PITR database to the original database.", zap.String("pitrDatabase", pitrDatabaseName), zap.String("database", database))
+	if err := driver.terminateConnections(ctx, pitrDatabaseName); err != nil {
+		return pitrDatabaseName, pitrOldDatabaseName, err
+	}
+	if _, err := driver.db.ExecContext(ctx, fmt.Sprintf("ALTER DATABASE %s RENAME TO %s", quoteName(pitrDatabaseName), quoteName(database))); err != nil {
+		return pitrDatabaseName, pitrOldDatabaseName, err
+	}
+	if owner != "" {
+		if _, err := driver.db.ExecContext(ctx, fmt.Sprintf("ALTER DATABASE %s OWNER TO %s", quoteName(database), quoteName(owner))); err != nil {
+			return pitrDatabaseName, pitrOldDatabaseName, err
+		}
+	}
+
+	return pitrDatabaseName, pitrOldDatabaseName, nil
+}
+
+func (driver *Driver) terminateConnections(ctx context.Context, database string) error {
+	if _, err := driver.db.ExecContext(ctx, "SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname = $1 AND pid <> pg_backend_pid()", database); err != nil {
+		return fmt.Errorf("failed to terminate the connections to database %q, error: %w", database, err)
+	}
+	return nil
+}
+
+// Composes a pitr database name that we use as the target database for full backup recovery and WAL replay.
+// For example, getPITRDatabaseName("dbfoo", 1653018005) -> "dbfoo_pitr_1653018005"
+func getPITRDatabaseName(database string, suffixTs int64) string {
+	suffix := fmt.Sprintf("pitr_%d", suffixTs)
+	return getSafeName(database, suffix)
+}
+
+// Composes a database name that we use as the target database for swapping out the original database.
+// For example, getPITROldDatabaseName("dbfoo", 1653018005) -> "dbfoo_pitr_1653018005_old"
+func getPITROldDatabaseName(database string, suffixTs int64) string {
+	suffix := fmt.Sprintf("pitr_%d_old", suffixTs)
+	return getSafeName(database, suffix)
+}
+
+func getSafeName(baseName, suffix string) string {
+	name := fmt.Sprintf("%s_%s", baseName, suffix)
+	if len(name) <= MaxDatabaseNameLength {
+		return name
+	}
+	extraCharacters := len(name) - MaxDatabaseNameLength
+	return fmt.Sprintf("%s_%s", baseName[0:len(baseName)-extraCharacters], suffix)
+}
@@ -0,0 +1,272 @@
+package pg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLSN(t *testing.T) {
+	a := require.New(t)
+	lsn, err := parseLSN("16/B374D848")
+	a.NoError(err)
+	a.Equal(uint64(0x16B374D848), lsn)
+	a.Equal("16/B374D848", formatLSN(lsn))
+	_, err = parseLSN("B374D848")
+	a.Error(err)
+
+	file, err := newWALArchiveFile(getWALArchiveFileName(0x16B374D848, 0x16B374E000))
+	a.NoError(err)
+	a.Equal(WALArchiveFile{Name: "00000016B374D848-00000016B374E000.log", StartLSN: 0x16B374D848, EndLSN: 0x16B374E000}, file)
+}
+
+func TestGetWALReplayList(t *testing.T) {
+	a := require.New(t)
+	fileList := []WALArchiveFile{
+		{Name: "a", StartLSN: 100, EndLSN: 100},
+		{Name: "b", StartLSN: 100, EndLSN: 200},
+		{Name: "c", StartLSN: 200, EndLSN: 300},
+		{Name: "d", StartLSN: 300, EndLSN: 400},
+	}
+
+	replayList, err := getWALReplayList(fileList, 250)
+	a.NoError(err)
+	a.Equal(fileList[2:], replayList)
+
+	replayList, err = getWALReplayList(fileList, 100)
+	a.NoError(err)
+	a.Equal(fileList, replayList)
+
+	// The archive starts after the backup.
+	_, err = getWALReplayList(fileList, 50)
+	a.Error(err)
+
+	// The archive is not continuous.
+	_, err = getWALReplayList([]WALArchiveFile{fileList[0], fileList[1], fileList[3]}, 150)
+	a.Error(err)
+
+	// The archive ends before the backup.
+	_, err = getWALReplayList(fileList, 500)
+	a.Error(err)
+}
+
+func TestTxSnapshotIsVisible(t *testing.T) {
+	a := require.New(t)
+	snapshot, err := parseSnapshot("100:105:100,103")
+	a.NoError(err)
+	tests := []struct {
+		xid  uint32
+		want bool
+	}{
+		{xid: 99, want: true},
+		{xid: 100, want: false},
+		{xid: 101, want: true},
+		{xid: 103, want: false},
+		{xid: 104, want: true},
+		{xid: 105, want: false},
+		{xid: 106, want: false},
+	}
+	for _, test := range tests {
+		a.Equal(test.want, snapshot.isVisible(test.xid), test.xid)
+	}
+
+	// The 64-bit txids after the wraparound.
+	snapshot, err = parseSnapshot("8589934590:8589934595:")
+	a.NoError(err)
+	a.True(snapshot.isVisible(4294967295))
+	a.False(snapshot.isVisible(3))
+
+	_, err = parseSnapshot("100:105")
+	a.Error(err)
+}
+
+func TestParseCommitTime(t *testing.T) {
+	a := require.New(t)
+	commitTime, err := parseCommitTime("COMMIT 529 (at 2022-07-08 10:00:00.123456+00)")
+	a.NoError(err)
+	a.Equal(time.Date(2022, 7, 8, 10, 0, 0, 123456000, time.UTC), commitTime.UTC())
+
+	commitTime, err = parseCommitTime("COMMIT 529 (at 2022-07-08 15:30:00+05:30)")
+	a.NoError(err)
+	a.Equal(time.Date(2022, 7, 8, 10, 0, 0, 0, time.UTC), commitTime.UTC())
+
+	_, err = parseCommitTime("COMMIT 529")
+	a.Error(err)
+}
+
+func TestGetWALRowChangeStatement(t *testing.T) {
+	getKeyColumnList := func(table string) ([]string, error) {
+		if table == "public.nokey" {
+			return nil, nil
+		}
+		return []string{"id", "Tenant"}, nil
+	}
+	tests := []struct {
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{
+			data: `table public.tbl: INSERT: id[integer]:1 name[text]:'it''s a b' price[numeric]:1.5 tags[text[]]:'{a,b}' flag[bit(4)]:B'0101' deleted[boolean]:false note[character varying]:null`,
+			want: `INSERT INTO public.tbl (id, name, price, tags, flag, deleted, note) VALUES (1, 'it''s a b', 1.5, '{a,b}', B'0101', false, null);`,
+		},
+		{
+			data: `table "My Schema"."My.Table": INSERT: "My Col"[text]:'x y'`,
+			want: `INSERT INTO "My Schema"."My.Table" ("My Col") VALUES ('x y');`,
+		},
+		{
+			data: `table public.tbl: UPDATE: id[integer]:1 "Tenant"[integer]:2 name[text]:'foo' doc[text]:unchanged-toast-datum`,
+			want: `UPDATE public.tbl SET id = 1, "Tenant" = 2, name = 'foo' WHERE id = 1 AND "Tenant" = 2;`,
+		},
+		{
+			data: `table public.tbl: UPDATE: old-key: id[integer]:1 "Tenant"[integer]:2 new-tuple: id[integer]:3 "Tenant"[integer]:2 name[text]:'foo'`,
+			want: `UPDATE public.tbl SET id = 3, "Tenant" = 2, name = 'foo' WHERE id = 1 AND "Tenant" = 2;`,
+		},
+		{
+			data: `table public.full: DELETE: id[integer]:1 name[text]:null`,
+			want: `DELETE FROM public.full WHERE id = 1 AND name IS NULL;`,
+		},
+		{
+			data: `table public.a, public.b: TRUNCATE: restart_seqs cascade`,
+			want: `TRUNCATE TABLE public.a, public.b RESTART IDENTITY CASCADE;`,
+		},
+		{
+			data: `table public.a: TRUNCATE: (no-flags)`,
+			want: `TRUNCATE TABLE public.a;`,
+		},
+		{
+			data:    `table public.nokey: DELETE: (no-tuple-data)`,
+			wantErr: true,
+		},
+		{
+			data:    `table public.nokey: UPDATE: id[integer]:1`,
+			wantErr: true,
+		},
+	}
+
+	a := require.New(t)
+	for _, test := range tests {
+		change, err := parseWALRowChange(test.data)
+		a.NoError(err, test.data)
+		stmt, err := change.getStatement(getKeyColumnList)
+		if test.wantErr {
+			a.Error(err, test.data)
+			continue
+		}
+		a.NoError(err, test.data)
+		a.Equal(test.want, stmt)
+	}
+
+	_, err := parseWALRowChange(`table public.tbl: INSERT: name[text]:'unterminated`)
+	a.Error(err)
+}
+
+func TestGetLatestBackupBeforeOrEqualTs(t *testing.T) {
+	a := require.New(t)
+	backupList := []*api.Backup{
+		{ID: 1, Payload: api.BackupPayload{WALInfo: api.WALInfo{LSN: "0/100", Snapshot: "1:1:", Ts: 100}}},
+		{ID: 2, Payload: api.BackupPayload{WALInfo: api.WALInfo{LSN: "0/300", Snapshot: "3:3:", Ts: 300}}},
+		{ID: 3, Payload: api.BackupPayload{WALInfo: api.WALInfo{LSN: "0/200", Snapshot: "2:2:", Ts: 200}}},
+		{ID: 4},
+	}
+
+	backup, err := GetLatestBackupBeforeOrEqualTs(backupList, 250)
+	a.NoError(err)
+	a.Equal(3, backup.ID)
+
+	backup, err = GetLatestBackupBeforeOrEqualTs(backupList, 300)
+	a.NoError(err)
+	a.Equal(2, backup.ID)
+
+	_, err = GetLatestBackupBeforeOrEqualTs(backupList, 99)
+	a.Error(err)
+}
+
+func TestGetEarliestRestorableBackup(t *testing.T) {
+	a := require.New(t)
+	backupList := []*api.Backup{
+		{ID: 1, Payload: api.BackupPayload{WALInfo: api.WALInfo{LSN: "0/100", Snapshot: "1:1:", Ts: 100}}},
+		{ID: 2, Payload: api.BackupPayload{WALInfo: api.WALInfo{LSN: "0/300", Snapshot: "3:3:", Ts: 300}}},
+		{ID: 3, Payload: api.BackupPayload{WALInfo: api.WALInfo{LSN: "0/500", Snapshot: "5:5:", Ts: 500}}},
+		{ID: 4},
+	}
+
+	backup, err := GetEarliestRestorableBackup(backupList, nil)
+	a.NoError(err)
+	a.Nil(backup)
+
+	backup, err = GetEarliestRestorableBackup(backupList, []WALArchiveFile{
+		{StartLSN: 0x100, EndLSN: 0x200},
+		{StartLSN: 0x200, EndLSN: 0x600},
+	})
+	a.NoError(err)
+	a.Equal(1, backup.ID)
+
+	// The changes between 0/200 and 0/400 are lost.
+	backup, err = GetEarliestRestorableBackup(backupList, []WALArchiveFile{
+		{StartLSN: 0x100, EndLSN: 0x200},
+		{StartLSN: 0x400, EndLSN: 0x600},
+	})
+	a.NoError(err)
+	a.Equal(3, backup.ID)
+
+	backup, err = GetEarliestRestorableBackup(backupList, []WALArchiveFile{
+		{StartLSN: 0x100, EndLSN: 0x200},
+		{StartLSN: 0x600, EndLSN: 0x700},
+	})
+	a.NoError(err)
+	a.Nil(backup)
+}
+
+func TestPruneWALArchiveFiles(t *testing.T) {
+	a := require.New(t)
+	archiveDir := t.TempDir()
+	a.NoError(writeWALArchiveFile(archiveDir, 0x100, 0x100, nil))
+	a.NoError(writeWALArchiveFile(archiveDir, 0x100, 0x200, nil))
+	a.NoError(writeWALArchiveFile(archiveDir, 0x200, 0x300, nil))
+	a.NoError(writeWALArchiveFile(archiveDir, 0x300, 0x400, nil))
+	getNameList := func() []string {
+		fileList, err := GetSortedWALArchiveFiles(archiveDir)
+		a.NoError(err)
+		var nameList []string
+		for _, file := range fileList {
+			nameList = append(nameList, file.Name)
+		}
+		return nameList
+	}
+
+	// Nothing is pruned without the WAL info.
+	a.NoError(PruneWALArchiveFiles(archiveDir, []*api.Backup{{ID: 1}}))
+	a.Len(getNameList(), 4)
+
+	backupList := []*api.Backup{
+		{ID: 1, Payload: api.BackupPayload{WALInfo: api.WALInfo{LSN: "0/380", Snapshot: "3:3:", Ts: 300}}},
+		{ID: 2, Payload: api.BackupPayload{WALInfo: api.WALInfo{LSN: "0/250", Snapshot: "2:2:", Ts: 200}}},
+	}
+	a.NoError(PruneWALArchiveFiles(archiveDir, backupList))
+	a.Equal([]string{getWALArchiveFileName(0x200, 0x300), getWALArchiveFileName(0x300, 0x400)}, getNameList())
+
+	// The latest archive file is kept.
+	backupList = []*api.Backup{
+		{ID: 3, Payload: api.BackupPayload{WALInfo: api.WALInfo{LSN: "0/500", Snapshot: "5:5:", Ts: 500}}},
+	}
+	a.NoError(PruneWALArchiveFiles(archiveDir, backupList))
+	a.Equal([]string{getWALArchiveFileName(0x300, 0x400)}, getNameList())
+}
+
+func TestCheckWALArchiveCoverage(t *testing.T) {
+	a := require.New(t)
+	archiveDir := t.TempDir()
+	a.NoError(writeWALArchiveFile(archiveDir, 0x100, 0x200, nil))
+	archivedTime := time.Unix(1000, 0)
+	a.NoError(os.Chtimes(filepath.Join(archiveDir, getWALArchiveFileName(0x100, 0x200)), archivedTime, archivedTime))
+	replayList := []WALArchiveFile{{Name: getWALArchiveFileName(0x100, 0x200), StartLSN: 0x100, EndLSN: 0x200}}
+
+	a.NoError(checkWALArchiveCoverage(archiveDir, replayList, 1000))
+	a.NoError(checkWALArchiveCoverage(archiveDir, replayList, 999))
+	a.Error(checkWALArchiveCoverage(archiveDir, replayList, 1001))
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -17,6 +18,28 @@ import (
 	"go.uber.org/zap"
 )
 
+// NotAppliedError is the error of executing the statement before any change is committed to the database,
+// e.g. the statement fails in a transaction which is rolled back. It's safe to execute the statement again.
+type NotAppliedError struct {
+	Err error
+}
+
+// Error implements the error interface.
+func (e *NotAppliedError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the embedded error.
+func (e *NotAppliedError) Unwrap() error {
+	return e.Err
+}
+
+// IsNotApplied returns true if the error is returned before any change is committed to the database.
+func IsNotApplied(err error) bool {
+	var e *NotAppliedError
+	return errors.As(err, &e)
+}
+
 // FormatErrorWithQuery will format the error with failed query.
 func FormatErrorWithQuery(err error, query string) error {
 	return common.Errorf(common.DbExecutionError, fmt.Errorf("failed to execute query %q, error: %w", query, err))
@@ -136,7 +159,7 @@ func ExecuteMigration(ctx context.Context, executor MigrationExecutor, m *db.Mig
 		// For baseline migration, we also record the live schema to detect the schema drift.
 		// See https://bytebase.com/blog/what-is-database-schema-drift
 		if _, err := executor.Dump(ctx, m.Database, &prevSchemaBuf, true /*schemaOnly*/); err != nil {
-			return -1, "", FormatError(err)
+			return -1, "", &NotAppliedError{Err: FormatError(err)}
 		}
 	}
 
@@ -144,7 +167,7 @@ func ExecuteMigration(ctx context.Context, executor MigrationExecutor, m *db.Mig
 	// Phase 2 - Record migration history as PENDING
 	insertedID, err := BeginMigration(ctx, executor, m, prevSchemaBuf.String(), statement, databaseName)
 	if err != nil {
-		return -1, "", err
+		return -1, "", &NotAppliedError{Err: err}
 	}
 
 	startedNs := time.Now().UnixNano()
@@ -173,9 +196,10 @@ func ExecuteMigration(ctx context.Context, executor MigrationExecutor, m *db.Mig
 		// Switch to the target database only if we're NOT creating this target database.
 		if !m.CreateDatabase {
 			if _, err := executor.GetDbConnection(ctx, m.Database); err != nil {
-				return -1, "", err
+				return -1, "", &NotAppliedError{Err: err}
 			}
 		}
+		// The driver returns NotAppliedError if the statement fails before committing any change.
 		if err := executor.Execute(ctx, statement); err != nil {
 			return -1, "", FormatError(err)
 		}
@@ -246,6 +246,44 @@ func (m *ActivityManager) getWebhookContext(ctx context.Context, activity *api.A
 			level = webhook.WebhookError
 			title = "Task failed - " + task.Name
 		}
+	case api.ActivityPipelineTaskApprove:
+		update := &api.ActivityPipelineTaskApprovePayload{}
+		if err := json.Unmarshal([]byte(activity.Payload), update); err != nil {
+			log.Warn("Failed to post webhook event after approving the issue task, failed to unmarshal payload",
+				zap.String("issue_name", meta.issue.Name),
+				zap.Error(err))
+			return webhookCtx, err
+		}
+		title = fmt.Sprintf("Task approval step %d/%d approved - %s", update.StepIndex+1, update.StepCount, update.TaskName)
+	case api.ActivityPipelineTaskDeploymentWindowOverride:
+		update := &api.ActivityPipelineTaskDeploymentWindowOverridePayload{}
+		if err := json.Unmarshal([]byte(activity.Payload), update); err != nil {
+			log.Warn("Failed to post webhook event after overriding the deployment window, failed to unmarshal payload",
+				zap.String("issue_name", meta.issue.Name),
+				zap.Error(err))
+			return webhookCtx, err
+		}
+		level = webhook.WebhookWarn
+		title = "Deployment window overridden - " + update.TaskName
+	case api.ActivityPipelineTaskVerificationFail:
+		update := &api.ActivityPipelineTaskVerificationFailPayload{}
+		if err := json.Unmarshal([]byte(activity.Payload), update); err != nil {
+			log.Warn("Failed to post webhook event after failing the task verification, failed to unmarshal payload",
+				zap.String("issue_name", meta.issue.Name),
+				zap.Error(err))
+			return webhookCtx, err
+		}
+		level = webhook.WebhookError
+		title = "Task verification failed - " + update.TaskName
+	case api.ActivityPipelineStageRolloutPromote:
+		update := &api.ActivityPipelineStageRolloutPromotePayload{}
+		if err := json.Unmarshal([]byte(activity.Payload), update); err != nil {
+			log.Warn("Failed to post webhook event after promoting the rollout wave, failed to unmarshal payload",
+				zap.String("issue_name", meta.issue.Name),
+				zap.Error(err))
+			return webhookCtx, err
+		}
+		title = fmt.Sprintf("Rollout wave %d/%d promoted - %s", update.WaveIndex+1, update.WaveCount, update.StageName)
 	}
 
 	webhookCtx = webhook.Context{
@@ -286,6 +324,14 @@ func shouldPostInbox(activity *api.Activity, createType api.ActivityType) (bool,
 		return true, nil
 	case api.ActivityPipelineTaskEarliestAllowedTimeUpdate:
 		return true, nil
+	case api.ActivityPipelineTaskApprove:
+		return true, nil
+	case api.ActivityPipelineTaskDeploymentWindowOverride:
+		return true, nil
+	case api.ActivityPipelineTaskVerificationFail:
+		return true, nil
+	case api.ActivityPipelineStageRolloutPromote:
+		return true, nil
 	case api.ActivityPipelineTaskStatusUpdate:
 		update := new(api.ActivityPipelineTaskStatusUpdatePayload)
 		if err := json.Unmarshal([]byte(activity.Payload), update); err != nil {
@@ -58,6 +58,11 @@ func (s *AnomalyScanner) Run(ctx context.Context, wg *sync.WaitGroup) {
 
 				ctx := context.Background()
 
+				// Only one replica runs the round.
+				if !s.server.claimRound(ctx, "anomaly_scanner", anomalyScanInterval) {
+					return
+				}
+
 				envList, err := s.server.store.FindEnvironment(ctx, &api.EnvironmentFind{})
 				if err != nil {
 					log.Error("Failed to retrieve instance list", zap.Error(err))
@@ -522,4 +527,19 @@ func (s *AnomalyScanner) checkBackupAnomaly(ctx context.Context, instance *api.I
 			}
 		}
 	}
+
+	// Close the binlog gap anomaly if backup is disabled, since the PITR archiver only checks the databases with backup enabled.
+	if backupSetting == nil || !backupSetting.Enabled {
+		err := s.server.store.ArchiveAnomaly(ctx, &api.AnomalyArchive{
+			DatabaseID: &database.ID,
+			Type:       api.AnomalyDatabasePITRBinlogGap,
+		})
+		if err != nil && common.ErrorCode(err) != common.NotFound {
+			log.Error("Failed to close anomaly",
+				zap.String("instance", instance.Name),
+				zap.String("database", database.Name),
+				zap.String("type", string(api.AnomalyDatabasePITRBinlogGap)),
+				zap.Error(err))
+		}
+	}
 }
@@ -5,12 +5,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/common"
 	"github.com/bytebase/bytebase/common/log"
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/db/pg"
 	"go.uber.org/zap"
 )
 
@@ -78,35 +83,48 @@ func (s *BackupRunner) Run(ctx context.Context, wg *sync.WaitGroup) {
 						continue
 					}
 					backupName := fmt.Sprintf("%s-%s-%s-autobackup", api.ProjectShortSlug(db.Project), api.EnvSlug(db.Instance.Environment), t.Format("20060102T030405"))
-					go func(database *api.Database, backupSettingID int, backupName string, hookURL string) {
+					go func(database *api.Database, backupSetting *api.BackupSetting, backupName string) {
 						log.Debug("Schedule auto backup",
 							zap.String("database", database.Name),
 							zap.String("backup", backupName),
 						)
 						defer func() {
 							mu.Lock()
-							delete(runningTasks, backupSettingID)
+							delete(runningTasks, backupSetting.ID)
 							mu.Unlock()
 						}()
-						err := s.scheduleBackupTask(ctx, database, backupName)
-						if err != nil {
-							log.Error("Failed to create automatic backup for database",
+						// The backup name is deterministic in the hour, so the lease only needs to guard the concurrent scheduling among the replicas.
+						if _, err := s.server.runWithLease(ctx, backupSettingLeaseResource(backupSetting.ID), func(ctx context.Context) {
+							err := s.scheduleBackupTask(ctx, database, backupSetting, backupName)
+							if err != nil {
+								log.Error("Failed to create automatic backup for database",
+									zap.Int("databaseID", database.ID),
+									zap.Error(err))
+								return
+							}
+							// Backup succeeded. POST hook URL.
+							hookURL := backupSetting.HookURL
+							if hookURL == "" {
+								return
+							}
+							_, err = http.PostForm(hookURL, nil)
+							if err != nil {
+								log.Warn("Failed to POST hook URL",
+									zap.String("hookURL", hookURL),
+									zap.Int("databaseID", database.ID),
+									zap.Error(err))
+							}
+						}); err != nil {
+							log.Error("Failed to schedule auto backup with lease",
 								zap.Int("databaseID", database.ID),
 								zap.Error(err))
-							return
 						}
-						// Backup succeeded. POST hook URL.
-						if hookURL == "" {
-							return
-						}
-						_, err = http.PostForm(hookURL, nil)
-						if err != nil {
-							log.Warn("Failed to POST hook URL",
-								zap.String("hookURL", hookURL),
-								zap.Int("databaseID", database.ID),
-								zap.Error(err))
-						}
-					}(db, backupSetting.ID, backupName, backupSetting.HookURL)
+					}(db, backupSetting, backupName)
+				}
+
+				// Only one replica prunes the expired backups in the round.
+				if s.server.claimRound(ctx, "backup_pruner", s.backupRunnerInterval) {
+					s.pruneBackups(ctx)
 				}
 			}()
 		case <-ctx.Done(): // if cancel() execute
@@ -115,8 +133,8 @@ func (s *BackupRunner) Run(ctx context.Context, wg *sync.WaitGroup) {
 	}
 }
 
-func (s *BackupRunner) scheduleBackupTask(ctx context.Context, database *api.Database, backupName string) error {
-	path := getBackupRelativeFilePath(database.ID, backupName)
+func (s *BackupRunner) scheduleBackupTask(ctx context.Context, database *api.Database, backupSetting *api.BackupSetting, backupName string) error {
+	path := getBackupRelativeFilePath(database.ID, backupName, backupSetting)
 	if err := createBackupDirectory(s.server.profile.DataDir, database.ID); err != nil {
 		return err
 	}
@@ -201,3 +219,160 @@ func (s *BackupRunner) scheduleBackupTask(ctx context.Context, database *api.Dat
 	}
 	return nil
 }
+
+// pruneBackups deletes the backups expired by the retention rules of the backup settings and the backup plan policies.
+func (s *BackupRunner) pruneBackups(ctx context.Context) {
+	retentionEnabled := true
+	backupSettingList, err := s.server.store.FindBackupSetting(ctx, &api.BackupSettingFind{RetentionEnabled: &retentionEnabled})
+	if err != nil {
+		log.Error("Failed to retrieve backup settings with retention", zap.Error(err))
+		return
+	}
+
+	backupPlanPolicyMap := make(map[int]*api.BackupPlanPolicy)
+	now := time.Now().Unix()
+	for _, backupSetting := range backupSettingList {
+		database := backupSetting.Database
+		if database.Name == api.AllDatabaseName {
+			continue
+		}
+		environmentID := database.Instance.EnvironmentID
+		if _, ok := backupPlanPolicyMap[environmentID]; !ok {
+			policy, err := s.server.store.GetBackupPlanPolicyByEnvID(ctx, environmentID)
+			if err != nil {
+				log.Error("Failed to retrieve backup plan policy",
+					zap.Int("environmentID", environmentID),
+					zap.Error(err))
+				continue
+			}
+			backupPlanPolicyMap[environmentID] = policy
+		}
+		retentionPeriodTs := backupSetting.RetentionPeriodTs
+		if minRetentionPeriodTs := backupPlanPolicyMap[environmentID].RetentionPeriodTs; retentionPeriodTs < minRetentionPeriodTs {
+			retentionPeriodTs = minRetentionPeriodTs
+		}
+
+		backupStatus := api.BackupStatusDone
+		backupList, err := s.server.store.FindBackup(ctx, &api.BackupFind{DatabaseID: &database.ID, Status: &backupStatus})
+		if err != nil {
+			log.Error("Failed to retrieve backups for database",
+				zap.Int("databaseID", database.ID),
+				zap.Error(err))
+			continue
+		}
+		restorableBackup, err := s.server.getEarliestRestorableBackup(database, backupList)
+		if err != nil {
+			log.Error("Failed to find the earliest restorable backup for database",
+				zap.Int("databaseID", database.ID),
+				zap.Error(err))
+			continue
+		}
+		prunedBackupSet := make(map[int]bool)
+		for _, backup := range getPrunableBackupList(backupList, restorableBackup, retentionPeriodTs, backupSetting.KeepLastCount, now) {
+			if err := s.pruneBackup(ctx, backup); err != nil {
+				log.Error("Failed to prune backup",
+					zap.Int("databaseID", database.ID),
+					zap.String("backup", backup.Name),
+					zap.Error(err))
+				continue
+			}
+			prunedBackupSet[backup.ID] = true
+		}
+
+		// The archived WAL changes before the retained backups are no longer needed.
+		if database.Instance.Engine == db.Postgres {
+			var retainedBackupList []*api.Backup
+			for _, backup := range backupList {
+				if !prunedBackupSet[backup.ID] {
+					retainedBackupList = append(retainedBackupList, backup)
+				}
+			}
+			archiveDir := getWALArchiveAbsDir(s.server.profile.DataDir, database.ID)
+			if err := pg.PruneWALArchiveFiles(archiveDir, retainedBackupList); err != nil && !os.IsNotExist(err) {
+				log.Error("Failed to prune WAL archive files",
+					zap.Int("databaseID", database.ID),
+					zap.Error(err))
+			}
+		}
+	}
+}
+
+// pruneBackup deletes the backup file and marks the backup as pruned.
+func (s *BackupRunner) pruneBackup(ctx context.Context, backup *api.Backup) error {
+	if backup.StorageBackend != api.BackupStorageBackendLocal {
+		return fmt.Errorf("pruning backups on storage backend %q is not supported", backup.StorageBackend)
+	}
+	if err := os.Remove(filepath.Join(s.server.profile.DataDir, backup.Path)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete backup file %q: %w", backup.Path, err)
+	}
+
+	payload, err := json.Marshal(backup.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal backup payload: %w", err)
+	}
+	if _, err := s.server.store.PatchBackup(ctx, &api.BackupPatch{
+		ID:        backup.ID,
+		UpdaterID: api.SystemBotID,
+		Status:    string(api.BackupStatusPruned),
+		Comment:   backup.Comment,
+		Payload:   string(payload),
+	}); err != nil {
+		return fmt.Errorf("failed to mark backup as pruned: %w", err)
+	}
+	log.Info("Pruned expired backup",
+		zap.Int("databaseID", backup.DatabaseID),
+		zap.String("backup", backup.Name))
+	return nil
+}
+
+// getPrunableBackupList returns the successful backups to prune.
+// A backup is retained if it's created within the retention period, or it's one of the latest keepLastCount backups.
+// The latest restorable backup created before the retention period is retained as well, because it's the base backup
+// to recover the database to the point in time at the start of the retention period. A backup is restorable if it's
+// not earlier than restorableBackup, the earliest backup from which the archived binlog or WAL is continuous to the latest one.
+// Nothing is pruned if both retentionPeriodTs and keepLastCount are 0.
+func getPrunableBackupList(backupList []*api.Backup, restorableBackup *api.Backup, retentionPeriodTs int64, keepLastCount int, now int64) []*api.Backup {
+	if retentionPeriodTs == 0 && keepLastCount == 0 {
+		return nil
+	}
+	var doneBackupList []*api.Backup
+	for _, backup := range backupList {
+		if backup.Status == api.BackupStatusDone {
+			doneBackupList = append(doneBackupList, backup)
+		}
+	}
+	// Sort the backups from the latest to the earliest.
+	sort.Slice(doneBackupList, func(i, j int) bool {
+		return doneBackupList[i].CreatedTs > doneBackupList[j].CreatedTs
+	})
+
+	// The base backup is the latest restorable backup created before the retention period.
+	cutoffTs := now - retentionPeriodTs
+	baseBackupIndex := -1
+	if retentionPeriodTs > 0 && restorableBackup != nil {
+		for i, backup := range doneBackupList {
+			if backup.CreatedTs < restorableBackup.CreatedTs {
+				break
+			}
+			if backup.CreatedTs >= cutoffTs {
+				continue
+			}
+			if backup.ID == restorableBackup.ID || !backup.Payload.BinlogInfo.IsEmpty() || !backup.Payload.WALInfo.IsEmpty() {
+				baseBackupIndex = i
+				break
+			}
+		}
+	}
+
+	var prunableBackupList []*api.Backup
+	for i, backup := range doneBackupList {
+		if i < keepLastCount || i == baseBackupIndex {
+			continue
+		}
+		if retentionPeriodTs > 0 && backup.CreatedTs >= cutoffTs {
+			continue
+		}
+		prunableBackupList = append(prunableBackupList, backup)
+	}
+	return prunableBackupList
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bytebase/bytebase/api"
+)
+
+func TestGetPrunableBackupList(t *testing.T) {
+	const day = int64(24 * 3600)
+	now := 100 * day
+	// Daily backups from day 90 to day 99, with a failed one on day 95, and one without the binlog info on day 94.
+	var backupList []*api.Backup
+	backupMap := make(map[int]*api.Backup)
+	for i := int64(90); i < 100; i++ {
+		status := api.BackupStatusDone
+		if i == 95 {
+			status = api.BackupStatusFailed
+		}
+		backup := &api.Backup{ID: int(i), Status: status, CreatedTs: i * day}
+		if i != 94 {
+			backup.Payload.BinlogInfo = api.BinlogInfo{FileName: fmt.Sprintf("binlog.%06d", i), Position: 4}
+		}
+		backupList = append(backupList, backup)
+		backupMap[backup.ID] = backup
+	}
+	getIDList := func(list []*api.Backup) []int {
+		var idList []int
+		for _, backup := range list {
+			idList = append(idList, backup.ID)
+		}
+		return idList
+	}
+
+	tests := []struct {
+		name              string
+		restorableID      int
+		retentionPeriodTs int64
+		keepLastCount     int
+		want              []int
+	}{
+		{
+			name:         "no retention",
+			restorableID: 90,
+			want:         nil,
+		},
+		{
+			name:              "retention period keeps the base backup",
+			restorableID:      90,
+			retentionPeriodTs: 3*day + 1,
+			// Day 96 is the base backup for day 97 minus 1 second.
+			want: []int{94, 93, 92, 91, 90},
+		},
+		{
+			name:              "base backup has the binlog info",
+			restorableID:      90,
+			retentionPeriodTs: 5*day + 1,
+			// Day 94 has no binlog info, so day 93 is the base backup for day 95 minus 1 second.
+			want: []int{94, 92, 91, 90},
+		},
+		{
+			name:              "base backup is after the binlog gap",
+			restorableID:      97,
+			retentionPeriodTs: 4*day + 1,
+			want:              []int{94, 93, 92, 91, 90},
+		},
+		{
+			name:              "no restorable backup",
+			retentionPeriodTs: 3*day + 1,
+			want:              []int{96, 94, 93, 92, 91, 90},
+		},
+		{
+			name:          "keep last",
+			restorableID:  90,
+			keepLastCount: 3,
+			want:          []int{96, 94, 93, 92, 91, 90},
+		},
+		{
+			name:              "keep last beyond retention period",
+			restorableID:      90,
+			retentionPeriodTs: 2 * day,
+			keepLastCount:     5,
+			want:              []int{93, 92, 91, 90},
+		},
+		{
+			name:              "latest backup is the base backup",
+			restorableID:      90,
+			retentionPeriodTs: 1,
+			want:              []int{98, 97, 96, 94, 93, 92, 91, 90},
+		},
+	}
+	for _, test := range tests {
+		got := getPrunableBackupList(backupList, backupMap[test.restorableID], test.retentionPeriodTs, test.keepLastCount, now)
+		assert.Equal(t, test.want, getIDList(got), test.name)
+	}
+}
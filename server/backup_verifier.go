@@ -0,0 +1,359 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/common/log"
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/db/mysql"
+	"github.com/bytebase/bytebase/plugin/db/pg"
+	"go.uber.org/zap"
+)
+
+// NewBackupVerifier creates a new backup verifier.
+func NewBackupVerifier(server *Server, backupVerifierInterval time.Duration) *BackupVerifier {
+	return &BackupVerifier{
+		server:                 server,
+		backupVerifierInterval: backupVerifierInterval,
+	}
+}
+
+// BackupVerifier is the backup verifier restoring the new backups into scratch databases.
+type BackupVerifier struct {
+	server                 *Server
+	backupVerifierInterval time.Duration
+}
+
+// Run is the runner for backup verifier.
+func (s *BackupVerifier) Run(ctx context.Context, wg *sync.WaitGroup) {
+	ticker := time.NewTicker(s.backupVerifierInterval)
+	defer ticker.Stop()
+	defer wg.Done()
+	log.Debug("Backup verifier started", zap.Duration("interval", s.backupVerifierInterval))
+	for {
+		select {
+		case <-ticker.C:
+			log.Debug("New backup verifier round started...")
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						err, ok := r.(error)
+						if !ok {
+							err = fmt.Errorf("%v", r)
+						}
+						log.Error("Backup verifier PANIC RECOVER", zap.Error(err))
+					}
+				}()
+
+				// Only one replica runs the round.
+				if !s.server.claimRound(ctx, "backup_verifier", s.backupVerifierInterval) {
+					return
+				}
+
+				status := api.BackupStatusDone
+				verificationStatus := api.BackupVerificationStatusPending
+				backupList, err := s.server.store.FindBackup(ctx, &api.BackupFind{
+					Status:             &status,
+					VerificationStatus: &verificationStatus,
+				})
+				if err != nil {
+					log.Error("Failed to retrieve backups pending verification", zap.Error(err))
+					return
+				}
+
+				// Verify the backups one by one to limit the load on the instances.
+				for _, backup := range backupList {
+					if err := s.verify(ctx, backup); err != nil {
+						log.Error("Failed to verify backup",
+							zap.Int("databaseID", backup.DatabaseID),
+							zap.String("backup", backup.Name),
+							zap.Error(err))
+					}
+				}
+			}()
+		case <-ctx.Done(): // if cancel() execute
+			return
+		}
+	}
+}
+
+// verify verifies the backup, records the verification status and raises the anomaly on failure.
+func (s *BackupVerifier) verify(ctx context.Context, backup *api.Backup) error {
+	database, err := s.server.store.GetDatabase(ctx, &api.DatabaseFind{ID: &backup.DatabaseID})
+	if err != nil {
+		return fmt.Errorf("failed to find database: %w", err)
+	}
+	if database == nil {
+		return fmt.Errorf("database ID not found: %d", backup.DatabaseID)
+	}
+
+	log.Debug("Start backup verification",
+		zap.String("database", database.Name),
+		zap.String("backup", backup.Name))
+	verificationStatus := api.BackupVerificationStatusPassed
+	payload := backup.Payload
+	payload.VerificationDetail = ""
+	if verifyErr := s.server.verifyBackup(ctx, database, backup); verifyErr != nil {
+		verificationStatus = api.BackupVerificationStatusFailed
+		payload.VerificationDetail = verifyErr.Error()
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal backup payload: %w", err)
+	}
+	if _, err := s.server.store.PatchBackup(ctx, &api.BackupPatch{
+		ID:                 backup.ID,
+		UpdaterID:          api.SystemBotID,
+		Status:             string(backup.Status),
+		Comment:            backup.Comment,
+		Payload:            string(payloadBytes),
+		VerificationStatus: &verificationStatus,
+	}); err != nil {
+		return fmt.Errorf("failed to patch backup verification status: %w", err)
+	}
+
+	if verificationStatus == api.BackupVerificationStatusPassed {
+		err := s.server.store.ArchiveAnomaly(ctx, &api.AnomalyArchive{
+			DatabaseID: &database.ID,
+			Type:       api.AnomalyDatabaseBackupVerificationFailure,
+		})
+		if err != nil && common.ErrorCode(err) != common.NotFound {
+			return fmt.Errorf("failed to close anomaly: %w", err)
+		}
+		return nil
+	}
+
+	anomalyPayload, err := json.Marshal(api.AnomalyDatabaseBackupVerificationFailurePayload{
+		BackupID:   backup.ID,
+		BackupName: backup.Name,
+		Detail:     payload.VerificationDetail,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal anomaly payload: %w", err)
+	}
+	if _, err := s.server.store.UpsertActiveAnomaly(ctx, &api.AnomalyUpsert{
+		CreatorID:  api.SystemBotID,
+		InstanceID: database.InstanceID,
+		DatabaseID: &database.ID,
+		Type:       api.AnomalyDatabaseBackupVerificationFailure,
+		Payload:    string(anomalyPayload),
+	}); err != nil {
+		return fmt.Errorf("failed to create anomaly: %w", err)
+	}
+	return nil
+}
+
+// verifyBackup restores the backup into a scratch database on the same instance, and compares the tables and
+// the schema of the scratch database with the ones recorded when taking the backup.
+func (s *Server) verifyBackup(ctx context.Context, database *api.Database, backup *api.Backup) error {
+	instance := database.Instance
+	if !isBackupVerificationSupported(instance.Engine) {
+		return fmt.Errorf("backup verification is not supported for %s", instance.Engine)
+	}
+
+	driver, err := getAdminDatabaseDriver(ctx, instance, database.Name, s.pgInstanceDir)
+	if err != nil {
+		return err
+	}
+	defer driver.Close(ctx)
+	// The scratch database is created and dropped through the connection to the source database,
+	// since Postgres disallows dropping the connected database.
+	sqlDB, err := driver.GetDbConnection(ctx, database.Name)
+	if err != nil {
+		return err
+	}
+	scratchDatabaseName := fmt.Sprintf("bytebase_verify_%d", backup.ID)
+	quotedScratchDatabaseName := quoteBackupIdentifier(instance.Engine, scratchDatabaseName)
+	// Drop the scratch database left by the previous verification interrupted.
+	if _, err := sqlDB.ExecContext(ctx, fmt.Sprintf("DROP DATABASE IF EXISTS %s", quotedScratchDatabaseName)); err != nil {
+		return fmt.Errorf("failed to drop scratch database %q: %w", scratchDatabaseName, err)
+	}
+	if _, err := sqlDB.ExecContext(ctx, fmt.Sprintf("CREATE DATABASE %s", quotedScratchDatabaseName)); err != nil {
+		return fmt.Errorf("failed to create scratch database %q: %w", scratchDatabaseName, err)
+	}
+	defer func() {
+		if _, err := sqlDB.ExecContext(ctx, fmt.Sprintf("DROP DATABASE IF EXISTS %s", quotedScratchDatabaseName)); err != nil {
+			log.Error("Failed to drop scratch database after backup verification",
+				zap.String("instance", instance.Name),
+				zap.String("database", scratchDatabaseName),
+				zap.Error(err))
+		}
+	}()
+
+	return func() error {
+		scratchDriver, err := getAdminDatabaseDriver(ctx, instance, scratchDatabaseName, s.pgInstanceDir)
+		if err != nil {
+			return err
+		}
+		defer scratchDriver.Close(ctx)
+
+		keyList, err := s.getBackupDecryptionKeyList(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get backup encryption keys, error: %w", err)
+		}
+		r, err := openBackupFile(backup, s.profile.DataDir, keyList)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		if err := scratchDriver.Restore(ctx, bufio.NewScanner(r)); err != nil {
+			return fmt.Errorf("failed to restore backup: %w", err)
+		}
+
+		rowCountMap, err := getBackupTableRowCountMap(ctx, scratchDriver, instance.Engine, scratchDatabaseName)
+		if err != nil {
+			return err
+		}
+		if problemList := compareBackupTableList(backup.Payload.TableList, rowCountMap); len(problemList) > 0 {
+			return fmt.Errorf("restored tables mismatch: %s", strings.Join(problemList, "; "))
+		}
+		// The schema is not recorded if it cannot be dumped in the snapshot of the backup.
+		if backup.Payload.SchemaChecksum == "" {
+			return nil
+		}
+		schemaChecksum, err := getBackupSchemaChecksum(ctx, scratchDriver, scratchDatabaseName, database.Name)
+		if err != nil {
+			return err
+		}
+		if schemaChecksum != backup.Payload.SchemaChecksum {
+			return fmt.Errorf("restored schema mismatches the schema when taking the backup")
+		}
+		return nil
+	}()
+}
+
+// isBackupVerificationSupported returns true if the backup of the engine can be verified.
+func isBackupVerificationSupported(engine db.Type) bool {
+	return engine == db.MySQL || engine == db.TiDB || engine == db.Postgres
+}
+
+func quoteBackupIdentifier(engine db.Type, identifier string) string {
+	if engine == db.Postgres {
+		return fmt.Sprintf(`"%s"`, strings.ReplaceAll(identifier, `"`, `""`))
+	}
+	return fmt.Sprintf("`%s`", strings.ReplaceAll(identifier, "`", "``"))
+}
+
+// getBackupTableRowCountMap counts the rows of the tables in the database restored from the backup.
+func getBackupTableRowCountMap(ctx context.Context, driver db.Driver, engine db.Type, databaseName string) (map[string]int64, error) {
+	schemaList, err := driver.SyncSchema(ctx, databaseName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sync schema of database %q: %w", databaseName, err)
+	}
+	rowCountMap := make(map[string]int64)
+	for _, schema := range schemaList {
+		for _, table := range schema.TableList {
+			// The Postgres table name is already quoted with the schema name.
+			quotedName := table.Name
+			if engine != db.Postgres {
+				quotedName = quoteBackupIdentifier(engine, table.Name)
+			}
+			_, row, err := queryFirstRow(ctx, driver, fmt.Sprintf("SELECT COUNT(*) FROM %s", quotedName))
+			if err != nil {
+				return nil, fmt.Errorf("failed to count rows of table %q: %w", table.Name, err)
+			}
+			if len(row) != 1 {
+				return nil, fmt.Errorf("failed to count rows of table %q", table.Name)
+			}
+			rowCount, err := parseBatchInt(row[0])
+			if err != nil {
+				return nil, fmt.Errorf("failed to count rows of table %q: %w", table.Name, err)
+			}
+			rowCountMap[table.Name] = rowCount
+		}
+	}
+	return rowCountMap, nil
+}
+
+// dumpWithTableRowCount dumps the database, and returns the row counts of the tables counted in the consistent snapshot of the dump.
+// The schema-only dump in the same snapshot is written into schemaOut.
+// The returned row counts are nil, and nothing is written into schemaOut, if the driver cannot dump them in the snapshot.
+func dumpWithTableRowCount(ctx context.Context, driver db.Driver, databaseName string, out, schemaOut io.Writer) (string, map[string]int64, error) {
+	switch d := driver.(type) {
+	case *mysql.Driver:
+		return d.DumpWithTableRowCount(ctx, databaseName, out, schemaOut)
+	case *pg.Driver:
+		return d.DumpWithTableRowCount(ctx, databaseName, out, schemaOut)
+	}
+	payload, err := driver.Dump(ctx, databaseName, out, false /* schemaOnly */)
+	return payload, nil, err
+}
+
+// getBackupTableList returns the tables with the row counts in the backup.
+func getBackupTableList(rowCountMap map[string]int64) []*api.BackupTable {
+	var tableList []*api.BackupTable
+	for name, rowCount := range rowCountMap {
+		tableList = append(tableList, &api.BackupTable{Name: name, RowCount: rowCount})
+	}
+	sort.Slice(tableList, func(i, j int) bool {
+		return tableList[i].Name < tableList[j].Name
+	})
+	return tableList
+}
+
+// compareBackupTableList returns the problems of the restored tables compared with the tables recorded in the backup.
+func compareBackupTableList(tableList []*api.BackupTable, rowCountMap map[string]int64) []string {
+	var problemList []string
+	tableSet := make(map[string]bool)
+	for _, table := range tableList {
+		tableSet[table.Name] = true
+		rowCount, ok := rowCountMap[table.Name]
+		if !ok {
+			problemList = append(problemList, fmt.Sprintf("table %q is missing", table.Name))
+			continue
+		}
+		if rowCount != table.RowCount {
+			problemList = append(problemList, fmt.Sprintf("table %q has %d rows, expect %d rows", table.Name, rowCount, table.RowCount))
+		}
+	}
+	// The tables are not recorded if the rows are not counted in the dump.
+	if len(tableList) == 0 {
+		return problemList
+	}
+	var unexpectedList []string
+	for name := range rowCountMap {
+		if !tableSet[name] {
+			unexpectedList = append(unexpectedList, name)
+		}
+	}
+	sort.Strings(unexpectedList)
+	for _, name := range unexpectedList {
+		problemList = append(problemList, fmt.Sprintf("table %q is unexpected", name))
+	}
+	return problemList
+}
+
+// getBackupSchemaChecksum dumps the schema of the database, and returns the checksum of it by getSchemaChecksum.
+func getBackupSchemaChecksum(ctx context.Context, driver db.Driver, databaseName, sourceDatabaseName string) (string, error) {
+	var schemaBuf bytes.Buffer
+	if _, err := driver.Dump(ctx, databaseName, &schemaBuf, true /* schemaOnly */); err != nil {
+		return "", fmt.Errorf("failed to dump schema of database %q: %w", databaseName, err)
+	}
+	return getSchemaChecksum(schemaBuf.String(), databaseName, sourceDatabaseName), nil
+}
+
+// getSchemaChecksum returns the checksum of the normalized schema-only dump of the database.
+// The name of the database is replaced by the sourceDatabaseName, so that the schema of the scratch database
+// restored from the backup is comparable with the source database.
+func getSchemaChecksum(schema, databaseName, sourceDatabaseName string) string {
+	schema = strings.Join(normalizeSchema(schema), "\n")
+	if databaseName != sourceDatabaseName {
+		schema = strings.ReplaceAll(schema, databaseName, sourceDatabaseName)
+	}
+	checksum := sha256.Sum256([]byte(schema))
+	return hex.EncodeToString(checksum[:])
+}
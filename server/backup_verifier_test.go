@@ -0,0 +1,29 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bytebase/bytebase/api"
+)
+
+func TestGetBackupTableList(t *testing.T) {
+	tableList := getBackupTableList(map[string]int64{"book": 10, "author": 5})
+	assert.Equal(t, []*api.BackupTable{
+		{Name: "author", RowCount: 5},
+		{Name: "book", RowCount: 10},
+	}, tableList)
+	assert.Empty(t, getBackupTableList(nil))
+
+	assert.Empty(t, compareBackupTableList(tableList, map[string]int64{"author": 5, "book": 10}))
+	assert.Equal(t, []string{
+		`table "author" has 6 rows, expect 5 rows`,
+		`table "book" is missing`,
+	}, compareBackupTableList(tableList, map[string]int64{"author": 6}))
+	assert.Equal(t, []string{
+		`table "created" is unexpected`,
+	}, compareBackupTableList(tableList, map[string]int64{"author": 5, "book": 10, "created": 3}))
+	// The tables are not compared if the rows are not counted in the dump.
+	assert.Empty(t, compareBackupTableList(nil, map[string]int64{"author": 5}))
+}
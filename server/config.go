@@ -59,6 +59,8 @@ type Profile struct {
 	PgURL string
 	// MetricConnectionKey is the connection key for metric.
 	MetricConnectionKey string
+	// MaxConcurrentTask is the max number of tasks running at the same time across all instances, 0 means unlimited.
+	MaxConcurrentTask int
 }
 
 func (prof *Profile) useEmbedDB() bool {
@@ -503,8 +503,11 @@ func (s *Server) registerDatabaseRoutes(g *echo.Group) {
 		if err := createBackupDirectory(s.profile.DataDir, database.ID); err != nil {
 			return err
 		}
-		path := getBackupRelativeFilePath(database.ID, backupCreate.Name)
-		backupCreate.Path = path
+		backupSetting, err := s.store.GetBackupSettingByDatabaseID(ctx, database.ID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to get backup setting for database ID: %v", id)).SetInternal(err)
+		}
+		backupCreate.Path = getBackupRelativeFilePath(database.ID, backupCreate.Name, backupSetting)
 
 		driver, err := getAdminDatabaseDriver(ctx, database.Instance, database.Name, s.pgInstanceDir)
 		if err != nil {
@@ -638,6 +641,84 @@ func (s *Server) registerDatabaseRoutes(g *echo.Group) {
 		return nil
 	})
 
+	g.GET("/database/:id/pitr-window", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("id"))).SetInternal(err)
+		}
+
+		database, err := s.store.GetDatabase(ctx, &api.DatabaseFind{ID: &id})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch database ID: %v", id)).SetInternal(err)
+		}
+		if database == nil {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Database not found with ID %d", id))
+		}
+
+		window, err := s.getPITRWindow(ctx, database)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to get PITR window for database %q", database.Name)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, window); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal PITR window response").SetInternal(err)
+		}
+		return nil
+	})
+
+	g.POST("/database/:id/flashback/preview", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		if !s.feature(api.FeaturePITR) {
+			return echo.NewHTTPError(http.StatusForbidden, api.FeaturePITR.AccessErrorMessage())
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("id"))).SetInternal(err)
+		}
+
+		preview := &api.FlashbackPreview{}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, preview); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformed flashback preview request").SetInternal(err)
+		}
+
+		database, err := s.store.GetDatabase(ctx, &api.DatabaseFind{ID: &id})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch database ID: %v", id)).SetInternal(err)
+		}
+		if database == nil {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Database not found with ID %d", id))
+		}
+		// The flashback statement contains the data changed in the database, so only the project members can preview it.
+		principalID := c.Get(getPrincipalIDContextKey()).(int)
+		isMember := false
+		for _, projectMember := range database.Project.ProjectMemberList {
+			if projectMember.PrincipalID == principalID {
+				isMember = true
+				break
+			}
+		}
+		if !isMember {
+			return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("Only the members of project %q can preview the flashback of database %q", database.Project.Name, database.Name))
+		}
+
+		statement, err := s.getFlashbackStatement(ctx, database, preview.TableList, preview.StartTs, preview.EndTs)
+		if err != nil {
+			if common.ErrorCode(err) == common.Invalid {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to generate flashback statement for database %q", database.Name)).SetInternal(err)
+		}
+		preview.Statement = statement
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, preview); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal flashback preview response").SetInternal(err)
+		}
+		return nil
+	})
+
 	g.GET("/database/:id/backup-setting", func(c echo.Context) error {
 		ctx := c.Request().Context()
 		id, err := strconv.Atoi(c.Param("id"))
@@ -2,6 +2,9 @@ package server
 
 import (
 	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
 	"sort"
 
 	"github.com/bytebase/bytebase/api"
@@ -22,9 +25,51 @@ func isMatchExpression(labels map[string]string, expression *api.LabelSelectorRe
 			}
 		}
 		return false
+	case api.NotInOperatorType:
+		value, ok := labels[expression.Key]
+		if !ok {
+			return true
+		}
+		for _, exprValue := range expression.Values {
+			if exprValue == value {
+				return false
+			}
+		}
+		return true
 	case api.ExistsOperatorType:
 		_, ok := labels[expression.Key]
 		return ok
+	case api.DoesNotExistOperatorType:
+		_, ok := labels[expression.Key]
+		return !ok
+	case api.GlobOperatorType:
+		value, ok := labels[expression.Key]
+		if !ok {
+			return false
+		}
+		for _, pattern := range expression.Values {
+			// Invalid patterns are rejected by the deployment schedule validation.
+			if matched, err := path.Match(pattern, value); err == nil && matched {
+				return true
+			}
+		}
+		return false
+	case api.RegexOperatorType:
+		value, ok := labels[expression.Key]
+		if !ok {
+			return false
+		}
+		for _, pattern := range expression.Values {
+			// The regular expression must match the whole label value.
+			re, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", pattern))
+			if err != nil {
+				continue
+			}
+			if re.MatchString(value) {
+				return true
+			}
+		}
+		return false
 	default:
 		return false
 	}
@@ -247,3 +247,75 @@ func TestGetDatabaseMatrixFromDeploymentSchedule(t *testing.T) {
 		assert.Equal(t, matrix, test.want)
 	}
 }
+
+func TestIsMatchExpression(t *testing.T) {
+	labels := map[string]string{
+		"bb.location": "eu-west1",
+		"bb.tenant":   "beta",
+	}
+	tests := []struct {
+		name       string
+		expression *api.LabelSelectorRequirement
+		want       bool
+	}{
+		{
+			name:       "In",
+			expression: &api.LabelSelectorRequirement{Key: "bb.tenant", Operator: api.InOperatorType, Values: []string{"alpha", "beta"}},
+			want:       true,
+		},
+		{
+			name:       "NotIn with the value",
+			expression: &api.LabelSelectorRequirement{Key: "bb.tenant", Operator: api.NotInOperatorType, Values: []string{"beta"}},
+			want:       false,
+		},
+		{
+			name:       "NotIn without the value",
+			expression: &api.LabelSelectorRequirement{Key: "bb.tenant", Operator: api.NotInOperatorType, Values: []string{"alpha"}},
+			want:       true,
+		},
+		{
+			name:       "NotIn without the label",
+			expression: &api.LabelSelectorRequirement{Key: "team", Operator: api.NotInOperatorType, Values: []string{"alpha"}},
+			want:       true,
+		},
+		{
+			name:       "Exists",
+			expression: &api.LabelSelectorRequirement{Key: "bb.location", Operator: api.ExistsOperatorType},
+			want:       true,
+		},
+		{
+			name:       "DoesNotExist with the label",
+			expression: &api.LabelSelectorRequirement{Key: "bb.location", Operator: api.DoesNotExistOperatorType},
+			want:       false,
+		},
+		{
+			name:       "DoesNotExist without the label",
+			expression: &api.LabelSelectorRequirement{Key: "team", Operator: api.DoesNotExistOperatorType},
+			want:       true,
+		},
+		{
+			name:       "Glob",
+			expression: &api.LabelSelectorRequirement{Key: "bb.location", Operator: api.GlobOperatorType, Values: []string{"us-*", "eu-*"}},
+			want:       true,
+		},
+		{
+			name:       "Glob mismatch",
+			expression: &api.LabelSelectorRequirement{Key: "bb.location", Operator: api.GlobOperatorType, Values: []string{"us-*"}},
+			want:       false,
+		},
+		{
+			name:       "Regex",
+			expression: &api.LabelSelectorRequirement{Key: "bb.location", Operator: api.RegexOperatorType, Values: []string{"eu-(west|east)[0-9]+"}},
+			want:       true,
+		},
+		{
+			name:       "Regex matches the whole value",
+			expression: &api.LabelSelectorRequirement{Key: "bb.location", Operator: api.RegexOperatorType, Values: []string{"eu"}},
+			want:       false,
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.want, isMatchExpression(labels, test.expression), test.name)
+	}
+}
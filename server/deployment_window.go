@@ -0,0 +1,231 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/google/jsonapi"
+	"github.com/labstack/echo/v4"
+)
+
+// maxDeploymentWindowLookupCount is the max number of freeze periods and maintenance windows we look through to find the next deployment time.
+const maxDeploymentWindowLookupCount = 1000
+
+func (s *Server) registerDeploymentWindowRoutes(g *echo.Group) {
+	// The ACL only allows the workspace owner to override the deployment window policy.
+	g.POST("/pipeline/:pipelineID/task/:taskID/deployment-window-override", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		taskID, err := strconv.Atoi(c.Param("taskID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Task ID is not a number: %s", c.Param("taskID"))).SetInternal(err)
+		}
+
+		task, err := s.store.GetTaskByID(ctx, taskID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to override deployment window").SetInternal(err)
+		}
+		if task == nil {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Task not found with ID %d", taskID))
+		}
+		if !isDeploymentWindowTaskType(task.Type) {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Deployment window policy does not apply to task type %s", task.Type))
+		}
+		if task.Status != api.TaskPendingApproval && task.Status != api.TaskPending {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Task %q has already started", task.Name))
+		}
+
+		issue, err := s.store.GetIssueByPipelineID(ctx, task.PipelineID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to find issue").SetInternal(err)
+		}
+		if issue == nil {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Issue not found by pipeline ID: %d", task.PipelineID))
+		}
+
+		payload, err := json.Marshal(api.ActivityPipelineTaskDeploymentWindowOverridePayload{
+			TaskID:    task.ID,
+			IssueName: issue.Name,
+			TaskName:  task.Name,
+		})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create activity after overriding deployment window").SetInternal(err)
+		}
+		activityCreate := &api.ActivityCreate{
+			CreatorID:   c.Get(getPrincipalIDContextKey()).(int),
+			ContainerID: issue.ID,
+			Type:        api.ActivityPipelineTaskDeploymentWindowOverride,
+			Level:       api.ActivityWarn,
+			Payload:     string(payload),
+		}
+		if _, err := s.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{
+			issue: issue,
+		}); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create activity after overriding deployment window").SetInternal(err)
+		}
+
+		// Rerun the check so that the result reflects the override.
+		skipIfAlreadyTerminated := false
+		taskUpdated, err := s.TaskCheckScheduler.ScheduleCheckIfNeeded(ctx, task, api.SystemBotID, skipIfAlreadyTerminated)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to run task check \"%v\"", task.Name)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, taskUpdated); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal override deployment window \"%v\" response", task.Name)).SetInternal(err)
+		}
+		return nil
+	})
+}
+
+// isDeploymentWindowTaskType returns true if the task type changes the database schema or data, which is restricted by the deployment window policy.
+func isDeploymentWindowTaskType(taskType api.TaskType) bool {
+	switch taskType {
+	case api.TaskDatabaseSchemaUpdate, api.TaskDatabaseDataUpdate, api.TaskDatabaseSchemaUpdateGhostSync, api.TaskDatabaseSchemaUpdateGhostCutover,
+		api.TaskDatabaseSchemaUpdatePgOnlineSync, api.TaskDatabaseSchemaUpdatePgOnlineCutover:
+		return true
+	}
+	return false
+}
+
+// deploymentWindowResult is the result of checking the task against the deployment window policy.
+type deploymentWindowResult struct {
+	allowed bool
+	// message explains why the task is allowed or not.
+	message string
+}
+
+// checkTaskDeploymentWindow checks if the task is allowed to start now by the deployment window policy of its environment.
+func (s *Server) checkTaskDeploymentWindow(ctx context.Context, task *api.Task) (*deploymentWindowResult, error) {
+	policy, err := s.store.GetDeploymentWindowPolicy(ctx, task.Instance.EnvironmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployment window policy for environment %d: %w", task.Instance.EnvironmentID, err)
+	}
+	now := time.Now()
+	next, err := getNextDeploymentTime(policy, now)
+	if err != nil {
+		return nil, err
+	}
+	if next.Equal(now) {
+		return &deploymentWindowResult{allowed: true, message: "Within the allowed deployment window"}, nil
+	}
+
+	overridden, err := s.isDeploymentWindowOverridden(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	if overridden {
+		return &deploymentWindowResult{allowed: true, message: "Deployment window policy is overridden by the workspace owner"}, nil
+	}
+
+	var reason string
+	if period := findFreezePeriod(policy, now); period != nil {
+		reason = fmt.Sprintf("In the freeze period until %s (UTC+0000)", time.Unix(period.EndTs, 0).UTC().Format(dataFormat))
+		if period.Reason != "" {
+			reason = fmt.Sprintf("%s: %s", reason, period.Reason)
+		}
+	} else {
+		reason = "Outside of the maintenance windows"
+	}
+	if next.IsZero() {
+		return &deploymentWindowResult{allowed: false, message: fmt.Sprintf("%s, and there is no upcoming deployment window", reason)}, nil
+	}
+	return &deploymentWindowResult{allowed: false, message: fmt.Sprintf("%s, the next deployment window opens at %s (UTC+0000)", reason, next.UTC().Format(dataFormat))}, nil
+}
+
+// isDeploymentWindowOverridden returns true if the workspace owner has overridden the deployment window policy for the task.
+func (s *Server) isDeploymentWindowOverridden(ctx context.Context, task *api.Task) (bool, error) {
+	issue, err := s.store.GetIssueByPipelineID(ctx, task.PipelineID)
+	if err != nil {
+		return false, fmt.Errorf("failed to find issue by pipeline ID %d: %w", task.PipelineID, err)
+	}
+	if issue == nil {
+		return false, nil
+	}
+	typePrefix := string(api.ActivityPipelineTaskDeploymentWindowOverride)
+	activityList, err := s.store.FindActivity(ctx, &api.ActivityFind{
+		ContainerID: &issue.ID,
+		TypePrefix:  &typePrefix,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to find activities of issue %d: %w", issue.ID, err)
+	}
+	for _, activity := range activityList {
+		payload := &api.ActivityPipelineTaskDeploymentWindowOverridePayload{}
+		if err := json.Unmarshal([]byte(activity.Payload), payload); err != nil {
+			return false, fmt.Errorf("failed to unmarshal activity %d payload: %w", activity.ID, err)
+		}
+		if payload.TaskID == task.ID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// getNextDeploymentTime returns the earliest time at or after now allowed by the deployment window policy.
+// Returns now if the deployment is allowed now, or the zero time if there is no upcoming deployment window.
+func getNextDeploymentTime(policy *api.DeploymentWindowPolicy, now time.Time) (time.Time, error) {
+	t := now
+	for i := 0; i < maxDeploymentWindowLookupCount; i++ {
+		if period := findFreezePeriod(policy, t); period != nil {
+			t = time.Unix(period.EndTs, 0)
+			continue
+		}
+		if len(policy.MaintenanceWindowList) == 0 {
+			return t, nil
+		}
+		in, next, err := lookupMaintenanceWindow(policy.MaintenanceWindowList, t)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if in {
+			return t, nil
+		}
+		if next.IsZero() {
+			return time.Time{}, nil
+		}
+		t = next
+	}
+	return time.Time{}, nil
+}
+
+// findFreezePeriod returns the freeze period covering t, or nil if none.
+func findFreezePeriod(policy *api.DeploymentWindowPolicy, t time.Time) *api.FreezePeriod {
+	for _, period := range policy.FreezePeriodList {
+		if !t.Before(time.Unix(period.StartTs, 0)) && t.Before(time.Unix(period.EndTs, 0)) {
+			return period
+		}
+	}
+	return nil
+}
+
+// lookupMaintenanceWindow returns whether t is within any of the maintenance windows, and the earliest window start after t.
+func lookupMaintenanceWindow(windowList []*api.MaintenanceWindow, t time.Time) (bool, time.Time, error) {
+	var next time.Time
+	for _, window := range windowList {
+		schedule, err := common.ParseCron(window.Cron)
+		if err != nil {
+			return false, time.Time{}, err
+		}
+		loc, err := window.GetLocation()
+		if err != nil {
+			return false, time.Time{}, err
+		}
+		local := t.In(loc)
+		duration := time.Duration(window.DurationMinutes) * time.Minute
+		// The latest window start in (t - duration, t] covers t.
+		if start := schedule.Next(local.Add(-duration)); !start.IsZero() && !start.After(local) {
+			return true, time.Time{}, nil
+		}
+		if start := schedule.Next(local); !start.IsZero() && (next.IsZero() || start.Before(next)) {
+			next = start
+		}
+	}
+	return false, next, nil
+}
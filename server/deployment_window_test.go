@@ -0,0 +1,89 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetNextDeploymentTime(t *testing.T) {
+	// 22:00-24:00 on weekdays in UTC+08:00, i.e. 14:00-16:00 UTC.
+	window := &api.MaintenanceWindow{
+		Cron:            "0 22 * * 1-5",
+		DurationMinutes: 120,
+		Timezone:        "Asia/Shanghai",
+	}
+	wednesday := func(hour, min int) time.Time {
+		return time.Date(2022, 7, 6, hour, min, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name   string
+		policy *api.DeploymentWindowPolicy
+		now    time.Time
+		want   time.Time
+	}{
+		{
+			name:   "empty policy",
+			policy: &api.DeploymentWindowPolicy{},
+			now:    wednesday(10, 0),
+			want:   wednesday(10, 0),
+		},
+		{
+			name: "before maintenance window",
+			policy: &api.DeploymentWindowPolicy{
+				MaintenanceWindowList: []*api.MaintenanceWindow{window},
+			},
+			now:  wednesday(10, 0),
+			want: wednesday(14, 0),
+		},
+		{
+			name: "within maintenance window",
+			policy: &api.DeploymentWindowPolicy{
+				MaintenanceWindowList: []*api.MaintenanceWindow{window},
+			},
+			now:  wednesday(15, 30),
+			want: wednesday(15, 30),
+		},
+		{
+			name: "after maintenance window",
+			policy: &api.DeploymentWindowPolicy{
+				MaintenanceWindowList: []*api.MaintenanceWindow{window},
+			},
+			now:  wednesday(16, 0),
+			want: time.Date(2022, 7, 7, 14, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "in freeze period",
+			policy: &api.DeploymentWindowPolicy{
+				FreezePeriodList: []*api.FreezePeriod{
+					{StartTs: wednesday(0, 0).Unix(), EndTs: wednesday(12, 0).Unix()},
+				},
+			},
+			now:  wednesday(10, 0),
+			want: wednesday(12, 0),
+		},
+		{
+			name: "freeze period covers the next maintenance windows",
+			policy: &api.DeploymentWindowPolicy{
+				MaintenanceWindowList: []*api.MaintenanceWindow{window},
+				FreezePeriodList: []*api.FreezePeriod{
+					{StartTs: wednesday(0, 0).Unix(), EndTs: wednesday(15, 0).Unix()},
+					{StartTs: wednesday(15, 0).Unix(), EndTs: time.Date(2022, 7, 11, 0, 0, 0, 0, time.UTC).Unix()},
+				},
+			},
+			now: wednesday(10, 0),
+			// The windows on Thursday and Friday are frozen, and there is no window on the weekend.
+			want: time.Date(2022, 7, 11, 14, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, test := range tests {
+		got, err := getNextDeploymentTime(test.policy, test.now)
+		require.NoError(t, err, test.name)
+		assert.True(t, test.want.Equal(got), "%s: want %v, got %v", test.name, test.want, got)
+	}
+}
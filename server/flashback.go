@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/db/mysql"
+)
+
+// getFlashbackStatement downloads the binlog files of the instance, and generates the statement reverting
+// the row changes of the tables in the database in [startTs, endTs).
+func (s *Server) getFlashbackStatement(ctx context.Context, database *api.Database, tableList []string, startTs, endTs int64) (string, error) {
+	if database.Instance.Engine != db.MySQL {
+		return "", common.Errorf(common.Invalid, fmt.Errorf("flashback is not supported for %s", database.Instance.Engine))
+	}
+	if len(tableList) == 0 {
+		return "", common.Errorf(common.Invalid, fmt.Errorf("no table to flashback"))
+	}
+	if startTs >= endTs {
+		return "", common.Errorf(common.Invalid, fmt.Errorf("the start time must be before the end time"))
+	}
+	if endTs > time.Now().Unix() {
+		return "", common.Errorf(common.Invalid, fmt.Errorf("the end time must not be in the future"))
+	}
+
+	driver, err := getAdminDatabaseDriver(ctx, database.Instance, "", "" /* pgInstanceDir */)
+	if err != nil {
+		return "", err
+	}
+	defer driver.Close(ctx)
+	mysqlDriver, ok := driver.(*mysql.Driver)
+	if !ok {
+		return "", fmt.Errorf("[internal] cast driver to mysql.Driver failed")
+	}
+	if err := mysqlDriver.CheckBinlogEnabled(ctx); err != nil {
+		return "", common.Errorf(common.Invalid, err)
+	}
+	if err := mysqlDriver.CheckBinlogRowFormat(ctx); err != nil {
+		return "", common.Errorf(common.Invalid, err)
+	}
+	if err := mysqlDriver.CheckBinlogRowImageFull(ctx); err != nil {
+		return "", common.Errorf(common.Invalid, err)
+	}
+
+	if err := createBinlogDir(s.profile.DataDir, database.Instance.ID); err != nil {
+		return "", err
+	}
+	mysqlDriver.SetUpForPITR(s.mysqlutil, getBinlogAbsDir(s.profile.DataDir, database.Instance.ID))
+	if err := mysqlDriver.FetchAllBinlogFiles(ctx); err != nil {
+		return "", fmt.Errorf("failed to download binlog files, error: %w", err)
+	}
+
+	statement, err := mysqlDriver.GenerateFlashbackStatement(ctx, database.Name, tableList, startTs, endTs)
+	if err != nil {
+		return "", common.Errorf(common.Invalid, err)
+	}
+	if statement == "" {
+		return "", common.Errorf(common.Invalid, fmt.Errorf("no row change found in the tables between %s and %s",
+			time.Unix(startTs, 0).Format(time.RFC822), time.Unix(endTs, 0).Format(time.RFC822)))
+	}
+	return statement, nil
+}
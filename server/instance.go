@@ -30,6 +30,9 @@ func (s *Server) registerInstanceRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusBadRequest, "Malformed create instance request").SetInternal(err)
 		}
 		instanceCreate.CreatorID = c.Get(getPrincipalIDContextKey()).(int)
+		if instanceCreate.MaxConcurrentTask < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid max concurrent task %d, must not be negative", instanceCreate.MaxConcurrentTask))
+		}
 		if err := s.disallowBytebaseStore(instanceCreate.Engine, instanceCreate.Host, instanceCreate.Port); err != nil {
 			return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 		}
@@ -121,6 +124,9 @@ func (s *Server) registerInstanceRoutes(g *echo.Group) {
 		if err := jsonapi.UnmarshalPayload(c.Request().Body, instancePatch); err != nil {
 			return echo.NewHTTPError(http.StatusBadRequest, "Malformed patch instance request").SetInternal(err)
 		}
+		if v := instancePatch.MaxConcurrentTask; v != nil && *v < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid max concurrent task %d, must not be negative", *v))
+		}
 
 		instance, err := s.store.GetInstanceByID(ctx, id)
 		if err != nil {
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/jsonapi"
 	"github.com/labstack/echo/v4"
@@ -15,6 +16,8 @@ import (
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/common"
 	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/db/mysql"
+	"github.com/bytebase/bytebase/plugin/db/pg"
 	"github.com/bytebase/bytebase/plugin/vcs"
 )
 
@@ -98,6 +101,11 @@ func (s *Server) registerIssueRoutes(g *echo.Group) {
 		if issue == nil {
 			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Issue ID not found: %d", id))
 		}
+		if issue.Pipeline != nil {
+			for _, stage := range issue.Pipeline.StageList {
+				s.setTaskQueuePosition(stage.TaskList...)
+			}
+		}
 
 		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
 		if err := jsonapi.MarshalPayload(c.Response().Writer, issue); err != nil {
@@ -271,6 +279,8 @@ func (s *Server) createIssue(ctx context.Context, issueCreate *api.IssueCreate,
 		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Cannot set assignee with user id %d", issueCreate.AssigneeID)).SetInternal(err)
 	}
 
+	// The creator is required to check the access to the databases in the pipeline.
+	issueCreate.CreatorID = creatorID
 	// If frontend does not pass the stageList, we will generate it from backend.
 	pipeline, err := s.createPipelineFromIssue(ctx, issueCreate, creatorID, issueCreate.ValidateOnly)
 	if err != nil {
@@ -285,7 +295,6 @@ func (s *Server) createIssue(ctx context.Context, issueCreate *api.IssueCreate,
 		return issue, nil
 	}
 
-	issueCreate.CreatorID = creatorID
 	issueCreate.PipelineID = pipeline.ID
 	issue, err := s.store.CreateIssue(ctx, issueCreate)
 	if err != nil {
@@ -439,6 +448,34 @@ func (s *Server) getPipelineCreate(ctx context.Context, issueCreate *api.IssueCr
 			return nil, echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 		}
 
+		var backup *api.Backup
+		if c.BackupID != 0 {
+			backup, err = s.store.GetBackupByID(ctx, c.BackupID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to find backup %v", c.BackupID)
+			}
+			if backup == nil {
+				return nil, fmt.Errorf("backup not found with ID %d", c.BackupID)
+			}
+			if backup.Status == api.BackupStatusPruned {
+				return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Backup %q has been pruned by the backup retention policy", backup.Name))
+			}
+			sourceDatabase, err := s.store.GetDatabase(ctx, &api.DatabaseFind{ID: &backup.DatabaseID})
+			if err != nil {
+				return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch database ID: %v", backup.DatabaseID)).SetInternal(err)
+			}
+			if sourceDatabase == nil {
+				return nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Database ID not found: %d", backup.DatabaseID))
+			}
+			// The backup can be restored to another instance, e.g. for a disaster recovery drill.
+			if sourceDatabase.InstanceID != instance.ID {
+				if err := checkRestoreCompatibility(sourceDatabase.Instance.Engine, sourceDatabase.Instance.EngineVersion, instance.Engine, instance.EngineVersion); err != nil {
+					return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Failed to create issue, %v", err))
+				}
+			}
+			c.CharacterSet, c.Collation = getRestoreCharsetAndCollation(sourceDatabase, instance, c.CharacterSet, c.Collation)
+		}
+
 		switch instance.Engine {
 		case db.ClickHouse:
 			// ClickHouse does not support character set and collation at the database level.
@@ -535,14 +572,7 @@ func (s *Server) getPipelineCreate(ctx context.Context, issueCreate *api.IssueCr
 			return nil, err
 		}
 
-		if c.BackupID != 0 {
-			backup, err := s.store.GetBackupByID(ctx, c.BackupID)
-			if err != nil {
-				return nil, fmt.Errorf("failed to find backup %v", c.BackupID)
-			}
-			if backup == nil {
-				return nil, fmt.Errorf("backup not found with ID %d", c.BackupID)
-			}
+		if backup != nil {
 			restorePayload := api.TaskDatabaseRestorePayload{}
 			restorePayload.DatabaseName = c.DatabaseName
 			restorePayload.BackupID = c.BackupID
@@ -624,6 +654,15 @@ func (s *Server) getPipelineCreate(ctx context.Context, issueCreate *api.IssueCr
 			return nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Database ID not found: %d", c.DatabaseID))
 		}
 
+		// The binlog after the window is downloaded on demand when restoring, but the one before the window has been purged.
+		window, err := s.getPITRWindow(ctx, database)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to get PITR window for database %q", database.Name)).SetInternal(err)
+		}
+		if window.EarliestTs > 0 && c.PointInTimeTs < window.EarliestTs {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("The earliest restorable time of database %q is %s", database.Name, time.Unix(window.EarliestTs, 0).Format(time.RFC822)))
+		}
+
 		taskStatus, err := s.getPipelineApprovalPolicyForEnv(ctx, database.Instance.EnvironmentID)
 		if err != nil {
 			return nil, err
@@ -646,6 +685,192 @@ func (s *Server) getPipelineCreate(ctx context.Context, issueCreate *api.IssueCr
 			},
 		}, nil
 
+	case api.IssueDatabaseFlashback:
+		if !s.feature(api.FeaturePITR) {
+			return nil, echo.NewHTTPError(http.StatusForbidden, api.FeaturePITR.AccessErrorMessage())
+		}
+		c := api.FlashbackContext{}
+		if err := json.Unmarshal([]byte(issueCreate.CreateContext), &c); err != nil {
+			return nil, err
+		}
+
+		database, err := s.store.GetDatabase(ctx, &api.DatabaseFind{ID: &c.DatabaseID})
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch database ID: %v", c.DatabaseID)).SetInternal(err)
+		}
+		if database == nil {
+			return nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Database ID not found: %d", c.DatabaseID))
+		}
+
+		// The generated statement is stored in the data update task, so that it is reviewed before execution.
+		statement, err := s.getFlashbackStatement(ctx, database, c.TableList, c.StartTs, c.EndTs)
+		if err != nil {
+			if common.ErrorCode(err) == common.Invalid {
+				return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to generate flashback statement for database %q", database.Name)).SetInternal(err)
+		}
+
+		taskStatus, err := s.getPipelineApprovalPolicyForEnv(ctx, database.Instance.EnvironmentID)
+		if err != nil {
+			return nil, err
+		}
+		detail := &api.UpdateSchemaDetail{
+			DatabaseID: database.ID,
+			Statement:  statement,
+		}
+		taskCreate, err := getUpdateTask(database, db.Data, nil /* vcsPushEvent */, detail, common.DefaultMigrationVersion(), taskStatus, nil /* rolloutWave */)
+		if err != nil {
+			return nil, err
+		}
+		taskCreate.Name = fmt.Sprintf("Flashback %q data", database.Name)
+
+		return &api.PipelineCreate{
+			Name: "Database flashback pipeline",
+			StageList: []api.StageCreate{
+				{
+					Name:          "Flashback",
+					EnvironmentID: database.Instance.Environment.ID,
+					TaskList:      []api.TaskCreate{*taskCreate},
+				},
+			},
+		}, nil
+
+	case api.IssueDatabaseClone:
+		c := api.CloneDatabaseContext{}
+		if err := json.Unmarshal([]byte(issueCreate.CreateContext), &c); err != nil {
+			return nil, err
+		}
+		if c.DatabaseName == "" {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "Failed to create issue, database name missing")
+		}
+
+		sourceDatabase, err := s.store.GetDatabase(ctx, &api.DatabaseFind{ID: &c.SourceDatabaseID})
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch database ID: %v", c.SourceDatabaseID)).SetInternal(err)
+		}
+		if sourceDatabase == nil {
+			return nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Database ID not found: %d", c.SourceDatabaseID))
+		}
+		// The clone copies the data of the source database, so the creator must have access to it,
+		// either through the project of the issue or the membership of the project of the source database.
+		if sourceDatabase.ProjectID != issueCreate.ProjectID {
+			isMember := false
+			for _, projectMember := range sourceDatabase.Project.ProjectMemberList {
+				if projectMember.PrincipalID == issueCreate.CreatorID {
+					isMember = true
+					break
+				}
+			}
+			if !isMember {
+				return nil, echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("Failed to create issue, database %q belongs to project %q, which the creator is not a member of", sourceDatabase.Name, sourceDatabase.Project.Name))
+			}
+		}
+		instance, err := s.store.GetInstanceByID(ctx, c.InstanceID)
+		if err != nil {
+			return nil, err
+		}
+		if instance == nil {
+			return nil, fmt.Errorf("instance ID not found %v", c.InstanceID)
+		}
+		project, err := s.store.GetProjectByID(ctx, issueCreate.ProjectID)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch project with ID %d", issueCreate.ProjectID)).SetInternal(err)
+		}
+		if project == nil {
+			err := fmt.Errorf("project ID not found %v", issueCreate.ProjectID)
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
+		}
+		for _, engine := range []db.Type{sourceDatabase.Instance.Engine, instance.Engine} {
+			if engine != db.MySQL && engine != db.TiDB {
+				return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Failed to create issue, only MySQL and TiDB are supported for cloning database for now, got %s", engine))
+			}
+		}
+
+		// The database is created with the character set and collation of the source database by default.
+		if c.CharacterSet == "" {
+			c.CharacterSet = sourceDatabase.CharacterSet
+		}
+		if c.Collation == "" {
+			c.Collation = sourceDatabase.Collation
+		}
+		if c.Labels != "" {
+			if err := s.setDatabaseLabels(ctx, c.Labels, &api.Database{Name: c.DatabaseName, Instance: instance} /* dummy database */, project, 0 /* dummy updaterID */, true /* validateOnly */); err != nil {
+				return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid database label %q, error %v", c.Labels, err))
+			}
+		}
+		if err := s.validateCloneConfig(ctx, sourceDatabase, c.Config); err != nil {
+			if common.ErrorCode(err) == common.Invalid {
+				return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to validate the clone config of database %q", sourceDatabase.Name)).SetInternal(err)
+		}
+
+		payload := api.TaskDatabaseCreatePayload{
+			ProjectID:     issueCreate.ProjectID,
+			CharacterSet:  c.CharacterSet,
+			Collation:     c.Collation,
+			Labels:        c.Labels,
+			SchemaVersion: common.DefaultMigrationVersion(),
+		}
+		payload.DatabaseName, payload.Statement = getDatabaseNameAndStatement(instance.Engine, api.CreateDatabaseContext{
+			DatabaseName: c.DatabaseName,
+			CharacterSet: c.CharacterSet,
+			Collation:    c.Collation,
+		}, "" /* schema */)
+		bytes, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create database creation task, unable to marshal payload %w", err)
+		}
+		clonePayload := api.TaskDatabaseClonePayload{
+			SourceDatabaseID: sourceDatabase.ID,
+			DatabaseName:     payload.DatabaseName,
+			Config:           c.Config,
+		}
+		cloneBytes, err := json.Marshal(clonePayload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create clone database task, unable to marshal payload %w", err)
+		}
+
+		taskStatus, err := s.getPipelineApprovalPolicyForEnv(ctx, instance.EnvironmentID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &api.PipelineCreate{
+			Name: fmt.Sprintf("Pipeline - Clone database %v from database %v", payload.DatabaseName, sourceDatabase.Name),
+			StageList: []api.StageCreate{
+				{
+					Name:          "Create database",
+					EnvironmentID: instance.EnvironmentID,
+					TaskList: []api.TaskCreate{
+						{
+							InstanceID:   c.InstanceID,
+							Name:         fmt.Sprintf("Create database %v", payload.DatabaseName),
+							Status:       taskStatus,
+							Type:         api.TaskDatabaseCreate,
+							DatabaseName: payload.DatabaseName,
+							Payload:      string(bytes),
+						},
+					},
+				},
+				{
+					Name:          "Clone database",
+					EnvironmentID: instance.EnvironmentID,
+					TaskList: []api.TaskCreate{
+						{
+							InstanceID:   c.InstanceID,
+							Name:         fmt.Sprintf("Clone database %v", sourceDatabase.Name),
+							Status:       api.TaskPending,
+							Type:         api.TaskDatabaseClone,
+							DatabaseName: payload.DatabaseName,
+							Payload:      string(cloneBytes),
+						},
+					},
+				},
+			},
+		}, nil
+
 	case api.IssueDatabaseSchemaUpdate, api.IssueDatabaseDataUpdate:
 		c := api.UpdateSchemaContext{}
 		if err := json.Unmarshal([]byte(issueCreate.CreateContext), &c); err != nil {
@@ -709,7 +934,7 @@ func (s *Server) getPipelineCreate(ctx context.Context, issueCreate *api.IssueCr
 					return nil, err
 				}
 
-				taskCreate, err := getUpdateTask(database, c.MigrationType, c.VCSPushEvent, d, schemaVersion, taskStatus)
+				taskCreate, err := getUpdateTask(database, c.MigrationType, c.VCSPushEvent, d, schemaVersion, taskStatus, nil /* rolloutWave */)
 				if err != nil {
 					return nil, err
 				}
@@ -738,7 +963,9 @@ func (s *Server) getPipelineCreate(ctx context.Context, issueCreate *api.IssueCr
 					environmentSet := make(map[string]bool)
 					var environmentID int
 					var taskCreateList []api.TaskCreate
-					for _, database := range databaseList {
+					// Databases are assigned to the rollout waves in the stage order.
+					rolloutWaveList := getTaskRolloutWaveList(deployments[i].Spec.Rollout, len(databaseList))
+					for j, database := range databaseList {
 						environmentSet[database.Instance.Environment.Name] = true
 						environmentID = database.Instance.EnvironmentID
 
@@ -747,7 +974,11 @@ func (s *Server) getPipelineCreate(ctx context.Context, issueCreate *api.IssueCr
 							return nil, err
 						}
 
-						taskCreate, err := getUpdateTask(database, c.MigrationType, c.VCSPushEvent, d, schemaVersion, taskStatus)
+						var rolloutWave *api.TaskRolloutWave
+						if rolloutWaveList != nil {
+							rolloutWave = rolloutWaveList[j]
+						}
+						taskCreate, err := getUpdateTask(database, c.MigrationType, c.VCSPushEvent, d, schemaVersion, taskStatus, rolloutWave)
 						if err != nil {
 							return nil, err
 						}
@@ -787,7 +1018,7 @@ func (s *Server) getPipelineCreate(ctx context.Context, issueCreate *api.IssueCr
 					return nil, err
 				}
 
-				taskCreate, err := getUpdateTask(database, c.MigrationType, c.VCSPushEvent, d, schemaVersion, taskStatus)
+				taskCreate, err := getUpdateTask(database, c.MigrationType, c.VCSPushEvent, d, schemaVersion, taskStatus, nil /* rolloutWave */)
 				if err != nil {
 					return nil, err
 				}
@@ -860,12 +1091,74 @@ func (s *Server) getPipelineCreate(ctx context.Context, issueCreate *api.IssueCr
 		}
 		return create, nil
 
+	case api.IssueDatabaseSchemaUpdatePgOnline:
+		if !s.feature(api.FeatureGhost) {
+			return nil, echo.NewHTTPError(http.StatusForbidden, api.FeatureGhost.AccessErrorMessage())
+		}
+		c := api.UpdateSchemaPgOnlineContext{}
+		if err := json.Unmarshal([]byte(issueCreate.CreateContext), &c); err != nil {
+			return nil, err
+		}
+		if !s.feature(api.FeatureTaskScheduleTime) {
+			for _, detail := range c.DetailList {
+				if detail.EarliestAllowedTs != 0 {
+					return nil, echo.NewHTTPError(http.StatusForbidden, api.FeatureTaskScheduleTime.AccessErrorMessage())
+				}
+			}
+		}
+
+		project, err := s.store.GetProjectByID(ctx, issueCreate.ProjectID)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to fetch project with ID %d", issueCreate.ProjectID)).SetInternal(err)
+		}
+		if project.TenantMode == api.TenantModeTenant {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "not implemented yet")
+		}
+
+		create := &api.PipelineCreate{}
+		create.Name = "Update database schema (online) pipeline"
+		schemaVersion := common.DefaultMigrationVersion()
+		for _, detail := range c.DetailList {
+			if detail.Statement == "" {
+				return nil, echo.NewHTTPError(http.StatusBadRequest, "failed to create issue, sql statement missing")
+			}
+
+			database, err := s.store.GetDatabase(ctx, &api.DatabaseFind{ID: &detail.DatabaseID})
+			if err != nil {
+				return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to fetch database ID: %v", detail.DatabaseID)).SetInternal(err)
+			}
+			if database == nil {
+				return nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("database ID not found: %d", detail.DatabaseID))
+			}
+			if database.Instance.Engine != db.Postgres {
+				return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("online schema change is only supported for Postgres, but database %q is %s", database.Name, database.Instance.Engine))
+			}
+
+			taskStatus, err := s.getPipelineApprovalPolicyForEnv(ctx, database.Instance.EnvironmentID)
+			if err != nil {
+				return nil, err
+			}
+
+			taskCreateList, taskIndexDAGList, err := createPgOnlineTaskList(database, c.VCSPushEvent, detail, schemaVersion, taskStatus)
+			if err != nil {
+				return nil, err
+			}
+
+			create.StageList = append(create.StageList, api.StageCreate{
+				Name:             fmt.Sprintf("%s %s", database.Instance.Environment.Name, database.Name),
+				EnvironmentID:    database.Instance.Environment.ID,
+				TaskList:         taskCreateList,
+				TaskIndexDAGList: taskIndexDAGList,
+			})
+		}
+		return create, nil
+
 	default:
 		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid issue type %q", issueCreate.Type))
 	}
 }
 
-func getUpdateTask(database *api.Database, migrationType db.MigrationType, vcsPushEvent *vcs.PushEvent, d *api.UpdateSchemaDetail, schemaVersion string, taskStatus api.TaskStatus) (*api.TaskCreate, error) {
+func getUpdateTask(database *api.Database, migrationType db.MigrationType, vcsPushEvent *vcs.PushEvent, d *api.UpdateSchemaDetail, schemaVersion string, taskStatus api.TaskStatus, rolloutWave *api.TaskRolloutWave) (*api.TaskCreate, error) {
 	taskName := fmt.Sprintf("Establish %q baseline", database.Name)
 	switch migrationType {
 	case db.Migrate:
@@ -873,12 +1166,37 @@ func getUpdateTask(database *api.Database, migrationType db.MigrationType, vcsPu
 	case db.Data:
 		taskName = fmt.Sprintf("Update %q data", database.Name)
 	}
-	payload := api.TaskDatabaseSchemaUpdatePayload{}
-	payload.MigrationType = migrationType
-	payload.Statement = d.Statement
-	payload.SchemaVersion = schemaVersion
-	if vcsPushEvent != nil {
-		payload.VCSPushEvent = vcsPushEvent
+	var payload interface{}
+	if migrationType == db.Data {
+		if d.Batch != nil {
+			if err := d.Batch.Validate(database.Instance.Engine); err != nil {
+				return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid batch config for database %q: %v", database.Name, err))
+			}
+			if _, err := getBatchStatement(database.Instance.Engine, d.Statement, d.Batch, 0, d.Batch.ChunkSize); err != nil {
+				return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid statement to run in batches for database %q: %v", database.Name, err))
+			}
+		}
+		payload = api.TaskDatabaseDataUpdatePayload{
+			Statement:     d.Statement,
+			SchemaVersion: schemaVersion,
+			VCSPushEvent:  vcsPushEvent,
+			RolloutWave:   rolloutWave,
+			Batch:         d.Batch,
+		}
+	} else {
+		schemaUpdatePayload := api.TaskDatabaseSchemaUpdatePayload{}
+		schemaUpdatePayload.MigrationType = migrationType
+		schemaUpdatePayload.Statement = d.Statement
+		schemaUpdatePayload.SchemaVersion = schemaVersion
+		if vcsPushEvent != nil {
+			schemaUpdatePayload.VCSPushEvent = vcsPushEvent
+		}
+		schemaUpdatePayload.RolloutWave = rolloutWave
+		if migrationType == db.Migrate {
+			schemaUpdatePayload.VerificationStatementList = d.VerificationStatementList
+			schemaUpdatePayload.ExpectedSchema = d.ExpectedSchema
+		}
+		payload = schemaUpdatePayload
 	}
 	bytes, err := json.Marshal(payload)
 	if err != nil {
@@ -954,12 +1272,17 @@ func createPITRTaskList(database *api.Database, projectID int, taskStatus api.Ta
 
 // creates gh-ost TaskCreate list and dependency
 func createGhostTaskList(database *api.Database, vcsPushEvent *vcs.PushEvent, detail *api.UpdateSchemaGhostDetail, schemaVersion string, taskStatus api.TaskStatus) ([]api.TaskCreate, []api.TaskIndexDAG, error) {
+	if err := validateGhostConfig(detail.Config); err != nil {
+		return nil, nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid gh-ost config for database %q: %v", database.Name, err))
+	}
+
 	var taskCreateList []api.TaskCreate
 	// task "sync"
 	payloadSync := api.TaskDatabaseSchemaUpdateGhostSyncPayload{
 		Statement:     detail.Statement,
 		SchemaVersion: schemaVersion,
 		VCSPushEvent:  vcsPushEvent,
+		Config:        detail.Config,
 	}
 	bytesSync, err := json.Marshal(payloadSync)
 	if err != nil {
@@ -1001,6 +1324,117 @@ func createGhostTaskList(database *api.Database, vcsPushEvent *vcs.PushEvent, de
 	return taskCreateList, taskIndexDAGList, nil
 }
 
+// createPgOnlineTaskList creates the sync task running the online schema update, and the cutover task if a table is rewritten
+// through a shadow table.
+func createPgOnlineTaskList(database *api.Database, vcsPushEvent *vcs.PushEvent, detail *api.UpdateSchemaPgOnlineDetail, schemaVersion string, taskStatus api.TaskStatus) ([]api.TaskCreate, []api.TaskIndexDAG, error) {
+	plan, err := pg.GetOnlineMigrationPlan(detail.Statement)
+	if err != nil {
+		return nil, nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid statement for online schema change of database %q: %v", database.Name, err))
+	}
+	if detail.BatchSize < 0 {
+		return nil, nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid batch size %d for database %q", detail.BatchSize, database.Name))
+	}
+
+	var taskCreateList []api.TaskCreate
+	// task "sync"
+	payloadSync := api.TaskDatabaseSchemaUpdatePgOnlineSyncPayload{
+		Statement:     detail.Statement,
+		SchemaVersion: schemaVersion,
+		VCSPushEvent:  vcsPushEvent,
+		BatchSize:     detail.BatchSize,
+	}
+	bytesSync, err := json.Marshal(payloadSync)
+	if err != nil {
+		return nil, nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to marshal database schema update online sync payload, error: %v", err))
+	}
+	taskCreateList = append(taskCreateList, api.TaskCreate{
+		Name:              fmt.Sprintf("Update %q schema online", database.Name),
+		InstanceID:        database.InstanceID,
+		DatabaseID:        &database.ID,
+		Status:            taskStatus,
+		Type:              api.TaskDatabaseSchemaUpdatePgOnlineSync,
+		Statement:         detail.Statement,
+		EarliestAllowedTs: detail.EarliestAllowedTs,
+		MigrationType:     db.Migrate,
+		Payload:           string(bytesSync),
+	})
+	if plan.ShadowTable == nil {
+		return taskCreateList, nil, nil
+	}
+
+	// task "cutover"
+	payloadCutover := api.TaskDatabaseSchemaUpdatePgOnlineCutoverPayload{}
+	bytesCutover, err := json.Marshal(payloadCutover)
+	if err != nil {
+		return nil, nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to marshal database schema update online cutover payload, error: %v", err))
+	}
+	taskCreateList = append(taskCreateList, api.TaskCreate{
+		Name:              fmt.Sprintf("Update %q schema online cutover", database.Name),
+		InstanceID:        database.InstanceID,
+		DatabaseID:        &database.ID,
+		Status:            taskStatus,
+		Type:              api.TaskDatabaseSchemaUpdatePgOnlineCutover,
+		EarliestAllowedTs: detail.EarliestAllowedTs,
+		Payload:           string(bytesCutover),
+	})
+
+	// Task "sync" blocks task "cutover".
+	taskIndexDAGList := []api.TaskIndexDAG{
+		{FromIndex: 0, ToIndex: 1},
+	}
+	return taskCreateList, taskIndexDAGList, nil
+}
+
+// validateCloneConfig validates the row filters and the masking of the clone config against the synced schema
+// of the source database. The column types are validated again against the live schema upon cloning.
+func (s *Server) validateCloneConfig(ctx context.Context, database *api.Database, config api.CloneConfig) error {
+	if config.SchemaOnly && (len(config.TableFilterList) > 0 || len(config.MaskingList) > 0) {
+		return common.Errorf(common.Invalid, fmt.Errorf("row filters and masking are not applicable to cloning the schema only"))
+	}
+	findTable := func(name string) (*api.Table, error) {
+		tableList, err := s.store.FindTable(ctx, &api.TableFind{DatabaseID: &database.ID, Name: &name})
+		if err != nil {
+			return nil, err
+		}
+		if len(tableList) == 0 {
+			return nil, common.Errorf(common.Invalid, fmt.Errorf("table %q not found in database %q", name, database.Name))
+		}
+		return tableList[0], nil
+	}
+
+	for _, filter := range config.TableFilterList {
+		if filter.Where == "" {
+			return common.Errorf(common.Invalid, fmt.Errorf("row filter of table %q is empty", filter.Table))
+		}
+		// The row filter is evaluated on the source database, so it must be a single expression without side effects.
+		if _, err := mysql.GetCloneRowFilter(filter.Where); err != nil {
+			return common.Errorf(common.Invalid, fmt.Errorf("invalid row filter of table %q, error: %w", filter.Table, err))
+		}
+		if _, err := findTable(filter.Table); err != nil {
+			return err
+		}
+	}
+	for _, masking := range config.MaskingList {
+		switch masking.Type {
+		case api.CloneMaskingNull, api.CloneMaskingHash, api.CloneMaskingRedact:
+		default:
+			return common.Errorf(common.Invalid, fmt.Errorf("unsupported masking type %q", masking.Type))
+		}
+		table, err := findTable(masking.Table)
+		if err != nil {
+			return err
+		}
+		columnList, err := s.store.FindColumn(ctx, &api.ColumnFind{DatabaseID: &database.ID, TableID: &table.ID, Name: &masking.Column})
+		if err != nil {
+			return err
+		}
+		if len(columnList) == 0 {
+			return common.Errorf(common.Invalid, fmt.Errorf("column %q not found in table %q", masking.Column, masking.Table))
+		}
+	}
+	return nil
+}
+
 func getDatabaseNameAndStatement(dbType db.Type, createDatabaseContext api.CreateDatabaseContext, schema string) (string, string) {
 	databaseName := createDatabaseContext.DatabaseName
 	// Snowflake needs to use upper case of DatabaseName.
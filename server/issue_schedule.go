@@ -0,0 +1,303 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/jsonapi"
+	"github.com/labstack/echo/v4"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+)
+
+func (s *Server) registerIssueScheduleRoutes(g *echo.Group) {
+	g.GET("/project/:projectID/issue-schedule", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		projectID, err := strconv.Atoi(c.Param("projectID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Project ID is not a number: %s", c.Param("projectID"))).SetInternal(err)
+		}
+
+		find := &api.IssueScheduleFind{
+			ProjectID: &projectID,
+		}
+		if rowStatusStr := c.QueryParam("rowstatus"); rowStatusStr != "" {
+			rowStatus := api.RowStatus(rowStatusStr)
+			find.RowStatus = &rowStatus
+		}
+		scheduleList, err := s.store.FindIssueSchedule(ctx, find)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch issue schedule list for project ID: %d", projectID)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, scheduleList); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal issue schedule list response for project ID: %d", projectID)).SetInternal(err)
+		}
+		return nil
+	})
+
+	g.POST("/project/:projectID/issue-schedule", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		projectID, err := strconv.Atoi(c.Param("projectID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Project ID is not a number: %s", c.Param("projectID"))).SetInternal(err)
+		}
+
+		scheduleCreate := &api.IssueScheduleCreate{
+			CreatorID: c.Get(getPrincipalIDContextKey()).(int),
+			ProjectID: projectID,
+		}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, scheduleCreate); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformed create issue schedule request").SetInternal(err)
+		}
+		if scheduleCreate.Name == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "Failed to create issue schedule, name missing")
+		}
+
+		nextRunTime, err := api.GetIssueScheduleNextRunTime(scheduleCreate.Cron, scheduleCreate.Timezone, time.Now())
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+		}
+		scheduleCreate.NextRunTs = nextRunTime.Unix()
+
+		schedule := &api.IssueSchedule{
+			ProjectID:     projectID,
+			AssigneeID:    scheduleCreate.AssigneeID,
+			Name:          scheduleCreate.Name,
+			Description:   scheduleCreate.Description,
+			Timezone:      scheduleCreate.Timezone,
+			IssueType:     scheduleCreate.IssueType,
+			CreateContext: scheduleCreate.CreateContext,
+			NextRunTs:     scheduleCreate.NextRunTs,
+		}
+		if err := s.validateIssueSchedule(ctx, schedule, scheduleCreate.CreatorID); err != nil {
+			return err
+		}
+
+		schedule, err = s.store.CreateIssueSchedule(ctx, scheduleCreate)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create issue schedule").SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, schedule); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal create issue schedule response").SetInternal(err)
+		}
+		return nil
+	})
+
+	g.GET("/project/:projectID/issue-schedule/:scheduleID", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		schedule, err := s.getIssueScheduleFromParam(ctx, c)
+		if err != nil {
+			return err
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, schedule); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal issue schedule ID response: %v", schedule.ID)).SetInternal(err)
+		}
+		return nil
+	})
+
+	g.PATCH("/project/:projectID/issue-schedule/:scheduleID", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		schedule, err := s.getIssueScheduleFromParam(ctx, c)
+		if err != nil {
+			return err
+		}
+
+		schedulePatch := &api.IssueSchedulePatch{
+			ID:        schedule.ID,
+			UpdaterID: c.Get(getPrincipalIDContextKey()).(int),
+		}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, schedulePatch); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformed patch issue schedule request").SetInternal(err)
+		}
+
+		ok, err := s.canEditIssueSchedule(ctx, schedule, schedulePatch.UpdaterID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to check the permission to patch issue schedule ID: %v", schedule.ID)).SetInternal(err)
+		}
+		if !ok {
+			return echo.NewHTTPError(http.StatusForbidden, "Only the creator and the project owners can update the issue schedule")
+		}
+
+		if v := schedulePatch.RowStatus; v != nil && api.RowStatus(*v) != api.Normal && api.RowStatus(*v) != api.Archived {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid row status %q", *v))
+		}
+		if v := schedulePatch.AssigneeID; v != nil {
+			schedule.AssigneeID = *v
+		}
+		if v := schedulePatch.Cron; v != nil {
+			schedule.Cron = *v
+		}
+		if v := schedulePatch.Timezone; v != nil {
+			schedule.Timezone = *v
+		}
+		if v := schedulePatch.CreateContext; v != nil {
+			// The edited statement isn't trusted to be auto approved until an issue created with it is done.
+			if *v != schedule.CreateContext {
+				statementUpdatedTs := time.Now().Unix()
+				schedulePatch.StatementUpdatedTs = &statementUpdatedTs
+			}
+			schedule.CreateContext = *v
+		}
+		// Restart the schedule from now on when the schedule changes or the schedule is restored,
+		// so that the runs missed while archived aren't caught up.
+		if schedulePatch.Cron != nil || schedulePatch.Timezone != nil || schedulePatch.RowStatus != nil {
+			nextRunTime, err := api.GetIssueScheduleNextRunTime(schedule.Cron, schedule.Timezone, time.Now())
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+			}
+			nextRunTs := nextRunTime.Unix()
+			schedulePatch.NextRunTs = &nextRunTs
+		}
+		// The issues are created on behalf of the updater from now on, so validate the schedule with the updater
+		// unless it's archived.
+		if schedulePatch.RowStatus == nil || api.RowStatus(*schedulePatch.RowStatus) == api.Normal {
+			if err := s.validateIssueSchedule(ctx, schedule, schedulePatch.UpdaterID); err != nil {
+				return err
+			}
+		}
+
+		schedule, err = s.store.PatchIssueSchedule(ctx, schedulePatch)
+		if err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Issue schedule ID not found: %d", schedulePatch.ID))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to patch issue schedule ID: %v", schedulePatch.ID)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, schedule); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal issue schedule patch response: %v", schedule.ID)).SetInternal(err)
+		}
+		return nil
+	})
+
+	g.GET("/project/:projectID/issue-schedule/:scheduleID/run", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		schedule, err := s.getIssueScheduleFromParam(ctx, c)
+		if err != nil {
+			return err
+		}
+
+		find := &api.IssueScheduleRunFind{
+			ScheduleID: &schedule.ID,
+		}
+		if limitStr := c.QueryParam("limit"); limitStr != "" {
+			limit, err := strconv.Atoi(limitStr)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Query parameter limit is not a number: %s", limitStr)).SetInternal(err)
+			}
+			find.Limit = &limit
+		}
+		runList, err := s.store.FindIssueScheduleRun(ctx, find)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch run list for issue schedule ID: %d", schedule.ID)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, runList); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal issue schedule run list response: %v", schedule.ID)).SetInternal(err)
+		}
+		return nil
+	})
+}
+
+// getIssueScheduleFromParam returns the issue schedule of the scheduleID path param in the project of the projectID path param.
+func (s *Server) getIssueScheduleFromParam(ctx context.Context, c echo.Context) (*api.IssueSchedule, error) {
+	projectID, err := strconv.Atoi(c.Param("projectID"))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Project ID is not a number: %s", c.Param("projectID"))).SetInternal(err)
+	}
+	id, err := strconv.Atoi(c.Param("scheduleID"))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Issue schedule ID is not a number: %s", c.Param("scheduleID"))).SetInternal(err)
+	}
+
+	schedule, err := s.store.GetIssueScheduleByID(ctx, id)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch issue schedule ID: %v", id)).SetInternal(err)
+	}
+	if schedule == nil || schedule.ProjectID != projectID {
+		return nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Issue schedule ID not found: %d", id))
+	}
+	return schedule, nil
+}
+
+// canEditIssueSchedule returns true if the principal is the creator of the issue schedule or an owner of its project.
+// The scheduled issues are created on behalf of the last updater and may be auto approved, so the other members can't edit the schedule.
+func (s *Server) canEditIssueSchedule(ctx context.Context, schedule *api.IssueSchedule, principalID int) (bool, error) {
+	if schedule.CreatorID == principalID {
+		return true, nil
+	}
+	memberList, err := s.store.FindProjectMember(ctx, &api.ProjectMemberFind{ProjectID: &schedule.ProjectID})
+	if err != nil {
+		return false, fmt.Errorf("failed to find members of project %d: %w", schedule.ProjectID, err)
+	}
+	for _, member := range memberList {
+		if member.PrincipalID == principalID && member.Role == string(common.ProjectOwner) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// validateIssueSchedule validates the issue schedule by creating its next issue in validate-only mode.
+func (s *Server) validateIssueSchedule(ctx context.Context, schedule *api.IssueSchedule, principalID int) error {
+	issueCreate, err := getIssueScheduleIssueCreate(schedule)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	issueCreate.ValidateOnly = true
+	if _, err := s.createIssue(ctx, issueCreate, principalID); err != nil {
+		if httpErr, ok := err.(*echo.HTTPError); ok {
+			return httpErr
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid issue schedule: %v", err)).SetInternal(err)
+	}
+	return nil
+}
+
+// getIssueScheduleIssueCreate returns the issue to create for the next run of the issue schedule.
+func getIssueScheduleIssueCreate(schedule *api.IssueSchedule) (*api.IssueCreate, error) {
+	if schedule.IssueType != api.IssueDatabaseSchemaUpdate && schedule.IssueType != api.IssueDatabaseDataUpdate {
+		return nil, fmt.Errorf("issue type %q is not supported by issue schedules, only %q and %q are supported", schedule.IssueType, api.IssueDatabaseSchemaUpdate, api.IssueDatabaseDataUpdate)
+	}
+	updateSchemaContext := &api.UpdateSchemaContext{}
+	if err := json.Unmarshal([]byte(schedule.CreateContext), updateSchemaContext); err != nil {
+		return nil, fmt.Errorf("invalid issue create context: %w", err)
+	}
+	// The issue is created on schedule, so neither the earliest allowed time nor the VCS push event applies.
+	updateSchemaContext.VCSPushEvent = nil
+	for _, detail := range updateSchemaContext.DetailList {
+		detail.EarliestAllowedTs = 0
+	}
+	createContext, err := json.Marshal(updateSchemaContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal issue create context: %w", err)
+	}
+
+	loc := time.UTC
+	if schedule.Timezone != "" {
+		if loc, err = time.LoadLocation(schedule.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %w", schedule.Timezone, err)
+		}
+	}
+	return &api.IssueCreate{
+		ProjectID:     schedule.ProjectID,
+		Name:          fmt.Sprintf("%s @%s", schedule.Name, time.Unix(schedule.NextRunTs, 0).In(loc).Format("2006-01-02 15:04")),
+		Type:          schedule.IssueType,
+		Description:   schedule.Description,
+		AssigneeID:    schedule.AssigneeID,
+		CreateContext: string(createContext),
+	}, nil
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+func TestGetIssueScheduleIssueCreate(t *testing.T) {
+	createContext, err := json.Marshal(&api.UpdateSchemaContext{
+		MigrationType: db.Data,
+		DetailList: []*api.UpdateSchemaDetail{
+			{
+				DatabaseID:        1,
+				Statement:         "DELETE FROM session WHERE expired_ts < UNIX_TIMESTAMP()",
+				EarliestAllowedTs: 1656000000,
+			},
+		},
+	})
+	require.NoError(t, err)
+	schedule := &api.IssueSchedule{
+		ProjectID:     101,
+		AssigneeID:    102,
+		Name:          "Purge expired sessions",
+		Timezone:      "Asia/Shanghai",
+		IssueType:     api.IssueDatabaseDataUpdate,
+		CreateContext: string(createContext),
+		NextRunTs:     time.Date(2022, 7, 4, 2, 0, 0, 0, time.UTC).Unix(),
+	}
+
+	issueCreate, err := getIssueScheduleIssueCreate(schedule)
+	require.NoError(t, err)
+	assert.Equal(t, "Purge expired sessions @2022-07-04 10:00", issueCreate.Name)
+	assert.Equal(t, api.IssueDatabaseDataUpdate, issueCreate.Type)
+	assert.Equal(t, 101, issueCreate.ProjectID)
+	assert.Equal(t, 102, issueCreate.AssigneeID)
+	updateSchemaContext := &api.UpdateSchemaContext{}
+	require.NoError(t, json.Unmarshal([]byte(issueCreate.CreateContext), updateSchemaContext))
+	require.Len(t, updateSchemaContext.DetailList, 1)
+	assert.Equal(t, int64(0), updateSchemaContext.DetailList[0].EarliestAllowedTs)
+
+	schedule.IssueType = api.IssueDatabaseCreate
+	_, err = getIssueScheduleIssueCreate(schedule)
+	assert.Error(t, err)
+}
+
+func TestGetIssueScheduleNextRunTime(t *testing.T) {
+	now := time.Date(2022, 7, 4, 2, 30, 0, 0, time.UTC)
+
+	next, err := api.GetIssueScheduleNextRunTime("0 3 * * *", "Asia/Shanghai", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2022, 7, 4, 19, 0, 0, 0, time.UTC), next.UTC())
+
+	next, err = api.GetIssueScheduleNextRunTime("0 3 * * *", "", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2022, 7, 4, 3, 0, 0, 0, time.UTC), next.UTC())
+
+	_, err = api.GetIssueScheduleNextRunTime("0 3 * *", "", now)
+	assert.Error(t, err)
+	_, err = api.GetIssueScheduleNextRunTime("0 3 * * *", "Mars/Olympus", now)
+	assert.Error(t, err)
+}
+
+func TestIsIssueScheduleStatementTrusted(t *testing.T) {
+	doneIssue := &api.Issue{Status: api.IssueDone}
+	canceledIssue := &api.Issue{Status: api.IssueCanceled}
+	tests := []struct {
+		name               string
+		statementUpdatedTs int64
+		previousRun        *api.IssueScheduleRun
+		previousIssue      *api.Issue
+		want               bool
+	}{
+		{
+			name: "statement never edited",
+			want: true,
+		},
+		{
+			name:               "no issue created after the edit",
+			statementUpdatedTs: 1656000000,
+			want:               false,
+		},
+		{
+			name:               "issue created before the edit",
+			statementUpdatedTs: 1656000000,
+			previousRun:        &api.IssueScheduleRun{CreatedTs: 1655000000},
+			previousIssue:      doneIssue,
+			want:               false,
+		},
+		{
+			name:               "issue created after the edit is canceled",
+			statementUpdatedTs: 1656000000,
+			previousRun:        &api.IssueScheduleRun{CreatedTs: 1657000000},
+			previousIssue:      canceledIssue,
+			want:               false,
+		},
+		{
+			name:               "issue created after the edit is done",
+			statementUpdatedTs: 1656000000,
+			previousRun:        &api.IssueScheduleRun{CreatedTs: 1657000000},
+			previousIssue:      doneIssue,
+			want:               true,
+		},
+	}
+
+	for _, test := range tests {
+		schedule := &api.IssueSchedule{StatementUpdatedTs: test.statementUpdatedTs}
+		assert.Equal(t, test.want, isIssueScheduleStatementTrusted(schedule, test.previousRun, test.previousIssue), test.name)
+	}
+}
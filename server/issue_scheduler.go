@@ -0,0 +1,288 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common/log"
+	"github.com/bytebase/bytebase/plugin/webhook"
+	"go.uber.org/zap"
+)
+
+const (
+	// The interval is the finest granularity of the cron schedule.
+	issueSchedulerInterval = time.Duration(1) * time.Minute
+)
+
+// NewIssueScheduler creates an issue scheduler.
+func NewIssueScheduler(server *Server) *IssueScheduler {
+	return &IssueScheduler{
+		server: server,
+	}
+}
+
+// IssueScheduler is the issue scheduler creating issues from the issue schedules.
+type IssueScheduler struct {
+	server *Server
+}
+
+// Run will run the issue scheduler.
+func (s *IssueScheduler) Run(ctx context.Context, wg *sync.WaitGroup) {
+	ticker := time.NewTicker(issueSchedulerInterval)
+	defer ticker.Stop()
+	defer wg.Done()
+	log.Debug(fmt.Sprintf("Issue scheduler started and will run every %v", issueSchedulerInterval))
+	for {
+		select {
+		case <-ticker.C:
+			log.Debug("New issue scheduler round started...")
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						err, ok := r.(error)
+						if !ok {
+							err = fmt.Errorf("%v", r)
+						}
+						log.Error("Issue scheduler PANIC RECOVER", zap.Error(err))
+					}
+				}()
+
+				// Only one replica runs the round.
+				if !s.server.claimRound(ctx, "issue_scheduler", issueSchedulerInterval) {
+					return
+				}
+
+				now := time.Now()
+				rowStatus := api.Normal
+				dueTs := now.Unix()
+				scheduleList, err := s.server.store.FindIssueSchedule(ctx, &api.IssueScheduleFind{
+					RowStatus: &rowStatus,
+					DueTs:     &dueTs,
+				})
+				if err != nil {
+					log.Error("Failed to retrieve due issue schedules", zap.Error(err))
+					return
+				}
+
+				for _, schedule := range scheduleList {
+					if err := s.runIssueSchedule(ctx, schedule, now); err != nil {
+						log.Error("Failed to run issue schedule",
+							zap.Int("id", schedule.ID),
+							zap.String("name", schedule.Name),
+							zap.Error(err))
+					}
+				}
+			}()
+		case <-ctx.Done(): // if cancel() execute
+			return
+		}
+	}
+}
+
+// runIssueSchedule creates the issue for the due issue schedule and records the run.
+func (s *IssueScheduler) runIssueSchedule(ctx context.Context, schedule *api.IssueSchedule, now time.Time) error {
+	issueCreate, createErr := getIssueScheduleIssueCreate(schedule)
+
+	// Advance the schedule before creating the issue, so that a crash in between skips the run instead of creating the issue twice.
+	// The runs missed while the server is down aren't caught up.
+	nextRunTime, err := api.GetIssueScheduleNextRunTime(schedule.Cron, schedule.Timezone, now)
+	if err != nil {
+		return fmt.Errorf("failed to get the next run time: %w", err)
+	}
+	nextRunTs := nextRunTime.Unix()
+	// Keep the updater, since the issues are created on behalf of the last updater.
+	if _, err := s.server.store.PatchIssueSchedule(ctx, &api.IssueSchedulePatch{
+		ID:        schedule.ID,
+		UpdaterID: schedule.UpdaterID,
+		NextRunTs: &nextRunTs,
+	}); err != nil {
+		return fmt.Errorf("failed to advance the next run time: %w", err)
+	}
+
+	if createErr != nil {
+		return s.recordIssueScheduleRun(ctx, schedule, nil, api.IssueScheduleRunFailed, createErr.Error())
+	}
+
+	// Skip the run if the issue created by the previous run is still open, so that the issues don't pile up.
+	limit := 1
+	createdStatus := api.IssueScheduleRunCreated
+	runList, err := s.server.store.FindIssueScheduleRun(ctx, &api.IssueScheduleRunFind{
+		ScheduleID: &schedule.ID,
+		Status:     &createdStatus,
+		Limit:      &limit,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find the previous run: %w", err)
+	}
+	var previousRun *api.IssueScheduleRun
+	var previousIssue *api.Issue
+	if len(runList) > 0 && runList[0].IssueID != nil {
+		previousRun = runList[0]
+		previousIssue, err = s.server.store.GetIssueByID(ctx, *previousRun.IssueID)
+		if err != nil {
+			return fmt.Errorf("failed to find the issue of the previous run: %w", err)
+		}
+		if previousIssue != nil && previousIssue.Status == api.IssueOpen {
+			return s.recordIssueScheduleRun(ctx, schedule, nil, api.IssueScheduleRunSkipped, fmt.Sprintf("Issue %q created by the previous run is still open", previousIssue.Name))
+		}
+	}
+
+	issue, err := s.server.createIssue(ctx, issueCreate, schedule.UpdaterID)
+	if err != nil {
+		return s.recordIssueScheduleRun(ctx, schedule, nil, api.IssueScheduleRunFailed, err.Error())
+	}
+	if isIssueScheduleStatementTrusted(schedule, previousRun, previousIssue) {
+		if schedule.StatementUpdatedTs != 0 {
+			statementUpdatedTs := int64(0)
+			if _, err := s.server.store.PatchIssueSchedule(ctx, &api.IssueSchedulePatch{
+				ID:                 schedule.ID,
+				UpdaterID:          schedule.UpdaterID,
+				StatementUpdatedTs: &statementUpdatedTs,
+			}); err != nil {
+				log.Error("Failed to trust the edited statement of the issue schedule",
+					zap.String("schedule", schedule.Name),
+					zap.Error(err))
+			}
+		}
+		if err := s.server.autoApproveScheduledIssue(ctx, issue); err != nil {
+			// The issue is created anyway, and it can still be approved manually.
+			log.Error("Failed to auto approve the scheduled issue",
+				zap.String("issue", issue.Name),
+				zap.Error(err))
+		}
+	}
+	return s.recordIssueScheduleRun(ctx, schedule, &issue.ID, api.IssueScheduleRunCreated, "")
+}
+
+// isIssueScheduleStatementTrusted returns true if the issues created by the issue schedule could be auto approved.
+// After the statement is edited, it's trusted again once an issue created after the edit is done.
+func isIssueScheduleStatementTrusted(schedule *api.IssueSchedule, previousRun *api.IssueScheduleRun, previousIssue *api.Issue) bool {
+	if schedule.StatementUpdatedTs == 0 {
+		return true
+	}
+	if previousRun == nil || previousIssue == nil {
+		return false
+	}
+	return previousRun.CreatedTs >= schedule.StatementUpdatedTs && previousIssue.Status == api.IssueDone
+}
+
+// recordIssueScheduleRun records the run of the issue schedule, and notifies the project if no issue is created.
+func (s *IssueScheduler) recordIssueScheduleRun(ctx context.Context, schedule *api.IssueSchedule, issueID *int, status api.IssueScheduleRunStatus, detail string) error {
+	if _, err := s.server.store.CreateIssueScheduleRun(ctx, &api.IssueScheduleRunCreate{
+		ScheduleID: schedule.ID,
+		IssueID:    issueID,
+		Status:     status,
+		Detail:     detail,
+	}); err != nil {
+		return fmt.Errorf("failed to record the %s run: %w", status, err)
+	}
+	if status == api.IssueScheduleRunCreated {
+		return nil
+	}
+
+	payload, err := json.Marshal(api.ActivityProjectIssueScheduleRunPayload{
+		ScheduleID:   schedule.ID,
+		Status:       status,
+		ScheduleName: schedule.Name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal activity payload: %w", err)
+	}
+	level := api.ActivityWarn
+	if status == api.IssueScheduleRunFailed {
+		level = api.ActivityError
+	}
+	activityCreate := &api.ActivityCreate{
+		CreatorID:   api.SystemBotID,
+		ContainerID: schedule.ProjectID,
+		Type:        api.ActivityProjectIssueScheduleRun,
+		Level:       level,
+		Comment:     detail,
+		Payload:     string(payload),
+	}
+	if _, err := s.server.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{}); err != nil {
+		return fmt.Errorf("failed to create activity: %w", err)
+	}
+	return s.postIssueScheduleRunWebhook(ctx, schedule, status, detail)
+}
+
+// postIssueScheduleRunWebhook posts the run which didn't create an issue to the project webhooks.
+func (s *IssueScheduler) postIssueScheduleRunWebhook(ctx context.Context, schedule *api.IssueSchedule, status api.IssueScheduleRunStatus, detail string) error {
+	activityType := api.ActivityProjectIssueScheduleRun
+	webhookList, err := s.server.store.FindProjectWebhook(ctx, &api.ProjectWebhookFind{
+		ProjectID:    &schedule.ProjectID,
+		ActivityType: &activityType,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find project webhook: %w", err)
+	}
+	if len(webhookList) == 0 {
+		return nil
+	}
+	project, err := s.server.store.GetProjectByID(ctx, schedule.ProjectID)
+	if err != nil {
+		return fmt.Errorf("failed to find project: %w", err)
+	}
+	if project == nil {
+		return fmt.Errorf("project ID not found: %d", schedule.ProjectID)
+	}
+
+	level := webhook.WebhookWarn
+	title := "Scheduled issue skipped - " + schedule.Name
+	if status == api.IssueScheduleRunFailed {
+		level = webhook.WebhookError
+		title = "Scheduled issue failed - " + schedule.Name
+	}
+	for _, hook := range webhookList {
+		webhookCtx := webhook.Context{
+			URL:          hook.URL,
+			Level:        level,
+			ActivityType: string(activityType),
+			Title:        title,
+			Description:  detail,
+			Link:         fmt.Sprintf("%s:%d/project/%s", s.server.profile.FrontendHost, s.server.profile.FrontendPort, api.ProjectSlug(project)),
+			CreatorID:    api.SystemBotID,
+			CreatorName:  "Bytebase",
+			CreatorEmail: "support@bytebase.com",
+			CreatedTs:    time.Now().Unix(),
+			Project:      &webhook.Project{ID: project.ID, Name: project.Name},
+		}
+		if err := webhook.Post(hook.Type, webhookCtx); err != nil {
+			// The external webhook endpoint might be invalid which is out of our code control, so we just emit a warning
+			log.Warn("Failed to post webhook event after running the issue schedule",
+				zap.String("webhook_type", hook.Type),
+				zap.String("webhook_name", hook.Name),
+				zap.String("schedule", schedule.Name),
+				zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// autoApproveScheduledIssue approves the tasks of the scheduled issue in the environments whose
+// pipeline approval policy allows auto approving scheduled issues.
+// The environments with an approval chain are skipped, because the chain must be passed by the approvers.
+func (s *Server) autoApproveScheduledIssue(ctx context.Context, issue *api.Issue) error {
+	for _, stage := range issue.Pipeline.StageList {
+		policy, err := s.store.GetPipelineApprovalPolicy(ctx, stage.EnvironmentID)
+		if err != nil {
+			return fmt.Errorf("failed to get approval policy for environment ID %d: %w", stage.EnvironmentID, err)
+		}
+		if !policy.AutoApproveScheduledIssue || len(policy.ApprovalStepList) > 0 {
+			continue
+		}
+		for _, task := range stage.TaskList {
+			if task.Status != api.TaskPendingApproval {
+				continue
+			}
+			if _, err := s.changeTaskStatus(ctx, task, api.TaskPending, api.SystemBotID); err != nil {
+				return fmt.Errorf("failed to approve task %q: %w", task.Name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common/log"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// The background runners claim their work through the leases in the metadata database,
+// so that multiple Bytebase replicas sharing the same metadata database could run active-active.
+// The holder renews the lease by heartbeats while working on it. If the holder replica crashes,
+// the lease expires and the work will be claimed again by any replica.
+const (
+	// leaseDuration is how long a lease lasts without renewal.
+	leaseDuration = time.Duration(30) * time.Second
+	// leaseHeartbeatInterval is how often the holder renews the lease.
+	leaseHeartbeatInterval = leaseDuration / 3
+)
+
+// newReplicaID returns the ID identifying this replica as the lease holder.
+func newReplicaID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%s", hostname, uuid.New().String())
+}
+
+// taskLeaseResourcePrefix is the resource prefix of the task leases.
+const taskLeaseResourcePrefix = "task/"
+
+func taskLeaseResource(taskID int) string {
+	return fmt.Sprintf("%s%d", taskLeaseResourcePrefix, taskID)
+}
+
+// findLeasedTaskIDs returns the IDs of the tasks being executed by any replica.
+func (s *Server) findLeasedTaskIDs(ctx context.Context) (map[int]bool, error) {
+	prefix := taskLeaseResourcePrefix
+	leaseList, err := s.store.FindLease(ctx, &api.LeaseFind{ResourcePrefix: &prefix})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find task leases: %w", err)
+	}
+	taskIDs := make(map[int]bool)
+	for _, lease := range leaseList {
+		id, err := strconv.Atoi(strings.TrimPrefix(lease.Resource, prefix))
+		if err != nil {
+			continue
+		}
+		taskIDs[id] = true
+	}
+	return taskIDs, nil
+}
+
+func taskCheckRunLeaseResource(taskCheckRunID int) string {
+	return fmt.Sprintf("task_check_run/%d", taskCheckRunID)
+}
+
+func backupSettingLeaseResource(backupSettingID int) string {
+	return fmt.Sprintf("backup_setting/%d", backupSettingID)
+}
+
+// acquireLease acquires or renews the lease on the resource for the duration.
+// Returns false if another replica holds the lease.
+func (s *Server) acquireLease(ctx context.Context, resource string, duration time.Duration) (bool, error) {
+	lease, err := s.store.AcquireLease(ctx, &api.LeaseAcquire{
+		Resource:        resource,
+		Holder:          s.replicaID,
+		DurationSeconds: int64(duration.Seconds()),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease %q: %w", resource, err)
+	}
+	return lease != nil, nil
+}
+
+// claimRound claims the periodic round of the runner, so that only one replica runs the round in the interval.
+// The lease is not released after the round, and the same replica will renew it in the next round.
+func (s *Server) claimRound(ctx context.Context, runner string, interval time.Duration) bool {
+	// Leave a little room so that the holder could renew the lease in its next round before it expires.
+	duration := interval - interval/10
+	if duration < leaseDuration {
+		duration = leaseDuration
+	}
+	claimed, err := s.acquireLease(ctx, fmt.Sprintf("runner/%s", runner), duration)
+	if err != nil {
+		log.Error("Failed to claim the runner round", zap.String("runner", runner), zap.Error(err))
+		return false
+	}
+	return claimed
+}
+
+// runWithLease runs f exclusively among the replicas if it acquires the lease on the resource.
+// The lease is renewed by heartbeats until f returns, and the context passed to f is canceled if the lease is lost.
+// Returns false if another replica holds the lease.
+func (s *Server) runWithLease(ctx context.Context, resource string, f func(ctx context.Context)) (bool, error) {
+	acquired, err := s.acquireLease(ctx, resource, leaseDuration)
+	if err != nil || !acquired {
+		return false, err
+	}
+
+	leaseCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		ticker := time.NewTicker(leaseHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				renewed, err := s.acquireLease(ctx, resource, leaseDuration)
+				if err != nil {
+					// The lease is still valid until it expires, so we will retry in the next heartbeat.
+					log.Warn("Failed to renew lease", zap.String("resource", resource), zap.Error(err))
+					continue
+				}
+				if !renewed {
+					log.Error("Lost lease, another replica has taken over the work", zap.String("resource", resource))
+					cancel()
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	defer func() {
+		close(done)
+		<-heartbeatDone
+		cancel()
+		if err := s.store.ReleaseLease(ctx, &api.LeaseRelease{
+			Resource: resource,
+			Holder:   s.replicaID,
+		}); err != nil {
+			// The lease will expire anyway.
+			log.Warn("Failed to release lease", zap.String("resource", resource), zap.Error(err))
+		}
+	}()
+	f(leaseCtx)
+	return true, nil
+}
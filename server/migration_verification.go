@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+)
+
+// mysqlAutoIncrementRegexp matches the AUTO_INCREMENT table option in the MySQL schema dump, which changes with the data.
+var mysqlAutoIncrementRegexp = regexp.MustCompile(` AUTO_INCREMENT=\d+`)
+
+// verifyMigration runs the verification statements against the database and compares the synced schema with the expected schema.
+// Returns nil if there is nothing to verify.
+func verifyMigration(ctx context.Context, server *Server, task *api.Task, statementList []string, expectedSchema, schema string) *api.TaskRunVerificationResult {
+	if len(statementList) == 0 && expectedSchema == "" {
+		return nil
+	}
+
+	var errorList []string
+	if len(statementList) > 0 {
+		errorList = append(errorList, runVerificationStatementList(ctx, server, task, statementList)...)
+	}
+	if expectedSchema != "" {
+		if diff := diffSchema(expectedSchema, schema); diff != "" {
+			errorList = append(errorList, fmt.Sprintf("Schema mismatch: %s", diff))
+		}
+	}
+	return &api.TaskRunVerificationResult{
+		Passed:    len(errorList) == 0,
+		ErrorList: errorList,
+	}
+}
+
+// runVerificationStatementList runs each verification statement in a read-only transaction, and returns the failures.
+// A verification statement is an assertion which passes if it returns no rows.
+func runVerificationStatementList(ctx context.Context, server *Server, task *api.Task, statementList []string) []string {
+	driver, err := getAdminDatabaseDriver(ctx, task.Instance, task.Database.Name, server.pgInstanceDir)
+	if err != nil {
+		return []string{fmt.Sprintf("Failed to connect database %q for verification: %v", task.Database.Name, err)}
+	}
+	defer driver.Close(ctx)
+
+	var errorList []string
+	for i, statement := range statementList {
+		statement = strings.TrimSpace(statement)
+		if statement == "" {
+			continue
+		}
+		// The query result is [column names, column types, rows].
+		result, err := driver.Query(ctx, statement, 1)
+		if err != nil {
+			errorList = append(errorList, fmt.Sprintf("Verification statement #%d failed: %v", i+1, err))
+			continue
+		}
+		if len(result) == 3 {
+			if rows, ok := result[2].([]interface{}); ok && len(rows) > 0 {
+				errorList = append(errorList, fmt.Sprintf("Verification statement #%d returned rows: %s", i+1, statement))
+			}
+		}
+	}
+	return errorList
+}
+
+// diffSchema compares the schema dumps ignoring blank lines, comments and the volatile table options.
+// Returns the first difference, or an empty string if the schemas are the same.
+func diffSchema(expected, actual string) string {
+	expectedLineList := normalizeSchema(expected)
+	actualLineList := normalizeSchema(actual)
+	for i := 0; i < len(expectedLineList) || i < len(actualLineList); i++ {
+		switch {
+		case i >= len(actualLineList):
+			return fmt.Sprintf("missing %q", expectedLineList[i])
+		case i >= len(expectedLineList):
+			return fmt.Sprintf("unexpected %q", actualLineList[i])
+		case expectedLineList[i] != actualLineList[i]:
+			return fmt.Sprintf("expected %q, got %q", expectedLineList[i], actualLineList[i])
+		}
+	}
+	return ""
+}
+
+func normalizeSchema(schema string) []string {
+	var lineList []string
+	for _, line := range strings.Split(schema, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "--") {
+			continue
+		}
+		lineList = append(lineList, mysqlAutoIncrementRegexp.ReplaceAllString(line, ""))
+	}
+	return lineList
+}
+
+// createTaskVerificationFailActivity creates the activity for the failed verification, which notifies the issue subscribers.
+func (s *Server) createTaskVerificationFailActivity(ctx context.Context, task *api.Task, verification *api.TaskRunVerificationResult) error {
+	issue, err := s.store.GetIssueByPipelineID(ctx, task.PipelineID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch containing issue: %w", err)
+	}
+	issueName := ""
+	containerID := task.PipelineID
+	if issue != nil {
+		issueName = issue.Name
+		containerID = issue.ID
+	}
+	payload, err := json.Marshal(api.ActivityPipelineTaskVerificationFailPayload{
+		TaskID:    task.ID,
+		IssueName: issueName,
+		TaskName:  task.Name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal activity payload: %w", err)
+	}
+	activityCreate := &api.ActivityCreate{
+		CreatorID:   api.SystemBotID,
+		ContainerID: containerID,
+		Type:        api.ActivityPipelineTaskVerificationFail,
+		Level:       api.ActivityError,
+		Comment:     strings.Join(verification.ErrorList, "\n"),
+		Payload:     string(payload),
+	}
+	_, err = s.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{issue: issue})
+	return err
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		actual   string
+		want     string
+	}{
+		{
+			name: "same schema ignoring comments, blank lines and auto increment",
+			expected: `--
+-- Table structure for ` + "`book`" + `
+--
+CREATE TABLE ` + "`book`" + ` (
+  ` + "`id`" + ` int NOT NULL AUTO_INCREMENT
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+`,
+			actual: `CREATE TABLE ` + "`book`" + ` (
+
+  ` + "`id`" + ` int NOT NULL AUTO_INCREMENT
+) ENGINE=InnoDB AUTO_INCREMENT=42 DEFAULT CHARSET=utf8mb4;`,
+			want: "",
+		},
+		{
+			name:     "different column",
+			expected: "CREATE TABLE t (\n  id int\n);",
+			actual:   "CREATE TABLE t (\n  id bigint\n);",
+			want:     `expected "id int", got "id bigint"`,
+		},
+		{
+			name:     "missing table",
+			expected: "CREATE TABLE t1 (id int);\nCREATE TABLE t2 (id int);",
+			actual:   "CREATE TABLE t1 (id int);",
+			want:     `missing "CREATE TABLE t2 (id int);"`,
+		},
+		{
+			name:     "unexpected table",
+			expected: "CREATE TABLE t1 (id int);",
+			actual:   "CREATE TABLE t1 (id int);\nCREATE TABLE t2 (id int);",
+			want:     `unexpected "CREATE TABLE t2 (id int);"`,
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.want, diffSchema(test.expected, test.actual), test.name)
+	}
+}
+
+func TestVerifyMigrationExpectedSchema(t *testing.T) {
+	ctx := context.Background()
+	// Nothing to verify.
+	assert.Nil(t, verifyMigration(ctx, nil, nil, nil, "", "CREATE TABLE t (id int);"))
+
+	result := verifyMigration(ctx, nil, nil, nil, "CREATE TABLE t (id int);", "CREATE TABLE t (id int);")
+	assert.True(t, result.Passed)
+	assert.Empty(t, result.ErrorList)
+
+	result = verifyMigration(ctx, nil, nil, nil, "CREATE TABLE t (id int);", "")
+	assert.False(t, result.Passed)
+	assert.Equal(t, []string{`Schema mismatch: missing "CREATE TABLE t (id int);"`}, result.ErrorList)
+}
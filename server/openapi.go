@@ -23,6 +23,12 @@ func (c *catalogService) FindIndex(ctx context.Context, find *catalog.IndexFind)
 	return nil, nil
 }
 
+// FindIndexList is the API message for find the index list of a table in catalog.
+// The tables are unknown without connecting to the user's database.
+func (c *catalogService) FindIndexList(ctx context.Context, find *catalog.IndexListFind) ([]*catalog.Index, error) {
+	return nil, catalog.ErrTableNotFound
+}
+
 func (s *Server) registerOpenAPIRoutes(g *echo.Group) {
 	g.GET("/sql/advise", s.sqlCheckController)
 }
@@ -76,7 +82,8 @@ func (s *Server) sqlCheckController(c echo.Context) error {
 		advisorDBType,
 		"utf8mb4",
 		"utf8mb4_general_ci",
-		envList[0].ID,
+		envList[0],
+		api.UnknownID,
 		statement,
 		&catalogService{},
 	)
@@ -0,0 +1,393 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/common/log"
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/db/mysql"
+	"github.com/bytebase/bytebase/plugin/db/pg"
+	"go.uber.org/zap"
+)
+
+// NewPITRArchiver creates a new PITR archiver.
+func NewPITRArchiver(server *Server, pitrArchiverInterval time.Duration) *PITRArchiver {
+	return &PITRArchiver{
+		server:               server,
+		pitrArchiverInterval: pitrArchiverInterval,
+	}
+}
+
+// PITRArchiver is the PITR archiver archiving the binlog of the MySQL instances and the WAL changes of the Postgres databases
+// with automatic backup enabled.
+type PITRArchiver struct {
+	server               *Server
+	pitrArchiverInterval time.Duration
+}
+
+// Run is the runner for PITR archiver.
+func (s *PITRArchiver) Run(ctx context.Context, wg *sync.WaitGroup) {
+	ticker := time.NewTicker(s.pitrArchiverInterval)
+	defer ticker.Stop()
+	defer wg.Done()
+	log.Debug("PITR archiver started", zap.Duration("interval", s.pitrArchiverInterval))
+	for {
+		select {
+		case <-ticker.C:
+			log.Debug("New PITR archiver round started...")
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						err, ok := r.(error)
+						if !ok {
+							err = fmt.Errorf("%v", r)
+						}
+						log.Error("PITR archiver PANIC RECOVER", zap.Error(err))
+					}
+				}()
+
+				if !s.server.feature(api.FeaturePITR) {
+					return
+				}
+				// Only one replica runs the round.
+				if !s.server.claimRound(ctx, "pitr_archiver", s.pitrArchiverInterval) {
+					return
+				}
+
+				enabled := true
+				backupSettingList, err := s.server.store.FindBackupSetting(ctx, &api.BackupSettingFind{Enabled: &enabled})
+				if err != nil {
+					log.Error("Failed to retrieve enabled backup settings", zap.Error(err))
+					return
+				}
+				databaseListMap := make(map[int][]*api.Database)
+				for _, backupSetting := range backupSettingList {
+					database := backupSetting.Database
+					if database.Name == api.AllDatabaseName {
+						continue
+					}
+					databaseListMap[database.InstanceID] = append(databaseListMap[database.InstanceID], database)
+				}
+
+				rowStatus := api.Normal
+				instanceList, err := s.server.store.FindInstance(ctx, &api.InstanceFind{RowStatus: &rowStatus})
+				if err != nil {
+					log.Error("Failed to retrieve instances", zap.Error(err))
+					return
+				}
+				for _, instance := range instanceList {
+					switch instance.Engine {
+					case db.MySQL:
+						if len(databaseListMap[instance.ID]) == 0 {
+							continue
+						}
+						if err := s.archiveMySQLInstance(ctx, instance, databaseListMap[instance.ID]); err != nil {
+							log.Error("Failed to archive binlog for instance",
+								zap.String("instance", instance.Name),
+								zap.Error(err))
+						}
+					case db.Postgres:
+						if err := s.archivePgInstance(ctx, instance, databaseListMap[instance.ID]); err != nil {
+							log.Error("Failed to archive WAL for instance",
+								zap.String("instance", instance.Name),
+								zap.Error(err))
+						}
+					}
+				}
+			}()
+		case <-ctx.Done(): // if cancel() execute
+			return
+		}
+	}
+}
+
+// archiveMySQLInstance downloads the new binlog files of the instance, and checks whether the databases are restorable
+// to the latest point in time with the archived binlog files.
+func (s *PITRArchiver) archiveMySQLInstance(ctx context.Context, instance *api.Instance, databaseList []*api.Database) error {
+	driver, err := getAdminDatabaseDriver(ctx, instance, "", "" /* pgInstanceDir */)
+	if err != nil {
+		return err
+	}
+	defer driver.Close(ctx)
+	mysqlDriver, ok := driver.(*mysql.Driver)
+	if !ok {
+		return fmt.Errorf("[internal] cast driver to mysql.Driver failed")
+	}
+	if err := mysqlDriver.CheckBinlogEnabled(ctx); err != nil {
+		log.Debug("Skip archiving binlog for instance", zap.String("instance", instance.Name), zap.Error(err))
+		return nil
+	}
+
+	if err := createBinlogDir(s.server.profile.DataDir, instance.ID); err != nil {
+		return err
+	}
+	binlogDir := getBinlogAbsDir(s.server.profile.DataDir, instance.ID)
+	mysqlDriver.SetUpForPITR(s.server.mysqlutil, binlogDir)
+	if err := mysqlDriver.ArchiveBinlogFiles(ctx); err != nil {
+		return err
+	}
+
+	binlogFileList, err := mysql.GetSortedLocalBinlogFiles(binlogDir)
+	if err != nil {
+		return fmt.Errorf("failed to read local binlog files, error: %w", err)
+	}
+	for _, database := range databaseList {
+		if err := s.checkBinlogGap(ctx, database, binlogFileList); err != nil {
+			log.Error("Failed to check binlog gap for database",
+				zap.String("instance", instance.Name),
+				zap.String("database", database.Name),
+				zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// checkBinlogGap raises the anomaly if the database has been backed up, but none of the backups is restorable
+// to the latest point in time, because the binlog files after the latest backup are purged before being archived.
+func (s *PITRArchiver) checkBinlogGap(ctx context.Context, database *api.Database, binlogFileList []mysql.BinlogFile) error {
+	backupStatus := api.BackupStatusDone
+	backupList, err := s.server.store.FindBackup(ctx, &api.BackupFind{DatabaseID: &database.ID, Status: &backupStatus})
+	if err != nil {
+		return err
+	}
+	var lastBackup *api.Backup
+	for _, backup := range backupList {
+		if backup.Payload.BinlogInfo.IsEmpty() {
+			continue
+		}
+		if lastBackup == nil || backup.UpdatedTs > lastBackup.UpdatedTs {
+			lastBackup = backup
+		}
+	}
+	restorableBackup, err := mysql.GetEarliestRestorableBackup(backupList, binlogFileList)
+	if err != nil {
+		return err
+	}
+	if lastBackup == nil || restorableBackup != nil {
+		return s.updatePITRGapAnomaly(ctx, database, nil)
+	}
+
+	// The binlog files before the first one archived are purged if there is no gap in the archived binlog files.
+	payload := &api.AnomalyDatabasePITRBinlogGapPayload{
+		LastBackupTs: lastBackup.UpdatedTs,
+	}
+	if len(binlogFileList) > 0 {
+		payload.BinlogAfter = binlogFileList[0].Name
+	}
+	if gapList := mysql.GetBinlogGapList(binlogFileList); len(gapList) > 0 {
+		payload.BinlogBefore = gapList[len(gapList)-1].Before
+		payload.BinlogAfter = gapList[len(gapList)-1].After
+	}
+	return s.updatePITRGapAnomaly(ctx, database, payload)
+}
+
+// checkWALGap raises the anomaly if the database has been backed up, but none of the backups is restorable
+// to the latest point in time, because the PITR slot is consumed beyond the WAL archive.
+func (s *PITRArchiver) checkWALGap(ctx context.Context, database *api.Database, archiveFileList []pg.WALArchiveFile) error {
+	backupStatus := api.BackupStatusDone
+	backupList, err := s.server.store.FindBackup(ctx, &api.BackupFind{DatabaseID: &database.ID, Status: &backupStatus})
+	if err != nil {
+		return err
+	}
+	var lastBackup *api.Backup
+	for _, backup := range backupList {
+		if backup.Payload.WALInfo.IsEmpty() {
+			continue
+		}
+		if lastBackup == nil || backup.UpdatedTs > lastBackup.UpdatedTs {
+			lastBackup = backup
+		}
+	}
+	restorableBackup, err := pg.GetEarliestRestorableBackup(backupList, archiveFileList)
+	if err != nil {
+		return err
+	}
+	if lastBackup == nil || restorableBackup != nil {
+		return s.updatePITRGapAnomaly(ctx, database, nil)
+	}
+
+	payload := &api.AnomalyDatabasePITRBinlogGapPayload{
+		LastBackupTs: lastBackup.UpdatedTs,
+	}
+	for i := 1; i < len(archiveFileList); i++ {
+		if archiveFileList[i].StartLSN != archiveFileList[i-1].EndLSN {
+			payload.BinlogBefore = archiveFileList[i-1].Name
+			payload.BinlogAfter = archiveFileList[i].Name
+		}
+	}
+	return s.updatePITRGapAnomaly(ctx, database, payload)
+}
+
+// updatePITRGapAnomaly upserts the PITR gap anomaly of the database with the payload, or archives it if the payload is nil.
+func (s *PITRArchiver) updatePITRGapAnomaly(ctx context.Context, database *api.Database, payload *api.AnomalyDatabasePITRBinlogGapPayload) error {
+	if payload == nil {
+		err := s.server.store.ArchiveAnomaly(ctx, &api.AnomalyArchive{
+			DatabaseID: &database.ID,
+			Type:       api.AnomalyDatabasePITRBinlogGap,
+		})
+		if err != nil && common.ErrorCode(err) != common.NotFound {
+			return fmt.Errorf("failed to close anomaly: %w", err)
+		}
+		return nil
+	}
+
+	anomalyPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal anomaly payload: %w", err)
+	}
+	if _, err := s.server.store.UpsertActiveAnomaly(ctx, &api.AnomalyUpsert{
+		CreatorID:  api.SystemBotID,
+		InstanceID: database.InstanceID,
+		DatabaseID: &database.ID,
+		Type:       api.AnomalyDatabasePITRBinlogGap,
+		Payload:    string(anomalyPayload),
+	}); err != nil {
+		return fmt.Errorf("failed to create anomaly: %w", err)
+	}
+	return nil
+}
+
+// getEarliestRestorableBackup returns the earliest backup in the backupList from which the database can be restored
+// to the latest point in time with the archived binlog files or WAL changes, or nil if there is no such backup.
+func (s *Server) getEarliestRestorableBackup(database *api.Database, backupList []*api.Backup) (*api.Backup, error) {
+	switch database.Instance.Engine {
+	case db.MySQL:
+		binlogFileList, err := mysql.GetSortedLocalBinlogFiles(getBinlogAbsDir(s.profile.DataDir, database.InstanceID))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to read local binlog files, error: %w", err)
+		}
+		return mysql.GetEarliestRestorableBackup(backupList, binlogFileList)
+	case db.Postgres:
+		archiveFileList, err := pg.GetSortedWALArchiveFiles(getWALArchiveAbsDir(s.profile.DataDir, database.ID))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to read WAL archive files, error: %w", err)
+		}
+		return pg.GetEarliestRestorableBackup(backupList, archiveFileList)
+	}
+	return nil, nil
+}
+
+// getPITRWindow returns the time window that the database can be restored to with the archived binlog files.
+// Only MySQL is supported for now, and the window is empty for the other engines.
+func (s *Server) getPITRWindow(ctx context.Context, database *api.Database) (*api.PITRWindow, error) {
+	window := &api.PITRWindow{ID: database.ID}
+	if database.Instance.Engine != db.MySQL {
+		return window, nil
+	}
+
+	binlogDir := getBinlogAbsDir(s.profile.DataDir, database.InstanceID)
+	binlogFileList, err := mysql.GetSortedLocalBinlogFiles(binlogDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return window, nil
+		}
+		return nil, fmt.Errorf("failed to read local binlog files, error: %w", err)
+	}
+	backupStatus := api.BackupStatusDone
+	backupList, err := s.store.FindBackup(ctx, &api.BackupFind{DatabaseID: &database.ID, Status: &backupStatus})
+	if err != nil {
+		return nil, err
+	}
+	backup, err := mysql.GetEarliestRestorableBackup(backupList, binlogFileList)
+	if err != nil {
+		return nil, err
+	}
+	if backup == nil {
+		return window, nil
+	}
+
+	// The modification time of the latest binlog file is the time up to which the binlog is archived.
+	latestBinlogFilePath := filepath.Join(binlogDir, binlogFileList[len(binlogFileList)-1].Name)
+	fileInfo, err := os.Stat(latestBinlogFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file %q stat, error: %w", latestBinlogFilePath, err)
+	}
+	// The backup is taken after the binlog is archived last time.
+	if fileInfo.ModTime().Unix() < backup.UpdatedTs {
+		return window, nil
+	}
+	window.EarliestTs = backup.UpdatedTs
+	window.LatestTs = fileInfo.ModTime().Unix()
+	return window, nil
+}
+
+// archivePgInstance archives the WAL changes of the databases in the instance, and drops the PITR slots
+// of the databases no longer archived, so that the instance does not retain the WAL for them.
+func (s *PITRArchiver) archivePgInstance(ctx context.Context, instance *api.Instance, databaseList []*api.Database) error {
+	driver, err := getAdminDatabaseDriver(ctx, instance, "", s.server.pgInstanceDir)
+	if err != nil {
+		return err
+	}
+	defer driver.Close(ctx)
+	pgDriver, ok := driver.(*pg.Driver)
+	if !ok {
+		return fmt.Errorf("[internal] cast driver to pg.Driver failed")
+	}
+
+	slotList, err := pgDriver.ListPITRSlots(ctx)
+	if err != nil {
+		return err
+	}
+	archivedSlotSet := make(map[string]bool)
+	if len(databaseList) > 0 {
+		if err := pgDriver.CheckLogicalDecodingEnabled(ctx); err != nil {
+			log.Debug("Skip archiving WAL for instance", zap.String("instance", instance.Name), zap.Error(err))
+			databaseList = nil
+		}
+	}
+	for _, database := range databaseList {
+		slot := pg.GetPITRSlotName(database.ID)
+		archivedSlotSet[slot] = true
+		if err := s.archivePgDatabase(ctx, pgDriver, database, slot); err != nil {
+			log.Error("Failed to archive WAL for database",
+				zap.String("instance", instance.Name),
+				zap.String("database", database.Name),
+				zap.Error(err))
+		}
+	}
+
+	for _, slot := range slotList {
+		if archivedSlotSet[slot] {
+			continue
+		}
+		if err := pgDriver.DropPITRSlot(ctx, slot); err != nil {
+			log.Error("Failed to drop PITR slot",
+				zap.String("instance", instance.Name),
+				zap.String("slot", slot),
+				zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (s *PITRArchiver) archivePgDatabase(ctx context.Context, pgDriver *pg.Driver, database *api.Database, slot string) error {
+	if err := createWALArchiveDir(s.server.profile.DataDir, database.ID); err != nil {
+		return err
+	}
+	archiveDir := getWALArchiveAbsDir(s.server.profile.DataDir, database.ID)
+	if err := pgDriver.CreatePITRSlotIfNotExists(ctx, database.Name, slot, archiveDir); err != nil {
+		return err
+	}
+	if err := pgDriver.ArchiveWAL(ctx, database.Name, slot, archiveDir); err != nil {
+		return err
+	}
+	archiveFileList, err := pg.GetSortedWALArchiveFiles(archiveDir)
+	if err != nil {
+		return err
+	}
+	return s.checkWALGap(ctx, database, archiveFileList)
+}
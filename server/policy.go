@@ -1,6 +1,8 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -10,6 +12,7 @@ import (
 
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/advisor"
 )
 
 // hasAccessToUpdatePolicy checks if user can access to policy control feature.
@@ -41,6 +44,10 @@ func (s *Server) hasAccessToUpsertPolicy(policyUpsert *api.PolicyUpsert) error {
 		if !s.feature(api.FeatureSchemaReviewPolicy) {
 			return fmt.Errorf(api.FeatureSchemaReviewPolicy.AccessErrorMessage())
 		}
+	case api.PolicyTypeDeploymentWindow:
+		if !s.feature(api.FeatureTaskScheduleTime) {
+			return fmt.Errorf(api.FeatureTaskScheduleTime.AccessErrorMessage())
+		}
 	}
 	return nil
 }
@@ -136,6 +143,167 @@ func (s *Server) registerPolicyRoutes(g *echo.Group) {
 		return nil
 	})
 
+	g.PATCH("/policy/project/:projectID", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		projectID, err := strconv.Atoi(c.Param("projectID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("projectID is not a number: %s", c.Param("projectID"))).SetInternal(err)
+		}
+
+		policyUpsert := &api.ProjectPolicyUpsert{}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, policyUpsert); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformed set project policy request").SetInternal(err)
+		}
+		pType := api.PolicyType(c.QueryParam("type"))
+		if err := api.ValidateProjectPolicy(pType, ""); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid project policy type: %q", pType)).SetInternal(err)
+		}
+
+		policyUpsert.ProjectID = projectID
+		policyUpsert.Type = pType
+		policyUpsert.UpdaterID = c.Get(getPrincipalIDContextKey()).(int)
+
+		if pType == api.PolicyTypeSchemaReview && !s.feature(api.FeatureSchemaReviewPolicy) {
+			return echo.NewHTTPError(http.StatusForbidden, api.FeatureSchemaReviewPolicy.AccessErrorMessage())
+		}
+
+		project, err := s.store.GetProjectByID(ctx, projectID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find project ID: %d", projectID)).SetInternal(err)
+		}
+		if project == nil {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Project ID not found: %d", projectID))
+		}
+
+		if pType == api.PolicyTypeSchemaReview && policyUpsert.Payload != nil && *policyUpsert.Payload != "" {
+			if err := s.validateSchemaReviewPolicyOverride(ctx, *policyUpsert.Payload); err != nil {
+				if common.ErrorCode(err) == common.Invalid {
+					return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+				}
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to validate schema review policy override").SetInternal(err)
+			}
+		}
+
+		policy, err := s.store.UpsertProjectPolicy(ctx, policyUpsert)
+		if err != nil {
+			if common.ErrorCode(err) == common.Invalid {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to set project policy for type %q", pType)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, policy); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal set project policy response").SetInternal(err)
+		}
+		return nil
+	})
+
+	g.DELETE("/policy/project/:projectID", func(c echo.Context) error {
+		projectID, err := strconv.Atoi(c.Param("projectID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("projectID is not a number: %s", c.Param("projectID"))).SetInternal(err)
+		}
+
+		policyDelete := &api.ProjectPolicyDelete{
+			ProjectID: projectID,
+			DeleterID: c.Get(getPrincipalIDContextKey()).(int),
+			Type:      api.PolicyType(c.QueryParam("type")),
+		}
+
+		ctx := c.Request().Context()
+		if err := s.store.DeleteProjectPolicy(ctx, policyDelete); err != nil {
+			switch common.ErrorCode(err) {
+			case common.Invalid:
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+			case common.NotFound:
+				return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to delete project policy by project ID %d", projectID)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		c.Response().WriteHeader(http.StatusOK)
+		return nil
+	})
+
+	g.GET("/policy/project/:projectID", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		projectID, err := strconv.Atoi(c.Param("projectID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("projectID is not a number: %s", c.Param("projectID"))).SetInternal(err)
+		}
+		pType := api.PolicyType(c.QueryParam("type"))
+		if err := api.ValidateProjectPolicy(pType, ""); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid project policy type: %q", pType)).SetInternal(err)
+		}
+
+		policy, err := s.store.GetProjectPolicy(ctx, &api.ProjectPolicyFind{
+			ProjectID: &projectID,
+			Type:      &pType,
+		})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to get project policy for type %q", pType)).SetInternal(err)
+		}
+		if policy == nil {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Project %d doesn't have the policy for type %q", projectID, pType))
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, policy); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal get project policy response: %v", pType)).SetInternal(err)
+		}
+		return nil
+	})
+
+	// The effective policy is the environment policy refined by the project policy,
+	// which is the one applied to the databases of the project in the environment.
+	g.GET("/policy/project/:projectID/environment/:environmentID/effective", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		projectID, err := strconv.Atoi(c.Param("projectID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("projectID is not a number: %s", c.Param("projectID"))).SetInternal(err)
+		}
+		environmentID, err := strconv.Atoi(c.Param("environmentID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("environmentID is not a number: %s", c.Param("environmentID"))).SetInternal(err)
+		}
+		pType := api.PolicyType(c.QueryParam("type"))
+		if err := api.ValidateProjectPolicy(pType, ""); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid project policy type: %q", pType)).SetInternal(err)
+		}
+
+		policy, err := s.store.GetPolicy(ctx, &api.PolicyFind{
+			EnvironmentID: &environmentID,
+			Type:          &pType,
+		})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to get policy for type %q", pType)).SetInternal(err)
+		}
+
+		switch pType {
+		case api.PolicyTypeSchemaReview:
+			schemaReviewPolicy, err := s.store.GetEffectiveSchemaReviewPolicy(ctx, environmentID, projectID)
+			if err != nil {
+				if common.ErrorCode(err) == common.NotFound {
+					return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
+				}
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to get effective schema review policy for project %d in environment %d", projectID, environmentID)).SetInternal(err)
+			}
+			payload, err := json.Marshal(schemaReviewPolicy)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal effective schema review policy").SetInternal(err)
+			}
+			policy.Payload = string(payload)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, policy); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal get effective policy response: %v", pType)).SetInternal(err)
+		}
+		return nil
+	})
+
 	g.GET("/policy", func(c echo.Context) error {
 		pType := api.PolicyType(c.QueryParam("type"))
 		if err := api.ValidatePolicy(pType, ""); err != nil {
@@ -159,3 +327,34 @@ func (s *Server) registerPolicyRoutes(g *echo.Group) {
 		return nil
 	})
 }
+
+// validateSchemaReviewPolicyOverride validates the rules added by the project override to the schema review policies
+// of the environments, which are the policies the override refines.
+func (s *Server) validateSchemaReviewPolicyOverride(ctx context.Context, payload string) error {
+	override, err := api.UnmarshalSchemaReviewPolicyOverride(payload)
+	if err != nil {
+		return common.Errorf(common.Invalid, err)
+	}
+	rowStatus := api.Normal
+	environmentList, err := s.store.FindEnvironment(ctx, &api.EnvironmentFind{RowStatus: &rowStatus})
+	if err != nil {
+		return err
+	}
+	var policyList []*advisor.SchemaReviewPolicy
+	for _, environment := range environmentList {
+		policy, err := s.store.GetNormalSchemaReviewPolicy(ctx, &api.PolicyFind{EnvironmentID: &environment.ID})
+		if err != nil {
+			if common.ErrorCode(err) == common.NotFound {
+				// The override is applied on its own in the environment without the policy.
+				policyList = append(policyList, &advisor.SchemaReviewPolicy{})
+				continue
+			}
+			return err
+		}
+		policyList = append(policyList, policy)
+	}
+	if err := override.ValidateNewRules(policyList); err != nil {
+		return common.Errorf(common.Invalid, fmt.Errorf("invalid schema review policy override: %w", err))
+	}
+	return nil
+}
@@ -520,6 +520,68 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 		return nil
 	})
 
+	// This function previews the databases matched by each deployment of the deployment configuration without saving it.
+	g.POST("/project/:id/deployment/preview", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("id"))).SetInternal(err)
+		}
+
+		preview := &api.DeploymentConfigPreview{}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, preview); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformed preview deployment configuration request").SetInternal(err)
+		}
+		if preview.DatabaseName == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "Database name is required to preview deployment configuration")
+		}
+		schedule, err := api.ValidateAndGetDeploymentSchedule(preview.Payload)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid deployment configuration: %v", err)).SetInternal(err)
+		}
+
+		project, err := s.store.GetProjectByID(ctx, id)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch project ID: %v", id)).SetInternal(err)
+		}
+		if project == nil {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Project not found with ID %d", id))
+		}
+
+		dbList, err := s.store.FindDatabase(ctx, &api.DatabaseFind{
+			ProjectID: &id,
+		})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch databases in project ID: %v", id)).SetInternal(err)
+		}
+		deployments, matrix, err := getDatabaseMatrixFromDeploymentSchedule(schedule, preview.DatabaseName, project.DBNameTemplate, dbList)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to preview deployment configuration").SetInternal(err)
+		}
+
+		deploymentMatrix := &api.DeploymentMatrix{}
+		for i, databaseList := range matrix {
+			stage := &api.DeploymentMatrixStage{
+				Name: deployments[i].Name,
+			}
+			rolloutWaveList := getTaskRolloutWaveList(deployments[i].Spec.Rollout, len(databaseList))
+			for j, database := range databaseList {
+				matrixDatabase := &api.DeploymentMatrixDatabase{
+					ID:              database.ID,
+					Name:            database.Name,
+					InstanceName:    database.Instance.Name,
+					EnvironmentName: database.Instance.Environment.Name,
+				}
+				if rolloutWaveList != nil {
+					matrixDatabase.RolloutWaveIndex = rolloutWaveList[j].Index
+				}
+				stage.DatabaseList = append(stage.DatabaseList, matrixDatabase)
+			}
+			deploymentMatrix.Deployments = append(deploymentMatrix.Deployments, stage)
+		}
+		return c.JSON(http.StatusOK, deploymentMatrix)
+	})
+
 	g.GET("/project/:id/deployment", func(c echo.Context) error {
 		ctx := c.Request().Context()
 		id, err := strconv.Atoi(c.Param("id"))
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+)
+
+// getRolloutWaveSizeList returns the number of databases in each wave to deploy total databases with the rollout strategy.
+// Waves that would be empty are omitted, and the databases not covered by the waves are deployed in the last wave.
+func getRolloutWaveSizeList(strategy *api.RolloutStrategy, total int) []int {
+	if strategy == nil {
+		return []int{total}
+	}
+	var sizeList []int
+	remaining := total
+	for _, wave := range strategy.WaveList {
+		if remaining == 0 {
+			break
+		}
+		size := wave.Count
+		if wave.Percent > 0 {
+			// Round up so that a wave has at least one database.
+			size = (total*wave.Percent + 99) / 100
+		}
+		if size > remaining {
+			size = remaining
+		}
+		sizeList = append(sizeList, size)
+		remaining -= size
+	}
+	if remaining > 0 {
+		sizeList = append(sizeList, remaining)
+	}
+	return sizeList
+}
+
+// getTaskRolloutWaveList returns the rollout wave for each of total databases in the stage, or nil if the stage is deployed in a single wave.
+func getTaskRolloutWaveList(strategy *api.RolloutStrategy, total int) []*api.TaskRolloutWave {
+	sizeList := getRolloutWaveSizeList(strategy, total)
+	if len(sizeList) <= 1 {
+		return nil
+	}
+	var waveList []*api.TaskRolloutWave
+	for i, size := range sizeList {
+		for j := 0; j < size; j++ {
+			waveList = append(waveList, &api.TaskRolloutWave{
+				Index:           i,
+				Count:           len(sizeList),
+				SoakMinutes:     strategy.SoakMinutes,
+				ManualPromotion: strategy.ManualPromotion,
+			})
+		}
+	}
+	return waveList
+}
+
+// getTaskRolloutWave returns the rollout wave of the task, or nil if the task isn't rolled out in waves.
+func getTaskRolloutWave(task *api.Task) (*api.TaskRolloutWave, error) {
+	if task.Type != api.TaskDatabaseSchemaUpdate && task.Type != api.TaskDatabaseDataUpdate {
+		return nil, nil
+	}
+	// The schema update and data update payloads share the same rollout wave field.
+	payload := &api.TaskDatabaseDataUpdatePayload{}
+	if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
+		return nil, fmt.Errorf("invalid task payload: %w", err)
+	}
+	return payload.RolloutWave, nil
+}
+
+// getNextRolloutWave returns the earliest wave of the stage which has not started yet, or nil if there is none.
+func getNextRolloutWave(stageTaskList []*api.Task) (*api.TaskRolloutWave, error) {
+	var next *api.TaskRolloutWave
+	for _, task := range stageTaskList {
+		if task.Status != api.TaskPendingApproval && task.Status != api.TaskPending {
+			continue
+		}
+		wave, err := getTaskRolloutWave(task)
+		if err != nil {
+			return nil, err
+		}
+		if wave != nil && (next == nil || wave.Index < next.Index) {
+			next = wave
+		}
+	}
+	return next, nil
+}
+
+// rolloutWaveResult is the result of checking whether the rollout wave of the task is ready to start.
+type rolloutWaveResult struct {
+	ready bool
+	// message explains why the wave isn't ready.
+	message string
+}
+
+// checkTaskRolloutWave checks if the rollout wave of the task is ready to start.
+func (s *Server) checkTaskRolloutWave(ctx context.Context, task *api.Task) (*rolloutWaveResult, error) {
+	wave, err := getTaskRolloutWave(task)
+	if err != nil {
+		return nil, err
+	}
+	if wave == nil || wave.Index == 0 {
+		return &rolloutWaveResult{ready: true}, nil
+	}
+
+	stageTaskList, err := s.store.FindTask(ctx, &api.TaskFind{PipelineID: &task.PipelineID, StageID: &task.StageID}, true /* returnOnErr */)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tasks in stage %d: %w", task.StageID, err)
+	}
+	promoted := false
+	if wave.ManualPromotion {
+		if promoted, err = s.isRolloutWavePromoted(ctx, task, wave.Index); err != nil {
+			return nil, err
+		}
+	}
+	return evaluateRolloutWave(wave, stageTaskList, promoted, time.Now())
+}
+
+// evaluateRolloutWave checks if the wave is ready to start given the tasks in the stage.
+// Any failed task in the previous waves halts the remaining waves.
+func evaluateRolloutWave(wave *api.TaskRolloutWave, stageTaskList []*api.Task, promoted bool, now time.Time) (*rolloutWaveResult, error) {
+	var previousWaveDoneTs int64
+	for _, task := range stageTaskList {
+		taskWave, err := getTaskRolloutWave(task)
+		if err != nil {
+			return nil, err
+		}
+		if taskWave == nil || taskWave.Index >= wave.Index {
+			continue
+		}
+		switch task.Status {
+		case api.TaskDone:
+			if task.UpdatedTs > previousWaveDoneTs {
+				previousWaveDoneTs = task.UpdatedTs
+			}
+		case api.TaskFailed, api.TaskCanceled:
+			return &rolloutWaveResult{ready: false, message: fmt.Sprintf("Rollout halted, task %q in wave %d/%d is %s", task.Name, taskWave.Index+1, taskWave.Count, task.Status)}, nil
+		default:
+			return &rolloutWaveResult{ready: false, message: fmt.Sprintf("Waiting for wave %d/%d to complete", taskWave.Index+1, taskWave.Count)}, nil
+		}
+	}
+
+	if wave.SoakMinutes > 0 {
+		soakUntil := time.Unix(previousWaveDoneTs, 0).Add(time.Duration(wave.SoakMinutes) * time.Minute)
+		if now.Before(soakUntil) {
+			return &rolloutWaveResult{ready: false, message: fmt.Sprintf("Soaking wave %d/%d until %s (UTC+0000)", wave.Index, wave.Count, soakUntil.UTC().Format(dataFormat))}, nil
+		}
+	}
+	if wave.ManualPromotion && !promoted {
+		return &rolloutWaveResult{ready: false, message: fmt.Sprintf("Waiting for wave %d/%d to be promoted", wave.Index+1, wave.Count)}, nil
+	}
+	return &rolloutWaveResult{ready: true}, nil
+}
+
+// isRolloutWavePromoted returns true if the wave of the stage of the task has been promoted.
+func (s *Server) isRolloutWavePromoted(ctx context.Context, task *api.Task, waveIndex int) (bool, error) {
+	issue, err := s.store.GetIssueByPipelineID(ctx, task.PipelineID)
+	if err != nil {
+		return false, fmt.Errorf("failed to find issue by pipeline ID %d: %w", task.PipelineID, err)
+	}
+	if issue == nil {
+		return false, nil
+	}
+	typePrefix := string(api.ActivityPipelineStageRolloutPromote)
+	activityList, err := s.store.FindActivity(ctx, &api.ActivityFind{
+		ContainerID: &issue.ID,
+		TypePrefix:  &typePrefix,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to find activities of issue %d: %w", issue.ID, err)
+	}
+	for _, activity := range activityList {
+		payload := &api.ActivityPipelineStageRolloutPromotePayload{}
+		if err := json.Unmarshal([]byte(activity.Payload), payload); err != nil {
+			return false, fmt.Errorf("failed to unmarshal activity %d payload: %w", activity.ID, err)
+		}
+		if payload.StageID == task.StageID && payload.WaveIndex == waveIndex {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRolloutWaveSizeList(t *testing.T) {
+	canary := &api.RolloutStrategy{
+		WaveList: []*api.RolloutWave{
+			{Count: 1},
+			{Percent: 10},
+		},
+	}
+	tests := []struct {
+		name     string
+		strategy *api.RolloutStrategy
+		total    int
+		want     []int
+	}{
+		{
+			name:     "no rollout strategy",
+			strategy: nil,
+			total:    5,
+			want:     []int{5},
+		},
+		{
+			name:     "canary",
+			strategy: canary,
+			total:    50,
+			want:     []int{1, 5, 44},
+		},
+		{
+			name:     "percent rounds up",
+			strategy: canary,
+			total:    5,
+			want:     []int{1, 1, 3},
+		},
+		{
+			name:     "fewer databases than waves",
+			strategy: canary,
+			total:    1,
+			want:     []int{1},
+		},
+		{
+			name: "waves cover all databases",
+			strategy: &api.RolloutStrategy{
+				WaveList: []*api.RolloutWave{
+					{Count: 2},
+					{Percent: 100},
+				},
+			},
+			total: 4,
+			want:  []int{2, 2},
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.want, getRolloutWaveSizeList(test.strategy, test.total), test.name)
+	}
+}
+
+func TestEvaluateRolloutWave(t *testing.T) {
+	now := time.Date(2022, 7, 6, 10, 0, 0, 0, time.UTC)
+	newTask := func(index int, status api.TaskStatus, updatedTs int64) *api.Task {
+		payload, err := json.Marshal(api.TaskDatabaseSchemaUpdatePayload{
+			RolloutWave: &api.TaskRolloutWave{Index: index, Count: 3},
+		})
+		require.NoError(t, err)
+		return &api.Task{
+			Name:      "task",
+			Type:      api.TaskDatabaseSchemaUpdate,
+			Status:    status,
+			Payload:   string(payload),
+			UpdatedTs: updatedTs,
+		}
+	}
+	soakWave := &api.TaskRolloutWave{Index: 1, Count: 3, SoakMinutes: 30}
+	manualWave := &api.TaskRolloutWave{Index: 1, Count: 3, ManualPromotion: true}
+
+	tests := []struct {
+		name          string
+		wave          *api.TaskRolloutWave
+		stageTaskList []*api.Task
+		promoted      bool
+		want          bool
+	}{
+		{
+			name:          "previous wave running",
+			wave:          soakWave,
+			stageTaskList: []*api.Task{newTask(0, api.TaskRunning, 0), newTask(1, api.TaskPending, 0)},
+			want:          false,
+		},
+		{
+			name:          "previous wave failed",
+			wave:          soakWave,
+			stageTaskList: []*api.Task{newTask(0, api.TaskFailed, 0), newTask(1, api.TaskPending, 0)},
+			want:          false,
+		},
+		{
+			name:          "soaking",
+			wave:          soakWave,
+			stageTaskList: []*api.Task{newTask(0, api.TaskDone, now.Add(-10*time.Minute).Unix()), newTask(1, api.TaskPending, 0)},
+			want:          false,
+		},
+		{
+			name:          "soak period elapsed",
+			wave:          soakWave,
+			stageTaskList: []*api.Task{newTask(0, api.TaskDone, now.Add(-30*time.Minute).Unix()), newTask(1, api.TaskPending, 0)},
+			want:          true,
+		},
+		{
+			name:          "not promoted",
+			wave:          manualWave,
+			stageTaskList: []*api.Task{newTask(0, api.TaskDone, 0), newTask(1, api.TaskPending, 0)},
+			want:          false,
+		},
+		{
+			name:          "promoted",
+			wave:          manualWave,
+			stageTaskList: []*api.Task{newTask(0, api.TaskDone, 0), newTask(1, api.TaskPending, 0)},
+			promoted:      true,
+			want:          true,
+		},
+	}
+
+	for _, test := range tests {
+		result, err := evaluateRolloutWave(test.wave, test.stageTaskList, test.promoted, now)
+		require.NoError(t, err, test.name)
+		assert.Equal(t, test.want, result.ready, "%s: %s", test.name, result.message)
+	}
+}
@@ -52,6 +52,11 @@ func (s *SchemaSyncer) Run(ctx context.Context, wg *sync.WaitGroup) {
 
 				ctx := context.Background()
 
+				// Only one replica runs the round.
+				if !s.server.claimRound(ctx, "schema_syncer", schemaSyncInterval) {
+					return
+				}
+
 				rowStatus := api.Normal
 				instanceFind := &api.InstanceFind{
 					RowStatus: &rowStatus,
@@ -49,7 +49,10 @@ type Server struct {
 	MetricReporter     *MetricReporter
 	SchemaSyncer       *SchemaSyncer
 	BackupRunner       *BackupRunner
+	BackupVerifier     *BackupVerifier
+	PITRArchiver       *PITRArchiver
 	AnomalyScanner     *AnomalyScanner
+	IssueScheduler     *IssueScheduler
 	runnerWG           sync.WaitGroup
 
 	ActivityManager *ActivityManager
@@ -65,6 +68,8 @@ type Server struct {
 	store         *store.Store
 	startedTs     int64
 	secret        string
+	// replicaID identifies this replica when claiming the background work among the replicas.
+	replicaID string
 
 	// boot specifies that whether the server boot correctly
 	cancel context.CancelFunc
@@ -135,6 +140,7 @@ func NewServer(ctx context.Context, prof Profile) (*Server, error) {
 	s := &Server{
 		profile:   prof,
 		startedTs: time.Now().Unix(),
+		replicaID: newReplicaID(),
 	}
 
 	// Display config
@@ -250,6 +256,9 @@ func NewServer(ctx context.Context, prof Profile) (*Server, error) {
 		restoreDBExecutor := NewDatabaseRestoreTaskExecutor()
 		taskScheduler.Register(api.TaskDatabaseRestore, restoreDBExecutor)
 
+		cloneDBExecutor := NewDatabaseCloneTaskExecutor()
+		taskScheduler.Register(api.TaskDatabaseClone, cloneDBExecutor)
+
 		schemaUpdateGhostSyncExecutor := NewSchemaUpdateGhostSyncTaskExecutor()
 		taskScheduler.Register(api.TaskDatabaseSchemaUpdateGhostSync, schemaUpdateGhostSyncExecutor)
 
@@ -259,6 +268,12 @@ func NewServer(ctx context.Context, prof Profile) (*Server, error) {
 		schemaUpdateGhostDropOriginalTableExecutor := NewSchemaUpdateGhostDropOriginalTableTaskExecutor()
 		taskScheduler.Register(api.TaskDatabaseSchemaUpdateGhostDropOriginalTable, schemaUpdateGhostDropOriginalTableExecutor)
 
+		schemaUpdatePgOnlineSyncExecutor := NewSchemaUpdatePgOnlineSyncTaskExecutor()
+		taskScheduler.Register(api.TaskDatabaseSchemaUpdatePgOnlineSync, schemaUpdatePgOnlineSyncExecutor)
+
+		schemaUpdatePgOnlineCutoverExecutor := NewSchemaUpdatePgOnlineCutoverTaskExecutor()
+		taskScheduler.Register(api.TaskDatabaseSchemaUpdatePgOnlineCutover, schemaUpdatePgOnlineCutoverExecutor)
+
 		pitrRestoreExecutor := NewPITRRestoreTaskExecutor(s.mysqlutil)
 		taskScheduler.Register(api.TaskDatabasePITRRestore, pitrRestoreExecutor)
 
@@ -289,6 +304,9 @@ func NewServer(ctx context.Context, prof Profile) (*Server, error) {
 		timingExecutor := NewTaskCheckTimingExecutor()
 		taskCheckScheduler.Register(api.TaskCheckGeneralEarliestAllowedTime, timingExecutor)
 
+		deploymentWindowExecutor := NewTaskCheckDeploymentWindowExecutor()
+		taskCheckScheduler.Register(api.TaskCheckGeneralDeploymentWindow, deploymentWindowExecutor)
+
 		s.TaskCheckScheduler = taskCheckScheduler
 
 		// Schema syncer
@@ -297,9 +315,18 @@ func NewServer(ctx context.Context, prof Profile) (*Server, error) {
 		// Backup runner
 		s.BackupRunner = NewBackupRunner(s, prof.BackupRunnerInterval)
 
+		// Backup verifier
+		s.BackupVerifier = NewBackupVerifier(s, prof.BackupRunnerInterval)
+
+		// PITR archiver
+		s.PITRArchiver = NewPITRArchiver(s, prof.BackupRunnerInterval)
+
 		// Anomaly scanner
 		s.AnomalyScanner = NewAnomalyScanner(s)
 
+		// Issue scheduler
+		s.IssueScheduler = NewIssueScheduler(s)
+
 		// Metric reporter
 		s.initMetricReporter(config.workspaceID)
 	}
@@ -353,6 +380,7 @@ func NewServer(ctx context.Context, prof Profile) (*Server, error) {
 	s.registerPolicyRoutes(apiGroup)
 	s.registerProjectRoutes(apiGroup)
 	s.registerProjectWebhookRoutes(apiGroup)
+	s.registerIssueScheduleRoutes(apiGroup)
 	s.registerProjectMemberRoutes(apiGroup)
 	s.registerEnvironmentRoutes(apiGroup)
 	s.registerInstanceRoutes(apiGroup)
@@ -360,6 +388,10 @@ func NewServer(ctx context.Context, prof Profile) (*Server, error) {
 	s.registerIssueRoutes(apiGroup)
 	s.registerIssueSubscriberRoutes(apiGroup)
 	s.registerTaskRoutes(apiGroup)
+	s.registerTaskApprovalRoutes(apiGroup)
+	s.registerDeploymentWindowRoutes(apiGroup)
+	s.registerTaskBatchRoutes(apiGroup)
+	s.registerTaskGhostRoutes(apiGroup)
 	s.registerStageRoutes(apiGroup)
 	s.registerActivityRoutes(apiGroup)
 	s.registerInboxRoutes(apiGroup)
@@ -460,6 +492,24 @@ func (s *Server) initSetting(ctx context.Context, store *store.Store) (*config,
 	}
 	conf.workspaceID = workspaceSetting.Value
 
+	// initial backup encryption key
+	if _, err := store.CreateSettingIfNotExist(ctx, &api.SettingCreate{
+		CreatorID:   api.SystemBotID,
+		Name:        api.SettingBackupEncryptionKey,
+		Value:       common.RandomString(secretLength),
+		Description: "Random string used to derive the key encrypting the backup files.",
+	}); err != nil {
+		return nil, err
+	}
+	if _, err := store.CreateSettingIfNotExist(ctx, &api.SettingCreate{
+		CreatorID:   api.SystemBotID,
+		Name:        api.SettingBackupRetiredEncryptionKey,
+		Value:       "[]",
+		Description: "The backup encryption keys replaced by the key rotation, kept to decrypt the backup files encrypted before.",
+	}); err != nil {
+		return nil, err
+	}
+
 	// initial license
 	if _, err = store.CreateSettingIfNotExist(ctx, &api.SettingCreate{
 		CreatorID:   api.SystemBotID,
@@ -487,8 +537,14 @@ func (s *Server) Run(ctx context.Context) error {
 		s.runnerWG.Add(1)
 		go s.BackupRunner.Run(ctx, &s.runnerWG)
 		s.runnerWG.Add(1)
+		go s.BackupVerifier.Run(ctx, &s.runnerWG)
+		s.runnerWG.Add(1)
+		go s.PITRArchiver.Run(ctx, &s.runnerWG)
+		s.runnerWG.Add(1)
 		go s.AnomalyScanner.Run(ctx, &s.runnerWG)
 		s.runnerWG.Add(1)
+		go s.IssueScheduler.Run(ctx, &s.runnerWG)
+		s.runnerWG.Add(1)
 
 		if s.MetricReporter != nil {
 			go s.MetricReporter.Run(ctx, &s.runnerWG)
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 
@@ -9,6 +11,7 @@ import (
 
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/common/backupfile"
 )
 
 var (
@@ -44,6 +47,31 @@ func (s *Server) registerSettingRoutes(g *echo.Group) {
 		return nil
 	})
 
+	// The backup encryption keys are exported to restore the encrypted backup files by "bb restore --encryption-key"
+	// outside Bytebase. The retired keys are included, since the backup files encrypted before the rotation still need them.
+	g.GET("/setting/backup-encryption-key", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		secretList, err := s.getBackupEncryptionSecretList(ctx)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch backup encryption key list").SetInternal(err)
+		}
+
+		keyList := []*api.BackupEncryptionKey{}
+		for i, secret := range secretList {
+			keyList = append(keyList, &api.BackupEncryptionKey{
+				ID:     backupfile.NewKey(secret).ID,
+				Key:    secret,
+				Active: i == 0,
+			})
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, keyList); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal backup encryption key list response").SetInternal(err)
+		}
+		return nil
+	})
+
 	g.PATCH("/setting/:name", func(c echo.Context) error {
 		ctx := c.Request().Context()
 		settingPatch := &api.SettingPatch{
@@ -59,7 +87,20 @@ func (s *Server) registerSettingRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusBadRequest, "Malformed update setting request").SetInternal(err)
 		}
 
-		setting, err := s.store.PatchSetting(ctx, settingPatch)
+		var setting *api.Setting
+		var err error
+		switch settingPatch.Name {
+		case api.SettingBackupRetiredEncryptionKey:
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Setting %s is managed by the backup encryption key rotation", settingPatch.Name))
+		case api.SettingBackupEncryptionKey:
+			if len(settingPatch.Value) < secretLength {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Backup encryption key must be at least %d characters", secretLength))
+			}
+			// The replaced key is retired instead of dropped, so that the existing backup files can still be decrypted.
+			setting, err = s.store.RotateBackupEncryptionKey(ctx, settingPatch)
+		default:
+			setting, err = s.store.PatchSetting(ctx, settingPatch)
+		}
 		if err != nil {
 			if common.ErrorCode(err) == common.NotFound {
 				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Setting name not found: %s", settingPatch.Name))
@@ -74,3 +115,56 @@ func (s *Server) registerSettingRoutes(g *echo.Group) {
 		return nil
 	})
 }
+
+// getBackupEncryptionSecretList returns the secret of the active backup encryption key followed by those of the retired keys.
+// The keys are read from the store on each use, so that a rotation takes effect on all the replicas.
+func (s *Server) getBackupEncryptionSecretList(ctx context.Context) ([]string, error) {
+	activeName := api.SettingBackupEncryptionKey
+	activeList, err := s.store.FindSetting(ctx, &api.SettingFind{Name: &activeName})
+	if err != nil {
+		return nil, err
+	}
+	if len(activeList) == 0 {
+		return nil, fmt.Errorf("setting %s not found", activeName)
+	}
+	retiredName := api.SettingBackupRetiredEncryptionKey
+	retiredList, err := s.store.FindSetting(ctx, &api.SettingFind{Name: &retiredName})
+	if err != nil {
+		return nil, err
+	}
+	if len(retiredList) == 0 {
+		return nil, fmt.Errorf("setting %s not found", retiredName)
+	}
+	var retiredSecretList []string
+	if err := json.Unmarshal([]byte(retiredList[0].Value), &retiredSecretList); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal setting %s, error: %w", retiredName, err)
+	}
+	// The most recently retired key comes first.
+	secretList := []string{activeList[0].Value}
+	for i := len(retiredSecretList) - 1; i >= 0; i-- {
+		secretList = append(secretList, retiredSecretList[i])
+	}
+	return secretList, nil
+}
+
+// getBackupEncryptionKey returns the active key encrypting the new backup files.
+func (s *Server) getBackupEncryptionKey(ctx context.Context) (*backupfile.Key, error) {
+	secretList, err := s.getBackupEncryptionSecretList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return backupfile.NewKey(secretList[0]), nil
+}
+
+// getBackupDecryptionKeyList returns the active and the retired keys decrypting the backup files.
+func (s *Server) getBackupDecryptionKeyList(ctx context.Context) ([]*backupfile.Key, error) {
+	secretList, err := s.getBackupEncryptionSecretList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var keyList []*backupfile.Key
+	for _, secret := range secretList {
+		keyList = append(keyList, backupfile.NewKey(secret))
+	}
+	return keyList, nil
+}
@@ -186,7 +186,8 @@ func (s *Server) registerSQLRoutes(g *echo.Group) {
 				dbType,
 				db.CharacterSet,
 				db.Collation,
-				instance.EnvironmentID,
+				instance.Environment,
+				db.ProjectID,
 				exec.Statement,
 				store.NewCatalog(&db.ID, s.store),
 			)
@@ -817,12 +818,13 @@ func (s *Server) sqlCheck(
 	dbType advisor.DBType,
 	dbCharacterSet string,
 	dbCollation string,
-	environmentID int,
+	environment *api.Environment,
+	projectID int,
 	statement string,
 	catalog catalog.Catalog,
 ) (advisor.Status, []advisor.Advice, error) {
 	var adviceList []advisor.Advice
-	policy, err := s.store.GetNormalSchemaReviewPolicy(ctx, &api.PolicyFind{EnvironmentID: &environmentID})
+	policy, err := s.store.GetEffectiveSchemaReviewPolicy(ctx, environment.ID, projectID)
 	if err != nil {
 		if e, ok := err.(*common.Error); ok && e.Code == common.NotFound {
 			adviceList = []advisor.Advice{
@@ -843,6 +845,8 @@ func (s *Server) sqlCheck(
 		Collation: dbCollation,
 		DbType:    dbType,
 		Catalog:   catalog,
+
+		EnvironmentName: environment.Name,
 	})
 	if err != nil {
 		return advisor.Error, nil, err
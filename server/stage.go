@@ -1,6 +1,7 @@
 package server
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -64,4 +65,87 @@ func (s *Server) registerStageRoutes(g *echo.Group) {
 		}
 		return nil
 	})
+
+	// This function promotes the next rollout wave of the stage.
+	g.POST("/pipeline/:pipelineID/stage/:stageID/promote", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		stageID, err := strconv.Atoi(c.Param("stageID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Stage ID is not a number: %s", c.Param("stageID"))).SetInternal(err)
+		}
+		pipelineID, err := strconv.Atoi(c.Param("pipelineID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Pipeline ID is not a number: %s", c.Param("pipelineID"))).SetInternal(err)
+		}
+
+		currentPrincipalID := c.Get(getPrincipalIDContextKey()).(int)
+		if err := s.validateIssueAssignee(ctx, currentPrincipalID, pipelineID); err != nil {
+			return err
+		}
+
+		stageList, err := s.store.FindStage(ctx, &api.StageFind{ID: &stageID, PipelineID: &pipelineID})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get stage").SetInternal(err)
+		}
+		if len(stageList) == 0 {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Stage not found with ID %d", stageID))
+		}
+		stage := stageList[0]
+		tasks, err := s.store.FindTask(ctx, &api.TaskFind{PipelineID: &pipelineID, StageID: &stageID}, true /* returnOnErr */)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get tasks").SetInternal(err)
+		}
+		wave, err := getNextRolloutWave(tasks)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get the next rollout wave").SetInternal(err)
+		}
+		if wave == nil || wave.Index == 0 || !wave.ManualPromotion {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Stage %q has no rollout wave to promote", stage.Name))
+		}
+		for _, task := range tasks {
+			taskWave, err := getTaskRolloutWave(task)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get the task rollout wave").SetInternal(err)
+			}
+			if taskWave != nil && taskWave.Index < wave.Index && task.Status != api.TaskDone {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Rollout wave %d/%d of stage %q has not completed", taskWave.Index+1, taskWave.Count, stage.Name))
+			}
+		}
+
+		issue, err := s.store.GetIssueByPipelineID(ctx, pipelineID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to find issue").SetInternal(err)
+		}
+		if issue == nil {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Issue not found by pipeline ID: %d", pipelineID))
+		}
+		payload, err := json.Marshal(api.ActivityPipelineStageRolloutPromotePayload{
+			StageID:   stage.ID,
+			WaveIndex: wave.Index,
+			WaveCount: wave.Count,
+			IssueName: issue.Name,
+			StageName: stage.Name,
+		})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create activity after promoting rollout wave").SetInternal(err)
+		}
+		activityCreate := &api.ActivityCreate{
+			CreatorID:   currentPrincipalID,
+			ContainerID: issue.ID,
+			Type:        api.ActivityPipelineStageRolloutPromote,
+			Level:       api.ActivityInfo,
+			Payload:     string(payload),
+		}
+		if _, err := s.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{
+			issue: issue,
+		}); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create activity after promoting rollout wave").SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, tasks); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal promote rollout wave response").SetInternal(err)
+		}
+		return nil
+	})
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/common"
 	"github.com/bytebase/bytebase/common/log"
+	"github.com/bytebase/bytebase/plugin/db/pg"
 )
 
 var (
@@ -114,6 +115,8 @@ func (s *Server) registerTaskRoutes(g *echo.Group) {
 				// We should update the schema version if we've updated the SQL, otherwise we will
 				// get migration history version conflict if the previous task has been attempted.
 				payload.SchemaVersion = common.DefaultMigrationVersion()
+				// The new statement runs from the first chunk.
+				payload.BatchCheckpoint = nil
 				bytes, err := json.Marshal(payload)
 				if err != nil {
 					return echo.NewHTTPError(http.StatusInternalServerError, "Failed to construct updated task payload").SetInternal(err)
@@ -153,6 +156,35 @@ func (s *Server) registerTaskRoutes(g *echo.Group) {
 				}
 				payloadStr := string(bytes)
 				taskPatch.Payload = &payloadStr
+
+			case api.TaskDatabaseSchemaUpdatePgOnlineSync:
+				payload := &api.TaskDatabaseSchemaUpdatePgOnlineSyncPayload{}
+				if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
+					return echo.NewHTTPError(http.StatusBadRequest, "Malformed database schema update online sync payload").SetInternal(err)
+				}
+				// The cutover task is created only if the statement rewrites a table, so the new statement must keep it that way.
+				oldPlan, err := pg.GetOnlineMigrationPlan(payload.Statement)
+				if err != nil {
+					return echo.NewHTTPError(http.StatusInternalServerError, "Failed to plan the online migration of the original statement").SetInternal(err)
+				}
+				newPlan, err := pg.GetOnlineMigrationPlan(*taskPatch.Statement)
+				if err != nil {
+					return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid statement for online migration: %v", err))
+				}
+				if (oldPlan.ShadowTable == nil) != (newPlan.ShadowTable == nil) {
+					return echo.NewHTTPError(http.StatusBadRequest, "Cannot change whether the statement rewrites a table, please create a new issue instead")
+				}
+				oldStatement = payload.Statement
+				payload.Statement = *taskPatch.Statement
+				// We should update the schema version if we've updated the SQL, otherwise we will
+				// get migration history version conflict if the previous task has been attempted.
+				payload.SchemaVersion = common.DefaultMigrationVersion()
+				bytes, err := json.Marshal(payload)
+				if err != nil {
+					return echo.NewHTTPError(http.StatusInternalServerError, "Failed to construct updated task payload").SetInternal(err)
+				}
+				payloadStr := string(bytes)
+				taskPatch.Payload = &payloadStr
 			}
 		}
 
@@ -162,7 +194,7 @@ func (s *Server) registerTaskRoutes(g *echo.Group) {
 		}
 
 		// create an activity and trigger task check for statement update
-		if taskPatched.Type == api.TaskDatabaseSchemaUpdate || taskPatched.Type == api.TaskDatabaseDataUpdate || taskPatched.Type == api.TaskDatabaseSchemaUpdateGhostSync {
+		if taskPatched.Type == api.TaskDatabaseSchemaUpdate || taskPatched.Type == api.TaskDatabaseDataUpdate || taskPatched.Type == api.TaskDatabaseSchemaUpdateGhostSync || taskPatched.Type == api.TaskDatabaseSchemaUpdatePgOnlineSync {
 			if oldStatement != newStatement {
 				// create an activity
 				if issue == nil {
@@ -302,6 +334,7 @@ func (s *Server) registerTaskRoutes(g *echo.Group) {
 			}
 		}
 
+		s.setTaskQueuePosition(taskPatched)
 		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
 		if err := jsonapi.MarshalPayload(c.Response().Writer, taskPatched); err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal update task \"%v\" status response", taskPatched.Name)).SetInternal(err)
@@ -345,6 +378,7 @@ func (s *Server) registerTaskRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to update task \"%v\" status", task.Name)).SetInternal(err)
 		}
 
+		s.setTaskQueuePosition(taskPatched)
 		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
 		if err := jsonapi.MarshalPayload(c.Response().Writer, taskPatched); err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal update task \"%v\" status response", taskPatched.Name)).SetInternal(err)
@@ -373,6 +407,7 @@ func (s *Server) registerTaskRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to run task check \"%v\"", task.Name)).SetInternal(err)
 		}
 
+		s.setTaskQueuePosition(taskUpdated)
 		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
 		if err := jsonapi.MarshalPayload(c.Response().Writer, taskUpdated); err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal update task \"%v\" status response", taskUpdated.Name)).SetInternal(err)
@@ -403,6 +438,17 @@ func (s *Server) validateIssueAssignee(ctx context.Context, currentPrincipalID,
 	return nil
 }
 
+// setTaskQueuePosition sets the queue position of the RUNNING tasks waiting for the concurrency limits.
+func (s *Server) setTaskQueuePosition(taskList ...*api.Task) {
+	// The task scheduler doesn't run in readonly mode.
+	if s.TaskScheduler == nil {
+		return
+	}
+	for _, task := range taskList {
+		task.QueuePosition = s.TaskScheduler.GetQueuePosition(task.ID)
+	}
+}
+
 func (s *Server) changeTaskStatus(ctx context.Context, task *api.Task, newStatus api.TaskStatus, updaterID int) (*api.Task, error) {
 	taskStatusPatch := &api.TaskStatusPatch{
 		ID:        task.ID,
@@ -429,6 +475,12 @@ func (s *Server) changeTaskStatusWithPatch(ctx context.Context, task *api.Task,
 			Code: common.Invalid,
 			Err:  fmt.Errorf("invalid task status transition from %v to %v. Applicable transition(s) %v", task.Status, taskStatusPatch.Status, applicableTaskStatusTransition[task.Status])}
 	}
+	// The task is approved through either the task or the stage status, so we check the approval chain here for both.
+	if task.Status == api.TaskPendingApproval && taskStatusPatch.Status == api.TaskPending {
+		if err := s.validateTaskApproval(ctx, task); err != nil {
+			return nil, err
+		}
+	}
 
 	taskPatched, err := s.store.PatchTaskStatus(ctx, taskStatusPatch)
 	if err != nil {
@@ -493,8 +545,9 @@ func (s *Server) changeTaskStatusWithPatch(ctx context.Context, task *api.Task,
 		return nil, err
 	}
 
-	// If create database, schema update and gh-ost cutover task completes, we sync the corresponding instance schema immediately.
-	if (taskPatched.Type == api.TaskDatabaseCreate || taskPatched.Type == api.TaskDatabaseSchemaUpdate || taskPatched.Type == api.TaskDatabaseSchemaUpdateGhostCutover) && taskPatched.Status == api.TaskDone {
+	// If create database, schema update, gh-ost cutover and Postgres online schema update task completes, we sync the corresponding instance schema immediately.
+	if (taskPatched.Type == api.TaskDatabaseCreate || taskPatched.Type == api.TaskDatabaseSchemaUpdate || taskPatched.Type == api.TaskDatabaseSchemaUpdateGhostCutover ||
+		taskPatched.Type == api.TaskDatabaseSchemaUpdatePgOnlineSync || taskPatched.Type == api.TaskDatabaseSchemaUpdatePgOnlineCutover) && taskPatched.Status == api.TaskDone {
 		instance, err := s.store.GetInstanceByID(ctx, task.InstanceID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to sync instance schema after completing task: %w", err)
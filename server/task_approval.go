@@ -0,0 +1,248 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/google/jsonapi"
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) registerTaskApprovalRoutes(g *echo.Group) {
+	g.POST("/pipeline/:pipelineID/task/:taskID/approval", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		taskID, err := strconv.Atoi(c.Param("taskID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Task ID is not a number: %s", c.Param("taskID"))).SetInternal(err)
+		}
+
+		task, err := s.store.GetTaskByID(ctx, taskID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to approve task").SetInternal(err)
+		}
+		if task == nil {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Task not found with ID %d", taskID))
+		}
+
+		currentPrincipalID := c.Get(getPrincipalIDContextKey()).(int)
+		if err := s.approveTask(ctx, task, currentPrincipalID); err != nil {
+			if common.ErrorCode(err) == common.Invalid {
+				return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessage(err))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to approve task %q", task.Name)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, task); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal approve task %q response", task.Name)).SetInternal(err)
+		}
+		return nil
+	})
+}
+
+// approveTask records the approval of the principal on the current step of the task approval chain.
+func (s *Server) approveTask(ctx context.Context, task *api.Task, principalID int) error {
+	if task.Status != api.TaskPendingApproval {
+		return &common.Error{Code: common.Invalid, Err: fmt.Errorf("task %q is not pending approval", task.Name)}
+	}
+	policy, err := s.store.GetPipelineApprovalPolicy(ctx, task.Instance.EnvironmentID)
+	if err != nil {
+		return fmt.Errorf("failed to get pipeline approval policy for environment %d: %w", task.Instance.EnvironmentID, err)
+	}
+	if len(policy.ApprovalStepList) == 0 {
+		return &common.Error{Code: common.Invalid, Err: fmt.Errorf("environment %q has no approval steps, the assignee could approve the task directly", task.Instance.Environment.Name)}
+	}
+
+	issue, err := s.store.GetIssueByPipelineID(ctx, task.PipelineID)
+	if err != nil {
+		return fmt.Errorf("failed to find issue by pipeline ID %d: %w", task.PipelineID, err)
+	}
+	if issue == nil {
+		return fmt.Errorf("issue not found by pipeline ID %d", task.PipelineID)
+	}
+	if issue.CreatorID == principalID {
+		return &common.Error{Code: common.Invalid, Err: fmt.Errorf("self-approval is not allowed")}
+	}
+
+	approvalList, err := s.findTaskApprovalList(ctx, task, issue)
+	if err != nil {
+		return err
+	}
+	for _, approval := range approvalList {
+		if approval.principalID == principalID {
+			return &common.Error{Code: common.Invalid, Err: fmt.Errorf("you have already approved step %d of task %q", approval.stepIndex+1, task.Name)}
+		}
+	}
+	stepIndex := getPendingApprovalStepIndex(policy.ApprovalStepList, approvalList)
+	if stepIndex == len(policy.ApprovalStepList) {
+		return &common.Error{Code: common.Invalid, Err: fmt.Errorf("task %q has already passed all the approval steps", task.Name)}
+	}
+	step := policy.ApprovalStepList[stepIndex]
+	eligible, err := s.isEligibleApprover(ctx, step, issue.ProjectID, principalID)
+	if err != nil {
+		return err
+	}
+	if !eligible {
+		return &common.Error{Code: common.Invalid, Err: fmt.Errorf("you are not eligible to approve step %d of task %q, which requires %s", stepIndex+1, task.Name, step.ApproverType)}
+	}
+
+	payload, err := json.Marshal(api.ActivityPipelineTaskApprovePayload{
+		TaskID:    task.ID,
+		StepIndex: stepIndex,
+		StepCount: len(policy.ApprovalStepList),
+		IssueName: issue.Name,
+		TaskName:  task.Name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal activity payload after approving task %q: %w", task.Name, err)
+	}
+	activityCreate := &api.ActivityCreate{
+		CreatorID:   principalID,
+		ContainerID: issue.ID,
+		Type:        api.ActivityPipelineTaskApprove,
+		Level:       api.ActivityInfo,
+		Payload:     string(payload),
+	}
+	if _, err := s.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{
+		issue: issue,
+	}); err != nil {
+		return fmt.Errorf("failed to create activity after approving task %q: %w", task.Name, err)
+	}
+	return nil
+}
+
+// validateTaskApproval returns an Invalid error if the task has not passed all the steps of the approval chain.
+func (s *Server) validateTaskApproval(ctx context.Context, task *api.Task) error {
+	policy, err := s.store.GetPipelineApprovalPolicy(ctx, task.Instance.EnvironmentID)
+	if err != nil {
+		return fmt.Errorf("failed to get pipeline approval policy for environment %d: %w", task.Instance.EnvironmentID, err)
+	}
+	if len(policy.ApprovalStepList) == 0 {
+		return nil
+	}
+
+	issue, err := s.store.GetIssueByPipelineID(ctx, task.PipelineID)
+	if err != nil {
+		return fmt.Errorf("failed to find issue by pipeline ID %d: %w", task.PipelineID, err)
+	}
+	if issue == nil {
+		return fmt.Errorf("issue not found by pipeline ID %d", task.PipelineID)
+	}
+	approvalList, err := s.findTaskApprovalList(ctx, task, issue)
+	if err != nil {
+		return err
+	}
+	stepIndex := getPendingApprovalStepIndex(policy.ApprovalStepList, approvalList)
+	if stepIndex < len(policy.ApprovalStepList) {
+		step := policy.ApprovalStepList[stepIndex]
+		return &common.Error{Code: common.Invalid, Err: fmt.Errorf("task %q is waiting for %d approval(s) from %s at step %d of %d", task.Name, step.GetQuorum(), step.ApproverType, stepIndex+1, len(policy.ApprovalStepList))}
+	}
+	return nil
+}
+
+// taskApproval is an approval recorded in the activities.
+type taskApproval struct {
+	principalID int
+	stepIndex   int
+}
+
+// findTaskApprovalList returns the approvals of the task.
+// Changing the task statement invalidates the approvals made before.
+func (s *Server) findTaskApprovalList(ctx context.Context, task *api.Task, issue *api.Issue) ([]*taskApproval, error) {
+	typePrefix := "bb.pipeline.task."
+	activityList, err := s.store.FindActivity(ctx, &api.ActivityFind{
+		ContainerID: &issue.ID,
+		TypePrefix:  &typePrefix,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find activities of issue %d: %w", issue.ID, err)
+	}
+	// Activities in the same second share the created_ts, so we rely on the ID for the order.
+	sort.Slice(activityList, func(i, j int) bool {
+		return activityList[i].ID < activityList[j].ID
+	})
+
+	var approvalList []*taskApproval
+	for _, activity := range activityList {
+		switch activity.Type {
+		case api.ActivityPipelineTaskStatementUpdate:
+			payload := &api.ActivityPipelineTaskStatementUpdatePayload{}
+			if err := json.Unmarshal([]byte(activity.Payload), payload); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal activity %d payload: %w", activity.ID, err)
+			}
+			if payload.TaskID == task.ID {
+				approvalList = nil
+			}
+		case api.ActivityPipelineTaskApprove:
+			payload := &api.ActivityPipelineTaskApprovePayload{}
+			if err := json.Unmarshal([]byte(activity.Payload), payload); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal activity %d payload: %w", activity.ID, err)
+			}
+			if payload.TaskID == task.ID {
+				approvalList = append(approvalList, &taskApproval{
+					principalID: activity.CreatorID,
+					stepIndex:   payload.StepIndex,
+				})
+			}
+		}
+	}
+	return approvalList, nil
+}
+
+// getPendingApprovalStepIndex returns the index of the first step which has not collected the quorum approvals,
+// or the length of the step list if all steps are passed.
+func getPendingApprovalStepIndex(stepList []*api.PipelineApprovalStep, approvalList []*taskApproval) int {
+	approvalCount := make(map[int]int)
+	for _, approval := range approvalList {
+		approvalCount[approval.stepIndex]++
+	}
+	for i, step := range stepList {
+		if approvalCount[i] < step.GetQuorum() {
+			return i
+		}
+	}
+	return len(stepList)
+}
+
+// isEligibleApprover returns true if the principal could approve the step for the issue in the project.
+func (s *Server) isEligibleApprover(ctx context.Context, step *api.PipelineApprovalStep, projectID int, principalID int) (bool, error) {
+	switch step.ApproverType {
+	case api.PipelineApprovalApproverProjectOwner:
+		memberList, err := s.store.FindProjectMember(ctx, &api.ProjectMemberFind{ProjectID: &projectID})
+		if err != nil {
+			return false, fmt.Errorf("failed to find members of project %d: %w", projectID, err)
+		}
+		for _, member := range memberList {
+			if member.PrincipalID == principalID && member.Role == string(common.ProjectOwner) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case api.PipelineApprovalApproverWorkspaceDBA, api.PipelineApprovalApproverWorkspaceOwner:
+		principal, err := s.store.GetPrincipalByID(ctx, principalID)
+		if err != nil {
+			return false, fmt.Errorf("failed to find principal %d: %w", principalID, err)
+		}
+		if principal == nil {
+			return false, nil
+		}
+		if step.ApproverType == api.PipelineApprovalApproverWorkspaceDBA {
+			return principal.Role == api.DBA, nil
+		}
+		return principal.Role == api.Owner, nil
+	case api.PipelineApprovalApproverPrincipal:
+		for _, id := range step.PrincipalIDList {
+			if id == principalID {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPendingApprovalStepIndex(t *testing.T) {
+	stepList := []*api.PipelineApprovalStep{
+		{ApproverType: api.PipelineApprovalApproverProjectOwner},
+		{ApproverType: api.PipelineApprovalApproverWorkspaceDBA, Quorum: 2},
+		{ApproverType: api.PipelineApprovalApproverPrincipal, PrincipalIDList: []int{101}},
+	}
+
+	tests := []struct {
+		name         string
+		approvalList []*taskApproval
+		want         int
+	}{
+		{
+			name:         "no approval",
+			approvalList: nil,
+			want:         0,
+		},
+		{
+			name: "first step passed",
+			approvalList: []*taskApproval{
+				{principalID: 102, stepIndex: 0},
+			},
+			want: 1,
+		},
+		{
+			name: "quorum not reached",
+			approvalList: []*taskApproval{
+				{principalID: 102, stepIndex: 0},
+				{principalID: 103, stepIndex: 1},
+			},
+			want: 1,
+		},
+		{
+			name: "quorum reached",
+			approvalList: []*taskApproval{
+				{principalID: 102, stepIndex: 0},
+				{principalID: 103, stepIndex: 1},
+				{principalID: 104, stepIndex: 1},
+			},
+			want: 2,
+		},
+		{
+			name: "all steps passed",
+			approvalList: []*taskApproval{
+				{principalID: 102, stepIndex: 0},
+				{principalID: 103, stepIndex: 1},
+				{principalID: 104, stepIndex: 1},
+				{principalID: 101, stepIndex: 2},
+			},
+			want: 3,
+		},
+	}
+
+	for _, test := range tests {
+		got := getPendingApprovalStepIndex(stepList, test.approvalList)
+		assert.Equal(t, test.want, got, test.name)
+	}
+}
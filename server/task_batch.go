@@ -0,0 +1,583 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/jsonapi"
+	"github.com/labstack/echo/v4"
+	pgquery "github.com/pganalyze/pg_query_go/v2"
+	tidbparser "github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/parser/ast"
+	"github.com/pingcap/tidb/parser/format"
+	"github.com/pingcap/tidb/parser/opcode"
+	"go.uber.org/zap"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/common/log"
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/db/util"
+)
+
+// batchPollInterval is the interval to check whether the paused or throttled batches can continue.
+const batchPollInterval = 5 * time.Second
+
+func (s *Server) registerTaskBatchRoutes(g *echo.Group) {
+	g.PATCH("/pipeline/:pipelineID/task/:taskID/batch", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		taskID, err := strconv.Atoi(c.Param("taskID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Task ID is not a number: %s", c.Param("taskID"))).SetInternal(err)
+		}
+
+		batchPatch := &api.TaskBatchPatch{
+			UpdaterID: c.Get(getPrincipalIDContextKey()).(int),
+		}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, batchPatch); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformed update task batch request").SetInternal(err)
+		}
+
+		task, err := s.store.GetTaskByID(ctx, taskID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to update task batch \"%v\"", taskID)).SetInternal(err)
+		}
+		if task == nil {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Task not found with ID %d", taskID))
+		}
+		if err := s.validateIssueAssignee(ctx, batchPatch.UpdaterID, task.PipelineID); err != nil {
+			return err
+		}
+		if task.Type != api.TaskDatabaseDataUpdate {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Task %q is not a data update task", task.Name))
+		}
+		if task.Status == api.TaskDone || task.Status == api.TaskCanceled {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Task %q has already finished", task.Name))
+		}
+		payload := &api.TaskDatabaseDataUpdatePayload{}
+		if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Invalid data update payload").SetInternal(err)
+		}
+		if payload.Batch == nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Task %q doesn't run in batches", task.Name))
+		}
+
+		// Only set the paused field, because the running task saves the checkpoint in the payload meanwhile.
+		bytes, err := json.Marshal(map[string]bool{"batchPaused": batchPatch.Paused})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal data update payload").SetInternal(err)
+		}
+		payloadMerge := string(bytes)
+		taskPatched, err := s.store.PatchTask(ctx, &api.TaskPatch{
+			ID:           task.ID,
+			UpdaterID:    batchPatch.UpdaterID,
+			PayloadMerge: &payloadMerge,
+		})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to update task batch \"%v\"", task.Name)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, taskPatched); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal update task batch \"%v\" response", task.Name)).SetInternal(err)
+		}
+		return nil
+	})
+}
+
+// runBatchMigration runs the data update statement in batches by the primary key ranges, and records it as a single migration.
+func runBatchMigration(ctx context.Context, server *Server, task *api.Task, payload *api.TaskDatabaseDataUpdatePayload) (terminated bool, result *api.TaskRunResultPayload, err error) {
+	mi, err := preMigration(ctx, server, task, db.Data, payload.Statement, payload.SchemaVersion, payload.VCSPushEvent)
+	if err != nil {
+		return true, nil, err
+	}
+	// The checkpoint tracks the finished chunks, so running the failed task again continues the migration history
+	// of the previous attempt from the checkpoint instead of being rejected.
+	mi.Force = true
+	migrationID, schema, progress, err := executeBatchMigration(ctx, server, task, payload, mi)
+	if err != nil {
+		return true, nil, err
+	}
+	terminated, result, err = postMigration(ctx, server, task, payload.VCSPushEvent, mi, migrationID, schema)
+	if err != nil {
+		return terminated, result, err
+	}
+	result.Detail = fmt.Sprintf("%s Completed %d chunks.", result.Detail, progress.CompletedChunkCount)
+	result.BatchProgress = progress
+	return terminated, result, nil
+}
+
+func executeBatchMigration(ctx context.Context, server *Server, task *api.Task, payload *api.TaskDatabaseDataUpdatePayload, mi *db.MigrationInfo) (migrationHistoryID int64, updatedSchema string, progress *api.TaskRunBatchProgress, resErr error) {
+	config := payload.Batch
+	driver, err := getAdminDatabaseDriver(ctx, task.Instance, task.Database.Name, server.pgInstanceDir)
+	if err != nil {
+		return -1, "", nil, err
+	}
+	defer driver.Close(ctx)
+	needsSetup, err := driver.NeedsSetupMigration(ctx)
+	if err != nil {
+		return -1, "", nil, fmt.Errorf("failed to check migration setup for instance %q: %w", task.Instance.Name, err)
+	}
+	if needsSetup {
+		return -1, "", nil, common.Errorf(common.MigrationSchemaMissing, fmt.Errorf("missing migration schema for instance %q", task.Instance.Name))
+	}
+	executor, ok := driver.(util.MigrationExecutor)
+	if !ok {
+		return -1, "", nil, fmt.Errorf("batch mode is not supported for %s", task.Instance.Engine)
+	}
+
+	var prevSchemaBuf bytes.Buffer
+	if _, err := driver.Dump(ctx, mi.Database, &prevSchemaBuf, true /* schemaOnly */); err != nil {
+		return -1, "", nil, err
+	}
+	insertedID, err := util.BeginMigration(ctx, executor, mi, prevSchemaBuf.String(), payload.Statement, db.BytebaseDatabase)
+	if err != nil {
+		return -1, "", nil, err
+	}
+	startedNs := time.Now().UnixNano()
+	defer func() {
+		if err := util.EndMigration(ctx, executor, startedNs, insertedID, updatedSchema, db.BytebaseDatabase, resErr == nil /*isDone*/); err != nil {
+			log.Error("failed to update migration history record",
+				zap.Error(err),
+				zap.Int64("migration_id", migrationHistoryID),
+			)
+		}
+	}()
+
+	// Switch to the target database.
+	if _, err := executor.GetDbConnection(ctx, mi.Database); err != nil {
+		return -1, "", nil, err
+	}
+	minKey, maxKey, empty, err := getBatchKeyRange(ctx, driver, config)
+	if err != nil {
+		return -1, "", nil, err
+	}
+
+	progress = getBatchInitialProgress(minKey, maxKey, empty, config.ChunkSize, payload.BatchCheckpoint)
+	if payload.BatchCheckpoint != nil {
+		log.Info("Resume the batches from the checkpoint",
+			zap.Int("task_id", task.ID),
+			zap.Int64("next_key", progress.NextKey),
+			zap.Int64("completed_chunk_count", progress.CompletedChunkCount),
+		)
+	}
+	for !empty && progress.NextKey <= maxKey {
+		if err := waitBatchResumed(ctx, server, task, progress); err != nil {
+			return -1, "", nil, err
+		}
+		if err := waitReplicationLag(ctx, server, task, driver, config, progress); err != nil {
+			return -1, "", nil, err
+		}
+
+		statement, err := getBatchStatement(task.Instance.Engine, payload.Statement, config, progress.NextKey, progress.NextKey+config.ChunkSize)
+		if err != nil {
+			return -1, "", nil, err
+		}
+		if err := driver.Execute(ctx, statement); err != nil {
+			return -1, "", nil, fmt.Errorf("failed to execute the chunk starting at %s %d: %w", config.PrimaryKey, progress.NextKey, util.FormatError(err))
+		}
+		progress.CompletedChunkCount++
+		progress.NextKey += config.ChunkSize
+		if err := saveBatchCheckpoint(ctx, server, task, progress); err != nil {
+			return -1, "", nil, err
+		}
+		reportBatchProgress(ctx, server, task, progress)
+
+		if config.SleepMs > 0 && progress.NextKey <= maxKey {
+			select {
+			case <-ctx.Done():
+				return -1, "", nil, ctx.Err()
+			case <-time.After(time.Duration(config.SleepMs) * time.Millisecond):
+			}
+		}
+	}
+	progress.State = api.TaskRunBatchDone
+
+	var afterSchemaBuf bytes.Buffer
+	if _, err := executor.Dump(ctx, mi.Database, &afterSchemaBuf, true /*schemaOnly*/); err != nil {
+		return -1, "", nil, util.FormatError(err)
+	}
+	return insertedID, afterSchemaBuf.String(), progress, nil
+}
+
+// getBatchInitialProgress returns the progress to start the batches with, which continues from the checkpoint if any.
+// The key range is read at the start, so the chunks finished before the checkpoint may be out of the range now.
+func getBatchInitialProgress(minKey, maxKey int64, empty bool, chunkSize int64, checkpoint *api.TaskBatchCheckpoint) *api.TaskRunBatchProgress {
+	progress := &api.TaskRunBatchProgress{
+		State:   api.TaskRunBatchRunning,
+		NextKey: minKey,
+	}
+	if checkpoint != nil {
+		progress.CompletedChunkCount = checkpoint.CompletedChunkCount
+		if checkpoint.NextKey > minKey {
+			progress.NextKey = checkpoint.NextKey
+		}
+	}
+	progress.TotalChunkCount = progress.CompletedChunkCount
+	if !empty && progress.NextKey <= maxKey {
+		progress.TotalChunkCount += getBatchChunkCount(progress.NextKey, maxKey, chunkSize)
+	}
+	return progress
+}
+
+// saveBatchCheckpoint saves the progress after the finished chunk in the task payload.
+// Only the checkpoint field is set, since the batches may be paused or resumed meanwhile.
+func saveBatchCheckpoint(ctx context.Context, server *Server, task *api.Task, progress *api.TaskRunBatchProgress) error {
+	bytes, err := json.Marshal(map[string]*api.TaskBatchCheckpoint{
+		"batchCheckpoint": {
+			NextKey:             progress.NextKey,
+			CompletedChunkCount: progress.CompletedChunkCount,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch checkpoint: %w", err)
+	}
+	payloadMerge := string(bytes)
+	if _, err := server.store.PatchTask(ctx, &api.TaskPatch{
+		ID:           task.ID,
+		UpdaterID:    api.SystemBotID,
+		PayloadMerge: &payloadMerge,
+	}); err != nil {
+		return fmt.Errorf("failed to save the batch checkpoint of task %d: %w", task.ID, err)
+	}
+	return nil
+}
+
+// waitBatchResumed blocks while the batches of the task are paused.
+func waitBatchResumed(ctx context.Context, server *Server, task *api.Task, progress *api.TaskRunBatchProgress) error {
+	for {
+		latestTask, err := server.store.GetTaskByID(ctx, task.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get task %d: %w", task.ID, err)
+		}
+		if latestTask == nil {
+			return fmt.Errorf("task ID not found: %d", task.ID)
+		}
+		payload := &api.TaskDatabaseDataUpdatePayload{}
+		if err := json.Unmarshal([]byte(latestTask.Payload), payload); err != nil {
+			return fmt.Errorf("invalid database data update payload: %w", err)
+		}
+		if !payload.BatchPaused {
+			progress.State = api.TaskRunBatchRunning
+			return nil
+		}
+		if progress.State != api.TaskRunBatchPaused {
+			progress.State = api.TaskRunBatchPaused
+			reportBatchProgress(ctx, server, task, progress)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(batchPollInterval):
+		}
+	}
+}
+
+// waitReplicationLag blocks while the replication lag exceeds the threshold.
+func waitReplicationLag(ctx context.Context, server *Server, task *api.Task, driver db.Driver, config *api.TaskBatchConfig, progress *api.TaskRunBatchProgress) error {
+	if config.MaxReplicationLagSeconds == 0 {
+		return nil
+	}
+	for {
+		lag, err := getReplicationLag(ctx, task, driver, config)
+		if err != nil {
+			return err
+		}
+		progress.ReplicationLagSeconds = lag
+		if lag <= int64(config.MaxReplicationLagSeconds) {
+			progress.State = api.TaskRunBatchRunning
+			return nil
+		}
+		if progress.State != api.TaskRunBatchThrottled {
+			progress.State = api.TaskRunBatchThrottled
+			reportBatchProgress(ctx, server, task, progress)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(batchPollInterval):
+		}
+	}
+}
+
+// reportBatchProgress reports the batch progress in the running task run.
+func reportBatchProgress(ctx context.Context, server *Server, task *api.Task, progress *api.TaskRunBatchProgress) {
+	reportTaskRunProgress(ctx, server, task, &api.TaskRunResultPayload{
+		Detail:        fmt.Sprintf("%s, completed %d/%d chunks.", progress.State, progress.CompletedChunkCount, progress.TotalChunkCount),
+		BatchProgress: progress,
+	})
+}
+
+// getBatchKeyRange returns the min and max primary key of the table, or empty if the table has no rows.
+func getBatchKeyRange(ctx context.Context, driver db.Driver, config *api.TaskBatchConfig) (minKey int64, maxKey int64, empty bool, err error) {
+	statement := fmt.Sprintf("SELECT MIN(%s), MAX(%s) FROM %s", config.PrimaryKey, config.PrimaryKey, config.Table)
+	_, row, err := queryFirstRow(ctx, driver, statement)
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("failed to get the primary key range of table %q: %w", config.Table, err)
+	}
+	if len(row) != 2 || row[0] == nil || row[1] == nil {
+		return 0, 0, true, nil
+	}
+	if minKey, err = parseBatchInt(row[0]); err != nil {
+		return 0, 0, false, fmt.Errorf("primary key %q must be an integer: %w", config.PrimaryKey, err)
+	}
+	if maxKey, err = parseBatchInt(row[1]); err != nil {
+		return 0, 0, false, fmt.Errorf("primary key %q must be an integer: %w", config.PrimaryKey, err)
+	}
+	return minKey, maxKey, false, nil
+}
+
+// getBatchChunkCount returns the number of chunks to cover the primary key range [minKey, maxKey].
+func getBatchChunkCount(minKey, maxKey, chunkSize int64) int64 {
+	return (maxKey-minKey)/chunkSize + 1
+}
+
+// getReplicationLag returns the max replication lag in seconds.
+// PostgreSQL reports the lag of the standbys on the primary, while MySQL reports it on each replica.
+func getReplicationLag(ctx context.Context, task *api.Task, driver db.Driver, config *api.TaskBatchConfig) (int64, error) {
+	switch task.Instance.Engine {
+	case db.Postgres:
+		_, row, err := queryFirstRow(ctx, driver, "SELECT COALESCE(MAX(EXTRACT(EPOCH FROM replay_lag)), 0)::BIGINT FROM pg_stat_replication")
+		if err != nil {
+			return 0, fmt.Errorf("failed to get the replication lag: %w", err)
+		}
+		if len(row) != 1 {
+			return 0, fmt.Errorf("failed to get the replication lag, unexpected result %v", row)
+		}
+		return parseBatchInt(row[0])
+	case db.MySQL:
+		var maxLag int64
+		for _, replica := range config.ReplicaList {
+			lag, err := getMySQLReplicaLag(ctx, task, replica)
+			if err != nil {
+				return 0, err
+			}
+			if lag > maxLag {
+				maxLag = lag
+			}
+		}
+		return maxLag, nil
+	}
+	return 0, fmt.Errorf("replication lag throttling is not supported for %s", task.Instance.Engine)
+}
+
+// getMySQLReplicaLag returns the replication lag of the MySQL replica at the host:port address.
+func getMySQLReplicaLag(ctx context.Context, task *api.Task, replica string) (int64, error) {
+	host, port, err := net.SplitHostPort(replica)
+	if err != nil {
+		return 0, fmt.Errorf("invalid replica address %q: %w", replica, err)
+	}
+	connCfg, err := getConnectionConfig(ctx, task.Instance, "")
+	if err != nil {
+		return 0, err
+	}
+	connCfg.Host = host
+	connCfg.Port = port
+	driver, err := getDatabaseDriver(ctx, task.Instance.Engine, db.DriverConfig{}, connCfg, db.ConnectionContext{
+		EnvironmentName: task.Instance.Environment.Name,
+		InstanceName:    task.Instance.Name,
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer driver.Close(ctx)
+
+	columnNameList, row, err := queryFirstRow(ctx, driver, "SHOW SLAVE STATUS")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the replication status of replica %q: %w", replica, err)
+	}
+	for i, columnName := range columnNameList {
+		if columnName != "Seconds_Behind_Master" || i >= len(row) {
+			continue
+		}
+		// The lag is NULL if the replication isn't running, and we don't know how far behind it is.
+		if row[i] == nil {
+			return 0, fmt.Errorf("replication isn't running on replica %q", replica)
+		}
+		return parseBatchInt(row[i])
+	}
+	return 0, fmt.Errorf("%q is not a replica", replica)
+}
+
+// queryFirstRow returns the column names and the first row of the query, or a nil row if there is no row.
+func queryFirstRow(ctx context.Context, driver db.Driver, statement string) ([]string, []interface{}, error) {
+	// The query result is [column names, column types, rows].
+	result, err := driver.Query(ctx, statement, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(result) != 3 {
+		return nil, nil, fmt.Errorf("unexpected query result of %q", statement)
+	}
+	columnNameList, _ := result[0].([]string)
+	rowList, _ := result[2].([]interface{})
+	if len(rowList) == 0 {
+		return columnNameList, nil, nil
+	}
+	row, _ := rowList[0].([]interface{})
+	return columnNameList, row, nil
+}
+
+func parseBatchInt(v interface{}) (int64, error) {
+	switch v := v.(type) {
+	case int64:
+		return v, nil
+	case int32:
+		return int64(v), nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	}
+	return 0, fmt.Errorf("unexpected integer value %v", v)
+}
+
+// getBatchStatement returns the UPDATE or DELETE statement restricted to the primary key range [lo, hi).
+// The statement must be a single UPDATE or DELETE statement on the batch table without ORDER BY or LIMIT.
+// The range condition is added to the parsed statement, so that the comments and the quoted strings in the statement
+// can't change where the condition applies.
+func getBatchStatement(engine db.Type, statement string, config *api.TaskBatchConfig, lo, hi int64) (string, error) {
+	rangeCondition := fmt.Sprintf("%s >= %d AND %s < %d", config.PrimaryKey, lo, config.PrimaryKey, hi)
+	switch engine {
+	case db.MySQL, db.TiDB:
+		return getMySQLBatchStatement(statement, config.Table, rangeCondition)
+	case db.Postgres:
+		return getPgBatchStatement(statement, config.Table, rangeCondition)
+	}
+	return "", fmt.Errorf("batch mode is not supported for %s", engine)
+}
+
+func getMySQLBatchStatement(statement, table, rangeCondition string) (string, error) {
+	nodeList, _, err := tidbparser.New().Parse(statement, "", "")
+	if err != nil {
+		return "", fmt.Errorf("failed to parse the statement to run in batches: %w", err)
+	}
+	if len(nodeList) != 1 {
+		return "", fmt.Errorf("only a single statement can run in batches")
+	}
+	var tableRefs *ast.TableRefsClause
+	var where *ast.ExprNode
+	switch node := nodeList[0].(type) {
+	case *ast.UpdateStmt:
+		if node.Order != nil || node.Limit != nil {
+			return "", fmt.Errorf("statement to run in batches must not contain ORDER BY or LIMIT")
+		}
+		if node.With != nil {
+			return "", fmt.Errorf("statement to run in batches must not contain WITH")
+		}
+		tableRefs, where = node.TableRefs, &node.Where
+	case *ast.DeleteStmt:
+		if node.Order != nil || node.Limit != nil {
+			return "", fmt.Errorf("statement to run in batches must not contain ORDER BY or LIMIT")
+		}
+		if node.With != nil {
+			return "", fmt.Errorf("statement to run in batches must not contain WITH")
+		}
+		if node.IsMultiTable {
+			return "", fmt.Errorf("only a single table can be deleted in batches")
+		}
+		tableRefs, where = node.TableRefs, &node.Where
+	default:
+		return "", fmt.Errorf("only UPDATE or DELETE statement can run in batches")
+	}
+	if tableRefs == nil || tableRefs.TableRefs == nil || tableRefs.TableRefs.Right != nil {
+		return "", fmt.Errorf("only a single table can be updated in batches")
+	}
+	tableSource, ok := tableRefs.TableRefs.Left.(*ast.TableSource)
+	if !ok {
+		return "", fmt.Errorf("only a single table can be updated in batches")
+	}
+	tableName, ok := tableSource.Source.(*ast.TableName)
+	if !ok {
+		return "", fmt.Errorf("only a single table can be updated in batches")
+	}
+	if !isBatchTable(tableName.Schema.O, tableName.Name.O, table) {
+		return "", fmt.Errorf("statement to run in batches must update table %q, got %q", table, tableName.Name.O)
+	}
+
+	// The parser reuses its buffers, so parse the condition with another parser to keep the statement intact.
+	conditionNodeList, _, err := tidbparser.New().Parse(fmt.Sprintf("SELECT 1 WHERE %s", rangeCondition), "", "")
+	if err != nil {
+		return "", fmt.Errorf("failed to parse the batch range condition: %w", err)
+	}
+	condition := conditionNodeList[0].(*ast.SelectStmt).Where
+	if *where != nil {
+		condition = &ast.BinaryOperationExpr{
+			Op: opcode.LogicAnd,
+			L:  condition,
+			R:  &ast.ParenthesesExpr{Expr: *where},
+		}
+	}
+	*where = condition
+
+	var buf strings.Builder
+	// The charset of the string literals without the explicit charset follows the connection.
+	if err := nodeList[0].Restore(format.NewRestoreCtx(format.DefaultRestoreFlags|format.RestoreStringWithoutDefaultCharset, &buf)); err != nil {
+		return "", fmt.Errorf("failed to restore the statement to run in batches: %w", err)
+	}
+	return buf.String(), nil
+}
+
+func getPgBatchStatement(statement, table, rangeCondition string) (string, error) {
+	tree, err := pgquery.Parse(statement)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse the statement to run in batches: %w", err)
+	}
+	if len(tree.Stmts) != 1 {
+		return "", fmt.Errorf("only a single statement can run in batches")
+	}
+	var relation *pgquery.RangeVar
+	var where **pgquery.Node
+	switch node := tree.Stmts[0].Stmt.Node.(type) {
+	case *pgquery.Node_UpdateStmt:
+		if node.UpdateStmt.WithClause != nil {
+			return "", fmt.Errorf("statement to run in batches must not contain WITH")
+		}
+		if len(node.UpdateStmt.FromClause) > 0 {
+			return "", fmt.Errorf("only a single table can be updated in batches")
+		}
+		relation, where = node.UpdateStmt.Relation, &node.UpdateStmt.WhereClause
+	case *pgquery.Node_DeleteStmt:
+		if node.DeleteStmt.WithClause != nil {
+			return "", fmt.Errorf("statement to run in batches must not contain WITH")
+		}
+		if len(node.DeleteStmt.UsingClause) > 0 {
+			return "", fmt.Errorf("only a single table can be deleted in batches")
+		}
+		relation, where = node.DeleteStmt.Relation, &node.DeleteStmt.WhereClause
+	default:
+		return "", fmt.Errorf("only UPDATE or DELETE statement can run in batches")
+	}
+	if relation == nil || !isBatchTable(relation.Schemaname, relation.Relname, table) {
+		return "", fmt.Errorf("statement to run in batches must update table %q", table)
+	}
+
+	conditionTree, err := pgquery.Parse(fmt.Sprintf("SELECT 1 WHERE %s", rangeCondition))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse the batch range condition: %w", err)
+	}
+	condition := conditionTree.Stmts[0].Stmt.GetSelectStmt().WhereClause
+	if *where != nil {
+		condition = pgquery.MakeBoolExprNode(pgquery.BoolExprType_AND_EXPR, []*pgquery.Node{condition, *where}, -1)
+	}
+	*where = condition
+
+	result, err := pgquery.Deparse(tree)
+	if err != nil {
+		return "", fmt.Errorf("failed to deparse the statement to run in batches: %w", err)
+	}
+	return result, nil
+}
+
+// isBatchTable returns true if the table in the statement is the batch table in "table" or "schema.table" form.
+func isBatchTable(schema, name, table string) bool {
+	if schema != "" {
+		name = fmt.Sprintf("%s.%s", schema, name)
+	}
+	return strings.EqualFold(name, table)
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/plugin/db"
+
+	// Register pingcap parser driver.
+	_ "github.com/pingcap/tidb/types/parser_driver"
+)
+
+func TestGetBatchStatement(t *testing.T) {
+	tests := []struct {
+		engine    db.Type
+		statement string
+		want      string
+		wantErr   bool
+	}{
+		{
+			engine:    db.MySQL,
+			statement: "DELETE FROM session",
+			want:      "DELETE FROM `session` WHERE `id`>=100 AND `id`<200",
+		},
+		{
+			engine:    db.MySQL,
+			statement: "UPDATE session SET status = 'ARCHIVED' WHERE updated_ts < 1656000000;",
+			want:      "UPDATE `session` SET `status`='ARCHIVED' WHERE `id`>=100 AND `id`<200 AND (`updated_ts`<1656000000)",
+		},
+		{
+			engine:    db.TiDB,
+			statement: "update session set name = (select name from profile where profile.user_id = session.user_id) where deleted or name = 'where'",
+			want:      "UPDATE `session` SET `name`=(SELECT `name` FROM `profile` WHERE `profile`.`user_id`=`session`.`user_id`) WHERE `id`>=100 AND `id`<200 AND (`deleted` OR `name`='where')",
+		},
+		{
+			// The comments can't hide the condition.
+			engine:    db.MySQL,
+			statement: "DELETE FROM session -- purge old\n",
+			want:      "DELETE FROM `session` WHERE `id`>=100 AND `id`<200",
+		},
+		{
+			engine:    db.MySQL,
+			statement: "DELETE FROM session /* where */ # where id = 1\nWHERE expired",
+			want:      "DELETE FROM `session` WHERE `id`>=100 AND `id`<200 AND (`expired`)",
+		},
+		{
+			engine:    db.MySQL,
+			statement: "DELETE FROM test.session WHERE expired",
+			wantErr:   true,
+		},
+		{
+			engine:    db.MySQL,
+			statement: "DELETE FROM user",
+			wantErr:   true,
+		},
+		{
+			engine:    db.MySQL,
+			statement: "UPDATE session JOIN user ON session.user_id = user.id SET session.expired = 1",
+			wantErr:   true,
+		},
+		{
+			engine:    db.MySQL,
+			statement: "INSERT INTO session (id) VALUES (1)",
+			wantErr:   true,
+		},
+		{
+			engine:    db.MySQL,
+			statement: "DELETE FROM session; DELETE FROM session",
+			wantErr:   true,
+		},
+		{
+			engine:    db.MySQL,
+			statement: "DELETE FROM session ORDER BY id LIMIT 1000",
+			wantErr:   true,
+		},
+		{
+			engine:    db.MySQL,
+			statement: "",
+			wantErr:   true,
+		},
+		{
+			engine:    db.Postgres,
+			statement: "DELETE FROM session -- purge old\n",
+			want:      "DELETE FROM session WHERE id >= 100 AND id < 200",
+		},
+		{
+			engine:    db.Postgres,
+			statement: "UPDATE session SET status = $$ WHERE $$ /* where */ WHERE deleted OR name = 'where';",
+			want:      "UPDATE session SET status = ' WHERE ' WHERE (id >= 100 AND id < 200) AND (deleted OR name = 'where')",
+		},
+		{
+			engine:    db.Postgres,
+			statement: "DELETE FROM session USING users WHERE session.user_id = users.id",
+			wantErr:   true,
+		},
+		{
+			engine:    db.Postgres,
+			statement: "WITH s AS (DELETE FROM users) DELETE FROM session",
+			wantErr:   true,
+		},
+	}
+
+	config := &api.TaskBatchConfig{Table: "session", PrimaryKey: "id", ChunkSize: 100}
+	for _, test := range tests {
+		got, err := getBatchStatement(test.engine, test.statement, config, 100, 200)
+		if test.wantErr {
+			assert.Error(t, err, test.statement)
+			continue
+		}
+		assert.NoError(t, err, test.statement)
+		assert.Equal(t, test.want, got)
+	}
+}
+
+func TestGetBatchInitialProgress(t *testing.T) {
+	// Start from the min key.
+	progress := getBatchInitialProgress(1, 1000, false, 100, nil)
+	assert.Equal(t, &api.TaskRunBatchProgress{State: api.TaskRunBatchRunning, NextKey: 1, TotalChunkCount: 10}, progress)
+
+	// Resume from the checkpoint.
+	progress = getBatchInitialProgress(1, 1000, false, 100, &api.TaskBatchCheckpoint{NextKey: 501, CompletedChunkCount: 5})
+	assert.Equal(t, &api.TaskRunBatchProgress{State: api.TaskRunBatchRunning, NextKey: 501, CompletedChunkCount: 5, TotalChunkCount: 10}, progress)
+
+	// The rows before the checkpoint are deleted.
+	progress = getBatchInitialProgress(650, 1000, false, 100, &api.TaskBatchCheckpoint{NextKey: 501, CompletedChunkCount: 5})
+	assert.Equal(t, &api.TaskRunBatchProgress{State: api.TaskRunBatchRunning, NextKey: 650, CompletedChunkCount: 5, TotalChunkCount: 9}, progress)
+
+	// All rows are deleted.
+	progress = getBatchInitialProgress(0, 0, true, 100, &api.TaskBatchCheckpoint{NextKey: 1001, CompletedChunkCount: 10})
+	assert.Equal(t, &api.TaskRunBatchProgress{State: api.TaskRunBatchRunning, NextKey: 1001, CompletedChunkCount: 10, TotalChunkCount: 10}, progress)
+}
+
+func TestGetBatchChunkCount(t *testing.T) {
+	assert.Equal(t, int64(1), getBatchChunkCount(1, 1, 1000))
+	assert.Equal(t, int64(1), getBatchChunkCount(1, 1000, 1000))
+	assert.Equal(t, int64(2), getBatchChunkCount(1, 1001, 1000))
+	assert.Equal(t, int64(50000), getBatchChunkCount(0, 49999999, 1000))
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+)
+
+// NewTaskCheckDeploymentWindowExecutor creates a task check deployment window executor.
+func NewTaskCheckDeploymentWindowExecutor() TaskCheckExecutor {
+	return &TaskCheckDeploymentWindowExecutor{}
+}
+
+// TaskCheckDeploymentWindowExecutor is the task check deployment window executor.
+type TaskCheckDeploymentWindowExecutor struct {
+}
+
+// Run will run the task check deployment window executor once.
+func (exec *TaskCheckDeploymentWindowExecutor) Run(ctx context.Context, server *Server, taskCheckRun *api.TaskCheckRun) (result []api.TaskCheckResult, err error) {
+	task, err := server.store.GetTaskByID(ctx, taskCheckRun.TaskID)
+	if err != nil {
+		return []api.TaskCheckResult{}, common.Errorf(common.Internal, err)
+	}
+	if task == nil {
+		return []api.TaskCheckResult{}, common.Errorf(common.NotFound, fmt.Errorf("task not found with ID %d", taskCheckRun.TaskID))
+	}
+
+	windowResult, err := server.checkTaskDeploymentWindow(ctx, task)
+	if err != nil {
+		return []api.TaskCheckResult{}, common.Errorf(common.Internal, err)
+	}
+	if !windowResult.allowed {
+		return []api.TaskCheckResult{
+			{
+				Status:    api.TaskCheckStatusError,
+				Namespace: api.BBNamespace,
+				Code:      common.TaskDeploymentWindowNotAllowed.Int(),
+				Title:     "Not in deployment window",
+				Content:   windowResult.message,
+			},
+		}, nil
+	}
+
+	return []api.TaskCheckResult{
+		{
+			Status:    api.TaskCheckStatusSuccess,
+			Namespace: api.BBNamespace,
+			Code:      common.Ok.Int(),
+			Title:     "OK",
+			Content:   windowResult.message,
+		},
+	}, nil
+}
@@ -98,7 +98,8 @@ func (exec *TaskCheckGhostSyncExecutor) Run(ctx context.Context, server *Server,
 		// On the source and each replica, you must set the server_id system variable to establish a unique replication ID. For each server, you should pick a unique positive integer in the range from 1 to 2^32 − 1, and each ID must be different from every other ID in use by any other source or replica in the replication topology. Example: server-id=3.
 		// https://dev.mysql.com/doc/refman/5.7/en/replication-options-source.html
 		// Here we use serverID = offset + task.ID to avoid potential conflicts.
-		serverID: 20000000 + uint(taskCheckRun.ID),
+		serverID:       20000000 + uint(taskCheckRun.ID),
+		throttleConfig: payload.Config,
 	})
 	if err != nil {
 		return []api.TaskCheckResult{}, common.Errorf(common.Internal, fmt.Errorf("failed to create migration context, error: %w", err))
@@ -40,7 +40,17 @@ func (exec *TaskCheckStatementAdvisorCompositeExecutor) Run(ctx context.Context,
 		return nil, common.Errorf(common.Invalid, fmt.Errorf("invalid check statement advise payload: %w", err))
 	}
 
-	policy, err := server.store.GetNormalSchemaReviewPolicy(ctx, &api.PolicyFind{ID: &payload.PolicyID})
+	task, err := server.store.GetTaskByID(ctx, taskCheckRun.TaskID)
+	if err != nil {
+		return nil, common.Errorf(common.Internal, fmt.Errorf("failed to get task by id: %w", err))
+	}
+
+	// The project schema review policy refines the environment one for the databases in the project.
+	projectID := api.UnknownID
+	if task.Database != nil {
+		projectID = task.Database.ProjectID
+	}
+	policy, err := server.store.GetEffectiveSchemaReviewPolicy(ctx, task.Instance.EnvironmentID, projectID)
 	if err != nil {
 		if e, ok := err.(*common.Error); ok && e.Code == common.NotFound {
 			return []api.TaskCheckResult{
@@ -56,11 +66,6 @@ func (exec *TaskCheckStatementAdvisorCompositeExecutor) Run(ctx context.Context,
 		return nil, common.Errorf(common.Internal, fmt.Errorf("failed to get schema review policy: %w", err))
 	}
 
-	task, err := server.store.GetTaskByID(ctx, taskCheckRun.TaskID)
-	if err != nil {
-		return nil, common.Errorf(common.Internal, fmt.Errorf("failed to get task by id: %w", err))
-	}
-
 	catalog := store.NewCatalog(task.DatabaseID, server.store)
 
 	dbType, err := api.ConvertToAdvisorDBType(payload.DbType)
@@ -68,12 +73,37 @@ func (exec *TaskCheckStatementAdvisorCompositeExecutor) Run(ctx context.Context,
 		return nil, err
 	}
 
-	adviceList, err := advisor.SchemaReviewCheck(ctx, payload.Statement, policy, advisor.SchemaReviewCheckContext{
+	checkContext := advisor.SchemaReviewCheckContext{
 		Charset:   payload.Charset,
 		Collation: payload.Collation,
 		DbType:    dbType,
 		Catalog:   catalog,
-	})
+
+		EnvironmentName: task.Instance.Environment.Name,
+	}
+	// Only connect to the database if some rule needs to consult it, e.g. EXPLAIN the statement.
+	// The database doesn't exist yet for some tasks, e.g. database creation, so there is nothing to consult.
+	for _, rule := range policy.RuleList {
+		if task.Database == nil {
+			break
+		}
+		if rule.Type != advisor.SchemaRuleStatementDMLAffectedRowLimit || rule.Level == advisor.SchemaRuleLevelDisabled {
+			continue
+		}
+		driver, err := getAdminDatabaseDriver(ctx, task.Instance, task.Database.Name, "" /* pgInstanceDir */)
+		if err != nil {
+			return nil, err
+		}
+		defer driver.Close(ctx)
+		sqlDB, err := driver.GetDbConnection(ctx, task.Database.Name)
+		if err != nil {
+			return nil, common.Errorf(common.DbConnectionFailure, fmt.Errorf("failed to get connection for database %q: %w", task.Database.Name, err))
+		}
+		checkContext.Driver = sqlDB
+		break
+	}
+
+	adviceList, err := advisor.SchemaReviewCheck(ctx, payload.Statement, policy, checkContext)
 	if err != nil {
 		return nil, err
 	}
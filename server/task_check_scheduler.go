@@ -87,74 +87,19 @@ func (s *TaskCheckScheduler) Run(ctx context.Context, wg *sync.WaitGroup) {
 							delete(runningTaskChecks, taskCheckRun.ID)
 							mu.Unlock()
 						}()
-						checkResultList, err := executor.Run(ctx, s.server, taskCheckRun)
-
-						if err == nil {
-							bytes, err := json.Marshal(api.TaskCheckRunResultPayload{
-								ResultList: checkResultList,
-							})
+						if _, err := s.server.runWithLease(ctx, taskCheckRunLeaseResource(taskCheckRun.ID), func(ctx context.Context) {
+							// Another replica may have finished the task check run before we acquire the lease.
+							taskCheckRunList, err := s.server.store.FindTaskCheckRun(ctx, &api.TaskCheckRunFind{ID: &taskCheckRun.ID})
 							if err != nil {
-								log.Error("Failed to marshal task check run result",
-									zap.Int("id", taskCheckRun.ID),
-									zap.Int("task_id", taskCheckRun.TaskID),
-									zap.String("type", string(taskCheckRun.Type)),
-									zap.Error(err),
-								)
+								log.Error("Failed to retrieve task check run", zap.Int("id", taskCheckRun.ID), zap.Error(err))
 								return
 							}
-
-							taskCheckRunStatusPatch := &api.TaskCheckRunStatusPatch{
-								ID:        &taskCheckRun.ID,
-								UpdaterID: api.SystemBotID,
-								Status:    api.TaskCheckRunDone,
-								Code:      common.Ok,
-								Result:    string(bytes),
-							}
-							_, err = s.server.store.PatchTaskCheckRunStatus(ctx, taskCheckRunStatusPatch)
-							if err != nil {
-								log.Error("Failed to mark task check run as DONE",
-									zap.Int("id", taskCheckRun.ID),
-									zap.Int("task_id", taskCheckRun.TaskID),
-									zap.String("type", string(taskCheckRun.Type)),
-									zap.Error(err),
-								)
-							}
-						} else {
-							log.Warn("Failed to run task check",
-								zap.Int("id", taskCheckRun.ID),
-								zap.Int("task_id", taskCheckRun.TaskID),
-								zap.String("type", string(taskCheckRun.Type)),
-								zap.Error(err),
-							)
-							bytes, marshalErr := json.Marshal(api.TaskCheckRunResultPayload{
-								Detail: err.Error(),
-							})
-							if marshalErr != nil {
-								log.Error("Failed to marshal task check run result",
-									zap.Int("id", taskCheckRun.ID),
-									zap.Int("task_id", taskCheckRun.TaskID),
-									zap.String("type", string(taskCheckRun.Type)),
-									zap.Error(marshalErr),
-								)
+							if len(taskCheckRunList) == 0 || taskCheckRunList[0].Status != api.TaskCheckRunRunning {
 								return
 							}
-
-							taskCheckRunStatusPatch := &api.TaskCheckRunStatusPatch{
-								ID:        &taskCheckRun.ID,
-								UpdaterID: api.SystemBotID,
-								Status:    api.TaskCheckRunFailed,
-								Code:      common.ErrorCode(err),
-								Result:    string(bytes),
-							}
-							_, err = s.server.store.PatchTaskCheckRunStatus(ctx, taskCheckRunStatusPatch)
-							if err != nil {
-								log.Error("Failed to mark task check run as FAILED",
-									zap.Int("id", taskCheckRun.ID),
-									zap.Int("task_id", taskCheckRun.TaskID),
-									zap.String("type", string(taskCheckRun.Type)),
-									zap.Error(err),
-								)
-							}
+							s.runTaskCheck(ctx, executor, taskCheckRun)
+						}); err != nil {
+							log.Error("Failed to run task check run with lease", zap.Int("id", taskCheckRun.ID), zap.Error(err))
 						}
 					}(taskCheckRun)
 				}
@@ -165,6 +110,79 @@ func (s *TaskCheckScheduler) Run(ctx context.Context, wg *sync.WaitGroup) {
 	}
 }
 
+// runTaskCheck runs the task check executor once and updates the task check run status with the result.
+func (s *TaskCheckScheduler) runTaskCheck(ctx context.Context, executor TaskCheckExecutor, taskCheckRun *api.TaskCheckRun) {
+	checkResultList, err := executor.Run(ctx, s.server, taskCheckRun)
+
+	if err == nil {
+		bytes, err := json.Marshal(api.TaskCheckRunResultPayload{
+			ResultList: checkResultList,
+		})
+		if err != nil {
+			log.Error("Failed to marshal task check run result",
+				zap.Int("id", taskCheckRun.ID),
+				zap.Int("task_id", taskCheckRun.TaskID),
+				zap.String("type", string(taskCheckRun.Type)),
+				zap.Error(err),
+			)
+			return
+		}
+
+		taskCheckRunStatusPatch := &api.TaskCheckRunStatusPatch{
+			ID:        &taskCheckRun.ID,
+			UpdaterID: api.SystemBotID,
+			Status:    api.TaskCheckRunDone,
+			Code:      common.Ok,
+			Result:    string(bytes),
+		}
+		_, err = s.server.store.PatchTaskCheckRunStatus(ctx, taskCheckRunStatusPatch)
+		if err != nil {
+			log.Error("Failed to mark task check run as DONE",
+				zap.Int("id", taskCheckRun.ID),
+				zap.Int("task_id", taskCheckRun.TaskID),
+				zap.String("type", string(taskCheckRun.Type)),
+				zap.Error(err),
+			)
+		}
+	} else {
+		log.Warn("Failed to run task check",
+			zap.Int("id", taskCheckRun.ID),
+			zap.Int("task_id", taskCheckRun.TaskID),
+			zap.String("type", string(taskCheckRun.Type)),
+			zap.Error(err),
+		)
+		bytes, marshalErr := json.Marshal(api.TaskCheckRunResultPayload{
+			Detail: err.Error(),
+		})
+		if marshalErr != nil {
+			log.Error("Failed to marshal task check run result",
+				zap.Int("id", taskCheckRun.ID),
+				zap.Int("task_id", taskCheckRun.TaskID),
+				zap.String("type", string(taskCheckRun.Type)),
+				zap.Error(marshalErr),
+			)
+			return
+		}
+
+		taskCheckRunStatusPatch := &api.TaskCheckRunStatusPatch{
+			ID:        &taskCheckRun.ID,
+			UpdaterID: api.SystemBotID,
+			Status:    api.TaskCheckRunFailed,
+			Code:      common.ErrorCode(err),
+			Result:    string(bytes),
+		}
+		_, err = s.server.store.PatchTaskCheckRunStatus(ctx, taskCheckRunStatusPatch)
+		if err != nil {
+			log.Error("Failed to mark task check run as FAILED",
+				zap.Int("id", taskCheckRun.ID),
+				zap.Int("task_id", taskCheckRun.TaskID),
+				zap.String("type", string(taskCheckRun.Type)),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
 // Register will register the task check executor.
 func (s *TaskCheckScheduler) Register(taskType api.TaskCheckType, executor TaskCheckExecutor) {
 	if executor == nil {
@@ -217,6 +235,44 @@ func (s *TaskCheckScheduler) shouldScheduleTimingTaskCheck(ctx context.Context,
 	return false, nil
 }
 
+// Returns true if we meet either of the following conditions:
+//  1. No deployment window task check has run before and the task is not allowed to start now, so we need to explain why.
+//  2. The latest task check result no longer reflects whether the task is allowed now, e.g. the maintenance window has opened.
+func (s *TaskCheckScheduler) shouldScheduleDeploymentWindowTaskCheck(ctx context.Context, task *api.Task, forceSchedule bool) (bool, error) {
+	statusList := []api.TaskCheckRunStatus{api.TaskCheckRunDone, api.TaskCheckRunFailed, api.TaskCheckRunRunning}
+	taskCheckType := api.TaskCheckGeneralDeploymentWindow
+	taskCheckRunList, err := s.server.store.FindTaskCheckRun(ctx, &api.TaskCheckRunFind{
+		TaskID:     &task.ID,
+		Type:       &taskCheckType,
+		StatusList: &statusList,
+		Latest:     true,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	windowResult, err := s.server.checkTaskDeploymentWindow(ctx, task)
+	if err != nil {
+		return false, err
+	}
+	if len(taskCheckRunList) == 0 {
+		return !windowResult.allowed, nil
+	}
+	if taskCheckRunList[0].Status == api.TaskCheckRunRunning {
+		return false, nil
+	}
+	if forceSchedule {
+		return true, nil
+	}
+
+	checkResult := &api.TaskCheckRunResultPayload{}
+	if err := json.Unmarshal([]byte(taskCheckRunList[0].Result), checkResult); err != nil {
+		return false, err
+	}
+	passed := len(checkResult.ResultList) > 0 && checkResult.ResultList[0].Status == api.TaskCheckStatusSuccess
+	return passed != windowResult.allowed, nil
+}
+
 // ScheduleCheckIfNeeded schedules a check if needed.
 func (s *TaskCheckScheduler) ScheduleCheckIfNeeded(ctx context.Context, task *api.Task, creatorID int, skipIfAlreadyTerminated bool) (*api.Task, error) {
 	// the following block is for timing task check
@@ -247,7 +303,26 @@ func (s *TaskCheckScheduler) ScheduleCheckIfNeeded(ctx context.Context, task *ap
 		}
 	}
 
-	if task.Type == api.TaskDatabaseSchemaUpdate || task.Type == api.TaskDatabaseDataUpdate || task.Type == api.TaskDatabaseSchemaUpdateGhostSync {
+	// the following block is for deployment window task check
+	if isDeploymentWindowTaskType(task.Type) {
+		flag, err := s.shouldScheduleDeploymentWindowTaskCheck(ctx, task, !skipIfAlreadyTerminated /* forceSchedule */)
+		if err != nil {
+			return nil, err
+		}
+
+		if flag {
+			if _, err := s.server.store.CreateTaskCheckRunIfNeeded(ctx, &api.TaskCheckRunCreate{
+				CreatorID:               creatorID,
+				TaskID:                  task.ID,
+				Type:                    api.TaskCheckGeneralDeploymentWindow,
+				SkipIfAlreadyTerminated: false,
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if task.Type == api.TaskDatabaseSchemaUpdate || task.Type == api.TaskDatabaseDataUpdate || task.Type == api.TaskDatabaseSchemaUpdateGhostSync || task.Type == api.TaskDatabaseSchemaUpdatePgOnlineSync {
 		statement := ""
 
 		switch task.Type {
@@ -269,6 +344,12 @@ func (s *TaskCheckScheduler) ScheduleCheckIfNeeded(ctx context.Context, task *ap
 				return nil, fmt.Errorf("invalid database data update payload: %w", err)
 			}
 			statement = taskPayload.Statement
+		case api.TaskDatabaseSchemaUpdatePgOnlineSync:
+			taskPayload := &api.TaskDatabaseSchemaUpdatePgOnlineSyncPayload{}
+			if err := json.Unmarshal([]byte(task.Payload), taskPayload); err != nil {
+				return nil, fmt.Errorf("invalid database schema update online sync payload: %w", err)
+			}
+			statement = taskPayload.Statement
 		}
 
 		database, err := s.server.store.GetDatabase(ctx, &api.DatabaseFind{ID: task.DatabaseID})
@@ -7,11 +7,13 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/common"
 	"github.com/bytebase/bytebase/common/log"
 	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/db/util"
 	vcsPlugin "github.com/bytebase/bytebase/plugin/vcs"
 	"go.uber.org/zap"
 )
@@ -28,8 +30,65 @@ type TaskExecutor interface {
 	RunOnce(ctx context.Context, server *Server, task *api.Task) (terminated bool, result *api.TaskRunResultPayload, err error)
 }
 
-// RunTaskExecutorOnce wraps a TaskExecutor.RunOnce call with panic recovery
+// RunTaskExecutorOnce wraps a TaskExecutor.RunOnce call with panic recovery.
+// Transient failures are retried with backoff according to the retry policy of the task type,
+// and each failed attempt is recorded as a separate task run.
 func RunTaskExecutorOnce(ctx context.Context, exec TaskExecutor, server *Server, task *api.Task) (terminated bool, result *api.TaskRunResultPayload, err error) {
+	policy := getTaskRetryPolicy(task.Type)
+	return runTaskExecutorWithRetry(ctx, exec, server, task, policy, func(attempt int, backoff time.Duration, err error) error {
+		bytes, marshalErr := json.Marshal(api.TaskRunResultPayload{
+			Detail: fmt.Sprintf("Attempt %d of %d failed, retry in %s: %s", attempt, policy.maxAttempts, backoff, err.Error()),
+		})
+		if marshalErr != nil {
+			return marshalErr
+		}
+		return server.store.RetryTaskRun(ctx, &api.TaskRunRetry{
+			UpdaterID: api.SystemBotID,
+			TaskID:    task.ID,
+			Code:      common.ErrorCode(err),
+			Result:    string(bytes),
+		})
+	})
+}
+
+// runTaskExecutorWithRetry runs the task executor until it succeeds, fails with a non-retryable error or runs out of attempts.
+// recordAttempt records the failed attempt before the next one, and the task is not retried if it fails.
+func runTaskExecutorWithRetry(ctx context.Context, exec TaskExecutor, server *Server, task *api.Task, policy *taskRetryPolicy, recordAttempt func(attempt int, backoff time.Duration, err error) error) (terminated bool, result *api.TaskRunResultPayload, err error) {
+	for attempt := 1; ; attempt++ {
+		terminated, result, err = runTaskExecutorAttempt(ctx, exec, server, task, attempt)
+		if !terminated || err == nil || attempt >= policy.maxAttempts || !policy.isRetryable(err) {
+			return terminated, result, err
+		}
+
+		backoff := policy.backoff(attempt)
+		log.Warn("Task failed with a transient error, will retry",
+			zap.Int("id", task.ID),
+			zap.String("name", task.Name),
+			zap.String("type", string(task.Type)),
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", backoff),
+			zap.Error(err),
+		)
+		if recordErr := recordAttempt(attempt, backoff, err); recordErr != nil {
+			log.Error("Failed to record the failed task run attempt",
+				zap.Int("id", task.ID),
+				zap.String("name", task.Name),
+				zap.Error(recordErr),
+			)
+			return terminated, result, err
+		}
+
+		select {
+		case <-ctx.Done():
+			// The task stays RUNNING and will be picked up again by the scheduler.
+			return false, nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// runTaskExecutorAttempt runs the task executor once with panic recovery.
+func runTaskExecutorAttempt(ctx context.Context, exec TaskExecutor, server *Server, task *api.Task, attempt int) (terminated bool, result *api.TaskRunResultPayload, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			panicErr, ok := r.(error)
@@ -43,9 +102,29 @@ func RunTaskExecutorOnce(ctx context.Context, exec TaskExecutor, server *Server,
 		}
 	}()
 
+	if retrier, ok := exec.(taskRetrier); ok && attempt > 1 {
+		return retrier.RetryOnce(ctx, server, task)
+	}
 	return exec.RunOnce(ctx, server, task)
 }
 
+// reportTaskRunProgress reports the progress of the long running task to the result of the running task run.
+// The progress is informational, so the failure is logged instead of failing the task.
+func reportTaskRunProgress(ctx context.Context, server *Server, task *api.Task, progress *api.TaskRunResultPayload) {
+	result, err := json.Marshal(progress)
+	if err != nil {
+		log.Error("Failed to marshal task run progress", zap.Int("task_id", task.ID), zap.Error(err))
+		return
+	}
+	if err := server.store.PatchRunningTaskRunResult(ctx, &api.TaskRunResultPatch{
+		UpdaterID: api.SystemBotID,
+		TaskID:    task.ID,
+		Result:    string(result),
+	}); err != nil {
+		log.Warn("Failed to report task run progress", zap.Int("task_id", task.ID), zap.Error(err))
+	}
+}
+
 func preMigration(ctx context.Context, server *Server, task *api.Task, migrationType db.MigrationType, statement, schemaVersion string, vcsPushEvent *vcsPlugin.PushEvent) (*db.MigrationInfo, error) {
 	if task.Database == nil {
 		msg := "missing database when updating schema"
@@ -132,7 +211,7 @@ func executeMigration(ctx context.Context, pgInstanceDir string, task *api.Task,
 
 	driver, err := getAdminDatabaseDriver(ctx, task.Instance, databaseName, pgInstanceDir)
 	if err != nil {
-		return 0, "", err
+		return 0, "", &util.NotAppliedError{Err: err}
 	}
 	defer driver.Close(ctx)
 
@@ -146,7 +225,7 @@ func executeMigration(ctx context.Context, pgInstanceDir string, task *api.Task,
 
 	setup, err := driver.NeedsSetupMigration(ctx)
 	if err != nil {
-		return 0, "", fmt.Errorf("failed to check migration setup for instance %q: %w", task.Instance.Name, err)
+		return 0, "", &util.NotAppliedError{Err: fmt.Errorf("failed to check migration setup for instance %q: %w", task.Instance.Name, err)}
 	}
 	if setup {
 		return 0, "", common.Errorf(common.MigrationSchemaMissing, fmt.Errorf("missing migration schema for instance %q", task.Instance.Name))
@@ -295,11 +374,16 @@ func postMigration(ctx context.Context, server *Server, task *api.Task, vcsPushE
 	}, nil
 }
 
-func runMigration(ctx context.Context, server *Server, task *api.Task, migrationType db.MigrationType, statement, schemaVersion string, vcsPushEvent *vcsPlugin.PushEvent) (terminated bool, result *api.TaskRunResultPayload, err error) {
+// runMigration runs the migration. If force is set, the migration takes over the migration history left by
+// the previous attempt, which must not have applied any change.
+func runMigration(ctx context.Context, server *Server, task *api.Task, migrationType db.MigrationType, statement, schemaVersion string, vcsPushEvent *vcsPlugin.PushEvent, force bool) (terminated bool, result *api.TaskRunResultPayload, err error) {
 	mi, err := preMigration(ctx, server, task, migrationType, statement, schemaVersion, vcsPushEvent)
 	if err != nil {
 		return true, nil, err
 	}
+	if force {
+		mi.Force = true
+	}
 	migrationID, schema, err := executeMigration(ctx, server.pgInstanceDir, task, statement, mi)
 	if err != nil {
 		return true, nil, err
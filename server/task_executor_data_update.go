@@ -11,7 +11,7 @@ import (
 
 // NewDataUpdateTaskExecutor creates a data update (DML) task executor.
 func NewDataUpdateTaskExecutor() TaskExecutor {
-	return &SchemaUpdateTaskExecutor{}
+	return &DataUpdateTaskExecutor{}
 }
 
 // DataUpdateTaskExecutor is the data update (DML) task executor.
@@ -20,10 +20,23 @@ type DataUpdateTaskExecutor struct {
 
 // RunOnce will run the data update (DML) task executor once.
 func (exec *DataUpdateTaskExecutor) RunOnce(ctx context.Context, server *Server, task *api.Task) (terminated bool, result *api.TaskRunResultPayload, err error) {
+	return exec.run(ctx, server, task, false /* force */)
+}
+
+// RetryOnce will run the data update (DML) task executor again after the previous attempt failed without applying any change.
+// The previous attempt has recorded the migration history as failed, so the retry has to take it over.
+func (exec *DataUpdateTaskExecutor) RetryOnce(ctx context.Context, server *Server, task *api.Task) (terminated bool, result *api.TaskRunResultPayload, err error) {
+	return exec.run(ctx, server, task, true /* force */)
+}
+
+func (exec *DataUpdateTaskExecutor) run(ctx context.Context, server *Server, task *api.Task, force bool) (terminated bool, result *api.TaskRunResultPayload, err error) {
 	payload := &api.TaskDatabaseDataUpdatePayload{}
 	if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
 		return true, nil, fmt.Errorf("invalid database data update payload: %w", err)
 	}
 
-	return runMigration(ctx, server, task, db.Data, payload.Statement, payload.SchemaVersion, payload.VCSPushEvent)
+	if payload.Batch != nil {
+		return runBatchMigration(ctx, server, task, payload)
+	}
+	return runMigration(ctx, server, task, db.Data, payload.Statement, payload.SchemaVersion, payload.VCSPushEvent, force)
 }
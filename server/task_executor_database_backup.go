@@ -1,17 +1,29 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common/backupfile"
 	"github.com/bytebase/bytebase/common/log"
 	"go.uber.org/zap"
 )
 
+const (
+	gzipBackupFileExt      = ".gz"
+	zstdBackupFileExt      = ".zst"
+	encryptedBackupFileExt = ".enc"
+)
+
 // NewDatabaseBackupTaskExecutor creates a new database backup task executor.
 func NewDatabaseBackupTaskExecutor() TaskExecutor {
 	return &DatabaseBackupTaskExecutor{}
@@ -41,7 +53,18 @@ func (exec *DatabaseBackupTaskExecutor) RunOnce(ctx context.Context, server *Ser
 		zap.String("backup", backup.Name),
 	)
 
-	backupPayload, backupErr := exec.backupDatabase(ctx, task.Instance, task.Database.Name, backup, server.profile.DataDir, server.pgInstanceDir)
+	backupSetting, err := server.store.GetBackupSettingByDatabaseID(ctx, task.Database.ID)
+	if err != nil {
+		return true, nil, fmt.Errorf("failed to find backup setting for database %q, error: %w", task.Database.Name, err)
+	}
+	verificationEnabled := backupSetting != nil && backupSetting.VerificationEnabled && isBackupVerificationSupported(task.Instance.Engine)
+
+	encryptionKey, err := server.getBackupEncryptionKey(ctx)
+	if err != nil {
+		return true, nil, fmt.Errorf("failed to get backup encryption key, error: %w", err)
+	}
+
+	backupPayload, backupErr := exec.backupDatabase(ctx, task.Instance, task.Database.Name, backup, server.profile.DataDir, server.pgInstanceDir, encryptionKey, verificationEnabled)
 	backupPatch := api.BackupPatch{
 		ID:        backup.ID,
 		Status:    string(api.BackupStatusDone),
@@ -52,6 +75,9 @@ func (exec *DatabaseBackupTaskExecutor) RunOnce(ctx context.Context, server *Ser
 	if backupErr != nil {
 		backupPatch.Status = string(api.BackupStatusFailed)
 		backupPatch.Comment = backupErr.Error()
+	} else if verificationEnabled {
+		verificationStatus := api.BackupVerificationStatusPending
+		backupPatch.VerificationStatus = &verificationStatus
 	}
 	if _, err := server.store.PatchBackup(ctx, &backupPatch); err != nil {
 		return true, nil, fmt.Errorf("failed to patch backup, error: %w", err)
@@ -67,7 +93,8 @@ func (exec *DatabaseBackupTaskExecutor) RunOnce(ctx context.Context, server *Ser
 }
 
 // backupDatabase will take a backup of a database.
-func (exec *DatabaseBackupTaskExecutor) backupDatabase(ctx context.Context, instance *api.Instance, databaseName string, backup *api.Backup, dataDir, pgInstanceDir string) (string, error) {
+// If verificationEnabled, the tables and the schema are recorded in the payload for the backup verification.
+func (exec *DatabaseBackupTaskExecutor) backupDatabase(ctx context.Context, instance *api.Instance, databaseName string, backup *api.Backup, dataDir, pgInstanceDir string, encryptionKey *backupfile.Key, verificationEnabled bool) (string, error) {
 	driver, err := getAdminDatabaseDriver(ctx, instance, databaseName, pgInstanceDir)
 	if err != nil {
 		return "", err
@@ -80,12 +107,113 @@ func (exec *DatabaseBackupTaskExecutor) backupDatabase(ctx context.Context, inst
 	}
 	defer f.Close()
 
-	payload, err := driver.Dump(ctx, databaseName, f, false /* schemaOnly */)
+	compression, encrypted := getBackupFileFormat(backup.Path)
+	var key *backupfile.Key
+	if encrypted {
+		key = encryptionKey
+	}
+	hash := sha256.New()
+	w, err := backupfile.NewWriter(io.MultiWriter(f, hash), backupfile.Compression(compression), key)
+	if err != nil {
+		return "", err
+	}
+	var dumpPayload string
+	var rowCountMap map[string]int64
+	var schemaBuf bytes.Buffer
+	if verificationEnabled {
+		dumpPayload, rowCountMap, err = dumpWithTableRowCount(ctx, driver, databaseName, w, &schemaBuf)
+	} else {
+		dumpPayload, err = driver.Dump(ctx, databaseName, w, false /* schemaOnly */)
+	}
 	if err != nil {
 		return "", err
 	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("failed to flush backup file: %w", err)
+	}
+
+	payload := api.BackupPayload{}
+	if dumpPayload != "" {
+		if err := json.Unmarshal([]byte(dumpPayload), &payload); err != nil {
+			return "", fmt.Errorf("failed to unmarshal backup payload: %w", err)
+		}
+	}
+	payload.Compression = compression
+	payload.Encrypted = encrypted
+	payload.Checksum = hex.EncodeToString(hash.Sum(nil))
+	if verificationEnabled {
+		if rowCountMap == nil {
+			log.Warn("The rows are not counted in the dump, only the schema of the backup is verified",
+				zap.String("instance", instance.Name),
+				zap.String("database", databaseName))
+		}
+		payload.TableList = getBackupTableList(rowCountMap)
+		// The schema is dumped in the same snapshot as the backup, so that it's consistent with the backup.
+		if schemaBuf.Len() == 0 {
+			log.Warn("The schema is not dumped in the snapshot of the dump, only the rows of the backup are verified",
+				zap.String("instance", instance.Name),
+				zap.String("database", databaseName))
+		} else {
+			payload.SchemaChecksum = getSchemaChecksum(schemaBuf.String(), databaseName, databaseName)
+		}
+	}
+	bytes, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal backup payload: %w", err)
+	}
+	return string(bytes), nil
+}
+
+// openBackupFile verifies the checksum of the backup file, and returns the reader of the dump in it.
+// The caller must close the returned reader.
+// The backup file encrypted by a retired key is decrypted as long as the key is in keyList.
+func openBackupFile(backup *api.Backup, dataDir string, keyList []*backupfile.Key) (io.ReadCloser, error) {
+	backupPath := backup.Path
+	if !filepath.IsAbs(backupPath) {
+		backupPath = filepath.Join(dataDir, backupPath)
+	}
 
-	return payload, nil
+	f, err := os.OpenFile(backupPath, os.O_RDONLY, os.ModePerm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup file at %s: %w", backupPath, err)
+	}
+	// The backups taken before recording the checksum are restored without verification.
+	if backup.Payload.Checksum != "" {
+		hash := sha256.New()
+		if _, err := io.Copy(hash, f); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to read backup file at %s: %w", backupPath, err)
+		}
+		if checksum := hex.EncodeToString(hash.Sum(nil)); checksum != backup.Payload.Checksum {
+			f.Close()
+			return nil, fmt.Errorf("backup file at %s is corrupted, expect checksum %s but got %s", backupPath, backup.Payload.Checksum, checksum)
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	r, err := backupfile.NewReader(f, keyList)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to read backup file at %s: %w", backupPath, err)
+	}
+	return &backupFileReader{ReadCloser: r, file: f}, nil
+}
+
+// backupFileReader closes the backup file after the dump reader.
+type backupFileReader struct {
+	io.ReadCloser
+	file *os.File
+}
+
+func (r *backupFileReader) Close() error {
+	if err := r.ReadCloser.Close(); err != nil {
+		r.file.Close()
+		return err
+	}
+	return r.file.Close()
 }
 
 // Get backup dir relative to the data dir.
@@ -93,14 +221,36 @@ func getBackupRelativeDir(databaseID int) string {
 	return filepath.Join("backup", "db", fmt.Sprintf("%d", databaseID))
 }
 
-func getBackupRelativeFilePath(databaseID int, name string) string {
+// getBackupRelativeFilePath returns the backup file path, whose extension is decided by the backup file format of the backup setting.
+// The backup setting is nil if the database doesn't have one.
+func getBackupRelativeFilePath(databaseID int, name string, backupSetting *api.BackupSetting) string {
 	dir := getBackupRelativeDir(databaseID)
-	return filepath.Join(dir, fmt.Sprintf("%s.sql", name))
+	ext := ".sql"
+	if backupSetting != nil {
+		switch backupSetting.Compression {
+		case api.BackupCompressionGzip:
+			ext += gzipBackupFileExt
+		case api.BackupCompressionZstd:
+			ext += zstdBackupFileExt
+		}
+		if backupSetting.Encrypted {
+			ext += encryptedBackupFileExt
+		}
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s%s", name, ext))
 }
 
-func getBackupAbsFilePath(dataDir string, databaseID int, name string) string {
-	path := getBackupRelativeFilePath(databaseID, name)
-	return filepath.Join(dataDir, path)
+// getBackupFileFormat returns the compression and the encryption of the backup file by its extension.
+func getBackupFileFormat(path string) (api.BackupCompression, bool) {
+	encrypted := strings.HasSuffix(path, encryptedBackupFileExt)
+	path = strings.TrimSuffix(path, encryptedBackupFileExt)
+	switch filepath.Ext(path) {
+	case gzipBackupFileExt:
+		return api.BackupCompressionGzip, encrypted
+	case zstdBackupFileExt:
+		return api.BackupCompressionZstd, encrypted
+	}
+	return api.BackupCompressionNone, encrypted
 }
 
 // Create backup directory for database.
@@ -124,3 +274,18 @@ func createBinlogDir(dataDir string, instanceID int) error {
 	absDir := filepath.Join(dataDir, dir)
 	return os.MkdirAll(absDir, os.ModePerm)
 }
+
+func getWALArchiveRelativeDir(databaseID int) string {
+	return filepath.Join(getBackupRelativeDir(databaseID), "wal")
+}
+
+func getWALArchiveAbsDir(dataDir string, databaseID int) string {
+	dir := getWALArchiveRelativeDir(databaseID)
+	return filepath.Join(dataDir, dir)
+}
+
+func createWALArchiveDir(dataDir string, databaseID int) error {
+	dir := getWALArchiveRelativeDir(databaseID)
+	absDir := filepath.Join(dataDir, dir)
+	return os.MkdirAll(absDir, os.ModePerm)
+}
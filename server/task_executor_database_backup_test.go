@@ -0,0 +1,48 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bytebase/bytebase/api"
+)
+
+func TestGetBackupFileFormat(t *testing.T) {
+	tests := []struct {
+		backupSetting *api.BackupSetting
+		path          string
+		compression   api.BackupCompression
+		encrypted     bool
+	}{
+		{
+			backupSetting: nil,
+			path:          "backup/db/101/prod-backup.sql",
+			compression:   api.BackupCompressionNone,
+		},
+		{
+			backupSetting: &api.BackupSetting{Compression: api.BackupCompressionZstd},
+			path:          "backup/db/101/prod-backup.sql.zst",
+			compression:   api.BackupCompressionZstd,
+		},
+		{
+			backupSetting: &api.BackupSetting{Compression: api.BackupCompressionGzip, Encrypted: true},
+			path:          "backup/db/101/prod-backup.sql.gz.enc",
+			compression:   api.BackupCompressionGzip,
+			encrypted:     true,
+		},
+		{
+			backupSetting: &api.BackupSetting{Compression: api.BackupCompressionNone, Encrypted: true},
+			path:          "backup/db/101/prod-backup.sql.enc",
+			compression:   api.BackupCompressionNone,
+			encrypted:     true,
+		},
+	}
+	for _, test := range tests {
+		path := getBackupRelativeFilePath(101, "prod-backup", test.backupSetting)
+		assert.Equal(t, test.path, path)
+		compression, encrypted := getBackupFileFormat(path)
+		assert.Equal(t, test.compression, compression, path)
+		assert.Equal(t, test.encrypted, encrypted, path)
+	}
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common/log"
+	"github.com/bytebase/bytebase/plugin/db/mysql"
+	"go.uber.org/zap"
+)
+
+// cloneProgressReportInterval is the interval to report the progress of copying the rows to the target database.
+const cloneProgressReportInterval = 5 * time.Second
+
+// NewDatabaseCloneTaskExecutor creates a new database clone task executor.
+func NewDatabaseCloneTaskExecutor() TaskExecutor {
+	return &DatabaseCloneTaskExecutor{}
+}
+
+// DatabaseCloneTaskExecutor is the task executor for database clone.
+// It clones the schema and optionally the data of the source database to the database created by the previous task.
+type DatabaseCloneTaskExecutor struct {
+}
+
+// RunOnce will run database clone once.
+func (exec *DatabaseCloneTaskExecutor) RunOnce(ctx context.Context, server *Server, task *api.Task) (terminated bool, result *api.TaskRunResultPayload, err error) {
+	payload := &api.TaskDatabaseClonePayload{}
+	if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
+		return true, nil, fmt.Errorf("invalid database clone payload: %w", err)
+	}
+
+	sourceDatabase, err := server.store.GetDatabase(ctx, &api.DatabaseFind{ID: &payload.SourceDatabaseID})
+	if err != nil {
+		return true, nil, fmt.Errorf("failed to find source database with ID %d, error: %w", payload.SourceDatabaseID, err)
+	}
+	if sourceDatabase == nil {
+		return true, nil, fmt.Errorf("source database ID not found %v", payload.SourceDatabaseID)
+	}
+
+	targetDatabaseFind := &api.DatabaseFind{
+		InstanceID: &task.InstanceID,
+		Name:       &payload.DatabaseName,
+	}
+	targetDatabase, err := server.store.GetDatabase(ctx, targetDatabaseFind)
+	if err != nil {
+		return true, nil, fmt.Errorf("failed to find target database %q in instance %q: %w", payload.DatabaseName, task.Instance.Name, err)
+	}
+	if targetDatabase == nil {
+		return true, nil, fmt.Errorf("target database %q not found in instance %q", payload.DatabaseName, task.Instance.Name)
+	}
+
+	log.Debug("Start database clone...",
+		zap.String("source_instance", sourceDatabase.Instance.Name),
+		zap.String("source_database", sourceDatabase.Name),
+		zap.String("target_instance", targetDatabase.Instance.Name),
+		zap.String("target_database", targetDatabase.Name),
+		zap.Bool("schema_only", payload.Config.SchemaOnly),
+	)
+
+	if err := exec.cloneDatabase(ctx, server, task, sourceDatabase, targetDatabase, payload.Config); err != nil {
+		return true, nil, err
+	}
+
+	// Like the restore, the branch migration history is created after the clone, since it is unlikely to fail.
+	description := fmt.Sprintf("Cloned from database %q.", sourceDatabase.Name)
+	if sourceDatabase.InstanceID != targetDatabase.InstanceID {
+		description = fmt.Sprintf("Cloned from database %q in instance %q.", sourceDatabase.Name, sourceDatabase.Instance.Name)
+	}
+	migrationID, version, err := createBranchMigrationHistory(ctx, server, targetDatabase, description, task)
+	if err != nil {
+		return true, nil, err
+	}
+
+	// Sync database schema after clone is completed.
+	if err := server.syncDatabaseSchema(ctx, targetDatabase.Instance, targetDatabase.Name); err != nil {
+		log.Error("failed to sync database schema",
+			zap.String("instance", targetDatabase.Instance.Name),
+			zap.String("databaseName", targetDatabase.Name),
+		)
+	}
+
+	return true, &api.TaskRunResultPayload{
+		Detail:      fmt.Sprintf("Cloned database %q from database %q", targetDatabase.Name, sourceDatabase.Name),
+		MigrationID: migrationID,
+		Version:     version,
+	}, nil
+}
+
+// cloneDatabase clones the source database to the target database.
+func (exec *DatabaseCloneTaskExecutor) cloneDatabase(ctx context.Context, server *Server, task *api.Task, sourceDatabase, targetDatabase *api.Database, config api.CloneConfig) error {
+	sourceDriver, err := getAdminDatabaseDriver(ctx, sourceDatabase.Instance, sourceDatabase.Name, server.pgInstanceDir)
+	if err != nil {
+		return err
+	}
+	defer sourceDriver.Close(ctx)
+	sourceMySQLDriver, ok := sourceDriver.(*mysql.Driver)
+	if !ok {
+		return fmt.Errorf("[internal] cast driver to mysql.Driver failed")
+	}
+	targetDriver, err := getAdminDatabaseDriver(ctx, targetDatabase.Instance, targetDatabase.Name, server.pgInstanceDir)
+	if err != nil {
+		return err
+	}
+	defer targetDriver.Close(ctx)
+	targetMySQLDriver, ok := targetDriver.(*mysql.Driver)
+	if !ok {
+		return fmt.Errorf("[internal] cast driver to mysql.Driver failed")
+	}
+
+	lastReport := time.Now()
+	if err := sourceMySQLDriver.CloneDatabase(ctx, sourceDatabase.Name, targetMySQLDriver, targetDatabase.Name, config, func(table string, copied int64) {
+		if time.Since(lastReport) < cloneProgressReportInterval {
+			return
+		}
+		lastReport = time.Now()
+		reportTaskRunProgress(ctx, server, task, &api.TaskRunResultPayload{
+			Detail: fmt.Sprintf("Copied %d rows of table %q", copied, table),
+		})
+	}); err != nil {
+		return fmt.Errorf("failed to clone database %q, error: %w", sourceDatabase.Name, err)
+	}
+	return nil
+}
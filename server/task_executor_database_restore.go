@@ -5,12 +5,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
+	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/common/backupfile"
 	"github.com/bytebase/bytebase/common/log"
 	"github.com/bytebase/bytebase/plugin/db"
 
@@ -40,6 +41,9 @@ func (exec *DatabaseRestoreTaskExecutor) RunOnce(ctx context.Context, server *Se
 	if backup == nil {
 		return true, nil, fmt.Errorf("backup with ID %d not found", payload.BackupID)
 	}
+	if backup.Status == api.BackupStatusPruned {
+		return true, nil, fmt.Errorf("backup %q has been pruned by the backup retention policy", backup.Name)
+	}
 
 	sourceDatabase, err := server.store.GetDatabase(ctx, &api.DatabaseFind{ID: &backup.DatabaseID})
 	if err != nil {
@@ -69,14 +73,23 @@ func (exec *DatabaseRestoreTaskExecutor) RunOnce(ctx context.Context, server *Se
 		zap.String("backup", backup.Name),
 	)
 
+	keyList, err := server.getBackupDecryptionKeyList(ctx)
+	if err != nil {
+		return true, nil, fmt.Errorf("failed to get backup encryption keys, error: %w", err)
+	}
+
 	// Restore the database to the target database.
-	if err := exec.restoreDatabase(ctx, targetDatabase.Instance, targetDatabase.Name, backup, server.profile.DataDir, server.pgInstanceDir); err != nil {
+	if err := exec.restoreDatabase(ctx, sourceDatabase.Instance, targetDatabase.Instance, targetDatabase.Name, backup, server.profile.DataDir, server.pgInstanceDir, keyList); err != nil {
 		return true, nil, err
 	}
 
 	// TODO(tianzhou): This should be done in the same transaction as restoreDatabase to guarantee consistency.
 	// For now, we do this after restoreDatabase, since this one is unlikely to fail.
-	migrationID, version, err := createBranchMigrationHistory(ctx, server, sourceDatabase, targetDatabase, backup, task)
+	description := fmt.Sprintf("Restored from backup %q of database %q.", backup.Name, sourceDatabase.Name)
+	if sourceDatabase.InstanceID != targetDatabase.InstanceID {
+		description = fmt.Sprintf("Restored from backup %q of database %q in instance %q.", backup.Name, sourceDatabase.Name, sourceDatabase.Instance.Name)
+	}
+	migrationID, version, err := createBranchMigrationHistory(ctx, server, targetDatabase, description, task)
 	if err != nil {
 		return true, nil, err
 	}
@@ -110,24 +123,31 @@ func (exec *DatabaseRestoreTaskExecutor) RunOnce(ctx context.Context, server *Se
 }
 
 // restoreDatabase will restore the database from a backup
-func (exec *DatabaseRestoreTaskExecutor) restoreDatabase(ctx context.Context, instance *api.Instance, databaseName string, backup *api.Backup, dataDir, pgInstanceDir string) error {
+func (exec *DatabaseRestoreTaskExecutor) restoreDatabase(ctx context.Context, sourceInstance, instance *api.Instance, databaseName string, backup *api.Backup, dataDir, pgInstanceDir string, keyList []*backupfile.Key) error {
 	driver, err := getAdminDatabaseDriver(ctx, instance, databaseName, pgInstanceDir)
 	if err != nil {
 		return err
 	}
 	defer driver.Close(ctx)
 
-	backupPath := backup.Path
-	if !filepath.IsAbs(backupPath) {
-		backupPath = filepath.Join(dataDir, backupPath)
+	// The version of the target instance is checked again, since it may have been changed after the issue is created.
+	// The source instance may be unavailable, e.g. in a disaster recovery, so its synced version is used.
+	if sourceInstance.ID != instance.ID {
+		version, err := driver.GetVersion(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get the version of instance %q, error: %w", instance.Name, err)
+		}
+		if err := checkRestoreCompatibility(sourceInstance.Engine, sourceInstance.EngineVersion, instance.Engine, version); err != nil {
+			return err
+		}
 	}
 
-	f, err := os.OpenFile(backupPath, os.O_RDONLY, os.ModePerm)
+	r, err := openBackupFile(backup, dataDir, keyList)
 	if err != nil {
-		return fmt.Errorf("failed to open backup file at %s: %w", backupPath, err)
+		return err
 	}
-	defer f.Close()
-	sc := bufio.NewScanner(f)
+	defer r.Close()
+	sc := bufio.NewScanner(r)
 
 	if err := driver.Restore(ctx, sc); err != nil {
 		return fmt.Errorf("failed to restore backup: %w", err)
@@ -137,9 +157,9 @@ func (exec *DatabaseRestoreTaskExecutor) restoreDatabase(ctx context.Context, in
 
 // createBranchMigrationHistory creates a migration history with "BRANCH" type. We choose NOT to copy over
 // all migration history from source database because that might be expensive (e.g. we may use restore to
-// create many ephemeral databases from backup for testing purpose)
+// create many ephemeral databases from backup or by cloning for testing purpose)
 // Returns migration history id and the version on success
-func createBranchMigrationHistory(ctx context.Context, server *Server, sourceDatabase, targetDatabase *api.Database, backup *api.Backup, task *api.Task) (int64, string, error) {
+func createBranchMigrationHistory(ctx context.Context, server *Server, targetDatabase *api.Database, description string, task *api.Task) (int64, string, error) {
 	targetDriver, err := getAdminDatabaseDriver(ctx, targetDatabase.Instance, targetDatabase.Name, server.pgInstanceDir)
 	if err != nil {
 		return -1, "", err
@@ -156,10 +176,6 @@ func createBranchMigrationHistory(ctx context.Context, server *Server, sourceDat
 	if issue != nil {
 		issueID = strconv.Itoa(issue.ID)
 	}
-	description := fmt.Sprintf("Restored from backup %q of database %q.", backup.Name, sourceDatabase.Name)
-	if sourceDatabase.InstanceID != targetDatabase.InstanceID {
-		description = fmt.Sprintf("Restored from backup %q of database %q in instance %q.", backup.Name, sourceDatabase.Name, sourceDatabase.Instance.Name)
-	}
 	// TODO(d): support semantic versioning.
 	m := &db.MigrationInfo{
 		ReleaseVersion: server.profile.Version,
@@ -179,3 +195,100 @@ func createBranchMigrationHistory(ctx context.Context, server *Server, sourceDat
 	}
 	return migrationID, m.Version, nil
 }
+
+var (
+	// The version of TiDB in its MySQL compatible version, e.g. "5.7.25-TiDB-v6.1.0".
+	tidbVersionReg = regexp.MustCompile(`-TiDB-v(\d+)\.(\d+)\.(\d+)`)
+	// The leading numbers of the version, e.g. "8.0.28-log" and "14.2 (Debian 14.2-1.pgdg110+1)".
+	engineVersionReg = regexp.MustCompile(`^(\d+)(?:\.(\d+))?(?:\.(\d+))?`)
+)
+
+// parseEngineVersion parses the major, minor and patch version of the engine.
+func parseEngineVersion(engine db.Type, version string) ([3]int, error) {
+	var v [3]int
+	reg := engineVersionReg
+	if engine == db.TiDB {
+		reg = tidbVersionReg
+	}
+	matches := reg.FindStringSubmatch(version)
+	if matches == nil {
+		return v, fmt.Errorf("invalid %s version %q", engine, version)
+	}
+	for i := range v {
+		if matches[i+1] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(matches[i+1])
+		if err != nil {
+			return v, fmt.Errorf("invalid %s version %q", engine, version)
+		}
+		v[i] = n
+	}
+	return v, nil
+}
+
+// checkRestoreCompatibility checks whether the backup of the source instance can be restored to the target instance.
+// The backup is restored to an instance of the same engine, and of the same or a newer version, since the dump of
+// a newer version may use the syntax unsupported by an older one. The check is skipped if the source version is unknown.
+func checkRestoreCompatibility(sourceEngine db.Type, sourceVersion string, targetEngine db.Type, targetVersion string) error {
+	if sourceEngine != targetEngine {
+		return common.Errorf(common.Invalid, fmt.Errorf("cannot restore the backup of %s to an instance of %s", sourceEngine, targetEngine))
+	}
+	if sourceVersion == "" {
+		return nil
+	}
+	source, err := parseEngineVersion(sourceEngine, sourceVersion)
+	if err != nil {
+		return err
+	}
+	target, err := parseEngineVersion(targetEngine, targetVersion)
+	if err != nil {
+		return err
+	}
+	// The major version of Postgres is the first number since Postgres 10, and the first two numbers before.
+	// The major version of MySQL and the others is the first two numbers.
+	length := 2
+	if sourceEngine == db.Postgres && source[0] >= 10 {
+		length = 1
+	}
+	for i := 0; i < length; i++ {
+		if target[i] > source[i] {
+			return nil
+		}
+		if target[i] < source[i] {
+			return common.Errorf(common.Invalid, fmt.Errorf("cannot restore the backup of %s %s to the older version %s", sourceEngine, sourceVersion, targetVersion))
+		}
+	}
+	return nil
+}
+
+// getRestoreCharsetAndCollation returns the character set and collation of the database restored from the backup
+// of the source database. The ones of the source database are used if they are not specified, and mapped to the ones
+// supported by the target instance.
+func getRestoreCharsetAndCollation(sourceDatabase *api.Database, targetInstance *api.Instance, charset, collation string) (string, string) {
+	if charset == "" {
+		charset = sourceDatabase.CharacterSet
+		if collation == "" {
+			collation = sourceDatabase.Collation
+			// The locales available differ between the operating systems of the Postgres instances,
+			// so the default of the target instance is used instead.
+			if targetInstance.Engine == db.Postgres && sourceDatabase.InstanceID != targetInstance.ID {
+				collation = ""
+			}
+		}
+	}
+
+	if targetInstance.Engine == db.MySQL {
+		// MySQL names utf8 as utf8mb3 since 8.0.30, and the collations of utf8mb3 are unknown to the earlier versions.
+		v, err := parseEngineVersion(db.MySQL, targetInstance.EngineVersion)
+		if err == nil && (v[0] < 8 || (v[0] == 8 && v[1] == 0 && v[2] < 30)) {
+			if charset == "utf8mb3" {
+				charset = "utf8"
+			}
+			if strings.HasPrefix(collation, "utf8mb3_") {
+				collation = "utf8_" + strings.TrimPrefix(collation, "utf8mb3_")
+			}
+		}
+	}
+	return charset, collation
+}
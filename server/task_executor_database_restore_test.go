@@ -0,0 +1,97 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+func TestCheckRestoreCompatibility(t *testing.T) {
+	tests := []struct {
+		sourceEngine  db.Type
+		sourceVersion string
+		targetEngine  db.Type
+		targetVersion string
+		wantErr       bool
+	}{
+		{sourceEngine: db.MySQL, sourceVersion: "5.7.36-log", targetEngine: db.MySQL, targetVersion: "8.0.28"},
+		{sourceEngine: db.MySQL, sourceVersion: "8.0.30", targetEngine: db.MySQL, targetVersion: "8.0.20"},
+		{sourceEngine: db.MySQL, sourceVersion: "8.0.28", targetEngine: db.MySQL, targetVersion: "5.7.36", wantErr: true},
+		{sourceEngine: db.MySQL, sourceVersion: "8.0.28", targetEngine: db.TiDB, targetVersion: "5.7.25-TiDB-v6.1.0", wantErr: true},
+		{sourceEngine: db.TiDB, sourceVersion: "5.7.25-TiDB-v5.4.0", targetEngine: db.TiDB, targetVersion: "5.7.25-TiDB-v6.1.0"},
+		{sourceEngine: db.TiDB, sourceVersion: "5.7.25-TiDB-v6.1.0", targetEngine: db.TiDB, targetVersion: "5.7.25-TiDB-v5.4.0", wantErr: true},
+		{sourceEngine: db.Postgres, sourceVersion: "14.5", targetEngine: db.Postgres, targetVersion: "14.2 (Debian 14.2-1.pgdg110+1)"},
+		{sourceEngine: db.Postgres, sourceVersion: "9.6.24", targetEngine: db.Postgres, targetVersion: "9.5.25", wantErr: true},
+		{sourceEngine: db.Postgres, sourceVersion: "14.5", targetEngine: db.Postgres, targetVersion: "13.8", wantErr: true},
+		// The check is skipped if the source version is unknown.
+		{sourceEngine: db.Postgres, sourceVersion: "", targetEngine: db.Postgres, targetVersion: "13.8"},
+	}
+
+	for _, test := range tests {
+		err := checkRestoreCompatibility(test.sourceEngine, test.sourceVersion, test.targetEngine, test.targetVersion)
+		if test.wantErr {
+			assert.Error(t, err, "%s %s to %s %s", test.sourceEngine, test.sourceVersion, test.targetEngine, test.targetVersion)
+		} else {
+			assert.NoError(t, err, "%s %s to %s %s", test.sourceEngine, test.sourceVersion, test.targetEngine, test.targetVersion)
+		}
+	}
+}
+
+func TestGetRestoreCharsetAndCollation(t *testing.T) {
+	tests := []struct {
+		sourceDatabase *api.Database
+		targetInstance *api.Instance
+		charset        string
+		collation      string
+		wantCharset    string
+		wantCollation  string
+	}{
+		{
+			sourceDatabase: &api.Database{InstanceID: 1, CharacterSet: "utf8mb4", Collation: "utf8mb4_0900_ai_ci"},
+			targetInstance: &api.Instance{ID: 2, Engine: db.MySQL, EngineVersion: "8.0.28"},
+			wantCharset:    "utf8mb4",
+			wantCollation:  "utf8mb4_0900_ai_ci",
+		},
+		{
+			sourceDatabase: &api.Database{InstanceID: 1, CharacterSet: "utf8mb4", Collation: "utf8mb4_0900_ai_ci"},
+			targetInstance: &api.Instance{ID: 2, Engine: db.MySQL, EngineVersion: "8.0.28"},
+			charset:        "latin1",
+			collation:      "latin1_swedish_ci",
+			wantCharset:    "latin1",
+			wantCollation:  "latin1_swedish_ci",
+		},
+		{
+			sourceDatabase: &api.Database{InstanceID: 1, CharacterSet: "utf8mb3", Collation: "utf8mb3_general_ci"},
+			targetInstance: &api.Instance{ID: 2, Engine: db.MySQL, EngineVersion: "8.0.20"},
+			wantCharset:    "utf8",
+			wantCollation:  "utf8_general_ci",
+		},
+		{
+			sourceDatabase: &api.Database{InstanceID: 1, CharacterSet: "utf8mb3", Collation: "utf8mb3_general_ci"},
+			targetInstance: &api.Instance{ID: 2, Engine: db.MySQL, EngineVersion: "8.0.31"},
+			wantCharset:    "utf8mb3",
+			wantCollation:  "utf8mb3_general_ci",
+		},
+		{
+			sourceDatabase: &api.Database{InstanceID: 1, CharacterSet: "UTF8", Collation: "en_US.UTF-8"},
+			targetInstance: &api.Instance{ID: 1, Engine: db.Postgres, EngineVersion: "14.2"},
+			wantCharset:    "UTF8",
+			wantCollation:  "en_US.UTF-8",
+		},
+		{
+			sourceDatabase: &api.Database{InstanceID: 1, CharacterSet: "UTF8", Collation: "en_US.UTF-8"},
+			targetInstance: &api.Instance{ID: 2, Engine: db.Postgres, EngineVersion: "14.2"},
+			wantCharset:    "UTF8",
+			wantCollation:  "",
+		},
+	}
+
+	for _, test := range tests {
+		charset, collation := getRestoreCharsetAndCollation(test.sourceDatabase, test.targetInstance, test.charset, test.collation)
+		assert.Equal(t, test.wantCharset, charset)
+		assert.Equal(t, test.wantCollation, collation)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
 	"strconv"
 
 	"github.com/bytebase/bytebase/api"
@@ -11,6 +12,7 @@ import (
 	"github.com/bytebase/bytebase/common/log"
 	"github.com/bytebase/bytebase/plugin/db"
 	"github.com/bytebase/bytebase/plugin/db/mysql"
+	"github.com/bytebase/bytebase/plugin/db/pg"
 	"github.com/bytebase/bytebase/resources/mysqlutil"
 	"go.uber.org/zap"
 )
@@ -80,30 +82,13 @@ func (exec *PITRCutoverTaskExecutor) pitrCutover(ctx context.Context, task *api.
 	}
 	defer driver.Close(ctx)
 
-	binlogDir := getBinlogAbsDir(server.profile.DataDir, task.Instance.ID)
-	if err := createBinlogDir(server.profile.DataDir, task.Instance.ID); err != nil {
-		return true, nil, err
-	}
-
-	driverDB, err := driver.GetDbConnection(ctx, "")
-	if err != nil {
-		return true, nil, err
-	}
-	conn, err := driverDB.Conn(ctx)
-	if err != nil {
-		return true, nil, err
-	}
-	defer conn.Close()
-
-	mysqlDriver, ok := driver.(*mysql.Driver)
-	if !ok {
-		log.Error("failed to cast driver to mysql.Driver")
-		return true, nil, fmt.Errorf("[internal] cast driver to mysql.Driver failed")
-	}
-	mysqlDriver.SetUpForPITR(exec.mysqlutil, binlogDir)
-
 	log.Debug("Swapping the original and PITR database.", zap.String("instance", task.Instance.Name), zap.String("originalDatabase", task.Database.Name))
-	pitrDatabaseName, pitrOldDatabaseName, err := mysql.SwapPITRDatabase(ctx, conn, task.Database.Name, issue.CreatedTs)
+	var pitrDatabaseName, pitrOldDatabaseName string
+	if task.Instance.Engine == db.Postgres {
+		pitrDatabaseName, pitrOldDatabaseName, err = exec.swapPgPITRDatabase(ctx, driver, server, task, issue)
+	} else {
+		pitrDatabaseName, pitrOldDatabaseName, err = exec.swapMySQLPITRDatabase(ctx, driver, server, task, issue)
+	}
 	if err != nil {
 		log.Error("Failed to swap databases and backup the original database", zap.Error(err))
 		return true, nil, fmt.Errorf("failed to swap the original and PITR database, error: %w", err)
@@ -142,3 +127,61 @@ func (exec *PITRCutoverTaskExecutor) pitrCutover(ctx context.Context, task *api.
 		Detail: fmt.Sprintf("Swapped PITR database for target database %q", task.Database.Name),
 	}, nil
 }
+
+func (exec *PITRCutoverTaskExecutor) swapMySQLPITRDatabase(ctx context.Context, driver db.Driver, server *Server, task *api.Task, issue *api.Issue) (string, string, error) {
+	binlogDir := getBinlogAbsDir(server.profile.DataDir, task.Instance.ID)
+	if err := createBinlogDir(server.profile.DataDir, task.Instance.ID); err != nil {
+		return "", "", err
+	}
+
+	driverDB, err := driver.GetDbConnection(ctx, "")
+	if err != nil {
+		return "", "", err
+	}
+	conn, err := driverDB.Conn(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	defer conn.Close()
+
+	mysqlDriver, ok := driver.(*mysql.Driver)
+	if !ok {
+		log.Error("failed to cast driver to mysql.Driver")
+		return "", "", fmt.Errorf("[internal] cast driver to mysql.Driver failed")
+	}
+	mysqlDriver.SetUpForPITR(exec.mysqlutil, binlogDir)
+
+	return mysql.SwapPITRDatabase(ctx, conn, task.Database.Name, issue.CreatedTs)
+}
+
+// swapPgPITRDatabase swaps the PITR database, and drops the PITR slot of the original database with its WAL archive.
+// The WAL archive of the swapped-in database starts from a new slot created by the PITR archiver,
+// so the next PITR requires a new backup after the cutover.
+func (*PITRCutoverTaskExecutor) swapPgPITRDatabase(ctx context.Context, driver db.Driver, server *Server, task *api.Task, issue *api.Issue) (string, string, error) {
+	pgDriver, ok := driver.(*pg.Driver)
+	if !ok {
+		log.Error("failed to cast driver to pg.Driver")
+		return "", "", fmt.Errorf("[internal] cast driver to pg.Driver failed")
+	}
+	pitrDatabaseName, pitrOldDatabaseName, err := pgDriver.SwapPITRDatabase(ctx, task.Database.Name, issue.CreatedTs)
+	if err != nil {
+		return pitrDatabaseName, pitrOldDatabaseName, err
+	}
+
+	slot := pg.GetPITRSlotName(task.Database.ID)
+	slotList, err := pgDriver.ListPITRSlots(ctx)
+	if err != nil {
+		return pitrDatabaseName, pitrOldDatabaseName, err
+	}
+	for _, s := range slotList {
+		if s == slot {
+			if err := pgDriver.DropPITRSlot(ctx, slot); err != nil {
+				return pitrDatabaseName, pitrOldDatabaseName, err
+			}
+		}
+	}
+	if err := os.RemoveAll(getWALArchiveAbsDir(server.profile.DataDir, task.Database.ID)); err != nil {
+		return pitrDatabaseName, pitrOldDatabaseName, fmt.Errorf("failed to remove WAL archive, error: %w", err)
+	}
+	return pitrDatabaseName, pitrOldDatabaseName, nil
+}
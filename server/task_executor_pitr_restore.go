@@ -5,13 +5,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common/backupfile"
 	"github.com/bytebase/bytebase/common/log"
 	"github.com/bytebase/bytebase/plugin/db"
 	"github.com/bytebase/bytebase/plugin/db/mysql"
+	"github.com/bytebase/bytebase/plugin/db/pg"
 	"github.com/bytebase/bytebase/resources/mysqlutil"
 	"github.com/bytebase/bytebase/store"
 	"go.uber.org/zap"
@@ -44,7 +45,12 @@ func (exec *PITRRestoreTaskExecutor) RunOnce(ctx context.Context, server *Server
 	}
 	defer driver.Close(ctx)
 
-	if err := exec.doPITRRestore(ctx, task, server.store, driver, server.profile.DataDir, payload.PointInTimeTs); err != nil {
+	keyList, err := server.getBackupDecryptionKeyList(ctx)
+	if err != nil {
+		return true, nil, fmt.Errorf("failed to get backup encryption keys, error: %w", err)
+	}
+
+	if err := exec.doPITRRestore(ctx, task, server.store, driver, server.profile.DataDir, keyList, payload.PointInTimeTs); err != nil {
 		log.Error("Failed to do PITR restore", zap.Error(err))
 		return true, nil, err
 	}
@@ -56,7 +62,7 @@ func (exec *PITRRestoreTaskExecutor) RunOnce(ctx context.Context, server *Server
 	}, nil
 }
 
-func (exec *PITRRestoreTaskExecutor) doPITRRestore(ctx context.Context, task *api.Task, store *store.Store, driver db.Driver, dataDir string, targetTs int64) error {
+func (exec *PITRRestoreTaskExecutor) doPITRRestore(ctx context.Context, task *api.Task, store *store.Store, driver db.Driver, dataDir string, keyList []*backupfile.Key, targetTs int64) error {
 	instance := task.Instance
 	database := task.Database
 
@@ -72,6 +78,15 @@ func (exec *PITRRestoreTaskExecutor) doPITRRestore(ctx context.Context, task *ap
 	}
 	log.Debug("Found backup list", zap.Array("backups", api.ZapBackupArray(backupList)))
 
+	if instance.Engine == db.Postgres {
+		pgDriver, ok := driver.(*pg.Driver)
+		if !ok {
+			log.Error("failed to cast driver to pg.Driver")
+			return fmt.Errorf("[internal] cast driver to pg.Driver failed")
+		}
+		return exec.doPgPITRRestore(ctx, issue, database, backupList, pgDriver, dataDir, keyList, targetTs)
+	}
+
 	binlogDir := getBinlogAbsDir(dataDir, task.Instance.ID)
 	if err := createBinlogDir(dataDir, task.Instance.ID); err != nil {
 		return err
@@ -101,13 +116,12 @@ func (exec *PITRRestoreTaskExecutor) doPITRRestore(ctx context.Context, task *ap
 		return fmt.Errorf("failed to get latest backup before or equal to %s, error: %w", dateTime, err)
 	}
 	log.Debug("Got latest backup before or equal to targetTs", zap.String("backup", backup.Name))
-	backupFileName := getBackupAbsFilePath(dataDir, backup.DatabaseID, backup.Name)
-	backupFile, err := os.OpenFile(backupFileName, os.O_RDONLY, os.ModePerm)
+	backupFile, err := openBackupFile(backup, dataDir, keyList)
 	if err != nil {
-		return fmt.Errorf("failed to open backup file: %s, error: %w", backupFileName, err)
+		return err
 	}
 	defer backupFile.Close()
-	log.Debug("Successfully opened backup file", zap.String("filename", backupFileName))
+	log.Debug("Successfully opened backup file", zap.String("path", backup.Path))
 
 	log.Debug("Start creating and restoring PITR database",
 		zap.String("instance", instance.Name),
@@ -128,6 +142,41 @@ func (exec *PITRRestoreTaskExecutor) doPITRRestore(ctx context.Context, task *ap
 	return nil
 }
 
+// doPgPITRRestore restores the latest backup before the targetTs, and replays the archived WAL changes to the targetTs.
+func (*PITRRestoreTaskExecutor) doPgPITRRestore(ctx context.Context, issue *api.Issue, database *api.Database, backupList []*api.Backup, pgDriver *pg.Driver, dataDir string, keyList []*backupfile.Key, targetTs int64) error {
+	// Archive the WAL changes till now, which might not be archived by the PITR archiver yet.
+	log.Debug("Archiving the latest WAL changes")
+	archiveDir := getWALArchiveAbsDir(dataDir, database.ID)
+	if err := createWALArchiveDir(dataDir, database.ID); err != nil {
+		return err
+	}
+	if err := pgDriver.ArchiveWAL(ctx, database.Name, pg.GetPITRSlotName(database.ID), archiveDir); err != nil {
+		return err
+	}
+
+	log.Debug("Getting latest backup before or equal to targetTs...", zap.Time("targetTs", time.Unix(targetTs, 0)))
+	backup, err := pg.GetLatestBackupBeforeOrEqualTs(backupList, targetTs)
+	if err != nil {
+		return err
+	}
+	log.Debug("Got latest backup before or equal to targetTs", zap.String("backup", backup.Name))
+	backupFile, err := openBackupFile(backup, dataDir, keyList)
+	if err != nil {
+		return err
+	}
+	defer backupFile.Close()
+
+	log.Debug("Start creating and restoring PITR database", zap.String("database", database.Name))
+	if err := pgDriver.RestorePITR(ctx, bufio.NewScanner(backupFile), backup.Payload.WALInfo, archiveDir, database.Name, issue.CreatedTs, targetTs); err != nil {
+		log.Error("failed to perform a PITR restore in the PITR database",
+			zap.Int("issueID", issue.ID),
+			zap.String("database", database.Name),
+			zap.Error(err))
+		return fmt.Errorf("failed to perform a PITR restore in the PITR database, error: %w", err)
+	}
+	return nil
+}
+
 func getIssueByPipelineID(ctx context.Context, store *store.Store, pid int) (*api.Issue, error) {
 	issue, err := store.GetIssueByPipelineID(ctx, pid)
 	if err != nil {
@@ -6,6 +6,8 @@ import (
 	"fmt"
 
 	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common/log"
+	"go.uber.org/zap"
 )
 
 // NewSchemaUpdateTaskExecutor creates a schema update (DDL) task executor.
@@ -24,5 +26,28 @@ func (exec *SchemaUpdateTaskExecutor) RunOnce(ctx context.Context, server *Serve
 		return true, nil, fmt.Errorf("invalid database schema update payload: %w", err)
 	}
 
-	return runMigration(ctx, server, task, payload.MigrationType, payload.Statement, payload.SchemaVersion, payload.VCSPushEvent)
+	mi, err := preMigration(ctx, server, task, payload.MigrationType, payload.Statement, payload.SchemaVersion, payload.VCSPushEvent)
+	if err != nil {
+		return true, nil, err
+	}
+	migrationID, schema, err := executeMigration(ctx, server.pgInstanceDir, task, payload.Statement, mi)
+	if err != nil {
+		return true, nil, err
+	}
+	terminated, result, err = postMigration(ctx, server, task, payload.VCSPushEvent, mi, migrationID, schema)
+	if err != nil {
+		return terminated, result, err
+	}
+
+	// The schema has been updated, so the verification failure doesn't fail the task.
+	result.Verification = verifyMigration(ctx, server, task, payload.VerificationStatementList, payload.ExpectedSchema, schema)
+	if result.Verification != nil && !result.Verification.Passed {
+		if err := server.createTaskVerificationFailActivity(ctx, task, result.Verification); err != nil {
+			log.Error("Failed to create activity after failing the verification",
+				zap.Int("task_id", task.ID),
+				zap.Error(err),
+			)
+		}
+	}
+	return terminated, result, nil
 }
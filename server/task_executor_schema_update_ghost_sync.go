@@ -15,7 +15,6 @@ import (
 	"github.com/bytebase/bytebase/common/log"
 	"github.com/bytebase/bytebase/plugin/db"
 	"github.com/bytebase/bytebase/plugin/db/util"
-	vcsPlugin "github.com/bytebase/bytebase/plugin/vcs"
 	"github.com/github/gh-ost/go/base"
 	"github.com/github/gh-ost/go/logic"
 	ghostsql "github.com/github/gh-ost/go/sql"
@@ -37,9 +36,17 @@ func (exec *SchemaUpdateGhostSyncTaskExecutor) RunOnce(ctx context.Context, serv
 	if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
 		return true, nil, fmt.Errorf("invalid database schema update gh-ost sync payload: %w", err)
 	}
-	return runGhostMigration(ctx, server, task, payload.Statement, payload.SchemaVersion, payload.VCSPushEvent)
+	return runGhostMigration(ctx, server, task, payload)
 }
 
+const (
+	// defaultGhostChunkSize and defaultGhostMaxLagMillis are used if they are not set in the gh-ost config of the task.
+	defaultGhostChunkSize    = 1000
+	defaultGhostMaxLagMillis = 1500
+	// ghostProgressReportInterval is the interval to report the progress of the gh-ost migration to the task run.
+	ghostProgressReportInterval = 5 * time.Second
+)
+
 func getSocketFilename(taskID int, databaseID int, databaseName string, tableName string) string {
 	return fmt.Sprintf("/tmp/gh-ost.%v.%v.%v.%v.sock", taskID, databaseID, databaseName, tableName)
 }
@@ -72,22 +79,24 @@ type ghostConfig struct {
 	socketFilename       string
 	postponeFlagFilename string
 	noop                 bool
+	// throttleConfig overrides the default throttling config if not nil.
+	throttleConfig *api.TaskGhostConfig
+	// paused starts the migration with the row copy paused.
+	paused bool
 }
 
 func newMigrationContext(config ghostConfig) (*base.MigrationContext, error) {
 	const (
-		allowedRunningOnMaster              = true
-		concurrentCountTableRows            = true
-		timestampAllTable                   = true
-		hooksStatusIntervalSec              = 60
-		heartbeatIntervalMilliseconds       = 100
-		niceRatio                           = 0
-		chunkSize                           = 1000
-		dmlBatchSize                        = 10
-		maxLagMillisecondsThrottleThreshold = 1500
-		defaultNumRetries                   = 60
-		cutoverLockTimeoutSeconds           = 3
-		exponentialBackoffMaxInterval       = 64
+		allowedRunningOnMaster        = true
+		concurrentCountTableRows      = true
+		timestampAllTable             = true
+		hooksStatusIntervalSec        = 60
+		heartbeatIntervalMilliseconds = 100
+		niceRatio                     = 0
+		dmlBatchSize                  = 10
+		defaultNumRetries             = 60
+		cutoverLockTimeoutSeconds     = 3
+		exponentialBackoffMaxInterval = 64
 	)
 	statement := strings.Join(strings.Fields(config.alterStatement), " ")
 	migrationContext := base.NewMigrationContext()
@@ -137,9 +146,27 @@ func newMigrationContext(config ghostConfig) (*base.MigrationContext, error) {
 	migrationContext.TimestampAllTable = timestampAllTable
 	migrationContext.SetHeartbeatIntervalMilliseconds(heartbeatIntervalMilliseconds)
 	migrationContext.SetNiceRatio(niceRatio)
-	migrationContext.SetChunkSize(chunkSize)
 	migrationContext.SetDMLBatchSize(dmlBatchSize)
-	migrationContext.SetMaxLagMillisecondsThrottleThreshold(maxLagMillisecondsThrottleThreshold)
+	chunkSize, maxLagMillis := int64(defaultGhostChunkSize), int64(defaultGhostMaxLagMillis)
+	if c := config.throttleConfig; c != nil {
+		if c.ChunkSize > 0 {
+			chunkSize = c.ChunkSize
+		}
+		if c.MaxLagMillis > 0 {
+			maxLagMillis = c.MaxLagMillis
+		}
+		if err := migrationContext.ReadMaxLoad(c.MaxLoad); err != nil {
+			return nil, err
+		}
+		if err := migrationContext.ReadCriticalLoad(c.CriticalLoad); err != nil {
+			return nil, err
+		}
+	}
+	migrationContext.SetChunkSize(chunkSize)
+	migrationContext.SetMaxLagMillisecondsThrottleThreshold(maxLagMillis)
+	if config.paused {
+		atomic.StoreInt64(&migrationContext.ThrottleCommandedByUser, 1)
+	}
 	migrationContext.SetDefaultNumRetries(defaultNumRetries)
 	migrationContext.ApplyCredentials()
 	if err := migrationContext.SetCutOverLockTimeoutSeconds(cutoverLockTimeoutSeconds); err != nil {
@@ -151,8 +178,9 @@ func newMigrationContext(config ghostConfig) (*base.MigrationContext, error) {
 	return migrationContext, nil
 }
 
-func runGhostMigration(ctx context.Context, server *Server, task *api.Task, statement, schemaVersion string, vcsPushEvent *vcsPlugin.PushEvent) (terminated bool, result *api.TaskRunResultPayload, err error) {
-	mi, err := preMigration(ctx, server, task, db.Migrate, statement, schemaVersion, vcsPushEvent)
+func runGhostMigration(ctx context.Context, server *Server, task *api.Task, payload *api.TaskDatabaseSchemaUpdateGhostSyncPayload) (terminated bool, result *api.TaskRunResultPayload, err error) {
+	vcsPushEvent := payload.VCSPushEvent
+	mi, err := preMigration(ctx, server, task, db.Migrate, payload.Statement, payload.SchemaVersion, vcsPushEvent)
 	if err != nil {
 		return true, nil, err
 	}
@@ -161,7 +189,7 @@ func runGhostMigration(ctx context.Context, server *Server, task *api.Task, stat
 	syncError := make(chan error)
 
 	go func() {
-		migrationID, schema, err := executeSync(ctx, task, mi, statement, syncDone)
+		migrationID, schema, err := executeSync(ctx, server, task, mi, payload, syncDone)
 		if err != nil {
 			log.Error("failed to execute schema update gh-ost sync executeSync", zap.Error(err))
 			// There could be an error in gh-ost migration after the syncDone channel returns which causes the outer function returns, too.
@@ -187,8 +215,8 @@ func runGhostMigration(ctx context.Context, server *Server, task *api.Task, stat
 
 }
 
-func executeSync(ctx context.Context, task *api.Task, mi *db.MigrationInfo, statement string, syncDone chan<- struct{}) (migrationHistoryID int64, updatedSchema string, resErr error) {
-	statement = strings.TrimSpace(statement)
+func executeSync(ctx context.Context, server *Server, task *api.Task, mi *db.MigrationInfo, payload *api.TaskDatabaseSchemaUpdateGhostSyncPayload, syncDone chan<- struct{}) (migrationHistoryID int64, updatedSchema string, resErr error) {
+	statement := strings.TrimSpace(payload.Statement)
 
 	driver, err := getAdminDatabaseDriver(ctx, task.Instance, task.Database.Name, "" /* pgInstanceDir */)
 	if err != nil {
@@ -224,7 +252,7 @@ func executeSync(ctx context.Context, task *api.Task, mi *db.MigrationInfo, stat
 			)
 		}
 	}()
-	if err = executeGhost(task, startedNs, statement, syncDone); err != nil {
+	if err = executeGhost(server, task, startedNs, statement, payload.Config, payload.Paused, syncDone); err != nil {
 		return -1, "", err
 	}
 
@@ -236,7 +264,7 @@ func executeSync(ctx context.Context, task *api.Task, mi *db.MigrationInfo, stat
 	return insertedID, afterSchemaBuf.String(), nil
 }
 
-func executeGhost(task *api.Task, startedNs int64, statement string, syncDone chan<- struct{}) error {
+func executeGhost(server *Server, task *api.Task, startedNs int64, statement string, throttleConfig *api.TaskGhostConfig, paused bool, syncDone chan<- struct{}) error {
 	instance := task.Instance
 	databaseName := task.Database.Name
 
@@ -264,7 +292,9 @@ func executeGhost(task *api.Task, startedNs int64, statement string, syncDone ch
 		// On the source and each replica, you must set the server_id system variable to establish a unique replication ID. For each server, you should pick a unique positive integer in the range from 1 to 2^32 − 1, and each ID must be different from every other ID in use by any other source or replica in the replication topology. Example: server-id=3.
 		// https://dev.mysql.com/doc/refman/5.7/en/replication-options-source.html
 		// Here we use serverID = offset + task.ID to avoid potential conflicts.
-		serverID: 10000000 + uint(task.ID),
+		serverID:       10000000 + uint(task.ID),
+		throttleConfig: throttleConfig,
+		paused:         paused,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to init migrationContext for gh-ost, error: %w", err)
@@ -277,6 +307,8 @@ func executeGhost(task *api.Task, startedNs int64, statement string, syncDone ch
 	go func(ctx context.Context, migrationContext *base.MigrationContext) {
 		ticker := time.NewTicker(1 * time.Second)
 		defer ticker.Stop()
+		progressTicker := time.NewTicker(ghostProgressReportInterval)
+		defer progressTicker.Stop()
 		for {
 			select {
 			case <-ticker.C:
@@ -285,6 +317,8 @@ func executeGhost(task *api.Task, startedNs int64, statement string, syncDone ch
 					close(syncDone)
 					return
 				}
+			case <-progressTicker.C:
+				reportGhostProgress(ctx, server, task, migrationContext)
 			case <-ctx.Done():
 				return
 			}
@@ -296,3 +330,25 @@ func executeGhost(task *api.Task, startedNs int64, statement string, syncDone ch
 	}
 	return nil
 }
+
+// reportGhostProgress reports the progress of the gh-ost migration to the running task run.
+func reportGhostProgress(ctx context.Context, server *Server, task *api.Task, migrationContext *base.MigrationContext) {
+	progress := &api.TaskRunGhostProgress{
+		RowsCopied:   migrationContext.GetTotalRowsCopied(),
+		RowsEstimate: atomic.LoadInt64(&migrationContext.RowsEstimate) + atomic.LoadInt64(&migrationContext.RowsDeltaEstimate),
+		ProgressPct:  migrationContext.GetProgressPct(),
+		ETASeconds:   migrationContext.GetETASeconds(),
+		LagMillis:    migrationContext.GetCurrentLagDuration().Milliseconds(),
+		Paused:       atomic.LoadInt64(&migrationContext.ThrottleCommandedByUser) > 0,
+	}
+	if progress.ETASeconds < 0 {
+		progress.ETASeconds = -1
+	}
+	if throttled, reason, _ := migrationContext.IsThrottled(); throttled {
+		progress.ThrottleReason = reason
+	}
+	reportTaskRunProgress(ctx, server, task, &api.TaskRunResultPayload{
+		Detail:        fmt.Sprintf("Copied %d of about %d rows", progress.RowsCopied, progress.RowsEstimate),
+		GhostProgress: progress,
+	})
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/db/pg"
+)
+
+// NewSchemaUpdatePgOnlineCutoverTaskExecutor creates a schema update (Postgres online) cutover task executor.
+func NewSchemaUpdatePgOnlineCutoverTaskExecutor() TaskExecutor {
+	return &SchemaUpdatePgOnlineCutoverTaskExecutor{}
+}
+
+// SchemaUpdatePgOnlineCutoverTaskExecutor is the schema update (Postgres online) cutover task executor.
+// It swaps the shadow table synced by the sync task with the table, and records the migration history.
+type SchemaUpdatePgOnlineCutoverTaskExecutor struct {
+}
+
+// RunOnce will run SchemaUpdatePgOnlineCutover task once.
+func (exec *SchemaUpdatePgOnlineCutoverTaskExecutor) RunOnce(ctx context.Context, server *Server, task *api.Task) (terminated bool, result *api.TaskRunResultPayload, err error) {
+	taskDAG, err := server.store.GetTaskDAGByToTaskID(ctx, task.ID)
+	if err != nil {
+		return true, nil, fmt.Errorf("failed to get a single taskDAG for schema update online cutover task, id: %v, error: %w", task.ID, err)
+	}
+	syncTask, err := server.store.GetTaskByID(ctx, taskDAG.FromTaskID)
+	if err != nil {
+		return true, nil, fmt.Errorf("failed to get schema update online sync task for cutover task, error: %w", err)
+	}
+	payload := &api.TaskDatabaseSchemaUpdatePgOnlineSyncPayload{}
+	if err := json.Unmarshal([]byte(syncTask.Payload), payload); err != nil {
+		return true, nil, fmt.Errorf("invalid database schema update online sync payload: %w", err)
+	}
+	plan, err := pg.GetOnlineMigrationPlan(payload.Statement)
+	if err != nil {
+		return true, nil, fmt.Errorf("failed to plan the online migration, error: %w", err)
+	}
+	if plan.ShadowTable == nil {
+		return true, nil, fmt.Errorf("no table is rewritten by the statement of the sync task")
+	}
+
+	// The migration is recorded as the one of the sync task, which carries the statement.
+	mi, err := preMigration(ctx, server, syncTask, db.Migrate, payload.Statement, payload.SchemaVersion, payload.VCSPushEvent)
+	if err != nil {
+		return true, nil, err
+	}
+	migrationID, schema, err := executePgOnlineMigration(ctx, server, task, mi, payload.Statement, func(ctx context.Context, driver *pg.Driver) error {
+		return driver.CutoverShadowTable(ctx, plan.ShadowTable)
+	})
+	if err != nil {
+		return true, nil, err
+	}
+	return postMigration(ctx, server, task, payload.VCSPushEvent, mi, migrationID, schema)
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/common/log"
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/db/pg"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultPgOnlineBatchSize is the number of rows copied to the shadow table in each batch if it is not set in the task.
+	defaultPgOnlineBatchSize = 1000
+	// pgOnlineProgressReportInterval is the interval to report the progress of copying the rows to the shadow table.
+	pgOnlineProgressReportInterval = 5 * time.Second
+)
+
+// NewSchemaUpdatePgOnlineSyncTaskExecutor creates a schema update (Postgres online) sync task executor.
+func NewSchemaUpdatePgOnlineSyncTaskExecutor() TaskExecutor {
+	return &SchemaUpdatePgOnlineSyncTaskExecutor{}
+}
+
+// SchemaUpdatePgOnlineSyncTaskExecutor is the schema update (Postgres online) sync task executor.
+// If no table is rewritten, it runs the online migration steps and records the migration history.
+// Otherwise, it prepares the shadow table and copies the rows to it, and the shadow table is kept in sync by the trigger
+// until the cutover task swaps it with the table.
+type SchemaUpdatePgOnlineSyncTaskExecutor struct {
+}
+
+// RunOnce will run SchemaUpdatePgOnlineSync task once.
+func (exec *SchemaUpdatePgOnlineSyncTaskExecutor) RunOnce(ctx context.Context, server *Server, task *api.Task) (terminated bool, result *api.TaskRunResultPayload, err error) {
+	payload := &api.TaskDatabaseSchemaUpdatePgOnlineSyncPayload{}
+	if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
+		return true, nil, fmt.Errorf("invalid database schema update online sync payload: %w", err)
+	}
+	plan, err := pg.GetOnlineMigrationPlan(payload.Statement)
+	if err != nil {
+		return true, nil, fmt.Errorf("failed to plan the online migration, error: %w", err)
+	}
+
+	if plan.ShadowTable == nil {
+		mi, err := preMigration(ctx, server, task, db.Migrate, payload.Statement, payload.SchemaVersion, payload.VCSPushEvent)
+		if err != nil {
+			return true, nil, err
+		}
+		migrationID, schema, err := executePgOnlineMigration(ctx, server, task, mi, payload.Statement, func(ctx context.Context, driver *pg.Driver) error {
+			for _, step := range plan.StepList {
+				log.Debug("Executing online migration step", zap.String("database", task.Database.Name), zap.String("statement", step.Statement))
+				if err := driver.ExecuteOnlineMigrationStep(ctx, step); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return true, nil, err
+		}
+		return postMigration(ctx, server, task, payload.VCSPushEvent, mi, migrationID, schema)
+	}
+
+	driver, err := getAdminDatabaseDriver(ctx, task.Instance, task.Database.Name, server.pgInstanceDir)
+	if err != nil {
+		return true, nil, err
+	}
+	defer driver.Close(ctx)
+	pgDriver, ok := driver.(*pg.Driver)
+	if !ok {
+		return true, nil, fmt.Errorf("[internal] cast driver to pg.Driver failed")
+	}
+
+	if err := pgDriver.PrepareShadowTable(ctx, plan.ShadowTable); err != nil {
+		return true, nil, fmt.Errorf("failed to prepare the shadow table for table %q, error: %w", plan.ShadowTable.Table, err)
+	}
+	batchSize := payload.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultPgOnlineBatchSize
+	}
+	lastReport := time.Now()
+	copied, err := pgDriver.CopyShadowTable(ctx, plan.ShadowTable, batchSize, func(copied int64) {
+		if time.Since(lastReport) < pgOnlineProgressReportInterval {
+			return
+		}
+		lastReport = time.Now()
+		reportTaskRunProgress(ctx, server, task, &api.TaskRunResultPayload{
+			Detail: fmt.Sprintf("Copied %d rows to the shadow table", copied),
+		})
+	})
+	if err != nil {
+		return true, nil, err
+	}
+
+	return true, &api.TaskRunResultPayload{
+		Detail: fmt.Sprintf("Copied %d rows to the shadow table of table %q, and the changes are synced until cutover", copied, plan.ShadowTable.Table),
+	}, nil
+}
+
+// executePgOnlineMigration executes the online migration with the migration history recorded.
+func executePgOnlineMigration(ctx context.Context, server *Server, task *api.Task, mi *db.MigrationInfo, statement string, execute func(ctx context.Context, driver *pg.Driver) error) (migrationID int64, schema string, err error) {
+	driver, err := getAdminDatabaseDriver(ctx, task.Instance, task.Database.Name, server.pgInstanceDir)
+	if err != nil {
+		return 0, "", err
+	}
+	defer driver.Close(ctx)
+	pgDriver, ok := driver.(*pg.Driver)
+	if !ok {
+		return 0, "", fmt.Errorf("[internal] cast driver to pg.Driver failed")
+	}
+
+	setup, err := driver.NeedsSetupMigration(ctx)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to check migration setup for instance %q: %w", task.Instance.Name, err)
+	}
+	if setup {
+		return 0, "", common.Errorf(common.MigrationSchemaMissing, fmt.Errorf("missing migration schema for instance %q", task.Instance.Name))
+	}
+
+	return pgDriver.ExecuteOnlineMigration(ctx, mi, statement, func(ctx context.Context) error {
+		return execute(ctx, pgDriver)
+	})
+}
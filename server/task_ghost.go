@@ -0,0 +1,188 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/github/gh-ost/go/base"
+	"github.com/google/jsonapi"
+	"github.com/labstack/echo/v4"
+
+	"github.com/bytebase/bytebase/api"
+)
+
+// ghostSocketTimeout is the timeout to send a command to gh-ost through its socket file.
+const ghostSocketTimeout = 5 * time.Second
+
+func (s *Server) registerTaskGhostRoutes(g *echo.Group) {
+	g.PATCH("/pipeline/:pipelineID/task/:taskID/ghost", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		taskID, err := strconv.Atoi(c.Param("taskID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Task ID is not a number: %s", c.Param("taskID"))).SetInternal(err)
+		}
+
+		ghostPatch := &api.TaskGhostPatch{
+			UpdaterID: c.Get(getPrincipalIDContextKey()).(int),
+		}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, ghostPatch); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformed update task gh-ost request").SetInternal(err)
+		}
+
+		task, err := s.store.GetTaskByID(ctx, taskID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to update task gh-ost \"%v\"", taskID)).SetInternal(err)
+		}
+		if task == nil {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Task not found with ID %d", taskID))
+		}
+		if err := s.validateIssueAssignee(ctx, ghostPatch.UpdaterID, task.PipelineID); err != nil {
+			return err
+		}
+		if task.Type != api.TaskDatabaseSchemaUpdateGhostSync {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Task %q is not a gh-ost sync task", task.Name))
+		}
+		if task.Status == api.TaskCanceled {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Task %q has been canceled", task.Name))
+		}
+		payload := &api.TaskDatabaseSchemaUpdateGhostSyncPayload{}
+		if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Invalid gh-ost sync payload").SetInternal(err)
+		}
+
+		config := &api.TaskGhostConfig{}
+		if payload.Config != nil {
+			*config = *payload.Config
+		}
+		// commandList is the list of gh-ost interactive commands to apply the patch to the running migration.
+		var commandList []string
+		if ghostPatch.ChunkSize != nil {
+			config.ChunkSize = *ghostPatch.ChunkSize
+			chunkSize := config.ChunkSize
+			if chunkSize == 0 {
+				chunkSize = defaultGhostChunkSize
+			}
+			commandList = append(commandList, fmt.Sprintf("chunk-size=%d", chunkSize))
+		}
+		if ghostPatch.MaxLoad != nil {
+			config.MaxLoad = *ghostPatch.MaxLoad
+			commandList = append(commandList, fmt.Sprintf("max-load=%s", config.MaxLoad))
+		}
+		if ghostPatch.CriticalLoad != nil {
+			config.CriticalLoad = *ghostPatch.CriticalLoad
+			commandList = append(commandList, fmt.Sprintf("critical-load=%s", config.CriticalLoad))
+		}
+		if ghostPatch.MaxLagMillis != nil {
+			config.MaxLagMillis = *ghostPatch.MaxLagMillis
+			maxLagMillis := config.MaxLagMillis
+			if maxLagMillis == 0 {
+				maxLagMillis = defaultGhostMaxLagMillis
+			}
+			commandList = append(commandList, fmt.Sprintf("max-lag-millis=%d", maxLagMillis))
+		}
+		if err := validateGhostConfig(config); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid gh-ost config: %v", err))
+		}
+		if ghostPatch.Paused != nil {
+			payload.Paused = *ghostPatch.Paused
+			if payload.Paused {
+				commandList = append(commandList, "throttle")
+			} else {
+				commandList = append(commandList, "no-throttle")
+			}
+		}
+		payload.Config = config
+
+		tableName, err := getTableNameFromStatement(payload.Statement)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to parse table name from statement").SetInternal(err)
+		}
+		socketFilename := getSocketFilename(task.ID, task.Database.ID, task.Database.Name, tableName)
+		// The gh-ost migration keeps running in the replica executing the sync task until the cutover finishes.
+		// If it's running in another replica, the commands can't be sent through the socket file in this replica.
+		_, err = os.Stat(socketFilename)
+		socketExists := err == nil
+		if len(commandList) > 0 && !socketExists && (task.Status == api.TaskRunning || task.Status == api.TaskDone) {
+			return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("The gh-ost migration of task %q is not running in this Bytebase replica, it has either finished or is running in another replica", task.Name))
+		}
+
+		bytes, err := json.Marshal(payload)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal gh-ost sync payload").SetInternal(err)
+		}
+		payloadStr := string(bytes)
+		taskPatched, err := s.store.PatchTask(ctx, &api.TaskPatch{
+			ID:        task.ID,
+			UpdaterID: ghostPatch.UpdaterID,
+			Payload:   &payloadStr,
+		})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to update task gh-ost \"%v\"", task.Name)).SetInternal(err)
+		}
+
+		// Apply the patch to the running migration. The gh-ost migration keeps running after the sync task
+		// is done, until the cutover task finishes.
+		// The payload is saved first, so that the config applied to gh-ost is never lost, and the migration restarted
+		// after a failure picks up the patched config even if sending the commands fails.
+		if socketExists {
+			for _, command := range commandList {
+				if _, err := sendGhostCommand(socketFilename, command); err != nil {
+					return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to send command %q to gh-ost", command)).SetInternal(err)
+				}
+			}
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, taskPatched); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal update task gh-ost \"%v\" response", task.Name)).SetInternal(err)
+		}
+		return nil
+	})
+}
+
+// validateGhostConfig validates the throttling config of gh-ost.
+func validateGhostConfig(config *api.TaskGhostConfig) error {
+	if config == nil {
+		return nil
+	}
+	if config.ChunkSize != 0 && (config.ChunkSize < 10 || config.ChunkSize > 100000) {
+		return fmt.Errorf("chunk size must be between 10 and 100000, got %d", config.ChunkSize)
+	}
+	if config.MaxLagMillis != 0 && config.MaxLagMillis < 100 {
+		return fmt.Errorf("max lag millis must be at least 100, got %d", config.MaxLagMillis)
+	}
+	if _, err := base.ParseLoadMap(config.MaxLoad); err != nil {
+		return fmt.Errorf("invalid max load %q: %w", config.MaxLoad, err)
+	}
+	if _, err := base.ParseLoadMap(config.CriticalLoad); err != nil {
+		return fmt.Errorf("invalid critical load %q: %w", config.CriticalLoad, err)
+	}
+	return nil
+}
+
+// sendGhostCommand sends the interactive command to the running gh-ost migration through its socket file,
+// and returns the response.
+func sendGhostCommand(socketFilename, command string) (string, error) {
+	conn, err := net.DialTimeout("unix", socketFilename, ghostSocketTimeout)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to gh-ost socket %q, error: %w", socketFilename, err)
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(ghostSocketTimeout)); err != nil {
+		return "", err
+	}
+	if _, err := fmt.Fprintln(conn, command); err != nil {
+		return "", fmt.Errorf("failed to send command to gh-ost socket %q, error: %w", socketFilename, err)
+	}
+	response, err := ioutil.ReadAll(conn)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response from gh-ost socket %q, error: %w", socketFilename, err)
+	}
+	return string(response), nil
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bytebase/bytebase/api"
+)
+
+func TestValidateGhostConfig(t *testing.T) {
+	tests := []struct {
+		config  *api.TaskGhostConfig
+		wantErr bool
+	}{
+		{
+			config: nil,
+		},
+		{
+			config: &api.TaskGhostConfig{},
+		},
+		{
+			config: &api.TaskGhostConfig{ChunkSize: 500, MaxLoad: "Threads_running=25", CriticalLoad: "Threads_running=1000,Threads_connected=2000", MaxLagMillis: 3000},
+		},
+		{
+			config:  &api.TaskGhostConfig{ChunkSize: 5},
+			wantErr: true,
+		},
+		{
+			config:  &api.TaskGhostConfig{ChunkSize: 200000},
+			wantErr: true,
+		},
+		{
+			config:  &api.TaskGhostConfig{MaxLagMillis: 50},
+			wantErr: true,
+		},
+		{
+			config:  &api.TaskGhostConfig{MaxLoad: "Threads_running"},
+			wantErr: true,
+		},
+		{
+			config:  &api.TaskGhostConfig{CriticalLoad: "Threads_running=high"},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		err := validateGhostConfig(test.config)
+		if test.wantErr {
+			assert.Error(t, err, "%+v", test.config)
+		} else {
+			assert.NoError(t, err, "%+v", test.config)
+		}
+	}
+}
+
+func TestNewMigrationContextThrottleConfig(t *testing.T) {
+	a := require.New(t)
+	config := ghostConfig{
+		database:       "db",
+		table:          "tbl",
+		alterStatement: "ALTER TABLE tbl ADD COLUMN c INT",
+	}
+	migrationContext, err := newMigrationContext(config)
+	a.NoError(err)
+	a.Equal(int64(defaultGhostChunkSize), atomic.LoadInt64(&migrationContext.ChunkSize))
+	a.Equal(int64(defaultGhostMaxLagMillis), atomic.LoadInt64(&migrationContext.MaxLagMillisecondsThrottleThreshold))
+	a.Equal(int64(0), atomic.LoadInt64(&migrationContext.ThrottleCommandedByUser))
+
+	config.throttleConfig = &api.TaskGhostConfig{ChunkSize: 200, MaxLoad: "Threads_running=25", CriticalLoad: "Threads_running=1000", MaxLagMillis: 3000}
+	config.paused = true
+	migrationContext, err = newMigrationContext(config)
+	a.NoError(err)
+	a.Equal(int64(200), atomic.LoadInt64(&migrationContext.ChunkSize))
+	a.Equal(int64(3000), atomic.LoadInt64(&migrationContext.MaxLagMillisecondsThrottleThreshold))
+	a.Equal(int64(25), migrationContext.GetMaxLoad()["Threads_running"])
+	a.Equal(int64(1000), migrationContext.GetCriticalLoad()["Threads_running"])
+	a.Equal(int64(1), atomic.LoadInt64(&migrationContext.ThrottleCommandedByUser))
+}
+
+func TestSendGhostCommand(t *testing.T) {
+	a := require.New(t)
+	dir, err := os.MkdirTemp("", "ghost")
+	a.NoError(err)
+	defer os.RemoveAll(dir)
+	socketFilename := filepath.Join(dir, "gh-ost.sock")
+	listener, err := net.Listen("unix", socketFilename)
+	a.NoError(err)
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 64)
+		n, _ := conn.Read(buf)
+		_, _ = conn.Write(append([]byte("received "), buf[:n]...))
+	}()
+
+	response, err := sendGhostCommand(socketFilename, "throttle")
+	a.NoError(err)
+	a.Equal("received throttle\n", response)
+
+	_, err = sendGhostCommand(filepath.Join(dir, "missing.sock"), "throttle")
+	a.Error(err)
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db/util"
+	"github.com/go-sql-driver/mysql"
+)
+
+// taskRetryPolicy is the policy to retry the task on transient failures.
+type taskRetryPolicy struct {
+	// maxAttempts is the max number of attempts including the first one.
+	maxAttempts int
+	// initialBackoff is the delay before the second attempt, and it doubles for each following attempt.
+	initialBackoff time.Duration
+	// maxBackoff is the upper bound of the delay between attempts.
+	maxBackoff time.Duration
+	// notAppliedOnly only retries the failures before any change is committed to the database,
+	// because running the statements again after they are partially applied is not safe.
+	notAppliedOnly bool
+}
+
+// noTaskRetryPolicy runs the task only once.
+var noTaskRetryPolicy = &taskRetryPolicy{maxAttempts: 1}
+
+// taskRetryPolicyMap is the retry policy for each task type.
+// Task types not in the map are never retried automatically, e.g. restoring backups and gh-ost tasks
+// leave intermediate state behind on failure, which requires human judgement before another run.
+// Schema and data updates may fail after applying part of the statements, or lose the connection while committing,
+// so they are only retried if the failure happens before any change is committed.
+var taskRetryPolicyMap = map[api.TaskType]*taskRetryPolicy{
+	api.TaskDatabaseCreate: {
+		maxAttempts:    3,
+		initialBackoff: 10 * time.Second,
+		maxBackoff:     time.Minute,
+	},
+	api.TaskDatabaseSchemaUpdate: {
+		maxAttempts:    3,
+		initialBackoff: 10 * time.Second,
+		maxBackoff:     time.Minute,
+		notAppliedOnly: true,
+	},
+	api.TaskDatabaseDataUpdate: {
+		maxAttempts:    3,
+		initialBackoff: 10 * time.Second,
+		maxBackoff:     time.Minute,
+		notAppliedOnly: true,
+	},
+	api.TaskDatabaseBackup: {
+		maxAttempts:    4,
+		initialBackoff: 30 * time.Second,
+		maxBackoff:     5 * time.Minute,
+	},
+}
+
+// getTaskRetryPolicy returns the retry policy of the task type.
+func getTaskRetryPolicy(taskType api.TaskType) *taskRetryPolicy {
+	if policy, ok := taskRetryPolicyMap[taskType]; ok {
+		return policy
+	}
+	return noTaskRetryPolicy
+}
+
+// backoff returns the delay after the given failed attempt, starting from 1.
+func (p *taskRetryPolicy) backoff(attempt int) time.Duration {
+	backoff := p.initialBackoff
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+		if backoff >= p.maxBackoff {
+			return p.maxBackoff
+		}
+	}
+	return backoff
+}
+
+// isRetryable returns true if the task error is worth retrying according to the policy.
+func (p *taskRetryPolicy) isRetryable(err error) bool {
+	if p.notAppliedOnly && !util.IsNotApplied(err) {
+		return false
+	}
+	return isRetryableTaskError(err)
+}
+
+// taskRetrier is the task executor which runs differently when the task is retried.
+type taskRetrier interface {
+	// RetryOnce runs the task again after the previous attempt failed with a retryable error.
+	RetryOnce(ctx context.Context, server *Server, task *api.Task) (terminated bool, result *api.TaskRunResultPayload, err error)
+}
+
+// mysqlRetryableErrorNumberMap contains the MySQL server error numbers which are worth retrying.
+var mysqlRetryableErrorNumberMap = map[uint16]bool{
+	1040: true, // ER_CON_COUNT_ERROR: Too many connections
+	1205: true, // ER_LOCK_WAIT_TIMEOUT: Lock wait timeout exceeded
+	1213: true, // ER_LOCK_DEADLOCK: Deadlock found when trying to get lock
+	2006: true, // CR_SERVER_GONE_ERROR: MySQL server has gone away
+	2013: true, // CR_SERVER_LOST: Lost connection to MySQL server during query
+}
+
+// postgresRetryableSQLStateMap contains the PostgreSQL SQLSTATE codes which are worth retrying.
+// Besides, all codes in class 08 (connection exception) are retryable.
+var postgresRetryableSQLStateMap = map[string]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"53300": true, // too_many_connections
+	"57P01": true, // admin_shutdown
+	"57P03": true, // cannot_connect_now
+}
+
+// isRetryableTaskError returns true if the task error is transient, such as a lost connection or a deadlock,
+// so that running the task again may succeed.
+func isRetryableTaskError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if common.ErrorCode(err) == common.DbConnectionFailure {
+		return true
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlRetryableErrorNumberMap[mysqlErr.Number]
+	}
+	// The PostgreSQL driver errors expose the SQLSTATE code.
+	var sqlStateErr interface{ SQLState() string }
+	if errors.As(err, &sqlStateErr) {
+		sqlState := sqlStateErr.SQLState()
+		return strings.HasPrefix(sqlState, "08") || postgresRetryableSQLStateMap[sqlState]
+	}
+	return false
+}
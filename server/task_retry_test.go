@@ -0,0 +1,230 @@
+package server
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db/util"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+type sqlStateError struct {
+	code string
+}
+
+func (e *sqlStateError) Error() string {
+	return fmt.Sprintf("SQLSTATE %s", e.code)
+}
+
+func (e *sqlStateError) SQLState() string {
+	return e.code
+}
+
+func TestTaskRetryPolicyBackoff(t *testing.T) {
+	policy := &taskRetryPolicy{
+		maxAttempts:    5,
+		initialBackoff: 10 * time.Second,
+		maxBackoff:     time.Minute,
+	}
+	want := []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute, time.Minute}
+	for i, backoff := range want {
+		assert.Equal(t, backoff, policy.backoff(i+1), "attempt %d", i+1)
+	}
+}
+
+func TestIsRetryableTaskError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "nil",
+			err:  nil,
+			want: false,
+		},
+		{
+			name: "connection failure",
+			err:  common.Errorf(common.DbConnectionFailure, fmt.Errorf("connection refused")),
+			want: true,
+		},
+		{
+			name: "bad connection",
+			err:  fmt.Errorf("failed to execute: %w", driver.ErrBadConn),
+			want: true,
+		},
+		{
+			name: "mysql deadlock wrapped by execution error",
+			err:  common.Errorf(common.DbExecutionError, fmt.Errorf("failed to execute: %w", &mysql.MySQLError{Number: 1213})),
+			want: true,
+		},
+		{
+			name: "mysql syntax error",
+			err:  common.Errorf(common.DbExecutionError, fmt.Errorf("failed to execute: %w", &mysql.MySQLError{Number: 1064})),
+			want: false,
+		},
+		{
+			name: "postgres serialization failure",
+			err:  fmt.Errorf("failed to execute: %w", &sqlStateError{code: "40001"}),
+			want: true,
+		},
+		{
+			name: "postgres connection exception",
+			err:  fmt.Errorf("failed to execute: %w", &sqlStateError{code: "08006"}),
+			want: true,
+		},
+		{
+			name: "postgres undefined table",
+			err:  fmt.Errorf("failed to execute: %w", &sqlStateError{code: "42P01"}),
+			want: false,
+		},
+		{
+			name: "migration failed",
+			err:  common.Errorf(common.MigrationFailed, fmt.Errorf("migration failed")),
+			want: false,
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.want, isRetryableTaskError(test.err), test.name)
+	}
+}
+
+// fakeTaskExecutor fails with the errors in order, and succeeds after running out of the errors.
+type fakeTaskExecutor struct {
+	errList  []error
+	runCount int
+}
+
+func (exec *fakeTaskExecutor) RunOnce(_ context.Context, _ *Server, _ *api.Task) (bool, *api.TaskRunResultPayload, error) {
+	exec.runCount++
+	if exec.runCount <= len(exec.errList) {
+		return true, nil, exec.errList[exec.runCount-1]
+	}
+	return true, &api.TaskRunResultPayload{Detail: fmt.Sprintf("run %d", exec.runCount)}, nil
+}
+
+func TestRunTaskExecutorWithRetry(t *testing.T) {
+	policy := &taskRetryPolicy{
+		maxAttempts:    3,
+		initialBackoff: time.Millisecond,
+		maxBackoff:     time.Millisecond,
+	}
+	transientErr := fmt.Errorf("failed to execute: %w", driver.ErrBadConn)
+	tests := []struct {
+		name            string
+		errList         []error
+		wantRunCount    int
+		wantRecordCount int
+		wantErr         bool
+	}{
+		{
+			name:            "succeed after retry",
+			errList:         []error{transientErr},
+			wantRunCount:    2,
+			wantRecordCount: 1,
+		},
+		{
+			name:            "run out of attempts",
+			errList:         []error{transientErr, transientErr, transientErr},
+			wantRunCount:    3,
+			wantRecordCount: 2,
+			wantErr:         true,
+		},
+		{
+			name:            "non-retryable error",
+			errList:         []error{common.Errorf(common.MigrationFailed, fmt.Errorf("migration failed"))},
+			wantRunCount:    1,
+			wantRecordCount: 0,
+			wantErr:         true,
+		},
+	}
+
+	for _, test := range tests {
+		exec := &fakeTaskExecutor{errList: test.errList}
+		recordCount := 0
+		terminated, result, err := runTaskExecutorWithRetry(context.Background(), exec, nil, &api.Task{}, policy, func(attempt int, _ time.Duration, _ error) error {
+			recordCount++
+			assert.Equal(t, recordCount, attempt, test.name)
+			return nil
+		})
+		assert.True(t, terminated, test.name)
+		assert.Equal(t, test.wantRunCount, exec.runCount, test.name)
+		assert.Equal(t, test.wantRecordCount, recordCount, test.name)
+		if test.wantErr {
+			assert.Error(t, err, test.name)
+			assert.Nil(t, result, test.name)
+		} else {
+			assert.NoError(t, err, test.name)
+			assert.Equal(t, fmt.Sprintf("run %d", test.wantRunCount), result.Detail, test.name)
+		}
+	}
+
+	// The task is not retried if the failed attempt can't be recorded.
+	exec := &fakeTaskExecutor{errList: []error{transientErr}}
+	_, _, err := runTaskExecutorWithRetry(context.Background(), exec, nil, &api.Task{}, policy, func(int, time.Duration, error) error {
+		return fmt.Errorf("store unavailable")
+	})
+	assert.ErrorIs(t, err, driver.ErrBadConn)
+	assert.Equal(t, 1, exec.runCount)
+}
+
+func TestGetTaskRetryPolicy(t *testing.T) {
+	for _, taskType := range []api.TaskType{
+		api.TaskDatabaseSchemaUpdateGhostSync,
+		api.TaskDatabaseRestore,
+	} {
+		assert.Equal(t, 1, getTaskRetryPolicy(taskType).maxAttempts, taskType)
+	}
+	assert.Equal(t, 3, getTaskRetryPolicy(api.TaskDatabaseCreate).maxAttempts)
+
+	// Migrations may have applied part of the statements before the failure, so they are only retried
+	// if nothing has been committed.
+	deadlockErr := common.Errorf(common.DbExecutionError, fmt.Errorf("failed to execute: %w", &mysql.MySQLError{Number: 1213}))
+	for _, taskType := range []api.TaskType{
+		api.TaskDatabaseSchemaUpdate,
+		api.TaskDatabaseDataUpdate,
+	} {
+		policy := getTaskRetryPolicy(taskType)
+		assert.Equal(t, 3, policy.maxAttempts, taskType)
+		assert.False(t, policy.isRetryable(deadlockErr), taskType)
+		assert.True(t, policy.isRetryable(&util.NotAppliedError{Err: deadlockErr}), taskType)
+		assert.True(t, policy.isRetryable(fmt.Errorf("failed to migrate: %w", &util.NotAppliedError{Err: driver.ErrBadConn})), taskType)
+		assert.False(t, policy.isRetryable(&util.NotAppliedError{Err: common.Errorf(common.MigrationFailed, fmt.Errorf("migration failed"))}), taskType)
+	}
+}
+
+// fakeTaskRetrier records whether the attempts are run as retries.
+type fakeTaskRetrier struct {
+	fakeTaskExecutor
+	retryCount int
+}
+
+func (exec *fakeTaskRetrier) RetryOnce(ctx context.Context, server *Server, task *api.Task) (bool, *api.TaskRunResultPayload, error) {
+	exec.retryCount++
+	return exec.RunOnce(ctx, server, task)
+}
+
+func TestRunTaskRetrier(t *testing.T) {
+	policy := &taskRetryPolicy{
+		maxAttempts:    3,
+		initialBackoff: time.Millisecond,
+		maxBackoff:     time.Millisecond,
+		notAppliedOnly: true,
+	}
+	notAppliedErr := &util.NotAppliedError{Err: driver.ErrBadConn}
+	exec := &fakeTaskRetrier{fakeTaskExecutor: fakeTaskExecutor{errList: []error{notAppliedErr, notAppliedErr}}}
+	_, _, err := runTaskExecutorWithRetry(context.Background(), exec, nil, &api.Task{}, policy, func(int, time.Duration, error) error {
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, exec.runCount)
+	// The first attempt is not a retry.
+	assert.Equal(t, 2, exec.retryCount)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -30,6 +31,11 @@ func NewTaskScheduler(server *Server) *TaskScheduler {
 // TaskScheduler is the task scheduler.
 type TaskScheduler struct {
 	executors map[api.TaskType]TaskExecutor
+	// queue is the RUNNING tasks waiting for the concurrency limits in the latest round.
+	queue struct {
+		position map[int]int // task id -> 1-based queue position
+		mu       sync.RWMutex
+	}
 
 	server *Server
 }
@@ -63,27 +69,10 @@ func (s *TaskScheduler) Run(ctx context.Context, wg *sync.WaitGroup) {
 
 				ctx := context.Background()
 
-				// Inspect all open pipelines and schedule the next PENDING task if applicable
-				pipelineStatus := api.PipelineOpen
-				pipelineFind := &api.PipelineFind{
-					Status: &pipelineStatus,
-				}
-				pipelineList, err := s.server.store.FindPipeline(ctx, pipelineFind, false)
-				if err != nil {
-					log.Error("Failed to retrieve open pipelines", zap.Error(err))
-					return
-				}
-				for _, pipeline := range pipelineList {
-					if pipeline.ID == api.OnboardingPipelineID {
-						continue
-					}
-
-					if _, err := s.server.ScheduleNextTaskIfNeeded(ctx, pipeline); err != nil {
-						log.Error("Failed to schedule next running task",
-							zap.Int("pipeline_id", pipeline.ID),
-							zap.Error(err),
-						)
-					}
+				// Inspect all open pipelines and schedule the next PENDING task if applicable.
+				// Only one replica schedules the pipelines in each round.
+				if s.server.claimRound(ctx, "task_scheduler", taskSchedulerInterval) {
+					s.scheduleOpenPipelines(ctx)
 				}
 
 				// Inspect all running tasks
@@ -99,6 +88,14 @@ func (s *TaskScheduler) Run(ctx context.Context, wg *sync.WaitGroup) {
 					return
 				}
 
+				// Tasks being executed by any replica hold the lease.
+				leasedTaskIDs, err := s.server.findLeasedTaskIDs(ctx)
+				if err != nil {
+					log.Error("Failed to retrieve leased tasks", zap.Error(err))
+					return
+				}
+
+				var executingList, queue []*api.Task
 				for _, task := range taskList {
 					if task.ID == api.OnboardingTaskID1 || task.ID == api.OnboardingTaskID2 {
 						continue
@@ -109,8 +106,15 @@ func (s *TaskScheduler) Run(ctx context.Context, wg *sync.WaitGroup) {
 						continue
 					}
 
-					executor, ok := s.executors[task.Type]
-					if !ok {
+					tasks.mu.RLock()
+					_, running := tasks.running[task.ID]
+					tasks.mu.RUnlock()
+					if running || leasedTaskIDs[task.ID] {
+						executingList = append(executingList, task)
+						continue
+					}
+
+					if _, ok := s.executors[task.Type]; !ok {
 						log.Error("Skip running task with unknown type",
 							zap.Int("id", task.ID),
 							zap.String("name", task.Name),
@@ -131,6 +135,17 @@ func (s *TaskScheduler) Run(ctx context.Context, wg *sync.WaitGroup) {
 						continue
 					}
 
+					queue = append(queue, task)
+				}
+
+				startList, positionMap := dispatchTaskQueue(executingList, queue, s.server.profile.MaxConcurrentTask)
+				s.queue.mu.Lock()
+				s.queue.position = positionMap
+				s.queue.mu.Unlock()
+
+				for _, task := range startList {
+					executor := s.executors[task.Type]
+
 					tasks.mu.Lock()
 					if _, ok := tasks.running[task.ID]; ok {
 						tasks.mu.Unlock()
@@ -139,86 +154,31 @@ func (s *TaskScheduler) Run(ctx context.Context, wg *sync.WaitGroup) {
 					tasks.running[task.ID] = true
 					tasks.mu.Unlock()
 
-					go func(task *api.Task) {
+					go func(task *api.Task, executor TaskExecutor) {
 						defer func() {
 							tasks.mu.Lock()
 							delete(tasks.running, task.ID)
 							tasks.mu.Unlock()
 						}()
-						done, result, err := RunTaskExecutorOnce(ctx, executor, s.server, task)
-						if done {
-							if err == nil {
-								bytes, err := json.Marshal(*result)
-								if err != nil {
-									log.Error("Failed to marshal task run result",
-										zap.Int("task_id", task.ID),
-										zap.String("type", string(task.Type)),
-										zap.Error(err),
-									)
-									return
-								}
-								code := common.Ok
-								result := string(bytes)
-								taskStatusPatch := &api.TaskStatusPatch{
-									ID:        task.ID,
-									UpdaterID: api.SystemBotID,
-									Status:    api.TaskDone,
-									Code:      &code,
-									Result:    &result,
-								}
-								_, err = s.server.changeTaskStatusWithPatch(ctx, task, taskStatusPatch)
-								if err != nil {
-									log.Error("Failed to mark task as DONE",
-										zap.Int("id", task.ID),
-										zap.String("name", task.Name),
-										zap.Error(err),
-									)
-								}
-							} else {
-								log.Warn("Failed to run task",
-									zap.Int("id", task.ID),
-									zap.String("name", task.Name),
-									zap.String("type", string(task.Type)),
-									zap.Error(err),
-								)
-								bytes, marshalErr := json.Marshal(api.TaskRunResultPayload{
-									Detail: err.Error(),
-								})
-								if marshalErr != nil {
-									log.Error("Failed to marshal task run result",
-										zap.Int("task_id", task.ID),
-										zap.String("type", string(task.Type)),
-										zap.Error(marshalErr),
-									)
-									return
-								}
-								code := common.ErrorCode(err)
-								result := string(bytes)
-								taskStatusPatch := &api.TaskStatusPatch{
-									ID:        task.ID,
-									UpdaterID: api.SystemBotID,
-									Status:    api.TaskFailed,
-									Code:      &code,
-									Result:    &result,
-								}
-								_, err = s.server.changeTaskStatusWithPatch(ctx, task, taskStatusPatch)
-								if err != nil {
-									log.Error("Failed to mark task as FAILED",
-										zap.Int("id", task.ID),
-										zap.String("name", task.Name),
-										zap.Error(err),
-									)
-								}
+						if _, err := s.server.runWithLease(ctx, taskLeaseResource(task.ID), func(ctx context.Context) {
+							// Another replica may have finished the task before we acquired the lease.
+							latest, err := s.server.store.GetTaskByID(ctx, task.ID)
+							if err != nil {
+								log.Error("Failed to get task", zap.Int("id", task.ID), zap.Error(err))
+								return
 							}
-						} else if err != nil {
-							log.Debug("Encountered transient error running task, will retry",
+							if latest == nil || latest.Status != api.TaskRunning {
+								return
+							}
+							s.runTask(ctx, executor, task)
+						}); err != nil {
+							log.Error("Failed to run task with lease",
 								zap.Int("id", task.ID),
 								zap.String("name", task.Name),
-								zap.String("type", string(task.Type)),
 								zap.Error(err),
 							)
 						}
-					}(task)
+					}(task, executor)
 				}
 			}()
 		case <-ctx.Done(): // if cancel() execute
@@ -227,6 +187,147 @@ func (s *TaskScheduler) Run(ctx context.Context, wg *sync.WaitGroup) {
 	}
 }
 
+// GetQueuePosition returns the 1-based position of the task waiting for the concurrency limits, or 0 if it's not queued.
+func (s *TaskScheduler) GetQueuePosition(taskID int) int {
+	s.queue.mu.RLock()
+	defer s.queue.mu.RUnlock()
+	return s.queue.position[taskID]
+}
+
+// dispatchTaskQueue decides which tasks in the queue to start under the global and per instance concurrency limits.
+// The queue is FIFO in the task creation order, and a task blocked by its instance limit doesn't block the tasks on other instances.
+// Returns the tasks to start and the queue position of the remaining tasks.
+// The replicas make the decision independently, so the limits may be exceeded briefly when multiple replicas start tasks in the same round.
+func dispatchTaskQueue(executingList []*api.Task, queue []*api.Task, globalLimit int) ([]*api.Task, map[int]int) {
+	executingCount := len(executingList)
+	instanceExecutingCount := make(map[int]int)
+	for _, task := range executingList {
+		instanceExecutingCount[task.InstanceID]++
+	}
+
+	sorted := make([]*api.Task, len(queue))
+	copy(sorted, queue)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	var startList []*api.Task
+	positionMap := make(map[int]int)
+	for _, task := range sorted {
+		instanceLimit := task.Instance.MaxConcurrentTask
+		if (globalLimit > 0 && executingCount >= globalLimit) || (instanceLimit > 0 && instanceExecutingCount[task.InstanceID] >= instanceLimit) {
+			positionMap[task.ID] = len(positionMap) + 1
+			continue
+		}
+		startList = append(startList, task)
+		executingCount++
+		instanceExecutingCount[task.InstanceID]++
+	}
+	return startList, positionMap
+}
+
+// scheduleOpenPipelines schedules the next PENDING task of all open pipelines if applicable.
+func (s *TaskScheduler) scheduleOpenPipelines(ctx context.Context) {
+	pipelineStatus := api.PipelineOpen
+	pipelineFind := &api.PipelineFind{
+		Status: &pipelineStatus,
+	}
+	pipelineList, err := s.server.store.FindPipeline(ctx, pipelineFind, false)
+	if err != nil {
+		log.Error("Failed to retrieve open pipelines", zap.Error(err))
+		return
+	}
+	for _, pipeline := range pipelineList {
+		if pipeline.ID == api.OnboardingPipelineID {
+			continue
+		}
+
+		if _, err := s.server.ScheduleNextTaskIfNeeded(ctx, pipeline); err != nil {
+			log.Error("Failed to schedule next running task",
+				zap.Int("pipeline_id", pipeline.ID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// runTask runs the task executor once and updates the task status with the result.
+func (s *TaskScheduler) runTask(ctx context.Context, executor TaskExecutor, task *api.Task) {
+	done, result, err := RunTaskExecutorOnce(ctx, executor, s.server, task)
+	if done {
+		if err == nil {
+			bytes, err := json.Marshal(*result)
+			if err != nil {
+				log.Error("Failed to marshal task run result",
+					zap.Int("task_id", task.ID),
+					zap.String("type", string(task.Type)),
+					zap.Error(err),
+				)
+				return
+			}
+			code := common.Ok
+			result := string(bytes)
+			taskStatusPatch := &api.TaskStatusPatch{
+				ID:        task.ID,
+				UpdaterID: api.SystemBotID,
+				Status:    api.TaskDone,
+				Code:      &code,
+				Result:    &result,
+			}
+			_, err = s.server.changeTaskStatusWithPatch(ctx, task, taskStatusPatch)
+			if err != nil {
+				log.Error("Failed to mark task as DONE",
+					zap.Int("id", task.ID),
+					zap.String("name", task.Name),
+					zap.Error(err),
+				)
+			}
+		} else {
+			log.Warn("Failed to run task",
+				zap.Int("id", task.ID),
+				zap.String("name", task.Name),
+				zap.String("type", string(task.Type)),
+				zap.Error(err),
+			)
+			bytes, marshalErr := json.Marshal(api.TaskRunResultPayload{
+				Detail: err.Error(),
+			})
+			if marshalErr != nil {
+				log.Error("Failed to marshal task run result",
+					zap.Int("task_id", task.ID),
+					zap.String("type", string(task.Type)),
+					zap.Error(marshalErr),
+				)
+				return
+			}
+			code := common.ErrorCode(err)
+			result := string(bytes)
+			taskStatusPatch := &api.TaskStatusPatch{
+				ID:        task.ID,
+				UpdaterID: api.SystemBotID,
+				Status:    api.TaskFailed,
+				Code:      &code,
+				Result:    &result,
+			}
+			_, err = s.server.changeTaskStatusWithPatch(ctx, task, taskStatusPatch)
+			if err != nil {
+				log.Error("Failed to mark task as FAILED",
+					zap.Int("id", task.ID),
+					zap.String("name", task.Name),
+					zap.Error(err),
+				)
+			}
+		}
+	} else if err != nil {
+		log.Debug("Encountered transient error running task, will retry",
+			zap.Int("id", task.ID),
+			zap.String("name", task.Name),
+			zap.String("type", string(task.Type)),
+			zap.Error(err),
+		)
+	}
+}
+
 // Register will register a task executor.
 func (s *TaskScheduler) Register(taskType api.TaskType, executor TaskExecutor) {
 	if executor == nil {
@@ -251,6 +352,24 @@ func (s *TaskScheduler) ScheduleIfNeeded(ctx context.Context, task *api.Task) (*
 		}
 	}
 
+	if isDeploymentWindowTaskType(task.Type) {
+		windowResult, err := s.server.checkTaskDeploymentWindow(ctx, task)
+		if err != nil {
+			return nil, err
+		}
+		if !windowResult.allowed {
+			return task, nil
+		}
+	}
+
+	waveResult, err := s.server.checkTaskRolloutWave(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	if !waveResult.ready {
+		return task, nil
+	}
+
 	// only schema update or data update task has required task check
 	if task.Type == api.TaskDatabaseSchemaUpdate || task.Type == api.TaskDatabaseDataUpdate {
 		pass, err := s.server.passCheck(ctx, s.server, task, api.TaskCheckDatabaseConnect)
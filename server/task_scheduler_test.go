@@ -0,0 +1,73 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDispatchTaskQueue(t *testing.T) {
+	limitedInstance := &api.Instance{ID: 1, MaxConcurrentTask: 1}
+	unlimitedInstance := &api.Instance{ID: 2}
+	newTask := func(id int, instance *api.Instance) *api.Task {
+		return &api.Task{ID: id, InstanceID: instance.ID, Instance: instance}
+	}
+	taskIDList := func(taskList []*api.Task) []int {
+		var ids []int
+		for _, task := range taskList {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name          string
+		executingList []*api.Task
+		queue         []*api.Task
+		globalLimit   int
+		wantStart     []int
+		wantPosition  map[int]int
+	}{
+		{
+			name:         "unlimited",
+			queue:        []*api.Task{newTask(3, unlimitedInstance), newTask(2, unlimitedInstance), newTask(1, unlimitedInstance)},
+			wantStart:    []int{1, 2, 3},
+			wantPosition: map[int]int{},
+		},
+		{
+			name:         "global limit in FIFO order",
+			queue:        []*api.Task{newTask(3, unlimitedInstance), newTask(2, unlimitedInstance), newTask(1, unlimitedInstance)},
+			globalLimit:  2,
+			wantStart:    []int{1, 2},
+			wantPosition: map[int]int{3: 1},
+		},
+		{
+			name:          "global limit reached by executing tasks",
+			executingList: []*api.Task{newTask(1, unlimitedInstance)},
+			queue:         []*api.Task{newTask(2, unlimitedInstance), newTask(3, unlimitedInstance)},
+			globalLimit:   1,
+			wantStart:     nil,
+			wantPosition:  map[int]int{2: 1, 3: 2},
+		},
+		{
+			name:          "instance limit doesn't block other instances",
+			executingList: []*api.Task{newTask(1, limitedInstance)},
+			queue:         []*api.Task{newTask(2, limitedInstance), newTask(3, unlimitedInstance), newTask(4, limitedInstance)},
+			wantStart:     []int{3},
+			wantPosition:  map[int]int{2: 1, 4: 2},
+		},
+		{
+			name:         "instance limit",
+			queue:        []*api.Task{newTask(2, limitedInstance), newTask(3, limitedInstance)},
+			wantStart:    []int{2},
+			wantPosition: map[int]int{3: 1},
+		},
+	}
+
+	for _, test := range tests {
+		startList, positionMap := dispatchTaskQueue(test.executingList, test.queue, test.globalLimit)
+		assert.Equal(t, test.wantStart, taskIDList(startList), test.name)
+		assert.Equal(t, test.wantPosition, positionMap, test.name)
+	}
+}
@@ -33,6 +33,7 @@ type backupRaw struct {
 	MigrationHistoryVersion string
 	Path                    string
 	Comment                 string
+	VerificationStatus      api.BackupVerificationStatus
 	// Payload contains data like PITR info, which will not be created at first.
 	// When backup runner executes the real backup job, it will fill this field.
 	Payload api.BackupPayload
@@ -61,6 +62,7 @@ func (raw *backupRaw) toBackup() *api.Backup {
 		MigrationHistoryVersion: raw.MigrationHistoryVersion,
 		Path:                    raw.Path,
 		Comment:                 raw.Comment,
+		VerificationStatus:      raw.VerificationStatus,
 		Payload:                 raw.Payload,
 	}
 }
@@ -84,7 +86,12 @@ type backupSettingRaw struct {
 	Hour      int
 	DayOfWeek int
 	// HookURL is the callback url to be requested (using HTTP GET) after a successful backup.
-	HookURL string
+	HookURL             string
+	RetentionPeriodTs   int64
+	KeepLastCount       int
+	Compression         api.BackupCompression
+	Encrypted           bool
+	VerificationEnabled bool
 }
 
 // toBackupSetting creates an instance of BackupSetting based on the backupSettingRaw.
@@ -107,7 +114,12 @@ func (raw *backupSettingRaw) toBackupSetting() *api.BackupSetting {
 		Hour:      raw.Hour,
 		DayOfWeek: raw.DayOfWeek,
 		// HookURL is the callback url to be requested (using HTTP GET) after a successful backup.
-		HookURL: raw.HookURL,
+		HookURL:             raw.HookURL,
+		RetentionPeriodTs:   raw.RetentionPeriodTs,
+		KeepLastCount:       raw.KeepLastCount,
+		Compression:         raw.Compression,
+		Encrypted:           raw.Encrypted,
+		VerificationEnabled: raw.VerificationEnabled,
 	}
 }
 
@@ -199,6 +211,23 @@ func (s *Store) UpsertBackupSetting(ctx context.Context, upsert *api.BackupSetti
 	return backup, nil
 }
 
+// FindBackupSetting finds a list of BackupSetting instances
+func (s *Store) FindBackupSetting(ctx context.Context, find *api.BackupSettingFind) ([]*api.BackupSetting, error) {
+	backupSettingRawList, err := s.findBackupSettingRaw(ctx, find)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find backup setting list with BackupSettingFind[%+v], error: %w", find, err)
+	}
+	var backupSettingList []*api.BackupSetting
+	for _, raw := range backupSettingRawList {
+		backupSetting, err := s.composeBackupSetting(ctx, raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compose BackupSetting with backupSettingRaw[%+v], error: %w", raw, err)
+		}
+		backupSettingList = append(backupSettingList, backupSetting)
+	}
+	return backupSettingList, nil
+}
+
 // FindBackupSettingsMatch finds a list of backup setting instances with match conditions
 func (s *Store) FindBackupSettingsMatch(ctx context.Context, match *api.BackupSettingsMatch) ([]*api.BackupSetting, error) {
 	backupSettingRawList, err := s.findBackupSettingsMatchImpl(ctx, match)
@@ -365,6 +394,20 @@ func (s *Store) upsertBackupSettingRaw(ctx context.Context, upsert *api.BackupSe
 			}
 		}
 	}
+	switch upsert.Compression {
+	case "":
+		upsert.Compression = api.BackupCompressionNone
+	case api.BackupCompressionNone, api.BackupCompressionGzip, api.BackupCompressionZstd:
+	default:
+		return nil, &common.Error{Code: common.Invalid, Err: fmt.Errorf("invalid backup setting Compression %q", upsert.Compression)}
+	}
+	// Backup plan policy check for backup retention.
+	if upsert.RetentionPeriodTs < 0 || upsert.KeepLastCount < 0 {
+		return nil, &common.Error{Code: common.Invalid, Err: fmt.Errorf("backup setting RetentionPeriodTs and KeepLastCount should not be negative")}
+	}
+	if upsert.RetentionPeriodTs > 0 && upsert.RetentionPeriodTs < backupPlanPolicy.RetentionPeriodTs {
+		return nil, &common.Error{Code: common.Invalid, Err: fmt.Errorf("backup setting RetentionPeriodTs should be at least %d seconds for the backup plan policy", backupPlanPolicy.RetentionPeriodTs)}
+	}
 
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -400,7 +443,7 @@ func (s *Store) createBackupImpl(ctx context.Context, tx *sql.Tx, create *api.Ba
 			path
 		)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, database_id, name, status, type, storage_backend, migration_history_version, path, comment
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, database_id, name, status, type, storage_backend, migration_history_version, path, comment, verification_status
 	`
 	row, err := tx.QueryContext(ctx, query,
 		create.CreatorID,
@@ -435,6 +478,7 @@ func (s *Store) createBackupImpl(ctx context.Context, tx *sql.Tx, create *api.Ba
 			&backupRaw.MigrationHistoryVersion,
 			&backupRaw.Path,
 			&backupRaw.Comment,
+			&backupRaw.VerificationStatus,
 		); err != nil {
 			return nil, FormatError(err)
 		}
@@ -461,6 +505,9 @@ func (s *Store) findBackupImpl(ctx context.Context, tx *sql.Tx, find *api.Backup
 	if v := find.Status; v != nil {
 		where, args = append(where, fmt.Sprintf("status = $%d", len(args)+1)), append(args, *v)
 	}
+	if v := find.VerificationStatus; v != nil {
+		where, args = append(where, fmt.Sprintf("verification_status = $%d", len(args)+1)), append(args, *v)
+	}
 
 	rows, err := tx.QueryContext(ctx, `
 		SELECT
@@ -477,6 +524,7 @@ func (s *Store) findBackupImpl(ctx context.Context, tx *sql.Tx, find *api.Backup
 			migration_history_version,
 			path,
 			comment,
+			verification_status,
 			payload
 		FROM backup
 		WHERE `+strings.Join(where, " AND ")+` ORDER BY updated_ts DESC`,
@@ -506,6 +554,7 @@ func (s *Store) findBackupImpl(ctx context.Context, tx *sql.Tx, find *api.Backup
 			&backupRaw.MigrationHistoryVersion,
 			&backupRaw.Path,
 			&backupRaw.Comment,
+			&backupRaw.VerificationStatus,
 			&payload,
 		); err != nil {
 			return nil, FormatError(err)
@@ -534,6 +583,9 @@ func (s *Store) patchBackupImpl(ctx context.Context, tx *sql.Tx, patch *api.Back
 	}
 
 	set, args = append(set, fmt.Sprintf("payload = $%d", len(args)+1)), append(args, patch.Payload)
+	if v := patch.VerificationStatus; v != nil {
+		set, args = append(set, fmt.Sprintf("verification_status = $%d", len(args)+1)), append(args, *v)
+	}
 	args = append(args, patch.ID)
 
 	// Execute update query with RETURNING.
@@ -541,7 +593,7 @@ func (s *Store) patchBackupImpl(ctx context.Context, tx *sql.Tx, patch *api.Back
 			UPDATE backup
 			SET `+strings.Join(set, ", ")+`
 			WHERE id = $%d
-			RETURNING id, creator_id, created_ts, updater_id, updated_ts, database_id, name, status, type, storage_backend, migration_history_version, path, comment, payload
+			RETURNING id, creator_id, created_ts, updater_id, updated_ts, database_id, name, status, type, storage_backend, migration_history_version, path, comment, verification_status, payload
 		`, len(args)),
 		args...,
 	)
@@ -567,6 +619,7 @@ func (s *Store) patchBackupImpl(ctx context.Context, tx *sql.Tx, patch *api.Back
 			&backupRaw.MigrationHistoryVersion,
 			&backupRaw.Path,
 			&backupRaw.Comment,
+			&backupRaw.VerificationStatus,
 			&payload,
 		); err != nil {
 			return nil, FormatError(err)
@@ -604,6 +657,22 @@ func (s *Store) getBackupSettingRaw(ctx context.Context, find *api.BackupSetting
 	return list[0], nil
 }
 
+// findBackupSettingRaw finds the backup settings.
+func (s *Store) findBackupSettingRaw(ctx context.Context, find *api.BackupSettingFind) ([]*backupSettingRaw, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.PTx.Rollback()
+
+	list, err := s.findBackupSettingImpl(ctx, tx.PTx, find)
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
 func (s *Store) findBackupSettingImpl(ctx context.Context, tx *sql.Tx, find *api.BackupSettingFind) ([]*backupSettingRaw, error) {
 	// Build WHERE clause.
 	where, args := []string{"1 = 1"}, []interface{}{}
@@ -613,6 +682,16 @@ func (s *Store) findBackupSettingImpl(ctx context.Context, tx *sql.Tx, find *api
 	if v := find.DatabaseID; v != nil {
 		where, args = append(where, fmt.Sprintf("database_id = $%d", len(args)+1)), append(args, *v)
 	}
+	if v := find.Enabled; v != nil {
+		where, args = append(where, fmt.Sprintf("enabled = $%d", len(args)+1)), append(args, *v)
+	}
+	if v := find.RetentionEnabled; v != nil {
+		if *v {
+			where = append(where, "(retention_period_ts > 0 OR keep_last_count > 0)")
+		} else {
+			where = append(where, "retention_period_ts = 0 AND keep_last_count = 0")
+		}
+	}
 
 	rows, err := tx.QueryContext(ctx, `
 		SELECT
@@ -625,7 +704,12 @@ func (s *Store) findBackupSettingImpl(ctx context.Context, tx *sql.Tx, find *api
 			enabled,
 			hour,
 			day_of_week,
-			hook_url
+			hook_url,
+			retention_period_ts,
+			keep_last_count,
+			compression,
+			encrypted,
+			verification_enabled
 		FROM backup_setting
 		WHERE `+strings.Join(where, " AND "),
 		args...,
@@ -650,6 +734,11 @@ func (s *Store) findBackupSettingImpl(ctx context.Context, tx *sql.Tx, find *api
 			&backupSettingRaw.Hour,
 			&backupSettingRaw.DayOfWeek,
 			&backupSettingRaw.HookURL,
+			&backupSettingRaw.RetentionPeriodTs,
+			&backupSettingRaw.KeepLastCount,
+			&backupSettingRaw.Compression,
+			&backupSettingRaw.Encrypted,
+			&backupSettingRaw.VerificationEnabled,
 		); err != nil {
 			return nil, FormatError(err)
 		}
@@ -674,15 +763,25 @@ func (s *Store) upsertBackupSettingImpl(ctx context.Context, tx *sql.Tx, upsert
 			enabled,
 			hour,
 			day_of_week,
-			hook_url
+			hook_url,
+			retention_period_ts,
+			keep_last_count,
+			compression,
+			encrypted,
+			verification_enabled
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		ON CONFLICT(database_id) DO UPDATE SET
 				enabled = EXCLUDED.enabled,
 				hour = EXCLUDED.hour,
 				day_of_week = EXCLUDED.day_of_week,
-				hook_url = EXCLUDED.hook_url
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, database_id, enabled, hour, day_of_week, hook_url
+				hook_url = EXCLUDED.hook_url,
+				retention_period_ts = EXCLUDED.retention_period_ts,
+				keep_last_count = EXCLUDED.keep_last_count,
+				compression = EXCLUDED.compression,
+				encrypted = EXCLUDED.encrypted,
+				verification_enabled = EXCLUDED.verification_enabled
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, database_id, enabled, hour, day_of_week, hook_url, retention_period_ts, keep_last_count, compression, encrypted, verification_enabled
 	`
 	row, err := tx.QueryContext(ctx, query,
 		upsert.UpdaterID,
@@ -692,6 +791,11 @@ func (s *Store) upsertBackupSettingImpl(ctx context.Context, tx *sql.Tx, upsert
 		upsert.Hour,
 		upsert.DayOfWeek,
 		upsert.HookURL,
+		upsert.RetentionPeriodTs,
+		upsert.KeepLastCount,
+		upsert.Compression,
+		upsert.Encrypted,
+		upsert.VerificationEnabled,
 	)
 
 	if err != nil {
@@ -712,6 +816,11 @@ func (s *Store) upsertBackupSettingImpl(ctx context.Context, tx *sql.Tx, upsert
 			&backupSettingRaw.Hour,
 			&backupSettingRaw.DayOfWeek,
 			&backupSettingRaw.HookURL,
+			&backupSettingRaw.RetentionPeriodTs,
+			&backupSettingRaw.KeepLastCount,
+			&backupSettingRaw.Compression,
+			&backupSettingRaw.Encrypted,
+			&backupSettingRaw.VerificationEnabled,
 		); err != nil {
 			return nil, FormatError(err)
 		}
@@ -742,7 +851,12 @@ func (s *Store) findBackupSettingsMatchImpl(ctx context.Context, match *api.Back
 			enabled,
 			hour,
 			day_of_week,
-			hook_url
+			hook_url,
+			retention_period_ts,
+			keep_last_count,
+			compression,
+			encrypted,
+			verification_enabled
 		FROM backup_setting
 		WHERE
 			enabled = true
@@ -776,6 +890,11 @@ func (s *Store) findBackupSettingsMatchImpl(ctx context.Context, match *api.Back
 			&backupSettingRaw.Hour,
 			&backupSettingRaw.DayOfWeek,
 			&backupSettingRaw.HookURL,
+			&backupSettingRaw.RetentionPeriodTs,
+			&backupSettingRaw.KeepLastCount,
+			&backupSettingRaw.Compression,
+			&backupSettingRaw.Encrypted,
+			&backupSettingRaw.VerificationEnabled,
 		); err != nil {
 			return nil, FormatError(err)
 		}
@@ -65,3 +65,51 @@ func (c *Catalog) FindIndex(ctx context.Context, find *catalog.IndexFind) (*cata
 		ColumnExpressions: columnExpressions,
 	}, nil
 }
+
+// FindIndexList finds all the indexes of the table. Implement the catalog.Catalog interface.
+func (c *Catalog) FindIndexList(ctx context.Context, find *catalog.IndexListFind) ([]*catalog.Index, error) {
+	// The tables of different databases share the name, so we can't find the table without the database.
+	if c.databaseID == nil {
+		return nil, catalog.ErrTableNotFound
+	}
+	table, err := c.store.GetTable(ctx, &api.TableFind{
+		DatabaseID: c.databaseID,
+		Name:       &find.TableName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if table == nil {
+		return nil, catalog.ErrTableNotFound
+	}
+
+	indexList, err := c.store.FindIndex(ctx, &api.IndexFind{
+		DatabaseID: c.databaseID,
+		TableID:    &table.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(indexList, func(i, j int) bool {
+		if indexList[i].Name != indexList[j].Name {
+			return indexList[i].Name < indexList[j].Name
+		}
+		return indexList[i].Position < indexList[j].Position
+	})
+
+	var result []*catalog.Index
+	for _, index := range indexList {
+		if len(result) == 0 || result[len(result)-1].Name != index.Name {
+			result = append(result, &catalog.Index{
+				Name:      index.Name,
+				TableName: table.Name,
+				Type:      index.Type,
+				Unique:    index.Unique,
+			})
+		}
+		last := result[len(result)-1]
+		last.ColumnExpressions = append(last.ColumnExpressions, index.Expression)
+	}
+	return result, nil
+}
@@ -9,6 +9,15 @@ WHERE
 -- resetting principal table.
 UPDATE setting SET creator_id = 1, updater_id = 1 WHERE name = 'bb.enterprise.license';
 
+DELETE FROM
+    lease;
+
+DELETE FROM
+    issue_schedule_run;
+
+DELETE FROM
+    issue_schedule;
+
 DELETE FROM
     anomaly;
 
@@ -112,6 +121,9 @@ DELETE FROM
 DELETE FROM
     deployment_config;
 
+DELETE FROM
+    project_policy;
+
 -- Project 1 refers to DEFAULT project which is considered as part of schema
 DELETE FROM
     project
@@ -34,6 +34,8 @@ type instanceRaw struct {
 	ExternalLink  string
 	Host          string
 	Port          string
+	// MaxConcurrentTask is the max number of tasks running on the instance at the same time, 0 means unlimited.
+	MaxConcurrentTask int
 }
 
 // toInstance creates an instance of Instance based on the instanceRaw.
@@ -53,12 +55,13 @@ func (raw *instanceRaw) toInstance() *api.Instance {
 		EnvironmentID: raw.EnvironmentID,
 
 		// Domain specific fields
-		Name:          raw.Name,
-		Engine:        raw.Engine,
-		EngineVersion: raw.EngineVersion,
-		ExternalLink:  raw.ExternalLink,
-		Host:          raw.Host,
-		Port:          raw.Port,
+		Name:              raw.Name,
+		Engine:            raw.Engine,
+		EngineVersion:     raw.EngineVersion,
+		ExternalLink:      raw.ExternalLink,
+		Host:              raw.Host,
+		Port:              raw.Port,
+		MaxConcurrentTask: raw.MaxConcurrentTask,
 	}
 }
 
@@ -426,10 +429,11 @@ func createInstanceImpl(ctx context.Context, tx *sql.Tx, create *api.InstanceCre
 			engine,
 			external_link,
 			host,
-			port
+			port,
+			max_concurrent_task
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING id, row_status, creator_id, created_ts, updater_id, updated_ts, environment_id, name, engine, engine_version, external_link, host, port
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, row_status, creator_id, created_ts, updater_id, updated_ts, environment_id, name, engine, engine_version, external_link, host, port, max_concurrent_task
 	`
 	row, err := tx.QueryContext(ctx, query,
 		create.CreatorID,
@@ -440,6 +444,7 @@ func createInstanceImpl(ctx context.Context, tx *sql.Tx, create *api.InstanceCre
 		create.ExternalLink,
 		create.Host,
 		create.Port,
+		create.MaxConcurrentTask,
 	)
 
 	if err != nil {
@@ -463,6 +468,7 @@ func createInstanceImpl(ctx context.Context, tx *sql.Tx, create *api.InstanceCre
 			&instanceRaw.ExternalLink,
 			&instanceRaw.Host,
 			&instanceRaw.Port,
+			&instanceRaw.MaxConcurrentTask,
 		); err != nil {
 			return nil, FormatError(err)
 		}
@@ -491,7 +497,8 @@ func findInstanceImpl(ctx context.Context, tx *sql.Tx, find *api.InstanceFind) (
 			engine_version,
 			external_link,
 			host,
-			port
+			port,
+			max_concurrent_task
 		FROM instance
 		WHERE `+where,
 		args...,
@@ -519,6 +526,7 @@ func findInstanceImpl(ctx context.Context, tx *sql.Tx, find *api.InstanceFind) (
 			&instanceRaw.ExternalLink,
 			&instanceRaw.Host,
 			&instanceRaw.Port,
+			&instanceRaw.MaxConcurrentTask,
 		); err != nil {
 			return nil, FormatError(err)
 		}
@@ -553,6 +561,9 @@ func patchInstanceImpl(ctx context.Context, tx *sql.Tx, patch *api.InstancePatch
 	if v := patch.Port; v != nil {
 		set, args = append(set, fmt.Sprintf("port = $%d", len(args)+1)), append(args, *v)
 	}
+	if v := patch.MaxConcurrentTask; v != nil {
+		set, args = append(set, fmt.Sprintf("max_concurrent_task = $%d", len(args)+1)), append(args, *v)
+	}
 
 	args = append(args, patch.ID)
 
@@ -561,7 +572,7 @@ func patchInstanceImpl(ctx context.Context, tx *sql.Tx, patch *api.InstancePatch
 		UPDATE instance
 		SET `+strings.Join(set, ", ")+`
 		WHERE id = $%d
-		RETURNING id, row_status, creator_id, created_ts, updater_id, updated_ts, environment_id, name, engine, engine_version, external_link, host, port
+		RETURNING id, row_status, creator_id, created_ts, updater_id, updated_ts, environment_id, name, engine, engine_version, external_link, host, port, max_concurrent_task
 	`, len(args)),
 		args...,
 	)
@@ -586,6 +597,7 @@ func patchInstanceImpl(ctx context.Context, tx *sql.Tx, patch *api.InstancePatch
 			&instanceRaw.ExternalLink,
 			&instanceRaw.Host,
 			&instanceRaw.Port,
+			&instanceRaw.MaxConcurrentTask,
 		); err != nil {
 			return nil, FormatError(err)
 		}
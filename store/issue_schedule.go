@@ -0,0 +1,550 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+)
+
+// issueScheduleRaw is the store model for an IssueSchedule.
+// Fields have exactly the same meanings as IssueSchedule.
+type issueScheduleRaw struct {
+	ID int
+
+	// Standard fields
+	RowStatus api.RowStatus
+	CreatorID int
+	CreatedTs int64
+	UpdaterID int
+	UpdatedTs int64
+
+	// Related fields
+	ProjectID  int
+	AssigneeID int
+
+	// Domain specific fields
+	Name               string
+	Description        string
+	Cron               string
+	Timezone           string
+	IssueType          api.IssueType
+	CreateContext      string
+	NextRunTs          int64
+	StatementUpdatedTs int64
+}
+
+// toIssueSchedule creates an instance of IssueSchedule based on the issueScheduleRaw.
+// This is intended to be called when we need to compose an IssueSchedule relationship.
+func (raw *issueScheduleRaw) toIssueSchedule() *api.IssueSchedule {
+	return &api.IssueSchedule{
+		ID: raw.ID,
+
+		// Standard fields
+		RowStatus: raw.RowStatus,
+		CreatorID: raw.CreatorID,
+		CreatedTs: raw.CreatedTs,
+		UpdaterID: raw.UpdaterID,
+		UpdatedTs: raw.UpdatedTs,
+
+		// Related fields
+		ProjectID:  raw.ProjectID,
+		AssigneeID: raw.AssigneeID,
+
+		// Domain specific fields
+		Name:               raw.Name,
+		Description:        raw.Description,
+		Cron:               raw.Cron,
+		Timezone:           raw.Timezone,
+		IssueType:          raw.IssueType,
+		CreateContext:      raw.CreateContext,
+		NextRunTs:          raw.NextRunTs,
+		StatementUpdatedTs: raw.StatementUpdatedTs,
+	}
+}
+
+// CreateIssueSchedule creates an instance of IssueSchedule
+func (s *Store) CreateIssueSchedule(ctx context.Context, create *api.IssueScheduleCreate) (*api.IssueSchedule, error) {
+	issueScheduleRaw, err := s.createIssueScheduleRaw(ctx, create)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create IssueSchedule with IssueScheduleCreate[%+v], error: %w", create, err)
+	}
+	issueSchedule, err := s.composeIssueSchedule(ctx, issueScheduleRaw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compose IssueSchedule with issueScheduleRaw[%+v], error: %w", issueScheduleRaw, err)
+	}
+	return issueSchedule, nil
+}
+
+// GetIssueScheduleByID gets an instance of IssueSchedule
+func (s *Store) GetIssueScheduleByID(ctx context.Context, id int) (*api.IssueSchedule, error) {
+	issueScheduleRawList, err := s.findIssueScheduleRaw(ctx, &api.IssueScheduleFind{ID: &id})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get IssueSchedule with ID %d, error: %w", id, err)
+	}
+	if len(issueScheduleRawList) == 0 {
+		return nil, nil
+	} else if len(issueScheduleRawList) > 1 {
+		return nil, &common.Error{Code: common.Conflict, Err: fmt.Errorf("found %d issue schedules with ID %d, expect 1", len(issueScheduleRawList), id)}
+	}
+	issueSchedule, err := s.composeIssueSchedule(ctx, issueScheduleRawList[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to compose IssueSchedule with issueScheduleRaw[%+v], error: %w", issueScheduleRawList[0], err)
+	}
+	return issueSchedule, nil
+}
+
+// FindIssueSchedule finds a list of IssueSchedule instances
+func (s *Store) FindIssueSchedule(ctx context.Context, find *api.IssueScheduleFind) ([]*api.IssueSchedule, error) {
+	issueScheduleRawList, err := s.findIssueScheduleRaw(ctx, find)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find IssueSchedule list with IssueScheduleFind[%+v], error: %w", find, err)
+	}
+	var issueScheduleList []*api.IssueSchedule
+	for _, raw := range issueScheduleRawList {
+		issueSchedule, err := s.composeIssueSchedule(ctx, raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compose IssueSchedule with issueScheduleRaw[%+v], error: %w", raw, err)
+		}
+		issueScheduleList = append(issueScheduleList, issueSchedule)
+	}
+	return issueScheduleList, nil
+}
+
+// PatchIssueSchedule patches an instance of IssueSchedule
+func (s *Store) PatchIssueSchedule(ctx context.Context, patch *api.IssueSchedulePatch) (*api.IssueSchedule, error) {
+	issueScheduleRaw, err := s.patchIssueScheduleRaw(ctx, patch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to patch IssueSchedule with IssueSchedulePatch[%+v], error: %w", patch, err)
+	}
+	issueSchedule, err := s.composeIssueSchedule(ctx, issueScheduleRaw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compose IssueSchedule with issueScheduleRaw[%+v], error: %w", issueScheduleRaw, err)
+	}
+	return issueSchedule, nil
+}
+
+// CreateIssueScheduleRun creates an instance of IssueScheduleRun
+func (s *Store) CreateIssueScheduleRun(ctx context.Context, create *api.IssueScheduleRunCreate) (*api.IssueScheduleRun, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.PTx.Rollback()
+
+	issueScheduleRun, err := createIssueScheduleRunImpl(ctx, tx.PTx, create)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create IssueScheduleRun with IssueScheduleRunCreate[%+v], error: %w", create, err)
+	}
+
+	if err := tx.PTx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return issueScheduleRun, nil
+}
+
+// FindIssueScheduleRun finds a list of IssueScheduleRun instances
+func (s *Store) FindIssueScheduleRun(ctx context.Context, find *api.IssueScheduleRunFind) ([]*api.IssueScheduleRun, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.PTx.Rollback()
+
+	issueScheduleRunList, err := findIssueScheduleRunImpl(ctx, tx.PTx, find)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find IssueScheduleRun list with IssueScheduleRunFind[%+v], error: %w", find, err)
+	}
+
+	return issueScheduleRunList, nil
+}
+
+//
+// private functions
+//
+
+func (s *Store) composeIssueSchedule(ctx context.Context, raw *issueScheduleRaw) (*api.IssueSchedule, error) {
+	issueSchedule := raw.toIssueSchedule()
+
+	creator, err := s.GetPrincipalByID(ctx, issueSchedule.CreatorID)
+	if err != nil {
+		return nil, err
+	}
+	issueSchedule.Creator = creator
+
+	updater, err := s.GetPrincipalByID(ctx, issueSchedule.UpdaterID)
+	if err != nil {
+		return nil, err
+	}
+	issueSchedule.Updater = updater
+
+	return issueSchedule, nil
+}
+
+// createIssueScheduleRaw creates a new issueSchedule.
+func (s *Store) createIssueScheduleRaw(ctx context.Context, create *api.IssueScheduleCreate) (*issueScheduleRaw, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.PTx.Rollback()
+
+	issueSchedule, err := createIssueScheduleImpl(ctx, tx.PTx, create)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.PTx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return issueSchedule, nil
+}
+
+// findIssueScheduleRaw retrieves a list of issueSchedules based on find.
+func (s *Store) findIssueScheduleRaw(ctx context.Context, find *api.IssueScheduleFind) ([]*issueScheduleRaw, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.PTx.Rollback()
+
+	list, err := findIssueScheduleImpl(ctx, tx.PTx, find)
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// patchIssueScheduleRaw updates an existing issueSchedule by ID.
+// Returns ENOTFOUND if issueSchedule does not exist.
+func (s *Store) patchIssueScheduleRaw(ctx context.Context, patch *api.IssueSchedulePatch) (*issueScheduleRaw, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.PTx.Rollback()
+
+	issueSchedule, err := patchIssueScheduleImpl(ctx, tx.PTx, patch)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+
+	if err := tx.PTx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return issueSchedule, nil
+}
+
+// createIssueScheduleImpl creates a new issueSchedule.
+func createIssueScheduleImpl(ctx context.Context, tx *sql.Tx, create *api.IssueScheduleCreate) (*issueScheduleRaw, error) {
+	if create.CreateContext == "" {
+		create.CreateContext = "{}"
+	}
+	// Insert row into database.
+	query := `
+		INSERT INTO issue_schedule (
+			creator_id,
+			updater_id,
+			project_id,
+			assignee_id,
+			name,
+			description,
+			cron,
+			timezone,
+			issue_type,
+			create_context,
+			next_run_ts
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, row_status, creator_id, created_ts, updater_id, updated_ts, project_id, assignee_id, name, description, cron, timezone, issue_type, create_context, next_run_ts, statement_updated_ts
+	`
+	var issueScheduleRaw issueScheduleRaw
+	if err := tx.QueryRowContext(ctx, query,
+		create.CreatorID,
+		create.CreatorID,
+		create.ProjectID,
+		create.AssigneeID,
+		create.Name,
+		create.Description,
+		create.Cron,
+		create.Timezone,
+		create.IssueType,
+		create.CreateContext,
+		create.NextRunTs,
+	).Scan(
+		&issueScheduleRaw.ID,
+		&issueScheduleRaw.RowStatus,
+		&issueScheduleRaw.CreatorID,
+		&issueScheduleRaw.CreatedTs,
+		&issueScheduleRaw.UpdaterID,
+		&issueScheduleRaw.UpdatedTs,
+		&issueScheduleRaw.ProjectID,
+		&issueScheduleRaw.AssigneeID,
+		&issueScheduleRaw.Name,
+		&issueScheduleRaw.Description,
+		&issueScheduleRaw.Cron,
+		&issueScheduleRaw.Timezone,
+		&issueScheduleRaw.IssueType,
+		&issueScheduleRaw.CreateContext,
+		&issueScheduleRaw.NextRunTs,
+		&issueScheduleRaw.StatementUpdatedTs,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, common.FormatDBErrorEmptyRowWithQuery(query)
+		}
+		return nil, FormatError(err)
+	}
+	return &issueScheduleRaw, nil
+}
+
+func findIssueScheduleImpl(ctx context.Context, tx *sql.Tx, find *api.IssueScheduleFind) ([]*issueScheduleRaw, error) {
+	// Build WHERE clause.
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := find.ID; v != nil {
+		where, args = append(where, fmt.Sprintf("id = $%d", len(args)+1)), append(args, *v)
+	}
+	if v := find.RowStatus; v != nil {
+		where, args = append(where, fmt.Sprintf("row_status = $%d", len(args)+1)), append(args, *v)
+	}
+	if v := find.ProjectID; v != nil {
+		where, args = append(where, fmt.Sprintf("project_id = $%d", len(args)+1)), append(args, *v)
+	}
+	if v := find.DueTs; v != nil {
+		where, args = append(where, fmt.Sprintf("next_run_ts <= $%d", len(args)+1)), append(args, *v)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			row_status,
+			creator_id,
+			created_ts,
+			updater_id,
+			updated_ts,
+			project_id,
+			assignee_id,
+			name,
+			description,
+			cron,
+			timezone,
+			issue_type,
+			create_context,
+			next_run_ts,
+			statement_updated_ts
+		FROM issue_schedule
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY id ASC`,
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into issueScheduleRawList.
+	var issueScheduleRawList []*issueScheduleRaw
+	for rows.Next() {
+		var issueScheduleRaw issueScheduleRaw
+		if err := rows.Scan(
+			&issueScheduleRaw.ID,
+			&issueScheduleRaw.RowStatus,
+			&issueScheduleRaw.CreatorID,
+			&issueScheduleRaw.CreatedTs,
+			&issueScheduleRaw.UpdaterID,
+			&issueScheduleRaw.UpdatedTs,
+			&issueScheduleRaw.ProjectID,
+			&issueScheduleRaw.AssigneeID,
+			&issueScheduleRaw.Name,
+			&issueScheduleRaw.Description,
+			&issueScheduleRaw.Cron,
+			&issueScheduleRaw.Timezone,
+			&issueScheduleRaw.IssueType,
+			&issueScheduleRaw.CreateContext,
+			&issueScheduleRaw.NextRunTs,
+			&issueScheduleRaw.StatementUpdatedTs,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+		issueScheduleRawList = append(issueScheduleRawList, &issueScheduleRaw)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return issueScheduleRawList, nil
+}
+
+// patchIssueScheduleImpl updates an issueSchedule by ID. Returns the new state of the issueSchedule after update.
+func patchIssueScheduleImpl(ctx context.Context, tx *sql.Tx, patch *api.IssueSchedulePatch) (*issueScheduleRaw, error) {
+	// Build UPDATE clause.
+	set, args := []string{"updater_id = $1"}, []interface{}{patch.UpdaterID}
+	if v := patch.RowStatus; v != nil {
+		set, args = append(set, fmt.Sprintf("row_status = $%d", len(args)+1)), append(args, api.RowStatus(*v))
+	}
+	if v := patch.AssigneeID; v != nil {
+		set, args = append(set, fmt.Sprintf("assignee_id = $%d", len(args)+1)), append(args, *v)
+	}
+	if v := patch.Name; v != nil {
+		set, args = append(set, fmt.Sprintf("name = $%d", len(args)+1)), append(args, *v)
+	}
+	if v := patch.Description; v != nil {
+		set, args = append(set, fmt.Sprintf("description = $%d", len(args)+1)), append(args, *v)
+	}
+	if v := patch.Cron; v != nil {
+		set, args = append(set, fmt.Sprintf("cron = $%d", len(args)+1)), append(args, *v)
+	}
+	if v := patch.Timezone; v != nil {
+		set, args = append(set, fmt.Sprintf("timezone = $%d", len(args)+1)), append(args, *v)
+	}
+	if v := patch.CreateContext; v != nil {
+		set, args = append(set, fmt.Sprintf("create_context = $%d", len(args)+1)), append(args, *v)
+	}
+	if v := patch.NextRunTs; v != nil {
+		set, args = append(set, fmt.Sprintf("next_run_ts = $%d", len(args)+1)), append(args, *v)
+	}
+	if v := patch.StatementUpdatedTs; v != nil {
+		set, args = append(set, fmt.Sprintf("statement_updated_ts = $%d", len(args)+1)), append(args, *v)
+	}
+
+	args = append(args, patch.ID)
+
+	// Execute update query with RETURNING.
+	var issueScheduleRaw issueScheduleRaw
+	if err := tx.QueryRowContext(ctx, fmt.Sprintf(`
+		UPDATE issue_schedule
+		SET `+strings.Join(set, ", ")+`
+		WHERE id = $%d
+		RETURNING id, row_status, creator_id, created_ts, updater_id, updated_ts, project_id, assignee_id, name, description, cron, timezone, issue_type, create_context, next_run_ts, statement_updated_ts
+	`, len(args)),
+		args...,
+	).Scan(
+		&issueScheduleRaw.ID,
+		&issueScheduleRaw.RowStatus,
+		&issueScheduleRaw.CreatorID,
+		&issueScheduleRaw.CreatedTs,
+		&issueScheduleRaw.UpdaterID,
+		&issueScheduleRaw.UpdatedTs,
+		&issueScheduleRaw.ProjectID,
+		&issueScheduleRaw.AssigneeID,
+		&issueScheduleRaw.Name,
+		&issueScheduleRaw.Description,
+		&issueScheduleRaw.Cron,
+		&issueScheduleRaw.Timezone,
+		&issueScheduleRaw.IssueType,
+		&issueScheduleRaw.CreateContext,
+		&issueScheduleRaw.NextRunTs,
+		&issueScheduleRaw.StatementUpdatedTs,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &common.Error{Code: common.NotFound, Err: fmt.Errorf("issue schedule ID not found: %d", patch.ID)}
+		}
+		return nil, FormatError(err)
+	}
+	return &issueScheduleRaw, nil
+}
+
+// createIssueScheduleRunImpl creates a new issueScheduleRun.
+func createIssueScheduleRunImpl(ctx context.Context, tx *sql.Tx, create *api.IssueScheduleRunCreate) (*api.IssueScheduleRun, error) {
+	query := `
+		INSERT INTO issue_schedule_run (
+			schedule_id,
+			issue_id,
+			status,
+			detail
+		)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_ts, schedule_id, issue_id, status, detail
+	`
+	var issueScheduleRun api.IssueScheduleRun
+	var issueID sql.NullInt32
+	if err := tx.QueryRowContext(ctx, query,
+		create.ScheduleID,
+		create.IssueID,
+		create.Status,
+		create.Detail,
+	).Scan(
+		&issueScheduleRun.ID,
+		&issueScheduleRun.CreatedTs,
+		&issueScheduleRun.ScheduleID,
+		&issueID,
+		&issueScheduleRun.Status,
+		&issueScheduleRun.Detail,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, common.FormatDBErrorEmptyRowWithQuery(query)
+		}
+		return nil, FormatError(err)
+	}
+	if issueID.Valid {
+		id := int(issueID.Int32)
+		issueScheduleRun.IssueID = &id
+	}
+	return &issueScheduleRun, nil
+}
+
+func findIssueScheduleRunImpl(ctx context.Context, tx *sql.Tx, find *api.IssueScheduleRunFind) ([]*api.IssueScheduleRun, error) {
+	// Build WHERE clause.
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := find.ScheduleID; v != nil {
+		where, args = append(where, fmt.Sprintf("schedule_id = $%d", len(args)+1)), append(args, *v)
+	}
+	if v := find.Status; v != nil {
+		where, args = append(where, fmt.Sprintf("status = $%d", len(args)+1)), append(args, *v)
+	}
+	limit := ""
+	if v := find.Limit; v != nil {
+		limit = fmt.Sprintf(" LIMIT %d", *v)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			created_ts,
+			schedule_id,
+			issue_id,
+			status,
+			detail
+		FROM issue_schedule_run
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY id DESC`+limit,
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into issueScheduleRunList.
+	var issueScheduleRunList []*api.IssueScheduleRun
+	for rows.Next() {
+		var issueScheduleRun api.IssueScheduleRun
+		var issueID sql.NullInt32
+		if err := rows.Scan(
+			&issueScheduleRun.ID,
+			&issueScheduleRun.CreatedTs,
+			&issueScheduleRun.ScheduleID,
+			&issueID,
+			&issueScheduleRun.Status,
+			&issueScheduleRun.Detail,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+		if issueID.Valid {
+			id := int(issueID.Int32)
+			issueScheduleRun.IssueID = &id
+		}
+		issueScheduleRunList = append(issueScheduleRunList, &issueScheduleRun)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return issueScheduleRunList, nil
+}
@@ -0,0 +1,160 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+)
+
+// AcquireLease acquires the lease on the resource, or renews it if the holder already holds it.
+// Returns nil if the lease is held by another holder and not expired yet.
+func (s *Store) AcquireLease(ctx context.Context, acquire *api.LeaseAcquire) (*api.Lease, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.PTx.Rollback()
+
+	lease, err := acquireLeaseImpl(ctx, tx.PTx, acquire)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.PTx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return lease, nil
+}
+
+// FindLease finds the unexpired leases.
+func (s *Store) FindLease(ctx context.Context, find *api.LeaseFind) ([]*api.Lease, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.PTx.Rollback()
+
+	leaseList, err := findLeaseImpl(ctx, tx.PTx, find)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.PTx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return leaseList, nil
+}
+
+// ReleaseLease releases the lease on the resource if the holder holds it.
+func (s *Store) ReleaseLease(ctx context.Context, release *api.LeaseRelease) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return FormatError(err)
+	}
+	defer tx.PTx.Rollback()
+
+	if _, err := tx.PTx.ExecContext(ctx, `
+		DELETE FROM lease
+		WHERE resource = $1 AND holder = $2
+	`,
+		release.Resource,
+		release.Holder,
+	); err != nil {
+		return FormatError(err)
+	}
+
+	if err := tx.PTx.Commit(); err != nil {
+		return FormatError(err)
+	}
+
+	return nil
+}
+
+// acquireLeaseImpl takes over the lease only if it is held by the same holder or has expired.
+// We rely on the row lock of the upsert to make the check-and-set atomic among the replicas.
+func acquireLeaseImpl(ctx context.Context, tx *sql.Tx, acquire *api.LeaseAcquire) (*api.Lease, error) {
+	query := `
+		INSERT INTO lease (
+			resource,
+			holder,
+			expire_ts
+		)
+		VALUES ($1, $2, extract(epoch from now()) + $3)
+		ON CONFLICT(resource) DO UPDATE SET
+			holder = EXCLUDED.holder,
+			expire_ts = EXCLUDED.expire_ts
+		WHERE lease.holder = EXCLUDED.holder OR lease.expire_ts < extract(epoch from now())
+		RETURNING id, created_ts, updated_ts, resource, holder, expire_ts
+	`
+	var lease api.Lease
+	if err := tx.QueryRowContext(ctx, query,
+		acquire.Resource,
+		acquire.Holder,
+		acquire.DurationSeconds,
+	).Scan(
+		&lease.ID,
+		&lease.CreatedTs,
+		&lease.UpdatedTs,
+		&lease.Resource,
+		&lease.Holder,
+		&lease.ExpireTs,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			// The lease is held by another holder.
+			return nil, nil
+		}
+		return nil, FormatError(err)
+	}
+	return &lease, nil
+}
+
+func findLeaseImpl(ctx context.Context, tx *sql.Tx, find *api.LeaseFind) ([]*api.Lease, error) {
+	// Build WHERE clause.
+	where, args := []string{"expire_ts >= extract(epoch from now())"}, []interface{}{}
+	if v := find.ResourcePrefix; v != nil {
+		where, args = append(where, fmt.Sprintf("resource LIKE $%d", len(args)+1)), append(args, fmt.Sprintf("%s%%", *v))
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			created_ts,
+			updated_ts,
+			resource,
+			holder,
+			expire_ts
+		FROM lease
+		WHERE `+strings.Join(where, " AND "),
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	var leaseList []*api.Lease
+	for rows.Next() {
+		var lease api.Lease
+		if err := rows.Scan(
+			&lease.ID,
+			&lease.CreatedTs,
+			&lease.UpdatedTs,
+			&lease.Resource,
+			&lease.Holder,
+			&lease.ExpireTs,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+		leaseList = append(leaseList, &lease)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return leaseList, nil
+}
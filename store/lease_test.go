@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	dbdriver "github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/resources/postgres"
+)
+
+func TestLease(t *testing.T) {
+	a := require.New(t)
+	pgDir := t.TempDir()
+	pgInstance, err := postgres.Install(path.Join(pgDir, "resource"), path.Join(pgDir, "data"), pgUser)
+	a.NoError(err)
+	// Use a different port from the other tests in case they run in parallel.
+	port := pgPort + 1
+	err = pgInstance.Start(port, os.Stdout, os.Stderr)
+	a.NoError(err)
+	defer pgInstance.Stop(os.Stdout, os.Stderr)
+
+	ctx := context.Background()
+	connCfg := dbdriver.ConnectionConfig{
+		Username: pgUser,
+		Password: "",
+		Host:     common.GetPostgresSocketDir(),
+		Port:     fmt.Sprintf("%d", port),
+	}
+	// The lease table is only in the dev schema for now.
+	db := NewDB(connCfg, pgInstance.BaseDir, "" /* demoDataDir */, false /* readonly */, serverVersion, common.ReleaseModeDev)
+	err = db.Open(ctx)
+	a.NoError(err)
+	defer db.Close()
+	s := New(db, nil)
+
+	t.Run("concurrent claims", func(t *testing.T) {
+		a := require.New(t)
+		const holderCount = 10
+		var wg sync.WaitGroup
+		leaseList := make([]*api.Lease, holderCount)
+		errList := make([]error, holderCount)
+		for i := 0; i < holderCount; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				leaseList[i], errList[i] = s.AcquireLease(ctx, &api.LeaseAcquire{
+					Resource:        "task/101",
+					Holder:          fmt.Sprintf("replica-%d", i),
+					DurationSeconds: 60,
+				})
+			}(i)
+		}
+		wg.Wait()
+
+		var holder string
+		for i := 0; i < holderCount; i++ {
+			a.NoError(errList[i])
+			if leaseList[i] == nil {
+				continue
+			}
+			a.Empty(holder, "the lease is held by both %q and %q", holder, leaseList[i].Holder)
+			holder = leaseList[i].Holder
+		}
+		a.NotEmpty(holder)
+
+		// The holder renews the lease, and the others still can't take it over.
+		lease, err := s.AcquireLease(ctx, &api.LeaseAcquire{Resource: "task/101", Holder: holder, DurationSeconds: 60})
+		a.NoError(err)
+		a.NotNil(lease)
+		a.Equal(holder, lease.Holder)
+		lease, err = s.AcquireLease(ctx, &api.LeaseAcquire{Resource: "task/101", Holder: "replica-other", DurationSeconds: 60})
+		a.NoError(err)
+		a.Nil(lease)
+	})
+
+	t.Run("expiry takeover", func(t *testing.T) {
+		a := require.New(t)
+		// The lease expires right away as if the holder stopped the heartbeats.
+		lease, err := s.AcquireLease(ctx, &api.LeaseAcquire{Resource: "task/102", Holder: "replica-1", DurationSeconds: -1})
+		a.NoError(err)
+		a.NotNil(lease)
+
+		prefix := "task/102"
+		leaseList, err := s.FindLease(ctx, &api.LeaseFind{ResourcePrefix: &prefix})
+		a.NoError(err)
+		a.Empty(leaseList)
+
+		lease, err = s.AcquireLease(ctx, &api.LeaseAcquire{Resource: "task/102", Holder: "replica-2", DurationSeconds: 60})
+		a.NoError(err)
+		a.NotNil(lease)
+		a.Equal("replica-2", lease.Holder)
+
+		leaseList, err = s.FindLease(ctx, &api.LeaseFind{ResourcePrefix: &prefix})
+		a.NoError(err)
+		a.Len(leaseList, 1)
+		a.Equal("replica-2", leaseList[0].Holder)
+	})
+
+	t.Run("release", func(t *testing.T) {
+		a := require.New(t)
+		lease, err := s.AcquireLease(ctx, &api.LeaseAcquire{Resource: "task/103", Holder: "replica-1", DurationSeconds: 60})
+		a.NoError(err)
+		a.NotNil(lease)
+
+		// Only the holder can release the lease.
+		err = s.ReleaseLease(ctx, &api.LeaseRelease{Resource: "task/103", Holder: "replica-2"})
+		a.NoError(err)
+		lease, err = s.AcquireLease(ctx, &api.LeaseAcquire{Resource: "task/103", Holder: "replica-2", DurationSeconds: 60})
+		a.NoError(err)
+		a.Nil(lease)
+
+		err = s.ReleaseLease(ctx, &api.LeaseRelease{Resource: "task/103", Holder: "replica-1"})
+		a.NoError(err)
+		prefix := "task/103"
+		leaseList, err := s.FindLease(ctx, &api.LeaseFind{ResourcePrefix: &prefix})
+		a.NoError(err)
+		a.Empty(leaseList)
+		lease, err = s.AcquireLease(ctx, &api.LeaseAcquire{Resource: "task/103", Holder: "replica-2", DurationSeconds: 60})
+		a.NoError(err)
+		a.NotNil(lease)
+		a.Equal("replica-2", lease.Holder)
+	})
+}
@@ -0,0 +1,25 @@
+-- project_policy stores the policies for each project.
+-- A project policy refines the environment policy of the same type for the databases in the project.
+CREATE TABLE project_policy (
+    id SERIAL PRIMARY KEY,
+    row_status row_status NOT NULL DEFAULT 'NORMAL',
+    creator_id INTEGER NOT NULL REFERENCES principal (id),
+    created_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
+    updater_id INTEGER NOT NULL REFERENCES principal (id),
+    updated_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
+    project_id INTEGER NOT NULL REFERENCES project (id),
+    type TEXT NOT NULL CHECK (type LIKE 'bb.policy.%'),
+    payload JSONB NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX idx_project_policy_project_id ON project_policy(project_id);
+
+CREATE UNIQUE INDEX idx_project_policy_unique_project_id_type ON project_policy(project_id, type);
+
+ALTER SEQUENCE project_policy_id_seq RESTART WITH 101;
+
+CREATE TRIGGER update_project_policy_updated_ts
+BEFORE
+UPDATE
+    ON project_policy FOR EACH ROW
+EXECUTE FUNCTION trigger_update_updated_ts();
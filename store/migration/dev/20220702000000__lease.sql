@@ -0,0 +1,24 @@
+-- lease stores the exclusive claims on the background work, so that multiple Bytebase replicas
+-- sharing the same metadata database won't run the same task twice.
+-- The holder renews the lease by heartbeats. Once the lease expires, e.g. the holder replica crashes,
+-- any replica could claim the work again.
+CREATE TABLE lease (
+    id SERIAL PRIMARY KEY,
+    created_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
+    updated_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
+    -- resource is the claimed work, e.g. "task/101".
+    resource TEXT NOT NULL,
+    -- holder is the ID of the replica holding the lease.
+    holder TEXT NOT NULL,
+    expire_ts BIGINT NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_lease_unique_resource ON lease(resource);
+
+ALTER SEQUENCE lease_id_seq RESTART WITH 101;
+
+CREATE TRIGGER update_lease_updated_ts
+BEFORE
+UPDATE
+    ON lease FOR EACH ROW
+EXECUTE FUNCTION trigger_update_updated_ts();
@@ -0,0 +1 @@
+ALTER TABLE instance ADD max_concurrent_task INTEGER NOT NULL CHECK (max_concurrent_task >= 0) DEFAULT 0;
@@ -0,0 +1,45 @@
+-- issue_schedule stores the issue templates which are instantiated into new issues on the cron schedule.
+CREATE TABLE issue_schedule (
+    id SERIAL PRIMARY KEY,
+    row_status row_status NOT NULL DEFAULT 'NORMAL',
+    creator_id INTEGER NOT NULL REFERENCES principal (id),
+    created_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
+    updater_id INTEGER NOT NULL REFERENCES principal (id),
+    updated_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
+    project_id INTEGER NOT NULL REFERENCES project (id),
+    assignee_id INTEGER NOT NULL REFERENCES principal (id),
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    cron TEXT NOT NULL,
+    timezone TEXT NOT NULL DEFAULT '',
+    issue_type TEXT NOT NULL CHECK (issue_type LIKE 'bb.issue.%'),
+    create_context JSONB NOT NULL DEFAULT '{}',
+    next_run_ts BIGINT NOT NULL
+);
+
+CREATE INDEX idx_issue_schedule_project_id ON issue_schedule(project_id);
+
+CREATE INDEX idx_issue_schedule_next_run_ts ON issue_schedule(next_run_ts);
+
+ALTER SEQUENCE issue_schedule_id_seq RESTART WITH 101;
+
+CREATE TRIGGER update_issue_schedule_updated_ts
+BEFORE
+UPDATE
+    ON issue_schedule FOR EACH ROW
+EXECUTE FUNCTION trigger_update_updated_ts();
+
+-- issue_schedule_run stores the run history of the issue schedules.
+CREATE TABLE issue_schedule_run (
+    id SERIAL PRIMARY KEY,
+    created_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
+    schedule_id INTEGER NOT NULL REFERENCES issue_schedule (id),
+    -- issue_id is NULL if no issue is created in the run.
+    issue_id INTEGER NULL REFERENCES issue (id),
+    status TEXT NOT NULL CHECK (status IN ('CREATED', 'SKIPPED', 'FAILED')),
+    detail TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_issue_schedule_run_schedule_id ON issue_schedule_run(schedule_id);
+
+ALTER SEQUENCE issue_schedule_run_id_seq RESTART WITH 101;
@@ -0,0 +1,6 @@
+ALTER TABLE backup DROP CONSTRAINT backup_status_check;
+ALTER TABLE backup ADD CONSTRAINT backup_status_check CHECK (status IN ('PENDING_CREATE', 'DONE', 'FAILED', 'PRUNED'));
+
+-- retention_period_ts and keep_last_count are the retention rules of the backups, 0 means unset.
+ALTER TABLE backup_setting ADD retention_period_ts BIGINT NOT NULL CHECK (retention_period_ts >= 0) DEFAULT 0;
+ALTER TABLE backup_setting ADD keep_last_count INTEGER NOT NULL CHECK (keep_last_count >= 0) DEFAULT 0;
@@ -0,0 +1,3 @@
+-- compression and encrypted are the format of the backup files.
+ALTER TABLE backup_setting ADD compression TEXT NOT NULL CHECK (compression IN ('NONE', 'GZIP', 'ZSTD')) DEFAULT 'NONE';
+ALTER TABLE backup_setting ADD encrypted BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,5 @@
+-- verification_status is the status of restoring the backup into a scratch database.
+ALTER TABLE backup ADD verification_status TEXT NOT NULL CHECK (verification_status IN ('UNSET', 'PENDING', 'PASSED', 'FAILED')) DEFAULT 'UNSET';
+
+-- verification_enabled enables verifying the backups by restoring into a scratch database.
+ALTER TABLE backup_setting ADD verification_enabled BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,3 @@
+-- statement_updated_ts is the time the statement of the issue schedule is edited, or 0 if the statement is trusted.
+-- The scheduled issues aren't auto approved until an issue created after the edit is done.
+ALTER TABLE issue_schedule ADD statement_updated_ts BIGINT NOT NULL DEFAULT 0;
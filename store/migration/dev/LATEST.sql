@@ -274,7 +274,9 @@ CREATE TABLE instance (
     engine_version TEXT NOT NULL DEFAULT '',
     host TEXT NOT NULL,
     port TEXT NOT NULL,
-    external_link TEXT NOT NULL DEFAULT ''
+    external_link TEXT NOT NULL DEFAULT '',
+    -- max_concurrent_task is the max number of tasks running on the instance at the same time, 0 means unlimited.
+    max_concurrent_task INTEGER NOT NULL CHECK (max_concurrent_task >= 0) DEFAULT 0
 );
 
 ALTER SEQUENCE instance_id_seq RESTART WITH 101;
@@ -536,13 +538,15 @@ CREATE TABLE backup (
     updated_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
     database_id INTEGER NOT NULL REFERENCES db (id),
     name TEXT NOT NULL,
-    status TEXT NOT NULL CHECK (status IN ('PENDING_CREATE', 'DONE', 'FAILED')),
+    status TEXT NOT NULL CHECK (status IN ('PENDING_CREATE', 'DONE', 'FAILED', 'PRUNED')),
     type TEXT NOT NULL CHECK (type IN ('MANUAL', 'AUTOMATIC')),
     storage_backend TEXT NOT NULL CHECK (storage_backend IN ('LOCAL', 'S3', 'GCS', 'OSS')),
     migration_history_version TEXT NOT NULL,
     path TEXT NOT NULL,
     comment TEXT NOT NULL DEFAULT '',
-    payload JSONB NOT NULL DEFAULT '{}'
+    payload JSONB NOT NULL DEFAULT '{}',
+    -- verification_status is the status of restoring the backup into a scratch database.
+    verification_status TEXT NOT NULL CHECK (verification_status IN ('UNSET', 'PENDING', 'PASSED', 'FAILED')) DEFAULT 'UNSET'
 );
 
 CREATE INDEX idx_backup_database_id ON backup(database_id);
@@ -572,7 +576,15 @@ CREATE TABLE backup_setting (
     -- day_of_week can be -1 which is wildcard (daily automatic backup).
     day_of_week INTEGER NOT NULL CHECK (day_of_week >= -1 AND day_of_week <= 6),
     -- hook_url is the callback url to be requested after a successful backup.
-    hook_url TEXT NOT NULL
+    hook_url TEXT NOT NULL,
+    -- retention_period_ts and keep_last_count are the retention rules of the backups, 0 means unset.
+    retention_period_ts BIGINT NOT NULL CHECK (retention_period_ts >= 0) DEFAULT 0,
+    keep_last_count INTEGER NOT NULL CHECK (keep_last_count >= 0) DEFAULT 0,
+    -- compression and encrypted are the format of the backup files.
+    compression TEXT NOT NULL CHECK (compression IN ('NONE', 'GZIP', 'ZSTD')) DEFAULT 'NONE',
+    encrypted BOOLEAN NOT NULL DEFAULT false,
+    -- verification_enabled enables verifying the backups by restoring into a scratch database.
+    verification_enabled BOOLEAN NOT NULL DEFAULT false
 );
 
 CREATE UNIQUE INDEX idx_backup_setting_unique_database_id ON backup_setting(database_id);
@@ -1048,6 +1060,33 @@ UPDATE
     ON db_label FOR EACH ROW
 EXECUTE FUNCTION trigger_update_updated_ts();
 
+-- Project Policy
+-- project_policy stores the policies for each project.
+-- A project policy refines the environment policy of the same type for the databases in the project.
+CREATE TABLE project_policy (
+    id SERIAL PRIMARY KEY,
+    row_status row_status NOT NULL DEFAULT 'NORMAL',
+    creator_id INTEGER NOT NULL REFERENCES principal (id),
+    created_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
+    updater_id INTEGER NOT NULL REFERENCES principal (id),
+    updated_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
+    project_id INTEGER NOT NULL REFERENCES project (id),
+    type TEXT NOT NULL CHECK (type LIKE 'bb.policy.%'),
+    payload JSONB NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX idx_project_policy_project_id ON project_policy(project_id);
+
+CREATE UNIQUE INDEX idx_project_policy_unique_project_id_type ON project_policy(project_id, type);
+
+ALTER SEQUENCE project_policy_id_seq RESTART WITH 101;
+
+CREATE TRIGGER update_project_policy_updated_ts
+BEFORE
+UPDATE
+    ON project_policy FOR EACH ROW
+EXECUTE FUNCTION trigger_update_updated_ts();
+
 -- Deployment Configuration.
 -- deployment_config stores deployment configurations at project level.
 CREATE TABLE deployment_config (
@@ -1120,3 +1159,79 @@ CREATE TABLE sheet_organizer (
 CREATE UNIQUE INDEX idx_sheet_organizer_unique_sheet_id_principal_id ON sheet_organizer(sheet_id, principal_id);
 
 CREATE INDEX idx_sheet_organizer_principal_id ON sheet_organizer(principal_id);
+
+-- Lease
+-- lease stores the exclusive claims on the background work, so that multiple Bytebase replicas
+-- sharing the same metadata database won't run the same task twice.
+-- The holder renews the lease by heartbeats. Once the lease expires, e.g. the holder replica crashes,
+-- any replica could claim the work again.
+CREATE TABLE lease (
+    id SERIAL PRIMARY KEY,
+    created_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
+    updated_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
+    -- resource is the claimed work, e.g. "task/101".
+    resource TEXT NOT NULL,
+    -- holder is the ID of the replica holding the lease.
+    holder TEXT NOT NULL,
+    expire_ts BIGINT NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_lease_unique_resource ON lease(resource);
+
+ALTER SEQUENCE lease_id_seq RESTART WITH 101;
+
+CREATE TRIGGER update_lease_updated_ts
+BEFORE
+UPDATE
+    ON lease FOR EACH ROW
+EXECUTE FUNCTION trigger_update_updated_ts();
+
+-- Issue Schedule
+-- issue_schedule stores the issue templates which are instantiated into new issues on the cron schedule.
+CREATE TABLE issue_schedule (
+    id SERIAL PRIMARY KEY,
+    row_status row_status NOT NULL DEFAULT 'NORMAL',
+    creator_id INTEGER NOT NULL REFERENCES principal (id),
+    created_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
+    updater_id INTEGER NOT NULL REFERENCES principal (id),
+    updated_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
+    project_id INTEGER NOT NULL REFERENCES project (id),
+    assignee_id INTEGER NOT NULL REFERENCES principal (id),
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    cron TEXT NOT NULL,
+    timezone TEXT NOT NULL DEFAULT '',
+    issue_type TEXT NOT NULL CHECK (issue_type LIKE 'bb.issue.%'),
+    create_context JSONB NOT NULL DEFAULT '{}',
+    next_run_ts BIGINT NOT NULL,
+    -- statement_updated_ts is the time the statement is edited, or 0 if the statement is trusted.
+    -- The scheduled issues aren't auto approved until an issue created after the edit is done.
+    statement_updated_ts BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_issue_schedule_project_id ON issue_schedule(project_id);
+
+CREATE INDEX idx_issue_schedule_next_run_ts ON issue_schedule(next_run_ts);
+
+ALTER SEQUENCE issue_schedule_id_seq RESTART WITH 101;
+
+CREATE TRIGGER update_issue_schedule_updated_ts
+BEFORE
+UPDATE
+    ON issue_schedule FOR EACH ROW
+EXECUTE FUNCTION trigger_update_updated_ts();
+
+-- issue_schedule_run stores the run history of the issue schedules.
+CREATE TABLE issue_schedule_run (
+    id SERIAL PRIMARY KEY,
+    created_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
+    schedule_id INTEGER NOT NULL REFERENCES issue_schedule (id),
+    -- issue_id is NULL if no issue is created in the run.
+    issue_id INTEGER NULL REFERENCES issue (id),
+    status TEXT NOT NULL CHECK (status IN ('CREATED', 'SKIPPED', 'FAILED')),
+    detail TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_issue_schedule_run_schedule_id ON issue_schedule_run(schedule_id);
+
+ALTER SEQUENCE issue_schedule_run_id_seq RESTART WITH 101;
@@ -177,6 +177,19 @@ func (s *Store) GetPipelineApprovalPolicy(ctx context.Context, environmentID int
 	return api.UnmarshalPipelineApprovalPolicy(policy.Payload)
 }
 
+// GetDeploymentWindowPolicy will get the deployment window policy for an environment.
+func (s *Store) GetDeploymentWindowPolicy(ctx context.Context, environmentID int) (*api.DeploymentWindowPolicy, error) {
+	pType := api.PolicyTypeDeploymentWindow
+	policy, err := s.getPolicyRaw(ctx, &api.PolicyFind{
+		EnvironmentID: &environmentID,
+		Type:          &pType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return api.UnmarshalDeploymentWindowPolicy(policy.Payload)
+}
+
 // GetNormalSchemaReviewPolicy will get the normal schema review policy for an environment.
 func (s *Store) GetNormalSchemaReviewPolicy(ctx context.Context, find *api.PolicyFind) (*advisor.SchemaReviewPolicy, error) {
 	if find.ID != nil && *find.ID == api.DefaultPolicyID {
@@ -0,0 +1,355 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/advisor"
+)
+
+// projectPolicyRaw is the store model for a ProjectPolicy.
+// Fields have exactly the same meanings as ProjectPolicy.
+type projectPolicyRaw struct {
+	ID int
+
+	// Standard fields
+	RowStatus api.RowStatus
+	CreatorID int
+	CreatedTs int64
+	UpdaterID int
+	UpdatedTs int64
+
+	// Related fields
+	ProjectID int
+
+	// Domain specific fields
+	Type    api.PolicyType
+	Payload string
+}
+
+// toProjectPolicy creates an instance of ProjectPolicy based on the projectPolicyRaw.
+// This is intended to be called when we need to compose a ProjectPolicy relationship.
+func (raw *projectPolicyRaw) toProjectPolicy() *api.ProjectPolicy {
+	return &api.ProjectPolicy{
+		ID: raw.ID,
+
+		// Standard fields
+		RowStatus: raw.RowStatus,
+		CreatorID: raw.CreatorID,
+		CreatedTs: raw.CreatedTs,
+		UpdaterID: raw.UpdaterID,
+		UpdatedTs: raw.UpdatedTs,
+
+		// Related fields
+		ProjectID: raw.ProjectID,
+
+		// Domain specific fields
+		Type:    raw.Type,
+		Payload: raw.Payload,
+	}
+}
+
+// UpsertProjectPolicy upserts an instance of ProjectPolicy.
+func (s *Store) UpsertProjectPolicy(ctx context.Context, upsert *api.ProjectPolicyUpsert) (*api.ProjectPolicy, error) {
+	// Validate policy.
+	if upsert.Payload != nil {
+		if err := api.ValidateProjectPolicy(upsert.Type, *upsert.Payload); err != nil {
+			return nil, &common.Error{Code: common.Invalid, Err: err}
+		}
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.PTx.Rollback()
+
+	raw, err := upsertProjectPolicyImpl(ctx, tx.PTx, upsert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert project policy with ProjectPolicyUpsert[%+v], error: %w", upsert, err)
+	}
+
+	if err := tx.PTx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	policy, err := s.composeProjectPolicy(ctx, raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compose project policy with projectPolicyRaw[%+v], error: %w", raw, err)
+	}
+	return policy, nil
+}
+
+// GetProjectPolicy gets a project policy.
+// Returns nil if the project doesn't have the policy.
+func (s *Store) GetProjectPolicy(ctx context.Context, find *api.ProjectPolicyFind) (*api.ProjectPolicy, error) {
+	if find.Type != nil {
+		if err := api.ValidateProjectPolicy(*find.Type, ""); err != nil {
+			return nil, &common.Error{Code: common.Invalid, Err: err}
+		}
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.PTx.Rollback()
+
+	rawList, err := findProjectPolicyImpl(ctx, tx.PTx, find)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project policy with ProjectPolicyFind[%+v], error: %w", find, err)
+	}
+	if len(rawList) == 0 {
+		return nil, nil
+	} else if len(rawList) > 1 {
+		return nil, &common.Error{Code: common.Conflict, Err: fmt.Errorf("found %d project policy with filter %+v, expect 1", len(rawList), find)}
+	}
+	policy, err := s.composeProjectPolicy(ctx, rawList[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to compose project policy with projectPolicyRaw[%+v], error: %w", rawList[0], err)
+	}
+	return policy, nil
+}
+
+// DeleteProjectPolicy deletes an existing project policy by ProjectPolicyDelete.
+// The project will fall back to the environment policy afterwards.
+func (s *Store) DeleteProjectPolicy(ctx context.Context, delete *api.ProjectPolicyDelete) error {
+	if err := api.ValidateProjectPolicy(delete.Type, ""); err != nil {
+		return &common.Error{Code: common.Invalid, Err: err}
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return FormatError(err)
+	}
+	defer tx.PTx.Rollback()
+
+	result, err := tx.PTx.ExecContext(ctx, `
+		DELETE FROM project_policy
+			WHERE project_id = $1 AND type = $2
+		`,
+		delete.ProjectID,
+		delete.Type,
+	)
+	if err != nil {
+		return FormatError(err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return FormatError(err)
+	}
+	if rows == 0 {
+		return &common.Error{Code: common.NotFound, Err: fmt.Errorf("project policy not found with ProjectPolicyDelete[%+v]", delete)}
+	}
+
+	if err := tx.PTx.Commit(); err != nil {
+		return FormatError(err)
+	}
+	return nil
+}
+
+// GetSchemaReviewPolicyOverrideByProjectID will get the schema review policy override for a project.
+// Returns nil if the project doesn't override the environment schema review policy.
+func (s *Store) GetSchemaReviewPolicyOverrideByProjectID(ctx context.Context, projectID int) (*advisor.SchemaReviewPolicyOverride, error) {
+	pType := api.PolicyTypeSchemaReview
+	policy, err := s.GetProjectPolicy(ctx, &api.ProjectPolicyFind{
+		ProjectID: &projectID,
+		Type:      &pType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil || policy.RowStatus == api.Archived {
+		return nil, nil
+	}
+	return api.UnmarshalSchemaReviewPolicyOverride(policy.Payload)
+}
+
+// GetEffectiveSchemaReviewPolicy will get the schema review policy applied to the databases of the project in the environment.
+// The project override is merged on top of the environment policy.
+// Returns NotFound if neither the environment nor the project has the schema review policy.
+func (s *Store) GetEffectiveSchemaReviewPolicy(ctx context.Context, environmentID int, projectID int) (*advisor.SchemaReviewPolicy, error) {
+	policy, err := s.GetNormalSchemaReviewPolicy(ctx, &api.PolicyFind{EnvironmentID: &environmentID})
+	if err != nil {
+		if common.ErrorCode(err) != common.NotFound {
+			return nil, err
+		}
+		policy = nil
+	}
+
+	var override *advisor.SchemaReviewPolicyOverride
+	if projectID != api.UnknownID {
+		override, err = s.GetSchemaReviewPolicyOverrideByProjectID(ctx, projectID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if policy == nil && override == nil {
+		return nil, &common.Error{Code: common.NotFound, Err: fmt.Errorf("schema review policy not found for environment %d and project %d", environmentID, projectID)}
+	}
+	return advisor.MergeSchemaReviewPolicy(policy, override), nil
+}
+
+//
+// private functions
+//
+
+func (s *Store) composeProjectPolicy(ctx context.Context, raw *projectPolicyRaw) (*api.ProjectPolicy, error) {
+	policy := raw.toProjectPolicy()
+
+	creator, err := s.GetPrincipalByID(ctx, policy.CreatorID)
+	if err != nil {
+		return nil, err
+	}
+	policy.Creator = creator
+
+	updater, err := s.GetPrincipalByID(ctx, policy.UpdaterID)
+	if err != nil {
+		return nil, err
+	}
+	policy.Updater = updater
+
+	project, err := s.GetProjectByID(ctx, policy.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	policy.Project = project
+
+	return policy, nil
+}
+
+func findProjectPolicyImpl(ctx context.Context, tx *sql.Tx, find *api.ProjectPolicyFind) ([]*projectPolicyRaw, error) {
+	// Build WHERE clause.
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := find.ID; v != nil {
+		where, args = append(where, fmt.Sprintf("id = $%d", len(args)+1)), append(args, *v)
+	}
+	if v := find.ProjectID; v != nil {
+		where, args = append(where, fmt.Sprintf("project_id = $%d", len(args)+1)), append(args, *v)
+	}
+	if v := find.Type; v != nil {
+		where, args = append(where, fmt.Sprintf("type = $%d", len(args)+1)), append(args, *v)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			creator_id,
+			created_ts,
+			updater_id,
+			updated_ts,
+			row_status,
+			project_id,
+			type,
+			payload
+		FROM project_policy
+		WHERE `+strings.Join(where, " AND "),
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into rawList.
+	var rawList []*projectPolicyRaw
+	for rows.Next() {
+		var raw projectPolicyRaw
+		if err := rows.Scan(
+			&raw.ID,
+			&raw.CreatorID,
+			&raw.CreatedTs,
+			&raw.UpdaterID,
+			&raw.UpdatedTs,
+			&raw.RowStatus,
+			&raw.ProjectID,
+			&raw.Type,
+			&raw.Payload,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+
+		rawList = append(rawList, &raw)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return rawList, nil
+}
+
+// upsertProjectPolicyImpl updates an existing project policy by project id and type.
+func upsertProjectPolicyImpl(ctx context.Context, tx *sql.Tx, upsert *api.ProjectPolicyUpsert) (*projectPolicyRaw, error) {
+	var set []string
+	if v := upsert.Payload; v != nil {
+		set = append(set, "payload = EXCLUDED.payload")
+	}
+	if v := upsert.RowStatus; v != nil {
+		set = append(set, "row_status = EXCLUDED.row_status")
+	}
+
+	if len(set) == 0 {
+		return nil, &common.Error{Code: common.Invalid, Err: fmt.Errorf("invalid project policy upsert %+v", upsert)}
+	}
+	set = append(set, "updater_id = EXCLUDED.updater_id")
+
+	if upsert.Payload == nil || *upsert.Payload == "" {
+		emptyPayload := "{}"
+		upsert.Payload = &emptyPayload
+	}
+	if upsert.RowStatus == nil {
+		rowStatus := api.Normal.String()
+		upsert.RowStatus = &rowStatus
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO project_policy (
+			creator_id,
+			updater_id,
+			project_id,
+			type,
+			payload,
+			row_status
+		)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT(project_id, type) DO UPDATE SET
+			%s
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, row_status, project_id, type, payload
+	`, strings.Join(set, ","))
+	row, err := tx.QueryContext(ctx, query,
+		upsert.UpdaterID,
+		upsert.UpdaterID,
+		upsert.ProjectID,
+		upsert.Type,
+		upsert.Payload,
+		upsert.RowStatus,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer row.Close()
+
+	if row.Next() {
+		var raw projectPolicyRaw
+		if err := row.Scan(
+			&raw.ID,
+			&raw.CreatorID,
+			&raw.CreatedTs,
+			&raw.UpdaterID,
+			&raw.UpdatedTs,
+			&raw.RowStatus,
+			&raw.ProjectID,
+			&raw.Type,
+			&raw.Payload,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+		return &raw, nil
+	}
+	if err := row.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+	return nil, common.FormatDBErrorEmptyRowWithQuery(query)
+}
@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -89,6 +90,20 @@ func (s *Store) PatchSetting(ctx context.Context, patch *api.SettingPatch) (*api
 	return setting, nil
 }
 
+// RotateBackupEncryptionKey replaces the backup encryption key, and appends the replaced key to the retired keys.
+// Both settings are updated in the same transaction, so that the concurrent rotations never lose a replaced key.
+func (s *Store) RotateBackupEncryptionKey(ctx context.Context, patch *api.SettingPatch) (*api.Setting, error) {
+	settingRaw, err := s.rotateBackupEncryptionKeyRaw(ctx, patch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate backup encryption key, error: %w", err)
+	}
+	setting, err := s.composeSetting(ctx, settingRaw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compose Setting with settingRaw[%+v], error: %w", settingRaw, err)
+	}
+	return setting, nil
+}
+
 //
 // private functions
 //
@@ -202,6 +217,79 @@ func (s *Store) patchSettingRaw(ctx context.Context, patch *api.SettingPatch) (*
 	return setting, nil
 }
 
+func (s *Store) rotateBackupEncryptionKeyRaw(ctx context.Context, patch *api.SettingPatch) (*settingRaw, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.PTx.Rollback()
+
+	// Lock the key until the rotation commits.
+	var oldKey string
+	if err := tx.PTx.QueryRowContext(ctx, `
+		SELECT value
+		FROM setting
+		WHERE name = $1
+		FOR UPDATE
+	`, api.SettingBackupEncryptionKey).Scan(&oldKey); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &common.Error{Code: common.NotFound, Err: fmt.Errorf("setting not found: %s", api.SettingBackupEncryptionKey)}
+		}
+		return nil, FormatError(err)
+	}
+
+	retiredName := api.SettingBackupRetiredEncryptionKey
+	retiredList, err := findSettingImpl(ctx, tx.PTx, &api.SettingFind{Name: &retiredName})
+	if err != nil {
+		return nil, err
+	}
+	if len(retiredList) == 0 {
+		return nil, &common.Error{Code: common.NotFound, Err: fmt.Errorf("setting not found: %s", retiredName)}
+	}
+	var retiredKeyList []string
+	if err := json.Unmarshal([]byte(retiredList[0].Value), &retiredKeyList); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal setting %s, error: %w", retiredName, err)
+	}
+	if oldKey != patch.Value {
+		retired := false
+		for _, key := range retiredKeyList {
+			if key == oldKey {
+				retired = true
+				break
+			}
+		}
+		if !retired {
+			retiredKeyList = append(retiredKeyList, oldKey)
+		}
+	}
+	retiredValue, err := json.Marshal(retiredKeyList)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal setting %s, error: %w", retiredName, err)
+	}
+	if _, err := patchSettingImpl(ctx, tx.PTx, &api.SettingPatch{
+		UpdaterID: patch.UpdaterID,
+		Name:      retiredName,
+		Value:     string(retiredValue),
+	}); err != nil {
+		return nil, err
+	}
+
+	setting, err := patchSettingImpl(ctx, tx.PTx, &api.SettingPatch{
+		UpdaterID: patch.UpdaterID,
+		Name:      api.SettingBackupEncryptionKey,
+		Value:     patch.Value,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.PTx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return setting, nil
+}
+
 // createSettingImpl creates a new setting.
 func createSettingImpl(ctx context.Context, tx *sql.Tx, create *api.SettingCreate) (*settingRaw, error) {
 	// Insert row into database.
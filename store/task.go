@@ -160,6 +160,44 @@ func (s *Store) PatchTaskStatus(ctx context.Context, patch *api.TaskStatusPatch)
 	return task, nil
 }
 
+// RetryTaskRun marks the running task run of the task as FAILED and creates a new task run for the next attempt.
+// The task itself stays RUNNING.
+func (s *Store) RetryTaskRun(ctx context.Context, retry *api.TaskRunRetry) error {
+	// Use serializable isolation for the same reason as patchTaskRawStatus.
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return FormatError(err)
+	}
+	defer tx.PTx.Rollback()
+
+	if err := s.retryTaskRunImpl(ctx, tx.PTx, retry); err != nil {
+		return fmt.Errorf("failed to retry TaskRun with TaskRunRetry[%+v], error: %w", retry, err)
+	}
+
+	if err := tx.PTx.Commit(); err != nil {
+		return FormatError(err)
+	}
+	return nil
+}
+
+// PatchRunningTaskRunResult updates the result of the running task run of the task, e.g. to report the progress.
+func (s *Store) PatchRunningTaskRunResult(ctx context.Context, patch *api.TaskRunResultPatch) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return FormatError(err)
+	}
+	defer tx.PTx.Rollback()
+
+	if err := s.patchRunningTaskRunResultImpl(ctx, tx.PTx, patch); err != nil {
+		return fmt.Errorf("failed to patch running TaskRun result with TaskRunResultPatch[%+v], error: %w", patch, err)
+	}
+
+	if err := tx.PTx.Commit(); err != nil {
+		return FormatError(err)
+	}
+	return nil
+}
+
 // CountTaskGroupByTypeAndStatus counts the number of TaskGroup and group by TaskType.
 // Used for the metric collector.
 func (s *Store) CountTaskGroupByTypeAndStatus(ctx context.Context) ([]*metric.TaskCountMetric, error) {
@@ -376,6 +414,55 @@ func (s *Store) patchTaskRawStatus(ctx context.Context, patch *api.TaskStatusPat
 	return task, nil
 }
 
+// retryTaskRunImpl finishes the running task run as FAILED and creates the task run for the next attempt.
+func (s *Store) retryTaskRunImpl(ctx context.Context, tx *sql.Tx, retry *api.TaskRunRetry) error {
+	taskRawObj, err := s.getTaskRawTx(ctx, tx, &api.TaskFind{ID: &retry.TaskID})
+	if err != nil {
+		return err
+	}
+	if taskRawObj == nil {
+		return &common.Error{Code: common.NotFound, Err: fmt.Errorf("task ID not found: %d", retry.TaskID)}
+	}
+	if taskRawObj.Status != api.TaskRunning {
+		return &common.Error{Code: common.Invalid, Err: fmt.Errorf("task %q is not running", taskRawObj.Name)}
+	}
+
+	taskRunRaw, err := s.getTaskRunRawTx(ctx, tx, &api.TaskRunFind{
+		TaskID: &taskRawObj.ID,
+		StatusList: &[]api.TaskRunStatus{
+			api.TaskRunRunning,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if taskRunRaw == nil {
+		return fmt.Errorf("no applicable running task to retry")
+	}
+	if _, err := s.patchTaskRunStatusImpl(ctx, tx, &api.TaskRunStatusPatch{
+		ID:        &taskRunRaw.ID,
+		UpdaterID: retry.UpdaterID,
+		TaskID:    &taskRawObj.ID,
+		Status:    api.TaskRunFailed,
+		Code:      &retry.Code,
+		Result:    &retry.Result,
+	}); err != nil {
+		return err
+	}
+
+	taskRunCreate := &api.TaskRunCreate{
+		CreatorID: retry.UpdaterID,
+		TaskID:    taskRawObj.ID,
+		Name:      fmt.Sprintf("%s %d", taskRawObj.Name, time.Now().Unix()),
+		Type:      taskRawObj.Type,
+		Payload:   taskRawObj.Payload,
+	}
+	if _, err := s.createTaskRunImpl(ctx, tx, taskRunCreate); err != nil {
+		return err
+	}
+	return nil
+}
+
 // createTaskImpl creates a new task.
 func (s *Store) createTaskImpl(ctx context.Context, tx *sql.Tx, create *api.TaskCreate) (*taskRaw, error) {
 	var row *sql.Rows
@@ -589,6 +676,9 @@ func (s *Store) patchTaskImpl(ctx context.Context, tx *sql.Tx, patch *api.TaskPa
 		}
 		set, args = append(set, fmt.Sprintf("payload = $%d", len(args)+1)), append(args, payload)
 	}
+	if v := patch.PayloadMerge; v != nil {
+		set, args = append(set, fmt.Sprintf("payload = payload || $%d::jsonb", len(args)+1)), append(args, *v)
+	}
 	if v := patch.EarliestAllowedTs; v != nil {
 		set, args = append(set, fmt.Sprintf("earliest_allowed_ts = $%d", len(args)+1)), append(args, *v)
 	}
@@ -203,6 +203,35 @@ func (s *Store) patchTaskRunStatusImpl(ctx context.Context, tx *sql.Tx, patch *a
 	return nil, &common.Error{Code: common.NotFound, Err: fmt.Errorf("project ID not found: %d", patch.ID)}
 }
 
+// patchRunningTaskRunResultImpl updates the result of the running taskRun of the task.
+func (s *Store) patchRunningTaskRunResultImpl(ctx context.Context, tx *sql.Tx, patch *api.TaskRunResultPatch) error {
+	result := "{}"
+	if patch.Result != "" {
+		result = patch.Result
+	}
+	res, err := tx.ExecContext(ctx, `
+		UPDATE task_run
+		SET updater_id = $1, result = $2
+		WHERE task_id = $3 AND status = $4
+	`,
+		patch.UpdaterID,
+		result,
+		patch.TaskID,
+		api.TaskRunRunning,
+	)
+	if err != nil {
+		return FormatError(err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return FormatError(err)
+	}
+	if rows == 0 {
+		return &common.Error{Code: common.NotFound, Err: fmt.Errorf("running task run not found for task ID: %d", patch.TaskID)}
+	}
+	return nil
+}
+
 func (s *Store) findTaskRunImpl(ctx context.Context, tx *sql.Tx, find *api.TaskRunFind) ([]*taskRunRaw, error) {
 	// Build WHERE clause.
 	where, args := []string{"1 = 1"}, []interface{}{}
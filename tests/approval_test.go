@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApprovalChainStageApproval(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+	ctx := context.Background()
+	ctl := &controller{}
+	dataDir := t.TempDir()
+	err := ctl.StartServer(ctx, dataDir, getTestPort(t.Name()))
+	a.NoError(err)
+	defer ctl.Close(ctx)
+	err = ctl.Login()
+	a.NoError(err)
+	err = ctl.setLicense()
+	a.NoError(err)
+
+	// Create a project.
+	project, err := ctl.createProject(api.ProjectCreate{
+		Name: "Test Project",
+		Key:  "TestApprovalChain",
+	})
+	a.NoError(err)
+
+	// Provision an instance.
+	instanceRootDir := t.TempDir()
+	instanceName := "testInstance1"
+	instanceDir, err := ctl.provisionSQLiteInstance(instanceRootDir, instanceName)
+	a.NoError(err)
+
+	environments, err := ctl.getEnvironments()
+	a.NoError(err)
+	prodEnvironment, err := findEnvironment(environments, "Prod")
+	a.NoError(err)
+
+	// Add an instance.
+	instance, err := ctl.addInstance(api.InstanceCreate{
+		EnvironmentID: prodEnvironment.ID,
+		Name:          instanceName,
+		Engine:        db.SQLite,
+		Host:          instanceDir,
+	})
+	a.NoError(err)
+
+	databaseName := "testApprovalChain"
+	err = ctl.createDatabase(project, instance, databaseName, nil /* labelMap */)
+	a.NoError(err)
+	databases, err := ctl.getDatabases(api.DatabaseFind{
+		ProjectID: &project.ID,
+	})
+	a.NoError(err)
+	a.Equal(1, len(databases))
+	database := databases[0]
+
+	// Require the approval of a workspace DBA in the Prod environment.
+	policyPayload, err := json.Marshal(&api.PipelineApprovalPolicy{
+		Value: api.PipelineApprovalValueManualAlways,
+		ApprovalStepList: []*api.PipelineApprovalStep{
+			{ApproverType: api.PipelineApprovalApproverWorkspaceDBA},
+		},
+	})
+	a.NoError(err)
+	payload := string(policyPayload)
+	err = ctl.upsertPolicy(api.PolicyUpsert{
+		EnvironmentID: prodEnvironment.ID,
+		Type:          api.PolicyTypePipelineApproval,
+		Payload:       &payload,
+	})
+	a.NoError(err)
+
+	createContext, err := json.Marshal(&api.UpdateSchemaContext{
+		MigrationType: db.Migrate,
+		DetailList: []*api.UpdateSchemaDetail{
+			{
+				DatabaseID: database.ID,
+				Statement:  migrationStatement,
+			},
+		},
+	})
+	a.NoError(err)
+	issue, err := ctl.createIssue(api.IssueCreate{
+		ProjectID:   project.ID,
+		Name:        fmt.Sprintf("update schema for database %q", databaseName),
+		Type:        api.IssueDatabaseSchemaUpdate,
+		Description: fmt.Sprintf("This updates the schema of database %q.", databaseName),
+		// Assign to self.
+		AssigneeID:    project.Creator.ID,
+		CreateContext: string(createContext),
+	})
+	a.NoError(err)
+	status, err := getAggregatedTaskStatus(issue)
+	a.NoError(err)
+	a.Equal(api.TaskPendingApproval, status)
+
+	// The assignee can't bypass the approval chain through the stage status.
+	stage := issue.Pipeline.StageList[0]
+	_, err = ctl.patchStageAllTaskStatus(api.StageAllTaskStatusPatch{
+		ID:     stage.ID,
+		Status: api.TaskPending,
+	}, issue.Pipeline.ID)
+	a.Error(err)
+	a.Contains(err.Error(), "is waiting for 1 approval(s) from WORKSPACE_DBA at step 1 of 1")
+
+	// Nor through the task status.
+	_, err = ctl.patchTaskStatus(api.TaskStatusPatch{
+		ID:     stage.TaskList[0].ID,
+		Status: api.TaskPending,
+	}, issue.Pipeline.ID)
+	a.Error(err)
+	a.Contains(err.Error(), "is waiting for 1 approval(s) from WORKSPACE_DBA at step 1 of 1")
+
+	issue, err = ctl.getIssue(issue.ID)
+	a.NoError(err)
+	status, err = getAggregatedTaskStatus(issue)
+	a.NoError(err)
+	a.Equal(api.TaskPendingApproval, status)
+}
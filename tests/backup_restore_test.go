@@ -103,6 +103,81 @@ func TestBackupRestoreBasic(t *testing.T) {
 	a.Equal(numRecords, i)
 }
 
+// TestBackupTableRowCount tests that the row counts returned with the dump are the ones in the dump,
+// while the table is being updated during the dump.
+func TestBackupTableRowCount(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	port := getTestPort(t.Name())
+	database := "backup_row_count"
+	restoredDatabase := "backup_row_count_restored"
+	table := "backup_row_count"
+
+	_, stop := resourcemysql.SetupTestInstance(t, port)
+	defer stop()
+
+	db, err := connectTestMySQL(port, "")
+	a.NoError(err)
+	defer db.Close()
+
+	_, err = db.Exec(fmt.Sprintf(`
+	CREATE DATABASE %s;
+	CREATE DATABASE %s;
+	USE %s;
+	CREATE TABLE %s (
+		id INT AUTO_INCREMENT,
+		PRIMARY KEY (id)
+	);
+	`, database, restoredDatabase, database, table))
+	a.NoError(err)
+	for i := 0; i < 100; i++ {
+		_, err = db.Exec(fmt.Sprintf("INSERT INTO %s VALUES ()", table))
+		a.NoError(err)
+	}
+
+	// Keep inserting rows during the dump.
+	insertDone := make(chan struct{})
+	go func() {
+		defer close(insertDone)
+		insertDB, err := connectTestMySQL(port, database)
+		if err != nil {
+			return
+		}
+		defer insertDB.Close()
+		for ctx.Err() == nil {
+			if _, err := insertDB.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s VALUES ()", table)); err != nil {
+				return
+			}
+		}
+	}()
+
+	driver, err := getTestMySQLDriver(ctx, strconv.Itoa(port), database)
+	a.NoError(err)
+	defer driver.Close(ctx)
+	mysqlDriver, ok := driver.(*pluginmysql.Driver)
+	a.True(ok)
+	var buf bytes.Buffer
+	_, rowCountMap, err := mysqlDriver.DumpWithTableRowCount(ctx, database, &buf, nil /* schemaOut */)
+	a.NoError(err)
+	cancel()
+	<-insertDone
+	a.GreaterOrEqual(rowCountMap[table], int64(100))
+
+	restoredDriver, err := getTestMySQLDriver(context.Background(), strconv.Itoa(port), restoredDatabase)
+	a.NoError(err)
+	defer restoredDriver.Close(context.Background())
+	err = restoredDriver.Restore(context.Background(), bufio.NewScanner(&buf))
+	a.NoError(err)
+
+	var rowCount int64
+	err = db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s.%s", restoredDatabase, table)).Scan(&rowCount)
+	a.NoError(err)
+	a.Equal(rowCountMap[table], rowCount)
+}
+
 // TestPITR tests the PITR behavior
 // The test plan is:
 // 0. prepare tables with foreign key constraints dependencies
@@ -135,6 +135,8 @@ func getTestPort(testName string) int {
 		"TestFetchBinlogFiles",
 
 		"TestSchemaSystem",
+		"TestBackupTableRowCount",
+		"TestApprovalChainStageApproval",
 	}
 	port := 1234
 	for _, name := range tests {